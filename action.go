@@ -111,8 +111,8 @@ var ActionProps = ki.Props{
 			"background-color": "linear-gradient(lighter-0, highlight-10)",
 		},
 		ButtonSelectors[ButtonInactive]: ki.Props{
-			"border-color": "lighter-50",
-			"color":        "lighter-50",
+			"border-color": "highlight-50",
+			"color":        "highlight-50",
 		},
 		ButtonSelectors[ButtonHover]: ki.Props{
 			"background-color": "linear-gradient(highlight-10, highlight-10)",
@@ -122,7 +122,7 @@ var ActionProps = ki.Props{
 			"background-color": "linear-gradient(samelight-50, highlight-10)",
 		},
 		ButtonSelectors[ButtonDown]: ki.Props{
-			"color":            "lighter-90",
+			"color":            "highlight-90",
 			"background-color": "linear-gradient(highlight-30, highlight-10)",
 		},
 		ButtonSelectors[ButtonSelected]: ki.Props{
@@ -134,8 +134,8 @@ var ActionProps = ki.Props{
 			"background-color": "linear-gradient(lighter-0, highlight-10)",
 		},
 		ButtonSelectors[ButtonInactive]: ki.Props{
-			"border-color": "lighter-50",
-			"color":        "lighter-50",
+			"border-color": "highlight-50",
+			"color":        "highlight-50",
 		},
 		ButtonSelectors[ButtonHover]: ki.Props{
 			"background-color": "linear-gradient(highlight-10, highlight-10)",
@@ -145,7 +145,7 @@ var ActionProps = ki.Props{
 			"background-color": "linear-gradient(samelight-50, highlight-10)",
 		},
 		ButtonSelectors[ButtonDown]: ki.Props{
-			"color":            "lighter-90",
+			"color":            "highlight-90",
 			"background-color": "linear-gradient(highlight-30, highlight-10)",
 		},
 		ButtonSelectors[ButtonSelected]: ki.Props{
@@ -0,0 +1,821 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/srwiley/rasterx"
+)
+
+// Style animations: the transition property, e.g., "background-color 0.2s
+// ease-out, color 100ms", makes changes in those properties animate over the
+// given duration when a widget switches between its state styles (e.g.,
+// hover, focus, selected -- see WidgetBase.TransitionStyle), instead of
+// changing instantly -- "all" animates all of the properties that change.
+// The animation property, e.g., "pulse 1s ease-in-out infinite alternate",
+// runs the @keyframes rule of that name, which is found in the CSS of the
+// widget (or its parents) under a key of "@keyframes pulse", with the props
+// for each keyframe under "from", "to", or a percent, e.g., "50%".  Colors
+// (including gradient stops), units.Value lengths and numbers (e.g.,
+// opacity) are interpolated -- other properties switch at the start.  A
+// widget has at most one style animation at a time, and a keyframe
+// animation takes precedence over transitions.  Animations are driven by a
+// frame ticker on the Window, running at AnimFPS, which only re-renders the
+// animating nodes, within the window event loop, and stops when there are
+// none.
+
+// KeyframesPrefix is the prefix of @keyframes rule keys in css props
+const KeyframesPrefix = "@keyframes"
+
+// AnimFPS is the number of frames per second at which animations are
+// updated -- 0 = no animations: style changes are instantaneous
+var AnimFPS = 60
+
+// Easings are the timing functions for animations and transitions, as in
+// CSS
+type Easings int32
+
+const (
+	// EaseLinear progresses at a constant rate
+	EaseLinear Easings = iota
+
+	// Ease is the CSS default: a fast start and a slow end
+	Ease
+
+	// EaseIn has a slow start
+	EaseIn
+
+	// EaseOut has a slow end
+	EaseOut
+
+	// EaseInOut has a slow start and end
+	EaseInOut
+
+	EasingsN
+)
+
+//go:generate stringer -type=Easings
+
+var KiT_Easings = kit.Enums.AddEnum(EasingsN, false, nil)
+
+func (ev Easings) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *Easings) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// EasingNames are the CSS names of the Easings
+var EasingNames = map[string]Easings{
+	"linear":      EaseLinear,
+	"ease":        Ease,
+	"ease-in":     EaseIn,
+	"ease-out":    EaseOut,
+	"ease-in-out": EaseInOut,
+}
+
+// easingBeziers are the cubic bezier control points of the Easings: x1, y1,
+// x2, y2
+var easingBeziers = [EasingsN][4]float32{
+	{0, 0, 1, 1},
+	{0.25, 0.1, 0.25, 1},
+	{0.42, 0, 1, 1},
+	{0, 0, 0.58, 1},
+	{0.42, 0, 0.58, 1},
+}
+
+// Eval returns the eased progress for linear progress t (0-1)
+func (ea Easings) Eval(t float32) float32 {
+	if t <= 0 {
+		return 0
+	}
+	if t >= 1 {
+		return 1
+	}
+	if ea <= EaseLinear || ea >= EasingsN {
+		return t
+	}
+	cp := easingBeziers[ea]
+	bz := func(p1, p2, s float32) float32 { // end points are 0 and 1
+		is := 1 - s
+		return 3*is*is*s*p1 + 3*is*s*s*p2 + s*s*s
+	}
+	lo, hi := float32(0), float32(1)
+	s := t
+	for i := 0; i < 20; i++ { // x is monotonic in s
+		s = 0.5 * (lo + hi)
+		if bz(cp[0], cp[2], s) < t {
+			lo = s
+		} else {
+			hi = s
+		}
+	}
+	return bz(cp[1], cp[3], s)
+}
+
+// StyleTransition specifies the transition of one property (or all of
+// them), from the transition property
+type StyleTransition struct {
+	Prop     string        `desc:"name of the property, e.g., background-color -- all = all properties"`
+	Duration time.Duration `desc:"duration of the transition"`
+	Delay    time.Duration `desc:"delay before the transition starts"`
+	Easing   Easings       `desc:"timing function"`
+}
+
+// StyleAnimation specifies a keyframe animation, from the animation property
+type StyleAnimation struct {
+	Name      string        `desc:"name of the @keyframes rule"`
+	Duration  time.Duration `desc:"duration of one iteration"`
+	Delay     time.Duration `desc:"delay before the animation starts"`
+	Easing    Easings       `desc:"timing function, applied between each pair of keyframes"`
+	Iters     float32       `desc:"number of iterations -- -1 = infinite"`
+	Alternate bool          `desc:"reverse the direction on every other iteration"`
+	Reverse   bool          `desc:"run the keyframes backwards"`
+	Forwards  bool          `desc:"keep the final keyframe values after the animation ends, instead of going back to the base style"`
+}
+
+// ParseStyleTime parses a CSS time value in s or ms
+func ParseStyleTime(str string) (time.Duration, bool) {
+	fact := float64(time.Second)
+	num := str
+	switch {
+	case strings.HasSuffix(str, "ms"):
+		num = str[:len(str)-2]
+		fact = float64(time.Millisecond)
+	case strings.HasSuffix(str, "s"):
+		num = str[:len(str)-1]
+	case str != "0":
+		return 0, false
+	}
+	val, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(val * fact), true
+}
+
+// ParseTransitions parses a CSS transition value: a comma-separated list of
+// property transitions, each with a property name, duration, and optional
+// easing and delay, e.g., "background-color 0.2s ease-out" -- "none"
+// returns no transitions
+func ParseTransitions(str string) ([]StyleTransition, error) {
+	str = strings.TrimSpace(str)
+	if str == "" || str == "none" {
+		return nil, nil
+	}
+	var trs []StyleTransition
+	for _, tstr := range splitStyleList(str) {
+		tr := StyleTransition{Prop: "all", Easing: Ease}
+		nt := 0
+		for _, f := range splitStyleFields(strings.ToLower(tstr)) {
+			if tm, ok := ParseStyleTime(f); ok {
+				if nt == 0 {
+					tr.Duration = tm
+				} else {
+					tr.Delay = tm
+				}
+				nt++
+				continue
+			}
+			if ea, ok := EasingNames[f]; ok {
+				tr.Easing = ea
+				continue
+			}
+			tr.Prop = f
+		}
+		if nt == 0 {
+			return nil, fmt.Errorf("gi.ParseTransitions: no duration in: %v", str)
+		}
+		trs = append(trs, tr)
+	}
+	return trs, nil
+}
+
+// ParseAnimation parses a CSS animation value, with the name of the
+// @keyframes rule, duration, and optional easing, delay, iteration count
+// (or infinite), direction (normal, reverse, alternate, alternate-reverse)
+// and fill mode (forwards or both to keep the final keyframe) -- "none"
+// returns an animation without a name
+func ParseAnimation(str string) (StyleAnimation, error) {
+	an := StyleAnimation{Easing: Ease, Iters: 1}
+	str = strings.TrimSpace(str)
+	if str == "" || str == "none" {
+		return an, nil
+	}
+	nt := 0
+	for _, f := range splitStyleFields(str) {
+		lf := strings.ToLower(f)
+		if tm, ok := ParseStyleTime(lf); ok {
+			if nt == 0 {
+				an.Duration = tm
+			} else {
+				an.Delay = tm
+			}
+			nt++
+			continue
+		}
+		if ea, ok := EasingNames[lf]; ok {
+			an.Easing = ea
+			continue
+		}
+		if it, err := strconv.ParseFloat(lf, 32); err == nil {
+			an.Iters = float32(it)
+			continue
+		}
+		switch lf {
+		case "infinite":
+			an.Iters = -1
+		case "reverse":
+			an.Reverse = true
+		case "alternate":
+			an.Alternate = true
+		case "alternate-reverse":
+			an.Alternate, an.Reverse = true, true
+		case "forwards", "both":
+			an.Forwards = true
+		case "normal", "none", "backwards", "running", "paused":
+		default:
+			an.Name = f
+		}
+	}
+	if an.Name != "" && an.Duration <= 0 {
+		return an, fmt.Errorf("gi.ParseAnimation: no duration in: %v", str)
+	}
+	return an, nil
+}
+
+// SetAnimProps sets the Transitions and Animation from the transition and
+// animation properties in props, if present -- called in SetStyleProps
+func (s *Style) SetAnimProps(par *Style, props ki.Props) {
+	if str, ok := PropStringWithVars(props, "transition", s.Vars); ok {
+		switch str {
+		case "inherit":
+			if par != nil {
+				s.Transitions = par.Transitions
+			}
+		case "initial":
+			s.Transitions = nil
+		default:
+			if trs, err := ParseTransitions(str); err != nil {
+				log.Println(err)
+			} else {
+				s.Transitions = trs
+			}
+		}
+	}
+	if str, ok := PropStringWithVars(props, "animation", s.Vars); ok {
+		switch str {
+		case "inherit":
+			if par != nil {
+				s.Animation = par.Animation
+			}
+		case "initial":
+			s.Animation = StyleAnimation{}
+		default:
+			if an, err := ParseAnimation(str); err != nil {
+				log.Println(err)
+			} else {
+				s.Animation = an
+			}
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Interpolation
+
+var kiT_UnitsValue = reflect.TypeOf(units.Value{})
+
+// IsStyleFieldLerp returns true if the style field can be interpolated:
+// colors, units.Value and float32 numbers
+func IsStyleFieldLerp(fld *StyledField) bool {
+	ft := fld.Field.Type
+	return ft == KiT_Color || ft == KiT_ColorSpec || ft == kiT_UnitsValue || ft.Kind() == reflect.Float32
+}
+
+// styleFieldEqual returns true if the (interpolatable) field has the same
+// value in both styles
+func styleFieldEqual(fld *StyledField, a, b *Style) bool {
+	ap := reflect.ValueOf(a).Pointer()
+	bp := reflect.ValueOf(b).Pointer()
+	switch av := fld.FieldIface(ap).(type) {
+	case *Color:
+		return *av == *fld.FieldIface(bp).(*Color)
+	case *ColorSpec:
+		bv := fld.FieldIface(bp).(*ColorSpec)
+		return av.Source == bv.Source && av.Color == bv.Color && av.Gradient == bv.Gradient
+	case *units.Value:
+		return av.Dots == fld.FieldIface(bp).(*units.Value).Dots
+	case *float32:
+		return *av == *fld.FieldIface(bp).(*float32)
+	}
+	return true
+}
+
+// LerpStyleField sets the field in cur to the value interpolated between
+// its values in from and to, at t (0-1) -- cur must start out as a copy of
+// to, and the field must be interpolatable (see IsStyleFieldLerp)
+func LerpStyleField(fld *StyledField, cur, from, to *Style, t float32) {
+	cp := reflect.ValueOf(cur).Pointer()
+	fp := reflect.ValueOf(from).Pointer()
+	tp := reflect.ValueOf(to).Pointer()
+	switch cv := fld.FieldIface(cp).(type) {
+	case *Color:
+		*cv = LerpColor(*fld.FieldIface(fp).(*Color), *fld.FieldIface(tp).(*Color), t)
+	case *ColorSpec:
+		lerpColorSpec(cv, fld.FieldIface(fp).(*ColorSpec), fld.FieldIface(tp).(*ColorSpec), t)
+	case *units.Value:
+		fv := fld.FieldIface(fp).(*units.Value)
+		tv := fld.FieldIface(tp).(*units.Value)
+		if fv.Un == tv.Un {
+			cv.Val = fv.Val + t*(tv.Val-fv.Val)
+		}
+		cv.Dots = fv.Dots + t*(tv.Dots-fv.Dots)
+	case *float32:
+		fv := *fld.FieldIface(fp).(*float32)
+		*cv = fv + t*(*fld.FieldIface(tp).(*float32)-fv)
+	}
+}
+
+// LerpColor returns the color interpolated between a and b at t (0-1)
+func LerpColor(a, b Color, t float32) Color {
+	lerp := func(x, y uint8) uint8 {
+		return uint8(float32(x) + t*(float32(y)-float32(x)) + 0.5)
+	}
+	return Color{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), lerp(a.A, b.A)}
+}
+
+// lerpColorSpec sets cur to the color interpolated between from and to at
+// t -- if either is a gradient, the gradient stops are interpolated, with a
+// solid color acting as a gradient with that color at all stops -- a
+// different number of stops switches at the start
+func lerpColorSpec(cur, from, to *ColorSpec, t float32) {
+	if from.Gradient == nil && to.Gradient == nil {
+		cur.SetColor(LerpColor(from.Color, to.Color, t))
+		return
+	}
+	gs := to
+	if to.Gradient == nil {
+		gs = from
+	}
+	n := len(gs.Gradient.Stops)
+	stop := func(cs *ColorSpec, i int) (Color, float64, bool) {
+		if cs.Gradient == nil {
+			return cs.Color, 1, true
+		}
+		if len(cs.Gradient.Stops) != n {
+			return Color{}, 0, false
+		}
+		var c Color
+		c.SetColor(cs.Gradient.Stops[i].StopColor)
+		return c, cs.Gradient.Stops[i].Opacity, true
+	}
+	cur.CopyFrom(gs)
+	stops := make([]rasterx.GradStop, n)
+	for i := range stops {
+		stops[i] = gs.Gradient.Stops[i]
+		fc, fop, fok := stop(from, i)
+		tc, top, tok := stop(to, i)
+		if !fok || !tok {
+			cur.CopyFrom(to)
+			return
+		}
+		stops[i].StopColor = color.Color(LerpColor(fc, tc, t))
+		stops[i].Opacity = fop + float64(t)*(top-fop)
+	}
+	cur.Gradient.Stops = stops
+}
+
+// styleAnimFields returns the interpolatable style fields for given
+// property name, including the longhands of box side shorthands, and the
+// sub-properties of box-shadow etc
+func styleAnimFields(prop string) []*StyledField {
+	var flds []*StyledField
+	add := func(key string) {
+		if fld, ok := StyleFields.Fields[key]; ok && IsStyleFieldLerp(fld) {
+			flds = append(flds, fld)
+		}
+	}
+	add(prop)
+	if sides, ok := BoxSideProps[prop]; ok {
+		for _, lk := range sides {
+			add(lk)
+		}
+	}
+	pfx := prop + "."
+	for key := range StyleFields.Fields {
+		if strings.HasPrefix(key, pfx) {
+			add(key)
+		}
+	}
+	return flds
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  StyleAnim
+
+// Animator is an animation that is driven by the frame ticker of a Window
+// -- see Window.AddAnim
+type Animator interface {
+	// Animate updates the animation for given time, including re-rendering
+	// the animated node, and returns true when the animation is done -- it
+	// is called within the window event loop
+	Animate(now time.Time) bool
+}
+
+// styleAnimTrack is the transition of one style field
+type styleAnimTrack struct {
+	fld *StyledField
+	tr  StyleTransition
+}
+
+// StyleKeyframe is one keyframe of a keyframe animation
+type StyleKeyframe struct {
+	Pos float32 `desc:"position of the keyframe within the animation (0-1)"`
+	Sty Style   `desc:"base style with the keyframe props applied"`
+}
+
+// StyleAnim is a running transition or keyframe animation of the style of a
+// widget -- it implements the Animator interface
+type StyleAnim struct {
+	Widget    *WidgetBase     `desc:"the widget whose style (Sty) is animated"`
+	Win       *Window         `desc:"the window driving the animation"`
+	Target    *Style          `desc:"the target style, e.g., one of the state styles of the widget -- identifies the animation"`
+	Base      Style           `desc:"copy of the target style: the end point of a transition, and the base style for keyframes"`
+	From      Style           `desc:"starting style of a transition"`
+	Anim      StyleAnimation  `desc:"keyframe animation parameters"`
+	Keyframes []StyleKeyframe `desc:"keyframes of a keyframe animation, in order -- empty for a transition"`
+	Start     time.Time       `desc:"time when the animation started"`
+	tracks    []styleAnimTrack
+	keyFlds   []*StyledField
+	cur       Style
+}
+
+// Animate updates the style of the widget and re-renders it -- implements
+// the Animator interface
+func (sa *StyleAnim) Animate(now time.Time) bool {
+	wb := sa.Widget
+	if wb.IsDeleted() || wb.IsDestroyed() {
+		return true
+	}
+	sa.Win.AnimMu.Lock()
+	if wb.styleAnim != sa { // replaced
+		sa.Win.AnimMu.Unlock()
+		return true
+	}
+	cur := sa.Base
+	var done bool
+	if len(sa.Keyframes) > 0 {
+		done = sa.animKeyframes(&cur, now)
+	} else {
+		done = sa.animTracks(&cur, now)
+	}
+	sa.cur = cur
+	wb.Sty = cur
+	if done {
+		wb.styleAnim = nil
+	}
+	sa.Win.AnimMu.Unlock()
+	if !wb.IsUpdatingAtomic() {
+		wb.UpdateSig()
+	}
+	return done
+}
+
+// animTracks sets the transition tracks in cur for given time, returning
+// true if done
+func (sa *StyleAnim) animTracks(cur *Style, now time.Time) bool {
+	el := now.Sub(sa.Start)
+	done := true
+	for i := range sa.tracks {
+		tk := &sa.tracks[i]
+		t := float32(1)
+		if tk.tr.Duration > 0 {
+			t = float32(el-tk.tr.Delay) / float32(tk.tr.Duration)
+		}
+		if t < 1 {
+			done = false
+		}
+		LerpStyleField(tk.fld, cur, &sa.From, &sa.Base, tk.tr.Easing.Eval(t))
+	}
+	return done
+}
+
+// animKeyframes sets the keyframe props in cur for given time, returning
+// true if done
+func (sa *StyleAnim) animKeyframes(cur *Style, now time.Time) bool {
+	an := &sa.Anim
+	el := now.Sub(sa.Start) - an.Delay
+	if el < 0 {
+		return false
+	}
+	iter := float32(el) / float32(an.Duration)
+	done := false
+	if an.Iters >= 0 && iter >= an.Iters {
+		if !an.Forwards {
+			return true
+		}
+		done = true
+		iter = an.Iters
+	}
+	n := int(iter)
+	p := iter - float32(n)
+	if done && p == 0 && n > 0 { // ended exactly at the end of an iteration
+		n--
+		p = 1
+	}
+	if an.Alternate && n%2 == 1 {
+		p = 1 - p
+	}
+	if an.Reverse {
+		p = 1 - p
+	}
+	kfs := sa.Keyframes
+	kn := 0
+	for kn < len(kfs)-2 && p > kfs[kn+1].Pos {
+		kn++
+	}
+	ka, kb := &kfs[kn], &kfs[kn+1]
+	t := float32(1)
+	if kb.Pos > ka.Pos {
+		t = (p - ka.Pos) / (kb.Pos - ka.Pos)
+	}
+	t = an.Easing.Eval(t)
+	for _, fld := range sa.keyFlds {
+		LerpStyleField(fld, cur, &ka.Sty, &kb.Sty, t)
+	}
+	return done
+}
+
+// newStyleTransition returns a new transition from the current style of the
+// widget to given target style, or nil if there is nothing to animate
+func (wb *WidgetBase) newStyleTransition(win *Window, to *Style) *StyleAnim {
+	sa := &StyleAnim{Widget: wb, Win: win, Target: to, Base: *to, From: wb.Sty, Start: time.Now()}
+	has := map[*StyledField]bool{}
+	for _, tr := range to.Transitions {
+		if tr.Duration <= 0 && tr.Delay <= 0 {
+			continue
+		}
+		for _, fld := range styleAnimFields(tr.Prop) {
+			if has[fld] || styleFieldEqual(fld, &sa.From, &sa.Base) {
+				continue
+			}
+			has[fld] = true
+			sa.tracks = append(sa.tracks, styleAnimTrack{fld: fld, tr: tr})
+		}
+	}
+	if len(sa.tracks) == 0 {
+		return nil
+	}
+	sa.cur = sa.From
+	return sa
+}
+
+// newStyleKeyframes returns a new keyframe animation for the animation of
+// given base style, or nil if its @keyframes rule is not found in the CSS
+// of the widget
+func (wb *WidgetBase) newStyleKeyframes(win *Window, base *Style) *StyleAnim {
+	an := base.Animation
+	if an.Duration <= 0 {
+		return nil
+	}
+	kfi, ok := wb.CSSAgg[KeyframesPrefix+" "+an.Name]
+	if !ok {
+		return nil
+	}
+	kfp, ok := kfi.(ki.Props)
+	if !ok {
+		return nil
+	}
+	sa := &StyleAnim{Widget: wb, Win: win, Target: base, Base: *base, Anim: an, Start: time.Now()}
+	has := map[*StyledField]bool{}
+	for key, val := range kfp {
+		pp, ok := val.(ki.Props)
+		if !ok {
+			continue
+		}
+		var pos float32
+		switch key {
+		case "from":
+		case "to":
+			pos = 1
+		default:
+			pct, err := strconv.ParseFloat(strings.TrimSuffix(key, "%"), 32)
+			if err != nil {
+				log.Printf("gi.StyleAnim: invalid keyframe: %v in @keyframes %v\n", key, an.Name)
+				continue
+			}
+			pos = float32(pct) / 100
+		}
+		kf := StyleKeyframe{Pos: pos, Sty: *base}
+		kf.Sty.SetStyleProps(nil, pp)
+		kf.Sty.ToDots()
+		sa.Keyframes = append(sa.Keyframes, kf)
+		for pk := range pp {
+			for _, fld := range styleAnimFields(pk) {
+				if !has[fld] {
+					has[fld] = true
+					sa.keyFlds = append(sa.keyFlds, fld)
+				}
+			}
+		}
+	}
+	if len(sa.keyFlds) == 0 {
+		return nil
+	}
+	sort.Slice(sa.Keyframes, func(i, j int) bool {
+		return sa.Keyframes[i].Pos < sa.Keyframes[j].Pos
+	})
+	if sa.Keyframes[0].Pos > 0 {
+		sa.Keyframes = append([]StyleKeyframe{{Pos: 0, Sty: *base}}, sa.Keyframes...)
+	}
+	if sa.Keyframes[len(sa.Keyframes)-1].Pos < 1 {
+		sa.Keyframes = append(sa.Keyframes, StyleKeyframe{Pos: 1, Sty: *base})
+	}
+	sa.cur = *base
+	return sa
+}
+
+// TransitionStyle sets the style of the widget (Sty) to given target style,
+// typically one of its state styles -- if the target has transitions and
+// differs from the last target style, the style transitions from the
+// current one over time, and if it has a keyframe animation, that is run
+// -- otherwise it is just copied
+func (wb *WidgetBase) TransitionStyle(to *Style) {
+	win := wb.ParentWindow()
+	if win == nil || AnimFPS <= 0 {
+		wb.Sty = *to
+		wb.styleTo = to
+		return
+	}
+	win.AnimMu.Lock()
+	sa := wb.styleAnim
+	prev := wb.styleTo
+	wb.styleTo = to
+	if sa != nil && (sa.Target == to || (len(sa.Keyframes) > 0 && sa.Anim.Name == to.Animation.Name)) {
+		if sa.Target != to || len(sa.Keyframes) > 0 {
+			sa.Target = to
+			sa.Base = *to
+		}
+		wb.Sty = sa.cur
+		win.AnimMu.Unlock()
+		return
+	}
+	win.AnimMu.Unlock()
+	var nsa *StyleAnim
+	if to.Animation.Name != wb.styleAnimName {
+		wb.styleAnimName = to.Animation.Name
+		if to.Animation.Name != "" {
+			nsa = wb.newStyleKeyframes(win, to)
+		}
+	}
+	if nsa == nil && prev != nil && prev != to && len(to.Transitions) > 0 {
+		nsa = wb.newStyleTransition(win, to)
+	}
+	wb.setStyleAnim(win, nsa)
+	if nsa == nil {
+		wb.Sty = *to
+	} else {
+		wb.Sty = nsa.cur
+	}
+}
+
+// StartStyleAnimation starts the keyframe animation of the style of the
+// widget, if it has one and it has not already been started -- called at
+// the end of Style2DWidget -- widgets that switch among state styles use
+// TransitionStyle instead
+func (wb *WidgetBase) StartStyleAnimation() {
+	if AnimFPS <= 0 {
+		return
+	}
+	win := wb.ParentWindow()
+	if win == nil {
+		return
+	}
+	win.AnimMu.Lock()
+	sa := wb.styleAnim
+	if sa != nil && len(sa.Keyframes) > 0 && sa.Anim.Name == wb.Sty.Animation.Name {
+		sa.Base = wb.Sty
+	}
+	win.AnimMu.Unlock()
+	if wb.Sty.Animation.Name == wb.styleAnimName {
+		return
+	}
+	wb.styleAnimName = wb.Sty.Animation.Name
+	if wb.Sty.Animation.Name == "" {
+		return
+	}
+	if nsa := wb.newStyleKeyframes(win, &wb.Sty); nsa != nil {
+		nsa.Target = nil // not a state style
+		wb.setStyleAnim(win, nsa)
+	}
+}
+
+// setStyleAnim sets the running style animation of the widget, replacing
+// any existing one (nil = none)
+func (wb *WidgetBase) setStyleAnim(win *Window, sa *StyleAnim) {
+	win.AnimMu.Lock()
+	wb.styleAnim = sa
+	win.AnimMu.Unlock()
+	if sa != nil {
+		win.AddAnim(sa)
+	}
+}
+
+// IsStyleAnimating returns true if the style of the widget is currently
+// being animated
+func (wb *WidgetBase) IsStyleAnimating() bool {
+	win := wb.ParentWindow()
+	if win == nil {
+		return false
+	}
+	win.AnimMu.Lock()
+	defer win.AnimMu.Unlock()
+	return wb.styleAnim != nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Window frame ticker
+
+// AddAnim adds an animation to be driven by the frame ticker of the window,
+// which is started if not already running -- the animation is removed when
+// it is done
+func (w *Window) AddAnim(an Animator) {
+	w.AnimMu.Lock()
+	defer w.AnimMu.Unlock()
+	w.Anims = append(w.Anims, an)
+	if w.AnimTicker == nil && AnimFPS > 0 {
+		w.AnimTicker = time.NewTicker(time.Second / time.Duration(AnimFPS))
+		go w.AnimLoop(w.AnimTicker)
+	}
+}
+
+// DeleteAnim removes given animation from those driven by the window
+func (w *Window) DeleteAnim(an Animator) {
+	w.AnimMu.Lock()
+	defer w.AnimMu.Unlock()
+	for i, a := range w.Anims {
+		if a == an {
+			w.Anims = append(w.Anims[:i], w.Anims[i+1:]...)
+			return
+		}
+	}
+}
+
+// AnimLoop posts a frame of the animations to the window event loop on each
+// tick of the given ticker, where animFrame runs them, so that they do not
+// race with the rendering of the window -- ticks are skipped while a frame
+// is still pending.  It runs until there are no animations left or the
+// window is closed, at which point the ticker is stopped
+func (w *Window) AnimLoop(tick *time.Ticker) {
+	for range tick.C {
+		w.AnimMu.Lock()
+		if len(w.Anims) == 0 || w.IsClosed() {
+			tick.Stop()
+			if w.AnimTicker == tick {
+				w.AnimTicker = nil
+			}
+			w.Anims = nil
+			w.AnimMu.Unlock()
+			return
+		}
+		if w.animPending {
+			w.AnimMu.Unlock()
+			continue
+		}
+		w.animPending = true
+		w.AnimMu.Unlock()
+		w.PostInEventLoop(func() {
+			w.animFrame(time.Now())
+		})
+	}
+}
+
+// animFrame runs one frame of the animations for given time, within the
+// window event loop -- all the resulting damage is rendered together
+func (w *Window) animFrame(now time.Time) {
+	w.AnimMu.Lock()
+	w.animPending = false
+	anims := make([]Animator, len(w.Anims))
+	copy(anims, w.Anims)
+	w.AnimMu.Unlock()
+	if len(anims) == 0 || w.IsResizing() || w.IsUpdating() {
+		return
+	}
+	updt := w.UpdateStart()
+	for _, an := range anims {
+		if an.Animate(now) {
+			w.DeleteAnim(an)
+		}
+	}
+	w.UpdateEnd(updt)
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+)
+
+// Per-side box styles: margin, padding, border-width and border-radius (and
+// outline-width, outline-radius) can be specified for each side (or corner),
+// using the longhand properties, e.g., margin-top, border-left-width,
+// border-top-left-radius, or the CSS shorthands with 1 to 4 values: 1 = all
+// sides, 2 = top & bottom, right & left, 3 = top, right & left, bottom, 4 =
+// top, right, bottom, left -- for radii the order is top-left, top-right,
+// bottom-right, bottom-left.  The longhands take precedence over the
+// shorthand when both are set in the same props.  The shorthand fields
+// (e.g., Layout.Margin) retain the first value, for code that only deals with
+// uniform values.
+
+// SideFloats contains float32 values for each side of a box, in dots, in
+// BoxSides order: top, right, bottom, left -- also used for the corner radii
+// of a box, in the CSS border-radius order: top-left, top-right,
+// bottom-right, bottom-left
+type SideFloats [BoxN]float32
+
+// NewSideFloats returns SideFloats with the same value on all sides
+func NewSideFloats(val float32) SideFloats {
+	return SideFloats{val, val, val, val}
+}
+
+// Add returns the sum of these and other side values
+func (sf SideFloats) Add(o SideFloats) SideFloats {
+	for i := range sf {
+		sf[i] += o[i]
+	}
+	return sf
+}
+
+// Pos returns the space before the content, at the left (X) and top (Y)
+func (sf SideFloats) Pos() Vec2D {
+	return Vec2D{sf[BoxLeft], sf[BoxTop]}
+}
+
+// Size returns the total space on both sides along each dimension: left +
+// right (X) and top + bottom (Y)
+func (sf SideFloats) Size() Vec2D {
+	return Vec2D{sf[BoxLeft] + sf[BoxRight], sf[BoxTop] + sf[BoxBottom]}
+}
+
+// IsUniform returns true if all sides have the same value
+func (sf SideFloats) IsUniform() bool {
+	return sf[1] == sf[0] && sf[2] == sf[0] && sf[3] == sf[0]
+}
+
+// IsZero returns true if all sides are zero
+func (sf SideFloats) IsZero() bool {
+	return sf == SideFloats{}
+}
+
+// Avg returns the average value across the sides
+func (sf SideFloats) Avg() float32 {
+	return 0.25 * (sf[0] + sf[1] + sf[2] + sf[3])
+}
+
+// BoxSideProps are the shorthand properties that can set each side (or
+// corner) of a box, with the longhand properties for each side, in BoxSides
+// (or border-radius corner) order
+var BoxSideProps = map[string][BoxN]string{
+	"margin":         {"margin-top", "margin-right", "margin-bottom", "margin-left"},
+	"padding":        {"padding-top", "padding-right", "padding-bottom", "padding-left"},
+	"border-width":   {"border-top-width", "border-right-width", "border-bottom-width", "border-left-width"},
+	"border-radius":  {"border-top-left-radius", "border-top-right-radius", "border-bottom-right-radius", "border-bottom-left-radius"},
+	"outline-width":  {"outline-top-width", "outline-right-width", "outline-bottom-width", "outline-left-width"},
+	"outline-radius": {"outline-top-left-radius", "outline-top-right-radius", "outline-bottom-right-radius", "outline-bottom-left-radius"},
+}
+
+// ParseBoxSides parses a CSS shorthand value with 1 to 4 values into values
+// for each side -- a value can also be a units.Value or a number, which
+// applies to all sides.  For radii, any elliptical radii after a / are
+// ignored.  Returns false if the value could not be parsed.
+func ParseBoxSides(val interface{}) ([BoxN]units.Value, bool) {
+	var sides [BoxN]units.Value
+	str, ok := val.(string)
+	if !ok {
+		if err := sides[0].SetIFace(val); err != nil {
+			return sides, false
+		}
+		sides[1], sides[2], sides[3] = sides[0], sides[0], sides[0]
+		return sides, true
+	}
+	if si := strings.Index(str, "/"); si >= 0 && !strings.Contains(str, "(") {
+		str = str[:si]
+	}
+	flds := splitStyleFields(str)
+	if len(flds) == 0 || len(flds) > 4 {
+		return sides, false
+	}
+	var vals [BoxN]units.Value
+	for i, f := range flds {
+		vals[i].SetString(f)
+	}
+	switch len(flds) {
+	case 1:
+		sides = [BoxN]units.Value{vals[0], vals[0], vals[0], vals[0]}
+	case 2:
+		sides = [BoxN]units.Value{vals[0], vals[1], vals[0], vals[1]}
+	case 3:
+		sides = [BoxN]units.Value{vals[0], vals[1], vals[2], vals[1]}
+	default:
+		sides = vals
+	}
+	return sides, true
+}
+
+// splitStyleFields splits a property value string on whitespace, except
+// within parentheses, e.g., calc(100% - 2em)
+func splitStyleFields(str string) []string {
+	var flds []string
+	depth := 0
+	st := -1
+	for i, r := range str {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case depth == 0 && (r == ' ' || r == '\t' || r == '\n'):
+			if st >= 0 {
+				flds = append(flds, str[st:i])
+				st = -1
+			}
+			continue
+		}
+		if st < 0 {
+			st = i
+		}
+	}
+	if st >= 0 {
+		flds = append(flds, str[st:])
+	}
+	return flds
+}
+
+// SetBoxSides sets the per-side values from any box side shorthand
+// properties (see BoxSideProps) in props, except for sides that have their
+// own longhand property in props -- called in SetStyleProps
+func (s *Style) SetBoxSides(par *Style, props ki.Props) {
+	objptr := reflect.ValueOf(s).Pointer()
+	var parptr uintptr
+	if par != nil {
+		parptr = reflect.ValueOf(par).Pointer()
+	}
+	for key, sides := range BoxSideProps {
+		val, has := props[key]
+		if !has {
+			continue
+		}
+		if vstr, ok := val.(string); ok && HasVarRef(vstr) {
+			rval, ok := ResolveVars(vstr, s.Vars)
+			if !ok {
+				continue
+			}
+			val = rval
+		}
+		var vals [BoxN]units.Value
+		switch val {
+		case "inherit":
+			if par == nil {
+				continue
+			}
+			for i, lk := range sides {
+				vals[i] = *StyleFields.Fields[lk].UnitsValue(parptr)
+			}
+		case "initial":
+			for i, lk := range sides {
+				vals[i] = StyleFields.Fields[lk].Default.Interface().(units.Value)
+			}
+		default:
+			var ok bool
+			if vals, ok = ParseBoxSides(val); !ok {
+				continue
+			}
+		}
+		for i, lk := range sides {
+			if _, has := props[lk]; has { // longhand takes precedence
+				continue
+			}
+			*StyleFields.Fields[lk].UnitsValue(objptr) = vals[i]
+		}
+	}
+}
+
+// InnerRadii returns the corner radii at the inner (padding) edge of a
+// border with given outer corner radii and widths on each side: each radius
+// is reduced by the larger of the adjacent border widths
+func InnerRadii(rad, bw SideFloats) SideFloats {
+	adj := [BoxN][2]BoxSides{{BoxTop, BoxLeft}, {BoxTop, BoxRight}, {BoxBottom, BoxRight}, {BoxBottom, BoxLeft}}
+	var irad SideFloats
+	for i := range irad {
+		irad[i] = Max32(rad[i]-Max32(bw[adj[i][0]], bw[adj[i][1]]), 0)
+	}
+	return irad
+}
+
+// MarginDots returns the margin on each side, in dots
+func (ls *LayoutStyle) MarginDots() SideFloats {
+	return SideFloats{ls.MarginTop.Dots, ls.MarginRight.Dots, ls.MarginBottom.Dots, ls.MarginLeft.Dots}
+}
+
+// PaddingDots returns the padding on each side, in dots
+func (ls *LayoutStyle) PaddingDots() SideFloats {
+	return SideFloats{ls.PaddingTop.Dots, ls.PaddingRight.Dots, ls.PaddingBottom.Dots, ls.PaddingLeft.Dots}
+}
+
+// WidthDots returns the border width on each side, in dots
+func (bs *BorderStyle) WidthDots() SideFloats {
+	return SideFloats{bs.TopWidth.Dots, bs.RightWidth.Dots, bs.BottomWidth.Dots, bs.LeftWidth.Dots}
+}
+
+// RadiusDots returns the radius of each corner, in dots, in the order:
+// top-left, top-right, bottom-right, bottom-left
+func (bs *BorderStyle) RadiusDots() SideFloats {
+	return SideFloats{bs.TopLeftRadius.Dots, bs.TopRightRadius.Dots, bs.BottomRightRadius.Dots, bs.BottomLeftRadius.Dots}
+}
+
+// BoxSpaceSides returns extra space around the central content in the box
+// model on each side, in dots: margin + border + padding
+func (s *Style) BoxSpaceSides() SideFloats {
+	return s.Layout.MarginDots().Add(s.Border.WidthDots()).Add(s.Layout.PaddingDots())
+}
+
+// BoxSpace returns extra space around the central content in the box model,
+// in dots -- box outside-in: margin | border | padding | content -- if the
+// space differs across sides, this is the average.
+//
+// Deprecated: use BoxSpaceSides, which has the space on each side.
+func (s *Style) BoxSpace() float32 {
+	return s.BoxSpaceSides().Avg()
+}
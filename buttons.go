@@ -215,7 +215,7 @@ func (bb *ButtonBase) SetButtonState(state ButtonStates) bool {
 		}
 	}
 	bb.State = state
-	bb.Sty = bb.StateStyles[state]
+	bb.TransitionStyle(&bb.StateStyles[state])
 	if prev != bb.State {
 		bb.SetFullReRenderIconLabel() // needs full rerender to update text, icon
 		return true
@@ -244,7 +244,7 @@ func (bb *ButtonBase) UpdateButtonStyle() bool {
 			bb.State = ButtonActive
 		}
 	}
-	bb.Sty = bb.StateStyles[bb.State]
+	bb.TransitionStyle(&bb.StateStyles[bb.State])
 	bb.This.(ButtonWidget).ConfigPartsIfNeeded()
 	if prev != bb.State {
 		bb.SetFullReRenderIconLabel() // needs full rerender
@@ -555,6 +555,7 @@ func (bb *ButtonBase) StyleButton() {
 				bb.StateStyles[i].SetStyleProps(pst, stclsp)
 			}
 		}
+		bb.StateStyles[i].StyleCSS(bb.This.(Node2D), bb.CSSAgg, ButtonSelectors[i])
 		bb.StateStyles[i].CopyUnitContext(&bb.Sty.UnContext)
 	}
 }
@@ -643,6 +644,7 @@ var ButtonProps = ki.Props{
 	"text-align":       AlignCenter,
 	"background-color": &Prefs.Colors.Control,
 	"color":            &Prefs.Colors.Font,
+	"transition":       "background-color 100ms ease-out, border-color 100ms ease-out",
 	"#space": ki.Props{
 		"width":     units.NewValue(.5, units.Ch),
 		"min-width": units.NewValue(.5, units.Ch),
@@ -675,8 +677,8 @@ var ButtonProps = ki.Props{
 		"background-color": "linear-gradient(lighter-0, highlight-10)",
 	},
 	ButtonSelectors[ButtonInactive]: ki.Props{
-		"border-color": "lighter-50",
-		"color":        "lighter-50",
+		"border-color": "highlight-50",
+		"color":        "highlight-50",
 	},
 	ButtonSelectors[ButtonHover]: ki.Props{
 		"background-color": "linear-gradient(highlight-10, highlight-10)",
@@ -686,7 +688,7 @@ var ButtonProps = ki.Props{
 		"background-color": "linear-gradient(samelight-50, highlight-10)",
 	},
 	ButtonSelectors[ButtonDown]: ki.Props{
-		"color":            "lighter-90",
+		"color":            "highlight-90",
 		"background-color": "linear-gradient(highlight-30, highlight-10)",
 	},
 	ButtonSelectors[ButtonSelected]: ki.Props{
@@ -484,6 +484,69 @@ func readFraction(v string) (f float64, err error) {
 // 	return nil, nil // not a gradient url, and not an error
 // }
 
+// MarshalXML writes the gradient of the color specification as an SVG
+// linearGradient or radialGradient element with its stops, adding to the
+// attributes in se (e.g., the id, which must be set externally) -- the
+// complement of UnmarshalXML
+func (cs *ColorSpec) MarshalXML(enc *xml.Encoder, se xml.StartElement) error {
+	g := cs.Gradient
+	if g == nil {
+		return fmt.Errorf("gi.ColorSpec.MarshalXML: color spec does not have a gradient")
+	}
+	num := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 32) }
+	attr := func(nm, val string) {
+		se.Attr = append(se.Attr, xml.Attr{Name: xml.Name{Local: nm}, Value: val})
+	}
+	p := g.Points
+	if cs.Source == RadialGradient {
+		se.Name.Local = "radialGradient"
+		attr("cx", num(p[0]))
+		attr("cy", num(p[1]))
+		attr("r", num(p[4]))
+		if p[2] != p[0] || p[3] != p[1] {
+			attr("fx", num(p[2]))
+			attr("fy", num(p[3]))
+		}
+	} else {
+		se.Name.Local = "linearGradient"
+		attr("x1", num(p[0]))
+		attr("y1", num(p[1]))
+		attr("x2", num(p[2]))
+		attr("y2", num(p[3]))
+	}
+	if g.Units == rasterx.UserSpaceOnUse {
+		attr("gradientUnits", "userSpaceOnUse")
+	}
+	switch g.Spread {
+	case rasterx.ReflectSpread:
+		attr("spreadMethod", "reflect")
+	case rasterx.RepeatSpread:
+		attr("spreadMethod", "repeat")
+	}
+	if m := g.Matrix; m != rasterx.Identity {
+		attr("gradientTransform", fmt.Sprintf("matrix(%v %v %v %v %v %v)", num(m.A), num(m.B), num(m.C), num(m.D), num(m.E), num(m.F)))
+	}
+	if err := enc.EncodeToken(se); err != nil {
+		return err
+	}
+	for _, stop := range g.Stops {
+		hex, a := svgColor(stop.StopColor)
+		ss := xml.StartElement{Name: xml.Name{Local: "stop"}}
+		ss.Attr = []xml.Attr{{Name: xml.Name{Local: "offset"}, Value: num(stop.Offset)},
+			{Name: xml.Name{Local: "stop-color"}, Value: hex}}
+		if op := float64(a) * stop.Opacity; op < 1 {
+			ss.Attr = append(ss.Attr, xml.Attr{Name: xml.Name{Local: "stop-opacity"}, Value: num(op)})
+		}
+		if err := enc.EncodeToken(ss); err != nil {
+			return err
+		}
+		if err := enc.EncodeToken(ss.End()); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(se.End())
+}
+
 func (cs *ColorSpec) ReadGradAttr(attr xml.Attr) (err error) {
 	switch attr.Name.Local {
 	case "gradientTransform":
@@ -6,6 +6,9 @@ package gi
 
 import (
 	"log"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/aymerick/douceur/css"
 	"github.com/aymerick/douceur/parser"
@@ -37,7 +40,10 @@ func (ss *StyleSheet) ParseString(str string) error {
 }
 
 // CSSProps returns the properties for each of the rules in this style sheet,
-// suitable for setting the CSS value of a node -- returns nil if empty sheet
+// suitable for setting the CSS value of a node -- returns nil if empty sheet.
+// The rules within @media rules are stored as a sub-map under a key of
+// "@media " + the query -- see MediaQuery -- and likewise the keyframes of
+// @keyframes rules under "@keyframes " + the name -- see StyleAnim.
 func (ss *StyleSheet) CSSProps() ki.Props {
 	if ss.Sheet == nil {
 		return nil
@@ -49,19 +55,168 @@ func (ss *StyleSheet) CSSProps() ki.Props {
 	pr := make(ki.Props, sz)
 	for _, r := range ss.Sheet.Rules {
 		if r.Kind == css.AtRule {
-			continue // not supported
+			if (r.Name != MediaPrefix && r.Name != KeyframesPrefix) || len(r.Rules) == 0 {
+				continue // not supported
+			}
+			key := r.Name + " " + strings.TrimSpace(r.Prelude)
+			var mp ki.Props
+			if mpi, has := pr[key]; has {
+				mp = mpi.(ki.Props)
+			} else {
+				mp = make(ki.Props, len(r.Rules))
+				pr[key] = mp
+			}
+			for _, mr := range r.Rules {
+				if mr.Kind == css.QualifiedRule {
+					addCSSRuleProps(mp, mr)
+				}
+			}
+			continue
+		}
+		addCSSRuleProps(pr, r)
+	}
+	return pr
+}
+
+// addCSSRuleProps adds the declarations of given rule to the props for each
+// of its selectors -- later rules add to / override earlier ones
+func addCSSRuleProps(pr ki.Props, r *css.Rule) {
+	nd := len(r.Declarations)
+	if nd == 0 {
+		return
+	}
+	for _, sel := range r.Selectors {
+		var sp ki.Props
+		if spi, has := pr[sel]; has {
+			sp = spi.(ki.Props)
+		} else {
+			sp = make(ki.Props, nd)
+			pr[sel] = sp
+		}
+		for _, de := range r.Declarations {
+			sp[de.Property] = de.Value
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   CSS cascade
+
+// cssSelCache caches the parsed selectors for css property keys -- keys that
+// are not valid selectors are stored as nil
+var cssSelCache = map[string][]*Selector{}
+var cssSelCacheMu sync.Mutex
+
+// cssSelectors returns the parsed selectors for given css key, or nil if not
+// a valid selector
+func cssSelectors(key string) []*Selector {
+	cssSelCacheMu.Lock()
+	defer cssSelCacheMu.Unlock()
+	if sels, ok := cssSelCache[key]; ok {
+		return sels
+	}
+	sels, _ := ParseSelectors(key)
+	cssSelCache[key] = sels
+	return sels
+}
+
+// cssMatch is a css property map that matched a node, with the specificity
+// of the matching selector, the @media nesting depth, and its key, for
+// ordering
+type cssMatch struct {
+	spec  int
+	media int
+	key   string
+	props ki.Props
+}
+
+// MatchCSS returns the property maps in css (whose keys are selectors, as
+// returned by StyleSheet.CSSProps, and sub-maps of which are properties) that
+// match given node, in cascade order: less specific selectors first, so that
+// applying them in order gives more specific selectors precedence -- see
+// Selector for the supported selector syntax.  Selectors with equal
+// specificity are ordered by their key.  If state is non-empty (e.g.,
+// :hover), then the properties for that state style of the node are
+// returned: those with selectors ending in that state pseudo-class (e.g.,
+// button:hover), and state sub-maps (e.g., "button": {":hover": {...}}) of
+// selectors matching the node.  Otherwise, selectors ending in a state
+// pseudo-class are skipped, so that the current state of the node (e.g.,
+// hovered) is not baked into its base style.  The rules within @media keys are
+// included if their query matches the MediaContext of the node, and take
+// precedence over rules of the same specificity outside of them.
+func MatchCSS(node ki.Ki, css ki.Props, state string) []ki.Props {
+	if len(css) == 0 {
+		return nil
+	}
+	var mc *MediaContext
+	ms := matchCSS(node, css, state, 0, &mc, nil)
+	if len(ms) == 0 {
+		return nil
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].spec != ms[j].spec {
+			return ms[i].spec < ms[j].spec
+		}
+		if ms[i].media != ms[j].media {
+			return ms[i].media < ms[j].media
+		}
+		return ms[i].key < ms[j].key
+	})
+	rval := make([]ki.Props, len(ms))
+	for i := range ms {
+		rval[i] = ms[i].props
+	}
+	return rval
+}
+
+// matchCSS adds the matches for given node in css to ms, recursing into
+// matching @media rules -- the MediaContext is only computed if needed
+func matchCSS(node ki.Ki, css ki.Props, state string, media int, mc **MediaContext, ms []cssMatch) []cssMatch {
+	st := strings.TrimPrefix(state, ":")
+	for key, pp := range css {
+		pmap, ok := pp.(ki.Props) // must be a props map
+		if !ok {
+			continue
 		}
-		nd := len(r.Declarations)
-		if nd == 0 {
+		if IsMediaKey(key) {
+			mq := MediaQueryForKey(key)
+			if mq == nil {
+				continue
+			}
+			if *mc == nil {
+				*mc = MediaContextForNode(node)
+			}
+			if mq.Matches(*mc) {
+				ms = matchCSS(node, pmap, state, media+1, mc, ms)
+			}
 			continue
 		}
-		for _, sel := range r.Selectors {
-			sp := make(ki.Props, nd)
-			for _, de := range r.Declarations {
-				sp[de.Property] = de.Value
+		sels := cssSelectors(key)
+		spec := -1
+		var mp ki.Props
+		for _, sel := range sels {
+			ss := sel.Specificity()
+			if ss <= spec {
+				continue
+			}
+			switch {
+			case st == "":
+				if !sel.HasState() && sel.Matches(node) { // state selectors are only for the state styles
+					spec, mp = ss, pmap
+				}
+			case sel.HasState():
+				if sel.MatchesState(node, st) {
+					spec, mp = ss, pmap
+				}
+			default:
+				if sp, has := SubProps(pmap, state); has && sel.Matches(node) {
+					spec, mp = ss+100, sp // state counts as a pseudo-class
+				}
 			}
-			pr[sel] = sp
+		}
+		if mp != nil {
+			ms = append(ms, cssMatch{spec: spec, media: media, key: key, props: mp})
 		}
 	}
-	return pr
+	return ms
 }
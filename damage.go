@@ -0,0 +1,322 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/goki/prof"
+)
+
+// Damage (dirty-region) rendering: when a node is updated without any
+// structural changes, the region that it occupies in the window is marked as
+// damaged, instead of rendering the node and uploading it directly.  When the
+// outermost UpdateEnd on the Window happens, all the damage is coalesced, and
+// each damaged region is re-rendered from the top-level viewport down, with
+// drawing clipped to the region and only the nodes that overlap it rendering
+// -- this gets the backgrounds and overlapping nodes right, and then only
+// those regions are uploaded to the window texture, prior to publishing.
+// Wrap a batch of changes in UpdateStart / UpdateEnd on the Window to have
+// them rendered and uploaded together.
+
+// DamageRender2D enables damage rendering -- if false, updated nodes are
+// re-rendered directly and their region is uploaded immediately
+var DamageRender2D = true
+
+// DamageFlash2D can be set to true to briefly flash each region that is
+// repainted by damage rendering, in DamageFlashColor -- for debugging
+var DamageFlash2D = false
+
+// DamageFlashColor is the color used to tint repainted regions when
+// DamageFlash2D is on
+var DamageFlashColor = color.RGBA{128, 0, 0, 128}
+
+// DamageFlashMSec is the number of milliseconds that repainted regions are
+// tinted when DamageFlash2D is on
+var DamageFlashMSec = 150
+
+// DamageMaxRects is the maximum number of separate damaged regions rendered
+// for each viewport in one update -- beyond this they are all merged into
+// their bounding box
+var DamageMaxRects = 16
+
+// WinDamage is a damaged region of a window, to be re-rendered by given
+// top-level (main or popup) viewport
+type WinDamage struct {
+	Vp   *Viewport2D     `desc:"top-level viewport that renders the region"`
+	Rect image.Rectangle `desc:"damaged region, in window coordinates"`
+}
+
+// DamageViewport returns the top-level viewport (the main viewport or an
+// active popup) that renders the damage for nodes in given viewport, or nil
+// if damage rendering is not available (e.g., for overlays and sprites)
+func (w *Window) DamageViewport(vp *Viewport2D) *Viewport2D {
+	if !DamageRender2D || vp == nil || w.IsInactive() || w.WinTex == nil {
+		return nil
+	}
+	for !vp.IsPopup() && vp.Viewport != nil {
+		vp = vp.Viewport
+	}
+	if vp == w.Viewport || w.IsPopupVp(vp) {
+		return vp
+	}
+	return nil
+}
+
+// IsPopupVp returns true if given viewport is the current popup or one on the
+// PopupStack
+func (w *Window) IsPopupVp(vp *Viewport2D) bool {
+	if w.Popup == vp.This {
+		return true
+	}
+	for _, pop := range w.PopupStack {
+		if pop == vp.This {
+			return true
+		}
+	}
+	return false
+}
+
+// VpWinBBox returns the region of the window covered by given top-level
+// (main or popup) viewport
+func (w *Window) VpWinBBox(vp *Viewport2D) image.Rectangle {
+	if vp == w.Viewport {
+		return vp.OSImage.Bounds()
+	}
+	return vp.Geom.Bounds()
+}
+
+// AddDamage marks the given region of the window (in window coordinates) as
+// damaged, for nodes within given viewport -- it is re-rendered and uploaded
+// when the current update of the window ends (see RenderDamage) -- returns
+// false if damage rendering is not available for the viewport
+func (w *Window) AddDamage(vp *Viewport2D, r image.Rectangle) bool {
+	tvp := w.DamageViewport(vp)
+	if tvp == nil {
+		return false
+	}
+	if r.Empty() {
+		return true
+	}
+	w.DamageMu.Lock()
+	w.Damage = append(w.Damage, WinDamage{Vp: tvp, Rect: r})
+	w.DamageMu.Unlock()
+	return true
+}
+
+// RenderDamage re-renders all the damaged regions, each within its top-level
+// viewport, and uploads them to the window texture -- called when the window
+// update ends, prior to Publish.  Nodes that need a full re-render during
+// this process add further damage, which is rendered in turn.
+func (w *Window) RenderDamage() {
+	if w.IsInactive() || w.WinTex == nil || w.IsClosed() {
+		w.DamageMu.Lock()
+		w.Damage = nil
+		w.DamageMu.Unlock()
+		return
+	}
+	var all []image.Rectangle
+	for pass := 0; pass < 4; pass++ {
+		w.DamageMu.Lock()
+		dmg := w.Damage
+		w.Damage = nil
+		w.DamageMu.Unlock()
+		if len(dmg) == 0 {
+			break
+		}
+		pr := prof.Start("win.RenderDamage")
+		updt := w.UpdateStart()
+		var vps []*Viewport2D
+		vrs := make(map[*Viewport2D][]image.Rectangle)
+		for _, d := range dmg {
+			if _, has := vrs[d.Vp]; !has {
+				vps = append(vps, d.Vp)
+			}
+			vrs[d.Vp] = append(vrs[d.Vp], d.Rect)
+		}
+		for _, vp := range vps {
+			if vp != w.Viewport && !w.IsPopupVp(vp) { // popup was closed
+				continue
+			}
+			vb := w.VpWinBBox(vp)
+			for _, r := range CoalesceRects(vrs[vp], DamageMaxRects) {
+				r = r.Intersect(vb)
+				if r.Empty() {
+					continue
+				}
+				if Render2DTrace {
+					fmt.Printf("Window: %v rendering damage in Vp %v at: %v\n", w.PathUnique(), vp.PathUnique(), r)
+				}
+				vp.RenderDamage(r.Sub(vb.Min))
+				all = append(all, r)
+			}
+		}
+		w.UpdateEndNoSig(updt)
+		pr.End()
+	}
+	if len(all) == 0 {
+		return
+	}
+	all = CoalesceRects(all, DamageMaxRects)
+	w.UploadDamage(all)
+	if DamageFlash2D {
+		w.FlashDamage(all)
+	}
+}
+
+// UploadDamage uploads given regions of the window (in window coordinates)
+// from the main viewport and then any popups over them, in the same order as
+// UploadAllViewports
+func (w *Window) UploadDamage(rects []image.Rectangle) {
+	if w.IsInactive() || w.WinTex == nil {
+		return
+	}
+	w.UpMu.Lock()
+	if w.IsClosed() { // could have closed while we waited for lock
+		w.UpMu.Unlock()
+		return
+	}
+	w.SetUpdating()
+	pr := prof.Start("win.UploadDamage")
+	vps := []*Viewport2D{w.Viewport}
+	for _, pop := range w.PopupStack {
+		if gii, _ := KiToNode2D(pop); gii != nil {
+			vps = append(vps, gii.AsViewport2D())
+		}
+	}
+	if w.Popup != nil {
+		if gii, _ := KiToNode2D(w.Popup); gii != nil {
+			vps = append(vps, gii.AsViewport2D())
+		}
+	}
+	for _, r := range rects {
+		for _, vp := range vps {
+			if vp == nil || vp.OSImage == nil {
+				continue
+			}
+			vb := w.VpWinBBox(vp)
+			ur := r.Intersect(vb)
+			if ur.Empty() {
+				continue
+			}
+			if Render2DTrace {
+				fmt.Printf("Window: %v uploading damage from Vp %v at: %v\n", w.PathUnique(), vp.PathUnique(), ur)
+			}
+			w.WinTex.Upload(ur.Min, vp.OSImage, ur.Sub(vb.Min))
+		}
+	}
+	pr.End()
+	w.ClearUpdating()
+	w.UpMu.Unlock()
+}
+
+// FlashDamage tints given regions of the window texture in DamageFlashColor,
+// and restores them after DamageFlashMSec -- used for DamageFlash2D
+func (w *Window) FlashDamage(rects []image.Rectangle) {
+	w.UpMu.Lock()
+	if w.IsClosed() || w.WinTex == nil {
+		w.UpMu.Unlock()
+		return
+	}
+	for _, r := range rects {
+		w.WinTex.Fill(r, DamageFlashColor, draw.Over)
+	}
+	w.UpMu.Unlock()
+	time.AfterFunc(time.Duration(DamageFlashMSec)*time.Millisecond, func() {
+		if w.IsClosed() {
+			return
+		}
+		w.UploadDamage(rects)
+		w.Publish()
+	})
+}
+
+// CoalesceRects merges rectangles that overlap, or whose bounding box is no
+// larger than their combined area, and merges all of them into their bounding
+// box if more than max remain -- empty rectangles are dropped
+func CoalesceRects(rects []image.Rectangle, max int) []image.Rectangle {
+	rs := make([]image.Rectangle, 0, len(rects))
+	for _, r := range rects {
+		if !r.Empty() {
+			rs = append(rs, r)
+		}
+	}
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(rs); i++ {
+			for j := i + 1; j < len(rs); j++ {
+				u := rs[i].Union(rs[j])
+				if rs[i].Overlaps(rs[j]) || rectArea(u) <= rectArea(rs[i])+rectArea(rs[j]) {
+					rs[i] = u
+					rs = append(rs[:j], rs[j+1:]...)
+					j = i
+					merged = true
+				}
+			}
+		}
+	}
+	if max > 0 && len(rs) > max {
+		u := rs[0]
+		for _, r := range rs[1:] {
+			u = u.Union(r)
+		}
+		rs = []image.Rectangle{u}
+	}
+	return rs
+}
+
+// rectArea returns the area of the rectangle
+func rectArea(r image.Rectangle) int {
+	sz := r.Size()
+	return sz.X * sz.Y
+}
+
+// RenderDamage re-renders the given region of the viewport (in its own
+// coordinates): the region is filled with the background, and Render2D then
+// runs from the viewport down, with all drawing clipped to the region and
+// only the nodes that overlap it rendering -- the result is not uploaded: see
+// Window.RenderDamage
+func (vp *Viewport2D) RenderDamage(r image.Rectangle) {
+	if vp.Pixels == nil || vp.IsUpdatingAtomic() {
+		return
+	}
+	r = r.Intersect(vp.Pixels.Bounds())
+	if r.Empty() {
+		return
+	}
+	rs := &vp.Render
+	rs.DamageBounds = r
+	rs.PushBounds(r)
+	vp.FillViewport()
+	rs.PopBounds()
+	vp.Render2DTree()
+	rs.DamageBounds = image.ZR
+}
+
+// AddDamage marks the window region of this node as damaged, so that it is
+// re-rendered and uploaded at the end of the current window update -- returns
+// false if damage rendering is not available for this node, in which case
+// it must be rendered directly
+func (nb *Node2DBase) AddDamage() bool {
+	if nb.Viewport == nil || nb.Viewport.Win == nil {
+		return false
+	}
+	return nb.Viewport.Win.AddDamage(nb.Viewport, nb.WinBBox)
+}
+
+// OutsideDamage returns true if the viewport is only re-rendering a damaged
+// region (see Viewport2D.RenderDamage) and this node does not overlap it, so
+// it does not need to render
+func (nb *Node2DBase) OutsideDamage() bool {
+	if nb.Viewport == nil {
+		return false
+	}
+	db := nb.Viewport.Render.DamageBounds
+	return !db.Empty() && !nb.VpBBox.Overlaps(db)
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"testing"
+)
+
+func TestCoalesceRects(t *testing.T) {
+	r := image.Rect
+	tests := []struct {
+		name  string
+		rects []image.Rectangle
+		max   int
+		cor   []image.Rectangle
+	}{
+		{"none", nil, 4, []image.Rectangle{}},
+		{"empty", []image.Rectangle{r(0, 0, 0, 10), r(5, 5, 5, 5)}, 4, []image.Rectangle{}},
+		{"disjoint", []image.Rectangle{r(0, 0, 10, 10), r(100, 100, 110, 110)}, 4, []image.Rectangle{r(0, 0, 10, 10), r(100, 100, 110, 110)}},
+		{"overlap", []image.Rectangle{r(0, 0, 10, 10), r(5, 5, 15, 15)}, 4, []image.Rectangle{r(0, 0, 15, 15)}},
+		{"adjacent", []image.Rectangle{r(0, 0, 10, 10), r(10, 0, 20, 10)}, 4, []image.Rectangle{r(0, 0, 20, 10)}},
+		{"near", []image.Rectangle{r(0, 0, 10, 10), r(11, 0, 21, 10)}, 4, []image.Rectangle{r(0, 0, 10, 10), r(11, 0, 21, 10)}},
+		{"chain", []image.Rectangle{r(0, 0, 10, 10), r(20, 0, 30, 10), r(8, 0, 22, 10)}, 4, []image.Rectangle{r(0, 0, 30, 10)}},
+		{"contained", []image.Rectangle{r(2, 2, 4, 4), r(0, 0, 10, 10), r(2, 2, 4, 4)}, 4, []image.Rectangle{r(0, 0, 10, 10)}},
+		{"max", []image.Rectangle{r(0, 0, 10, 10), r(50, 0, 60, 10), r(0, 50, 10, 60)}, 2, []image.Rectangle{r(0, 0, 60, 60)}},
+		{"no max", []image.Rectangle{r(0, 0, 10, 10), r(50, 0, 60, 10), r(0, 50, 10, 60)}, 0, []image.Rectangle{r(0, 0, 10, 10), r(50, 0, 60, 10), r(0, 50, 10, 60)}},
+	}
+	for _, tt := range tests {
+		rs := CoalesceRects(tt.rects, tt.max)
+		if len(rs) != len(tt.cor) {
+			t.Errorf("CoalesceRects %v: got %v, expected %v\n", tt.name, rs, tt.cor)
+			continue
+		}
+		for i := range rs {
+			if rs[i] != tt.cor[i] {
+				t.Errorf("CoalesceRects %v: got %v, expected %v\n", tt.name, rs, tt.cor)
+				break
+			}
+		}
+	}
+}
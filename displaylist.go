@@ -0,0 +1,445 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/ki/kit"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// A DisplayList is a replayable recording of the rendering operations done
+// on a RenderState: path construction, Fill, Stroke and Clip (with a
+// snapshot of the Paint style), solid box fills, images, masks (e.g., box
+// shadows), text spans, transforms and bounds -- sub-viewports (icons, svg
+// etc) that render while their parent is recording are recorded as nested
+// lists.  Recording is started with RenderState.StartRecording (or
+// Node2DBase.RecordRender2DTree for a subtree), and happens in addition to
+// the normal rendering.  All points are recorded in device (transformed)
+// coordinates, so a list can be replayed with Render under any additional
+// transform, e.g., to re-raster a cached subtree at a different scale or into
+// a different image, and the operations can be walked directly, e.g., by
+// vector exporters.  Text glyphs, images and masks are re-rastered by
+// transforming their pixels, so they lose some crispness at larger scales.
+type DisplayList struct {
+	Ops []DisplayOp `desc:"the recorded operations, in order"`
+}
+
+// DisplayOps are the operations recorded in a DisplayList
+type DisplayOps int32
+
+const (
+	// DisplayMoveTo starts a new subpath at Pts[0]
+	DisplayMoveTo DisplayOps = iota
+
+	// DisplayLineTo adds a line to Pts[0]
+	DisplayLineTo
+
+	// DisplayQuadTo adds a quadratic bezier with control point Pts[0] to Pts[1]
+	DisplayQuadTo
+
+	// DisplayCubicTo adds a cubic bezier with control points Pts[0], Pts[1]
+	// to Pts[2]
+	DisplayCubicTo
+
+	// DisplayClosePath closes the current subpath
+	DisplayClosePath
+
+	// DisplayClearPath clears the current path
+	DisplayClearPath
+
+	// DisplayNewSubPath starts a new subpath without a current point
+	DisplayNewSubPath
+
+	// DisplayFill fills the current path with Paint, under XForm
+	DisplayFill
+
+	// DisplayStroke strokes the current path with Paint, under XForm
+	DisplayStroke
+
+	// DisplayClip intersects the clipping mask with the current path
+	DisplayClip
+
+	// DisplayResetClip clears the clipping mask
+	DisplayResetClip
+
+	// DisplayFillBox fills Rect with the uniform Color
+	DisplayFillBox
+
+	// DisplayImage draws Image, transformed by XForm
+	DisplayImage
+
+	// DisplayMask draws the uniform Color through the alpha mask in Image,
+	// transformed by XForm
+	DisplayMask
+
+	// DisplayText draws the glyphs of Span at Pos -- any text decorations
+	// are recorded separately as fills and strokes
+	DisplayText
+
+	// DisplayPushXForm pushes XForm onto the transform stack -- not needed
+	// for replay, as points are recorded in device coordinates, but it marks
+	// groups of operations for exporters
+	DisplayPushXForm
+
+	// DisplayPopXForm pops the transform stack
+	DisplayPopXForm
+
+	// DisplayPushBounds restricts drawing to Rect
+	DisplayPushBounds
+
+	// DisplayPopBounds restores the previous bounds
+	DisplayPopBounds
+
+	// DisplaySubList replays List, transformed by XForm, within Rect if
+	// non-empty
+	DisplaySubList
+
+	DisplayOpsN
+)
+
+//go:generate stringer -type=DisplayOps
+
+var KiT_DisplayOps = kit.Enums.AddEnum(DisplayOpsN, false, nil)
+
+func (ev DisplayOps) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *DisplayOps) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// DisplayOp is one operation in a DisplayList -- which fields are used
+// depends on the Op
+type DisplayOp struct {
+	Op    DisplayOps      `desc:"the operation"`
+	Pts   []Vec2D         `desc:"points for path operations, in device coordinates"`
+	XForm Matrix2D        `desc:"transform in effect for Fill, Stroke and Clip (for gradients and stroke width), from source to device coordinates for Image, Mask and SubList, and the pushed transform for PushXForm"`
+	Rect  image.Rectangle `desc:"region for FillBox, PushBounds and SubList, in device coordinates"`
+	Paint *Paint          `desc:"snapshot of the paint style for Fill, Stroke and Clip"`
+	Color color.Color     `desc:"color for FillBox and Mask"`
+	Image image.Image     `desc:"source image for Image, alpha mask for Mask -- referenced, not copied"`
+	Span  *SpanRender     `desc:"text span for Text"`
+	Pos   Vec2D           `desc:"position of the span for Text, in device coordinates"`
+	List  *DisplayList    `desc:"nested list for SubList"`
+}
+
+// Add adds an operation to the list
+func (dl *DisplayList) Add(op DisplayOp) {
+	dl.Ops = append(dl.Ops, op)
+}
+
+// AddPts adds a path operation with given points
+func (dl *DisplayList) AddPts(op DisplayOps, pts ...Vec2D) {
+	dl.Ops = append(dl.Ops, DisplayOp{Op: op, Pts: pts})
+}
+
+// AddPaint adds a Fill, Stroke or Clip operation, with a snapshot of given
+// paint and the transform in effect
+func (dl *DisplayList) AddPaint(op DisplayOps, pc *Paint, xf Matrix2D) {
+	cp := &Paint{}
+	*cp = *pc
+	if pc.StrokeStyle.Dashes != nil {
+		cp.StrokeStyle.Dashes = append([]float64(nil), pc.StrokeStyle.Dashes...)
+	}
+	dl.Ops = append(dl.Ops, DisplayOp{Op: op, Paint: cp, XForm: xf})
+}
+
+// Reset clears all the operations
+func (dl *DisplayList) Reset() {
+	dl.Ops = nil
+}
+
+// StartRecording starts recording all the rendering operations into a new
+// DisplayList, which is returned -- rendering otherwise continues as usual
+func (rs *RenderState) StartRecording() *DisplayList {
+	rs.Rec = &DisplayList{}
+	return rs.Rec
+}
+
+// StopRecording stops recording, returning the list that was being recorded
+// (nil if not recording)
+func (rs *RenderState) StopRecording() *DisplayList {
+	dl := rs.Rec
+	rs.Rec = nil
+	return dl
+}
+
+// Render replays the list into given render state, with the recorded device
+// coordinates further transformed by xf (Identity2D to replay in place) --
+// drawing is restricted to the current bounds of the render state.  If the
+// render state is itself recording, the list is recorded as a nested list.
+func (dl *DisplayList) Render(rs *RenderState, xf Matrix2D) {
+	if rec := rs.Rec; rec != nil {
+		rec.Add(DisplayOp{Op: DisplaySubList, List: dl, XForm: xf})
+		rs.Rec = nil
+		defer func() { rs.Rec = rec }()
+	}
+	if rs.Bounds.Empty() {
+		rs.Bounds = rs.Image.Bounds()
+	}
+	var pushed []bool // bounds pushed, true if empty
+	nempty := 0
+	for i := range dl.Ops {
+		op := &dl.Ops[i]
+		switch op.Op {
+		case DisplayMoveTo:
+			p := xf.TransformPointVec2D(op.Pts[0])
+			if rs.HasCurrent {
+				rs.Path.Stop(false)
+			}
+			rs.Path.Start(p.Fixed())
+			rs.Start = p
+			rs.Current = p
+			rs.HasCurrent = true
+		case DisplayLineTo:
+			p := xf.TransformPointVec2D(op.Pts[0])
+			rs.Path.Line(p.Fixed())
+			rs.Current = p
+		case DisplayQuadTo:
+			p1 := xf.TransformPointVec2D(op.Pts[0])
+			p2 := xf.TransformPointVec2D(op.Pts[1])
+			rs.Path.QuadBezier(p1.Fixed(), p2.Fixed())
+			rs.Current = p2
+		case DisplayCubicTo:
+			p1 := xf.TransformPointVec2D(op.Pts[0])
+			p2 := xf.TransformPointVec2D(op.Pts[1])
+			p3 := xf.TransformPointVec2D(op.Pts[2])
+			rs.Path.CubeBezier(p1.Fixed(), p2.Fixed(), p3.Fixed())
+			rs.Current = p3
+		case DisplayClosePath:
+			if rs.HasCurrent {
+				rs.Path.Stop(true)
+				rs.Current = rs.Start
+			}
+		case DisplayClearPath:
+			rs.Path.Clear()
+			rs.HasCurrent = false
+		case DisplayNewSubPath:
+			rs.HasCurrent = false
+		case DisplayFill, DisplayStroke, DisplayClip:
+			if nempty > 0 {
+				continue
+			}
+			pc := *op.Paint
+			sxf := rs.XForm
+			rs.XForm = op.XForm.Multiply(xf)
+			switch op.Op {
+			case DisplayFill:
+				pc.fill(rs)
+			case DisplayStroke:
+				pc.stroke(rs)
+			default:
+				pc.ClipPreserve(rs)
+			}
+			rs.XForm = sxf
+		case DisplayResetClip:
+			rs.Mask = nil
+		case DisplayFillBox:
+			if nempty > 0 {
+				continue
+			}
+			dl.renderFillBox(rs, op, xf)
+		case DisplayImage:
+			if nempty > 0 {
+				continue
+			}
+			renderImageXForm(rs, op.XForm.Multiply(xf), op.Image, nil)
+		case DisplayMask:
+			if nempty > 0 {
+				continue
+			}
+			renderImageXForm(rs, op.XForm.Multiply(xf), image.NewUniform(op.Color), op.Image)
+		case DisplayText:
+			if nempty > 0 {
+				continue
+			}
+			op.Span.RenderGlyphs(rs, op.Pos, xf)
+		case DisplayPushBounds:
+			r := XFormRect(xf, op.Rect).Intersect(rs.Bounds)
+			rs.PushBounds(r)
+			pushed = append(pushed, r.Empty())
+			if r.Empty() {
+				nempty++
+			}
+		case DisplayPopBounds:
+			if len(pushed) == 0 { // unmatched, e.g., from before recording started
+				continue
+			}
+			if pushed[len(pushed)-1] {
+				nempty--
+			}
+			pushed = pushed[:len(pushed)-1]
+			rs.PopBounds()
+		case DisplaySubList:
+			if nempty > 0 || op.List == nil {
+				continue
+			}
+			if op.Rect.Empty() {
+				op.List.Render(rs, op.XForm.Multiply(xf))
+				continue
+			}
+			r := XFormRect(xf, op.Rect).Intersect(rs.Bounds)
+			if r.Empty() {
+				continue
+			}
+			rs.PushBounds(r)
+			op.List.Render(rs, op.XForm.Multiply(xf))
+			rs.PopBounds()
+		}
+	}
+	for range pushed {
+		rs.PopBounds()
+	}
+}
+
+// RenderImage replays the list into a new image of given size, with the
+// recorded device coordinates transformed by xf -- e.g., to render a
+// recorded region at 2x: Translate2D(-min.X, -min.Y).Scale(2, 2)
+func (dl *DisplayList) RenderImage(size image.Point, xf Matrix2D) *image.RGBA {
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	rs := &RenderState{}
+	rs.Init(size.X, size.Y, img)
+	rs.Bounds = img.Bounds()
+	dl.Render(rs, xf)
+	return img
+}
+
+// renderFillBox replays a FillBox operation -- the box is filled directly if
+// the transform keeps it axis-aligned, and as a path otherwise
+func (dl *DisplayList) renderFillBox(rs *RenderState, op *DisplayOp, xf Matrix2D) {
+	if xf.XY == 0 && xf.YX == 0 {
+		b := rs.Bounds.Intersect(XFormRect(xf, op.Rect))
+		draw.Draw(rs.Image, b, &image.Uniform{op.Color}, image.ZP, draw.Src)
+		return
+	}
+	pc := &Paint{}
+	pc.Defaults()
+	pc.FillStyle.SetColor(op.Color)
+	r := op.Rect
+	pts := [4]Vec2D{{float32(r.Min.X), float32(r.Min.Y)}, {float32(r.Max.X), float32(r.Min.Y)},
+		{float32(r.Max.X), float32(r.Max.Y)}, {float32(r.Min.X), float32(r.Max.Y)}}
+	rs.Path.Clear()
+	for i, p := range pts {
+		tp := xf.TransformPointVec2D(p).Fixed()
+		if i == 0 {
+			rs.Path.Start(tp)
+		} else {
+			rs.Path.Line(tp)
+		}
+	}
+	rs.Path.Stop(true)
+	pc.fill(rs)
+	rs.Path.Clear()
+	rs.HasCurrent = false
+}
+
+// renderImageXForm draws src, or src through the alpha mask if non-nil, into
+// the render state image with given transform from source to device
+// coordinates, restricted to the current bounds -- integer translations are
+// drawn directly, and anything else with BiLinear interpolation
+func renderImageXForm(rs *RenderState, xf Matrix2D, src image.Image, mask image.Image) {
+	dst, ok := rs.Image.SubImage(rs.Bounds).(*image.RGBA)
+	if !ok || dst.Bounds().Empty() {
+		return
+	}
+	sr := src.Bounds()
+	if mask != nil {
+		sr = mask.Bounds()
+	}
+	if xf.XX == 1 && xf.YY == 1 && xf.XY == 0 && xf.YX == 0 &&
+		xf.X0 == math32.Floor(xf.X0) && xf.Y0 == math32.Floor(xf.Y0) {
+		off := image.Point{int(xf.X0), int(xf.Y0)}
+		dr := sr.Add(off).Intersect(dst.Bounds())
+		sp := dr.Min.Sub(off)
+		if mask != nil {
+			draw.DrawMask(dst, dr, src, sp, mask, sp, draw.Over)
+		} else {
+			draw.Draw(dst, dr, src, sp, draw.Over)
+		}
+		return
+	}
+	s2d := f64.Aff3{float64(xf.XX), float64(xf.XY), float64(xf.X0), float64(xf.YX), float64(xf.YY), float64(xf.Y0)}
+	var opts *draw.Options
+	if mask != nil {
+		opts = &draw.Options{SrcMask: mask, SrcMaskP: sr.Min}
+	}
+	draw.BiLinear.Transform(dst, s2d, src, sr, draw.Over, opts)
+}
+
+// XFormRect returns the bounding box of the given rectangle after
+// transforming it by xf
+func XFormRect(xf Matrix2D, r image.Rectangle) image.Rectangle {
+	pts := [4]Vec2D{xf.TransformPointVec2D(NewVec2DFmPoint(r.Min)), xf.TransformPointVec2D(NewVec2DFmPoint(r.Max)),
+		xf.TransformPointVec2D(Vec2D{float32(r.Min.X), float32(r.Max.Y)}), xf.TransformPointVec2D(Vec2D{float32(r.Max.X), float32(r.Min.Y)})}
+	min, max := pts[0], pts[0]
+	for _, p := range pts[1:] {
+		min.SetMin(p)
+		max.SetMax(p)
+	}
+	return image.Rectangle{Min: min.ToPointFloor(), Max: max.ToPointCeil()}
+}
+
+// RecordRender2DTree renders this node and its children as usual, while
+// recording all the rendering into a DisplayList, which is returned (nil if
+// there is no viewport) -- e.g., to cache the rendering of a subtree and
+// replay it later at a different scale or into another image
+func (nb *Node2DBase) RecordRender2DTree() *DisplayList {
+	if nb.Viewport == nil {
+		return nil
+	}
+	rs := &nb.Viewport.Render
+	prev := rs.Rec
+	dl := rs.StartRecording()
+	nb.Render2DTree()
+	rs.Rec = prev
+	if prev != nil { // nested recording
+		prev.Add(DisplayOp{Op: DisplaySubList, List: dl, XForm: Identity2D()})
+	}
+	return dl
+}
+
+// RecordRender2DTree renders the viewport and its children as usual, while
+// recording all the rendering into its own render state, returning the
+// DisplayList
+func (vp *Viewport2D) RecordRender2DTree() *DisplayList {
+	rs := &vp.Render
+	prev := rs.Rec
+	dl := rs.StartRecording()
+	vp.Render2DTree()
+	rs.Rec = prev
+	if prev != nil { // nested recording
+		prev.Add(DisplayOp{Op: DisplaySubList, List: dl, XForm: Identity2D()})
+	}
+	return dl
+}
+
+// RecordForParent starts recording the rendering of this viewport if its
+// parent viewport is recording, so that DrawIntoParent records it as a nested
+// DisplayList -- call in Render2D after PushBounds
+func (vp *Viewport2D) RecordForParent() {
+	if vp.Viewport != nil && vp.Viewport.Render.Rec != nil && !vp.IsOverlay() && !vp.IsPopup() {
+		vp.Render.StartRecording()
+	}
+}
+
+// recordIntoParent records the drawing of region r of our image (starting at
+// sp) into the parent viewport, if it is recording: as a nested list if we
+// recorded our own rendering, and otherwise as an image of our pixels
+func (vp *Viewport2D) recordIntoParent(parVp *Viewport2D, r image.Rectangle, sp image.Point) {
+	prec := parVp.Render.Rec
+	sub := vp.Render.StopRecording()
+	if prec == nil || r.Empty() {
+		return
+	}
+	off := r.Min.Sub(sp)
+	if sub != nil {
+		prec.Add(DisplayOp{Op: DisplaySubList, List: sub, Rect: r, XForm: Translate2D(float32(off.X), float32(off.Y))})
+		return
+	}
+	img := image.NewRGBA(image.Rectangle{Min: sp, Max: sp.Add(r.Size())})
+	draw.Draw(img, img.Rect, vp.Pixels, sp, draw.Src)
+	prec.Add(DisplayOp{Op: DisplayImage, Image: img, XForm: Translate2D(float32(off.X), float32(off.Y))})
+}
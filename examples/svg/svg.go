@@ -24,6 +24,11 @@ func main() {
 }
 
 var CurFilename = ""
+
+// Changed is set when the drawing has been edited since it was opened or
+// saved -- zooming and panning are not edits, as they are not saved
+var Changed = false
+
 var TheSVG *svg.Editor
 var TheZoom *gi.SpinBox
 var TheTransX *gi.SpinBox
@@ -50,6 +55,7 @@ func OpenSVG(fnm string) {
 	TheSVG.SetFullReRender()
 	fmt.Printf("Opening: %v\n", CurFilename)
 	TheSVG.OpenXML(CurFilename)
+	Changed = false
 	SetZoom(TheSVG.Viewport.Win.LogicalDPI() / 96.0)
 	SetTrans(0, 0)
 	TheSVG.UpdateEnd(updt)
@@ -65,6 +71,34 @@ func FileViewOpenSVG(vp *gi.Viewport2D) {
 		})
 }
 
+// SaveSVG saves the current svg to given file, which becomes the current file
+func SaveSVG(fnm string) error {
+	CurFilename = fnm
+	TheFile.SetText(CurFilename)
+	fmt.Printf("Saving: %v\n", CurFilename)
+	err := TheSVG.SaveXML(CurFilename)
+	if err != nil {
+		fmt.Printf("Error saving: %v\n", err)
+		return err
+	}
+	Changed = false
+	return nil
+}
+
+// FileViewSaveSVG prompts for a file to save the svg to, and then calls
+// saved, if non-nil, after it has been saved there
+func FileViewSaveSVG(vp *gi.Viewport2D, saved func()) {
+	giv.FileViewDialog(vp, CurFilename, ".svg", giv.DlgOpts{Title: "Save SVG As"}, nil,
+		vp.Win, func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig == int64(gi.DialogAccepted) {
+				dlg, _ := send.(*gi.Dialog)
+				if SaveSVG(giv.FileViewDialogValue(dlg)) == nil && saved != nil {
+					saved()
+				}
+			}
+		})
+}
+
 func mainrun() {
 	width := 1600
 	height := 1200
@@ -105,6 +139,10 @@ func mainrun() {
 	loads.SetText("Open SVG")
 	loads.StartFocus()
 
+	saves := tbar.AddNewChild(gi.KiT_Action, "savesvg").(*gi.Action)
+	saves.SetText("Save SVG")
+	saves.Tooltip = "save the svg to the current file"
+
 	fnm := tbar.AddNewChild(gi.KiT_TextField, "cur-fname").(*gi.TextField)
 	TheFile = fnm
 	fnm.SetMinPrefWidth(units.NewValue(60, units.Ch))
@@ -159,6 +197,14 @@ func mainrun() {
 		FileViewOpenSVG(vp)
 	})
 
+	saves.ActionSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if CurFilename == "" {
+			FileViewSaveSVG(vp, nil)
+		} else {
+			SaveSVG(CurFilename)
+		}
+	})
+
 	fnm.TextFieldSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 		if sig == int64(gi.TextFieldDone) {
 			tf := send.(*gi.TextField)
@@ -183,6 +229,10 @@ func mainrun() {
 		win.FullReRender()
 	})
 
+	svge.EditSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		Changed = true
+	})
+
 	svge.NodeSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 		ssvg := send.Embed(svg.KiT_Editor).(*svg.Editor)
 		SetZoom(ssvg.Scale)
@@ -212,18 +262,61 @@ func mainrun() {
 		win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 			FileViewOpenSVG(vp)
 		})
+	fmen.Menu.AddAction(gi.ActOpts{Label: "Save", Shortcut: "Command+S"},
+		win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+			if CurFilename == "" {
+				FileViewSaveSVG(vp, nil)
+			} else {
+				SaveSVG(CurFilename)
+			}
+		})
+	fmen.Menu.AddAction(gi.ActOpts{Label: "Save As..", Shortcut: "Shift+Command+S"},
+		win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+			FileViewSaveSVG(vp, nil)
+		})
 	fmen.Menu.AddSeparator("csep")
 	fmen.Menu.AddAction(gi.ActOpts{Label: "Close Window", Shortcut: "Command+W"},
 		win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
-			win.OSWin.Close()
+			win.OSWin.CloseReq()
 		})
 
+	inClosePrompt := false
+	win.OSWin.SetCloseReqFunc(func(w oswin.Window) {
+		if !Changed {
+			w.Close()
+			return
+		}
+		if !inClosePrompt {
+			inClosePrompt = true
+			gi.ChoiceDialog(vp, gi.DlgOpts{Title: "Save Before Closing?",
+				Prompt: "Do you want to save your changes to: " + CurFilename + "?"},
+				[]string{"Save and Close", "Close Without Saving", "Cancel"},
+				win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+					switch sig {
+					case 0:
+						if CurFilename == "" {
+							inClosePrompt = false
+							FileViewSaveSVG(vp, func() { w.Close() })
+							return
+						}
+						if SaveSVG(CurFilename) != nil {
+							inClosePrompt = false
+							return
+						}
+						w.Close()
+					case 1:
+						w.Close()
+					case 2:
+						inClosePrompt = false
+					}
+				})
+		}
+	})
+
 	win.OSWin.SetCloseCleanFunc(func(w oswin.Window) {
 		go oswin.TheApp.Quit() // once main window is closed, quit
 	})
 
-	// todo: when saving works, add option to save, and change above to CloseReq
-
 	win.MainMenuUpdated()
 
 	win.StartEventLoop()
@@ -60,36 +60,28 @@ func (fr *Frame) FrameStdRender() {
 	sz := fr.LayData.AllocSize
 	pc.FillBox(rs, pos, sz, &st.Font.BgColor)
 
-	rad := st.Border.Radius.Dots
-	pos = pos.AddVal(st.Layout.Margin.Dots).SubVal(0.5 * st.Border.Width.Dots)
-	sz = sz.SubVal(2.0 * st.Layout.Margin.Dots).AddVal(st.Border.Width.Dots)
-
-	// then any shadow -- todo: optimize!
-	if st.BoxShadow.HasShadow() {
-		spos := pos.Add(Vec2D{st.BoxShadow.HOffset.Dots, st.BoxShadow.VOffset.Dots})
+	rad := st.Border.RadiusDots()
+	mrg := st.Layout.MarginDots()
+	pos = pos.Add(mrg.Pos())
+	sz = sz.Sub(mrg.Size())
+
+	// then any shadows, with the background redrawn over the outset ones
+	if st.HasShadows(false) {
+		st.RenderShadows(rs, pos, sz, rad, false)
 		pc.StrokeStyle.SetColor(nil)
-		pc.FillStyle.SetColor(&st.BoxShadow.Color)
-		if rad == 0.0 {
-			pc.DrawRectangle(rs, spos.X, spos.Y, sz.X, sz.Y)
-		} else {
-			pc.DrawRoundedRectangle(rs, spos.X, spos.Y, sz.X, sz.Y, rad)
-		}
+		pc.FillStyle.SetColorSpec(&st.Font.BgColor)
+		pc.DrawRoundedRectangleRadii(rs, pos.X, pos.Y, sz.X, sz.Y, rad)
 		pc.FillStrokeClear(rs)
 	}
+	st.RenderShadows(rs, pos, sz, rad, true)
 
 	if fr.Lay == LayoutGrid && fr.Stripes != NoStripes {
 		fr.RenderStripes()
 	}
 
-	pc.FillStyle.SetColor(nil)
-	pc.StrokeStyle.SetColor(&st.Border.Color)
-	pc.StrokeStyle.Width = st.Border.Width
-	if rad == 0.0 {
-		pc.DrawRectangle(rs, pos.X, pos.Y, sz.X, sz.Y)
-	} else {
-		pc.DrawRoundedRectangle(rs, pos.X, pos.Y, sz.X, sz.Y, rad)
-	}
-	pc.FillStrokeClear(rs)
+	// border is drawn inside the margin box on all sides, whether uniform or not
+	fr.RenderBorder(st, pos, sz, rad)
+	fr.RenderOutline(st, pos, sz, rad)
 }
 
 func (fr *Frame) RenderStripes() {
@@ -0,0 +1,31 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package gitest provides golden-image (snapshot) testing for GoGi renders.
+
+A widget tree is rendered through Viewport2D.FullRender2DTree into its Pixels
+image, which is then compared against a stored PNG file (the "golden" image),
+pixel by pixel, with a configurable per-channel tolerance.  When the images
+differ, the rendered image and a diff image highlighting the mismatched pixels
+in red are written next to the golden file, so the failure can be inspected
+visually.
+
+Typical use, in a _test.go file:
+
+	func TestMain(m *testing.M) {
+		gitest.Main(m) // runs the tests under the headless offscreen driver
+	}
+
+	func TestButton(t *testing.T) {
+		vp := gi.NewViewport2D(200, 100)
+		...  // add widgets to vp
+		gitest.AssertViewport(t, vp, "button")
+	}
+
+Goldens are stored in the testdata directory by default (see Options.Dir).
+Run the tests with -update-goldens (or set GOGI_UPDATE_GOLDENS=1) to
+(re)write all golden images from the current renders instead of comparing.
+*/
+package gitest
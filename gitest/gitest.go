@@ -0,0 +1,213 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+)
+
+// UpdateGoldens causes all Assert calls to write the current render as the
+// new golden image, instead of comparing against it.  It is set by the
+// -update-goldens test flag or the GOGI_UPDATE_GOLDENS environment variable.
+var UpdateGoldens = os.Getenv("GOGI_UPDATE_GOLDENS") != ""
+
+func init() {
+	flag.BoolVar(&UpdateGoldens, "update-goldens", UpdateGoldens, "gitest: write current renders as the golden images instead of comparing against them")
+}
+
+// Options control the comparison of a render against its golden image.
+type Options struct {
+	// Dir is the directory holding golden images -- defaults to testdata
+	Dir string
+
+	// Tolerance is the maximum absolute difference allowed in any one color
+	// channel (0-255) for two pixels to be considered the same -- allows for
+	// minor anti-aliasing differences across platforms
+	Tolerance uint8
+
+	// MaxDiffPixels is the number of pixels that can differ (beyond
+	// Tolerance) before the comparison fails
+	MaxDiffPixels int
+}
+
+// DefaultOptions are the options used by AssertViewport and AssertWindow.
+var DefaultOptions = Options{
+	Dir:       "testdata",
+	Tolerance: 2,
+}
+
+// Main runs the tests in m under the headless offscreen driver, and exits
+// with the result -- call from TestMain in test packages that render.
+func Main(m *testing.M) {
+	code := 0
+	offscreen.Main(func(app oswin.App) {
+		code = m.Run()
+	})
+	os.Exit(code)
+}
+
+// RenderViewport does a full render of the viewport's tree, and returns a
+// copy of the resulting pixels.
+func RenderViewport(vp *gi.Viewport2D) *image.RGBA {
+	vp.FullRender2DTree()
+	return cloneRGBA(vp.Pixels)
+}
+
+// RenderWindow does a full render of the window and publishes it, returning
+// the resulting window contents, including popups and overlays.  Window
+// contents are only directly available under the offscreen driver -- for
+// other drivers the main viewport image is returned.
+func RenderWindow(win *gi.Window) *image.RGBA {
+	win.FullReRender()
+	win.RenderOverlays()
+	win.Publish()
+	if img := offscreen.Capture(win.OSWin); img != nil {
+		return img
+	}
+	return cloneRGBA(win.Viewport.Pixels)
+}
+
+// AssertViewport renders the viewport and compares it against the golden
+// image of given name, using DefaultOptions, reporting any failure on t.
+func AssertViewport(t testing.TB, vp *gi.Viewport2D, name string) {
+	t.Helper()
+	AssertImage(t, RenderViewport(vp), name, &DefaultOptions)
+}
+
+// AssertWindow renders the window and compares it against the golden image
+// of given name, using DefaultOptions, reporting any failure on t.
+func AssertWindow(t testing.TB, win *gi.Window, name string) {
+	t.Helper()
+	AssertImage(t, RenderWindow(win), name, &DefaultOptions)
+}
+
+// AssertImage compares the image against the golden image of given name
+// (name.png in opts.Dir), reporting any failure on t -- if they differ, the
+// image is saved as name.got.png and a diff image as name.diff.png.  If
+// UpdateGoldens is set, the image is instead saved as the new golden.  A nil
+// opts uses DefaultOptions.
+func AssertImage(t testing.TB, img image.Image, name string, opts *Options) {
+	t.Helper()
+	if err := CheckImage(img, name, opts); err != nil {
+		t.Error(err)
+	}
+}
+
+// CheckImage is the error-returning version of AssertImage, for use outside
+// of the testing framework.  A nil opts uses DefaultOptions.
+func CheckImage(img image.Image, name string, opts *Options) error {
+	if opts == nil {
+		opts = &DefaultOptions
+	}
+	dir := opts.Dir
+	if dir == "" {
+		dir = "testdata"
+	}
+	gpath := filepath.Join(dir, name+".png")
+	gotPath := filepath.Join(dir, name+".got.png")
+	diffPath := filepath.Join(dir, name+".diff.png")
+	if UpdateGoldens {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		os.Remove(gotPath)
+		os.Remove(diffPath)
+		return gi.SavePNG(gpath, img)
+	}
+	golden, err := gi.OpenPNG(gpath)
+	if err != nil {
+		gi.SavePNG(gotPath, img)
+		return fmt.Errorf("gitest: could not open golden image %v: %v -- rendered image saved to %v, run with -update-goldens to accept it", gpath, err, gotPath)
+	}
+	ndiff, diff := CompareImages(img, golden, opts.Tolerance)
+	if ndiff <= opts.MaxDiffPixels {
+		os.Remove(gotPath)
+		os.Remove(diffPath)
+		return nil
+	}
+	gi.SavePNG(gotPath, img)
+	gi.SavePNG(diffPath, diff)
+	if img.Bounds().Size() != golden.Bounds().Size() {
+		return fmt.Errorf("gitest: %v: size %v does not match golden size %v -- see %v and %v", name, img.Bounds().Size(), golden.Bounds().Size(), gotPath, diffPath)
+	}
+	return fmt.Errorf("gitest: %v: %v pixels differ from golden by more than %v (max allowed: %v) -- see %v and %v", name, ndiff, opts.Tolerance, opts.MaxDiffPixels, gotPath, diffPath)
+}
+
+// DiffColor is the color used to mark differing pixels in diff images.
+var DiffColor = color.RGBA{255, 0, 0, 255}
+
+// CompareImages compares two images pixel by pixel, returning the number of
+// pixels where any color channel differs by more than tol, and a diff image
+// showing a faded version of want, with differing pixels in DiffColor.
+// Images of different sizes are compared over the union of their bounds, and
+// pixels outside of either image count as different.
+func CompareImages(got, want image.Image, tol uint8) (int, *image.RGBA) {
+	gb := got.Bounds()
+	wb := want.Bounds()
+	sz := gb.Size()
+	wsz := wb.Size()
+	if wsz.X > sz.X {
+		sz.X = wsz.X
+	}
+	if wsz.Y > sz.Y {
+		sz.Y = wsz.Y
+	}
+	diff := image.NewRGBA(image.Rectangle{Max: sz})
+	draw.Draw(diff, diff.Bounds(), &image.Uniform{color.White}, image.ZP, draw.Src)
+	ndiff := 0
+	for y := 0; y < sz.Y; y++ {
+		for x := 0; x < sz.X; x++ {
+			gp := image.Point{gb.Min.X + x, gb.Min.Y + y}
+			wp := image.Point{wb.Min.X + x, wb.Min.Y + y}
+			if !gp.In(gb) || !wp.In(wb) {
+				ndiff++
+				diff.SetRGBA(x, y, DiffColor)
+				continue
+			}
+			gc := color.RGBAModel.Convert(got.At(gp.X, gp.Y)).(color.RGBA)
+			wc := color.RGBAModel.Convert(want.At(wp.X, wp.Y)).(color.RGBA)
+			if chanDiff(gc.R, wc.R) > tol || chanDiff(gc.G, wc.G) > tol || chanDiff(gc.B, wc.B) > tol || chanDiff(gc.A, wc.A) > tol {
+				ndiff++
+				diff.SetRGBA(x, y, DiffColor)
+				continue
+			}
+			// faded version of the expected image, for context
+			diff.SetRGBA(x, y, color.RGBA{fade(wc.R, wc.A), fade(wc.G, wc.A), fade(wc.B, wc.A), 255})
+		}
+	}
+	return ndiff, diff
+}
+
+// chanDiff returns the absolute difference between two channel values
+func chanDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// fade blends a premultiplied channel value 75% toward white
+func fade(c, a uint8) uint8 {
+	unc := int(c) + (255 - int(a)) // composite over white
+	return uint8(191 + unc/4)
+}
+
+// cloneRGBA returns a copy of the image, with bounds starting at 0,0
+func cloneRGBA(src *image.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rectangle{Max: src.Bounds().Size()})
+	draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Src)
+	return img
+}
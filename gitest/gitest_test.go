@@ -0,0 +1,59 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestCompareImages(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	b := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	a.SetRGBA(1, 1, color.RGBA{100, 100, 100, 255})
+	b.SetRGBA(1, 1, color.RGBA{102, 99, 100, 255})
+	if n, _ := CompareImages(a, b, 2); n != 0 {
+		t.Errorf("within tolerance: got %v diffs, expected 0", n)
+	}
+	if n, _ := CompareImages(a, b, 1); n != 1 {
+		t.Errorf("beyond tolerance: got %v diffs, expected 1", n)
+	}
+	b.SetRGBA(3, 2, color.RGBA{0, 0, 255, 255})
+	n, diff := CompareImages(a, b, 2)
+	if n != 1 {
+		t.Errorf("got %v diffs, expected 1", n)
+	}
+	if diff.RGBAAt(3, 2) != DiffColor {
+		t.Errorf("diff image pixel not marked: %v", diff.RGBAAt(3, 2))
+	}
+	c := image.NewRGBA(image.Rect(0, 0, 5, 4))
+	if n, diff := CompareImages(c, image.NewRGBA(image.Rect(0, 0, 4, 4)), 0); n != 4 || diff.Bounds().Dx() != 5 {
+		t.Errorf("size mismatch: got %v diffs in %v, expected 4 in 5x4", n, diff.Bounds())
+	}
+}
+
+func TestCheckImageNilOpts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	odir := DefaultOptions.Dir
+	DefaultOptions.Dir = dir
+	defer func() { DefaultOptions.Dir = odir }()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	UpdateGoldens = true
+	err = CheckImage(img, "nil-opts", nil)
+	UpdateGoldens = false
+	if err != nil {
+		t.Fatalf("saving golden: %v", err)
+	}
+	if err := CheckImage(img, "nil-opts", nil); err != nil {
+		t.Errorf("comparing against golden: %v", err)
+	}
+}
@@ -94,7 +94,7 @@ func (ft *FileTree) SetDirClosed(fpath gi.FileName) {
 //    FileNode
 
 // FileNodeHiStyle is the default style for syntax highlighting to use for
-// file node buffers
+// file node buffers, if the color theme does not specify one (see CurHiStyle)
 var FileNodeHiStyle = HiStyleName("emacs")
 
 // FileNode represents a file in the file system -- the name of the node is
@@ -299,7 +299,7 @@ func (fn *FileNode) OpenBuf() (bool, error) {
 		fn.Buf = &TextBuf{}
 		fn.Buf.InitName(fn.Buf, fn.Nm)
 	}
-	fn.Buf.Hi.Style = FileNodeHiStyle
+	fn.Buf.UpdateHiTheme()
 	return true, fn.Buf.Open(fn.FPath)
 }
 
@@ -6,7 +6,8 @@ package giv
 
 import (
 	"bytes"
-	// htmlstd "html"
+	"fmt"
+	htmlstd "html"
 	"log"
 	"strings"
 
@@ -21,6 +22,16 @@ import (
 // HiStyleName is a highlighting style name
 type HiStyleName string
 
+// CurHiStyle returns the current default syntax highlighting style: the
+// HiStyle of the Prefs Colors, which is set by the active color theme, or
+// FileNodeHiStyle if that is not set
+func CurHiStyle() HiStyleName {
+	if gi.Prefs.Colors.HiStyle != "" {
+		return HiStyleName(gi.Prefs.Colors.HiStyle)
+	}
+	return FileNodeHiStyle
+}
+
 // HiMarkup manages the syntax highlighting state for TextBuf
 type HiMarkup struct {
 	Lang      string        `desc:"language for syntax highlighting the code"`
@@ -88,6 +99,78 @@ func (hm *HiMarkup) MarkupText(txt []byte) ([][]byte, error) {
 	return mtlns, nil
 }
 
+// HiState is the state of the lexer at the end of a line, for incremental
+// markup starting from any line that begins afresh: the type of the comment
+// or string token that continues onto the next line, and otherwise 0 -- this
+// only approximates the full state of the lexer, which can have a stack of
+// states, but it is enough to find where markup can start again after an
+// edit, and where it converges with the prior markup
+type HiState chroma.TokenType
+
+// MarkupLinesFunc does syntax highlighting markup of given text line by
+// line, calling given function with the index, marked-up version, and
+// ending state of each line in turn, until it returns false -- the text is
+// only lexed as far as needed, so the whole rest of a text can be given to
+// mark up just its start -- it should end with a newline
+func (hm *HiMarkup) MarkupLinesFunc(txt string, fun func(ln int, mu []byte, st HiState) bool) {
+	if hm.lexer == nil {
+		return
+	}
+	iterator, err := hm.lexer.Tokenise(nil, txt)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	var mu bytes.Buffer
+	ln := 0
+	for tok := iterator(); tok != chroma.EOF; tok = iterator() {
+		cls := hiClass(tok.Type)
+		v := tok.Value
+		for off := 0; off < len(v); {
+			seg := v[off:]
+			nl := strings.IndexByte(seg, '\n')
+			if nl >= 0 {
+				seg = seg[:nl]
+			}
+			if seg != "" {
+				if cls != "" {
+					fmt.Fprintf(&mu, `<span class="%s">%s</span>`, cls, htmlstd.EscapeString(seg))
+				} else {
+					mu.WriteString(htmlstd.EscapeString(seg))
+				}
+			}
+			if nl < 0 {
+				break
+			}
+			off += nl + 1
+			st := HiState(0)
+			isCont := tok.Type.Category() == chroma.Comment || tok.Type.SubCategory() == chroma.LiteralString
+			if isCont && (off < len(v) || v == "\n") { // within the token, or its state
+				st = HiState(tok.Type)
+			}
+			b := make([]byte, mu.Len())
+			copy(b, mu.Bytes())
+			if !fun(ln, b, st) {
+				return
+			}
+			mu.Reset()
+			ln++
+		}
+	}
+}
+
+// hiClass returns the CSS class for given token type, as used by the chroma
+// html formatter
+func hiClass(tt chroma.TokenType) string {
+	if cls, ok := chroma.StandardTypes[tt]; ok {
+		return cls
+	}
+	if cls, ok := chroma.StandardTypes[tt.SubCategory()]; ok {
+		return cls
+	}
+	return chroma.StandardTypes[tt.Category()]
+}
+
 // MarkupLine returns a marked-up version of line of text
 func (hm *HiMarkup) MarkupLine(txtln []byte) ([]byte, error) {
 	var htmlBuf bytes.Buffer
@@ -108,6 +191,35 @@ func (hm *HiMarkup) MarkupLine(txtln []byte) ([]byte, error) {
 	return hm.FixMarkupLine(b), nil
 }
 
+// Brackets returns, for each line of given text, the brackets ({[( and )]})
+// that the lexer reads as punctuation or operators, i.e., not within strings
+// or comments -- used for finding the structure of the code, e.g., for
+// folding -- returns nil if there is no lexer for the language
+func (hm *HiMarkup) Brackets(txt []byte) [][]rune {
+	if hm.lexer == nil {
+		return nil
+	}
+	iterator, err := hm.lexer.Tokenise(nil, string(txt))
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	brs := make([][]rune, bytes.Count(txt, []byte("\n"))+1)
+	ln := 0
+	for _, tok := range iterator.Tokens() {
+		isbr := tok.Type.InCategory(chroma.Punctuation) || tok.Type.InCategory(chroma.Operator)
+		for _, r := range tok.Value {
+			switch {
+			case r == '\n':
+				ln++
+			case isbr && strings.ContainsRune("{[()]}", r) && ln < len(brs):
+				brs[ln] = append(brs[ln], r)
+			}
+		}
+	}
+	return brs
+}
+
 // FixMarkupLine fixes the output of chroma markup
 func (hm *HiMarkup) FixMarkupLine(mt []byte) []byte {
 	esp := []byte(`</span>`)
@@ -0,0 +1,54 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"testing"
+
+	"github.com/alecthomas/chroma"
+)
+
+func TestMarkupLinesFunc(t *testing.T) {
+	src := "x := 1 /* a\nb\nc */ y := 2\ns := `raw\nmore` + \"q\"\n// line\nz := 3\n"
+	cm, str := HiState(chroma.CommentMultiline), HiState(chroma.LiteralString)
+	tests := []struct {
+		st HiState
+		mu string
+	}{
+		{cm, `<span class="nx">x</span> <span class="o">:=</span> <span class="mi">1</span> <span class="cm">/* a</span>`},
+		{cm, `<span class="cm">b</span>`},
+		{0, `<span class="cm">c */</span> <span class="nx">y</span> <span class="o">:=</span> <span class="mi">2</span>`},
+		{str, `<span class="nx">s</span> <span class="o">:=</span> <span class="s">` + "`raw" + `</span>`},
+		{0, `<span class="s">more` + "`" + `</span> <span class="o">+</span> <span class="s">&#34;q&#34;</span>`},
+		{0, `<span class="c1">// line</span>`},
+		{0, `<span class="nx">z</span> <span class="o">:=</span> <span class="mi">3</span>`},
+	}
+	hm := &HiMarkup{Lang: "Go", Style: "emacs"}
+	hm.Init()
+	n := 0
+	hm.MarkupLinesFunc(src, func(ln int, mu []byte, st HiState) bool {
+		if ln != n || ln >= len(tests) {
+			t.Errorf("MarkupLinesFunc: got line %v, expected %v of %v\n", ln, n, len(tests))
+			return false
+		}
+		n++
+		if st != tests[ln].st || string(mu) != tests[ln].mu {
+			t.Errorf("MarkupLinesFunc line %v: got state %v: %q, expected %v: %q\n", ln, st, mu, tests[ln].st, tests[ln].mu)
+		}
+		return true
+	})
+	if n != len(tests) {
+		t.Errorf("MarkupLinesFunc: got %v lines, expected %v\n", n, len(tests))
+	}
+
+	n = 0
+	hm.MarkupLinesFunc(src, func(ln int, mu []byte, st HiState) bool {
+		n++
+		return ln < 1
+	})
+	if n != 2 {
+		t.Errorf("MarkupLinesFunc: got %v lines when stopped after line 1, expected 2\n", n)
+	}
+}
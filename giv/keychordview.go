@@ -87,7 +87,7 @@ var KeyChordEditProps = ki.Props{
 		"background-color": "lighter-0",
 	},
 	gi.LabelSelectors[gi.LabelInactive]: ki.Props{
-		"color": "lighter-50",
+		"color": "highlight-50",
 	},
 	gi.LabelSelectors[gi.LabelSelected]: ki.Props{
 		"background-color": &gi.Prefs.Colors.Select,
@@ -11,9 +11,13 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/alecthomas/chroma/lexers"
 	"github.com/goki/gi"
@@ -54,6 +58,21 @@ type TextBuf struct {
 	UndoPos    int            `json:"-" xml:"-" desc:"undo position"`
 	FileModOk  bool           `json:"-" xml:"-" desc:"have already asked about fact that file has changed since being opened, user is ok"`
 	PosHistory []TextPos      `json:"-" xml:"-" desc:"history of cursor positions -- can move back through them"`
+	hiTheme    HiStyleName
+
+	UndoGroup  int        `json:"-" xml:"-" desc:"undo group of edits being saved between BeginUndoGroup and EndUndoGroup -- 0 if not in a group"`
+	undoDepth  int        // nesting depth of BeginUndoGroup
+	undoGroups int        // number of undo groups started, for unique group ids
+	typingGrp  int        // undo group of the last typed characters
+	undoCurSet bool       // undoCursor and undoSel are set for the next edit
+	undoCursor TextPos    // cursor position before the next edit, see SetUndoCursor
+	undoSel    TextRegion // selection before the next edit, see SetUndoCursor
+	undoCurGrp int        // undo group in which SetUndoCursor was last called
+
+	hiStates []HiState // lexer state at the end of each line, from the markup
+	hiStale  int       // first line from which Markup and hiStates are not yet up to date -- see MarkupStale
+	hiGen    int       // incremented for each edit of the lines, so that background markup can detect edits made while it runs
+	hiBusy   bool      // MarkupStale is running
 }
 
 var KiT_TextBuf = kit.Types.AddType(&TextBuf{}, TextBufProps)
@@ -92,9 +111,9 @@ const (
 	// current state *after* the edit.
 	TextBufDelete
 
-	// TextBufMarkUpdt signals that the Markup text has been updated -- this
-	// signal is typically sent from a separate goroutine so should be used
-	// with a mutex
+	// TextBufMarkUpdt signals that the Markup text has been updated -- data
+	// is the TextRegion of the lines updated -- this signal is typically
+	// sent from a separate goroutine so should be used with a mutex
 	TextBufMarkUpdt
 
 	TextBufSignalsN
@@ -134,6 +153,9 @@ func (tb *TextBuf) New(nlines int) {
 	tb.Lines = make([][]rune, nlines)
 	tb.LineBytes = make([][]byte, nlines)
 	tb.Markup = make([][]byte, nlines)
+	tb.hiStates = make([]HiState, nlines)
+	tb.hiStale = 0
+	tb.hiGen++
 
 	if cap(tb.ByteOffs) >= nlines {
 		tb.ByteOffs = tb.ByteOffs[:nlines]
@@ -146,6 +168,7 @@ func (tb *TextBuf) New(nlines int) {
 		tb.Lines[0] = []rune("")
 		tb.LineBytes[0] = []byte("")
 		tb.Markup[0] = []byte("")
+		tb.hiStale = 1 // nothing to mark up
 	}
 
 	tb.NLines = nlines
@@ -622,6 +645,7 @@ func (tb *TextBuf) BytesToLines() {
 		bo += len(txt) + 1 // lf
 	}
 	tb.TotalBytes = bo
+	tb.hiStale = 0
 }
 
 /////////////////////////////////////////////////////////////////////////////
@@ -640,10 +664,6 @@ func (tb *TextBuf) Search(find []byte, ignoreCase bool) (int, []FileSearchMatch)
 	}
 	cnt := 0
 	var matches []FileSearchMatch
-	mst := []byte("<mark>")
-	mstsz := len(mst)
-	med := []byte("</mark>")
-	medsz := len(med)
 	for ln, b := range tb.LineBytes {
 		if ignoreCase {
 			b = bytes.ToLower(b)
@@ -658,26 +678,147 @@ func (tb *TextBuf) Search(find []byte, ignoreCase bool) (int, []FileSearchMatch)
 			i += ci
 			ci = i + fsz
 			reg := TextRegion{Start: TextPos{Ln: ln, Ch: i}, End: TextPos{Ln: ln, Ch: ci}}
-			cist := ints.MaxInt(i-FileSearchContext, 0)
-			cied := ints.MinInt(ci+FileSearchContext, sz)
-			tlen := mstsz + medsz + cied - cist
-			txt := make([]byte, tlen)
-			copy(txt, b[cist:i])
-			ti := i - cist
-			copy(txt[ti:], mst)
-			ti += mstsz
-			copy(txt[ti:], b[i:ci])
-			ti += fsz
-			copy(txt[ti:], med)
-			ti += medsz
-			copy(txt[ti:], b[ci:cied])
-			matches = append(matches, FileSearchMatch{Reg: reg, Text: txt})
+			matches = append(matches, FileSearchMatch{Reg: reg, Text: searchMatchText(b, i, ci)})
 			cnt++
 		}
 	}
 	return cnt, matches
 }
 
+// searchMatchText returns the text of a match from st to ed in given line,
+// marked with <mark>, and surrounded by at most FileSearchContext on either side
+func searchMatchText(b []byte, st, ed int) []byte {
+	cist := ints.MaxInt(st-FileSearchContext, 0)
+	cied := ints.MinInt(ed+FileSearchContext, len(b))
+	txt := make([]byte, 0, len("<mark></mark>")+cied-cist)
+	txt = append(txt, b[cist:st]...)
+	txt = append(txt, "<mark>"...)
+	txt = append(txt, b[st:ed]...)
+	txt = append(txt, "</mark>"...)
+	txt = append(txt, b[ed:cied]...)
+	return txt
+}
+
+// SearchRegexp looks for matches of given regular expression within given
+// region of the buffer (the whole buffer if it is TextRegionZero), returning
+// number of occurences and specific match position list.  Unlike Search,
+// match positions are in runes, as used for editing.  Matches are within a
+// single line, and empty matches are skipped.
+func (tb *TextBuf) SearchRegexp(re *regexp.Regexp, reg TextRegion) (int, []FileSearchMatch) {
+	var matches []FileSearchMatch
+	stln, edln := tb.regexpLines(reg)
+	for ln := stln; ln <= edln; ln++ {
+		b := tb.LineBytes[ln]
+		for _, m := range tb.regexpMatches(re, ln, reg) {
+			st := utf8.RuneCount(b[:m[0]])
+			ed := st + utf8.RuneCount(b[m[0]:m[1]])
+			mreg := TextRegion{Start: TextPos{Ln: ln, Ch: st}, End: TextPos{Ln: ln, Ch: ed}}
+			matches = append(matches, FileSearchMatch{Reg: mreg, Text: searchMatchText(b, m[0], m[1])})
+		}
+	}
+	return len(matches), matches
+}
+
+// ReplaceNext replaces the first match of given regular expression at or
+// after given position within given region (the whole buffer if it is
+// TextRegionZero), wrapping around to the start of the region, with the
+// repl template, in which $1, ${name} etc refer to the submatches (see
+// regexp.Expand -- use $$ for a literal $).  The replacement is a single
+// undo step -- returns its region (which is empty if repl is) and true if
+// a match was replaced.
+func (tb *TextBuf) ReplaceNext(re *regexp.Regexp, repl string, pos TextPos, reg TextRegion) (TextRegion, bool) {
+	stln, edln := tb.regexpLines(reg)
+	if stln > edln {
+		return TextRegionZero, false
+	}
+	pos = tb.ValidPos(pos)
+	if pos.Ln < stln || pos.Ln > edln {
+		pos = TextPos{Ln: stln}
+	}
+	for i := 0; i <= edln-stln+1; i++ { // last time around is the start of pos line
+		ln := stln + (pos.Ln-stln+i)%(edln-stln+1)
+		b := tb.LineBytes[ln]
+		for _, m := range tb.regexpMatches(re, ln, reg) {
+			if i == 0 && utf8.RuneCount(b[:m[0]]) < pos.Ch {
+				continue
+			}
+			return tb.replaceMatch(re, repl, ln, b, m), true
+		}
+	}
+	tb.undoCurDone()
+	return TextRegionZero, false
+}
+
+// ReplaceAll replaces all the matches of given regular expression within
+// given region (the whole buffer if it is TextRegionZero) with the repl
+// template, in which $1, ${name} etc refer to the submatches (see
+// regexp.Expand -- use $$ for a literal $).  The replacements are a single
+// undo step -- returns the number of matches replaced.
+func (tb *TextBuf) ReplaceAll(re *regexp.Regexp, repl string, reg TextRegion) int {
+	n := 0
+	tb.BeginUndoGroup()
+	stln, edln := tb.regexpLines(reg)
+	for ln := edln; ln >= stln; ln-- { // from the end, so positions remain valid
+		b := tb.LineBytes[ln]
+		ms := tb.regexpMatches(re, ln, reg)
+		for i := len(ms) - 1; i >= 0; i-- {
+			tb.replaceMatch(re, repl, ln, b, ms[i])
+			n++
+		}
+	}
+	tb.EndUndoGroup()
+	return n
+}
+
+// replaceMatch replaces the match m (submatch byte indexes) of re in line ln
+// with given bytes b, with the expansion of the repl template, as one undo
+// step, returning the region of the replacement
+func (tb *TextBuf) replaceMatch(re *regexp.Regexp, repl string, ln int, b []byte, m []int) TextRegion {
+	st := TextPos{Ln: ln, Ch: utf8.RuneCount(b[:m[0]])}
+	ed := TextPos{Ln: ln, Ch: st.Ch + utf8.RuneCount(b[m[0]:m[1]])}
+	rb := re.Expand(nil, []byte(repl), b, m)
+	tb.BeginUndoGroup()
+	tb.DeleteText(st, ed, true, true)
+	reg := TextRegion{Start: st, End: st}
+	if tbe := tb.InsertText(st, rb, true, true); tbe != nil {
+		reg.End = tbe.Reg.End
+	}
+	tb.EndUndoGroup()
+	return reg
+}
+
+// regexpLines returns the range of lines to search within given region
+// (all lines if it is TextRegionZero) -- end is *inclusive*
+func (tb *TextBuf) regexpLines(reg TextRegion) (stln, edln int) {
+	if reg == TextRegionZero {
+		return 0, tb.NLines - 1
+	}
+	return ints.MaxInt(reg.Start.Ln, 0), ints.MinInt(reg.End.Ln, tb.NLines-1)
+}
+
+// regexpMatches returns the submatch byte indexes of the non-empty matches
+// of re in given line that are entirely within given region (the whole line
+// if it is TextRegionZero)
+func (tb *TextBuf) regexpMatches(re *regexp.Regexp, ln int, reg TextRegion) [][]int {
+	b := tb.LineBytes[ln]
+	sb, eb := 0, len(b)
+	if reg != TextRegionZero {
+		if ln == reg.Start.Ln {
+			sb = len(string(tb.Lines[ln][:ints.MinInt(reg.Start.Ch, len(tb.Lines[ln]))]))
+		}
+		if ln == reg.End.Ln {
+			eb = len(string(tb.Lines[ln][:ints.MinInt(reg.End.Ch, len(tb.Lines[ln]))]))
+		}
+	}
+	var ms [][]int
+	for _, m := range re.FindAllSubmatchIndex(b, -1) {
+		if m[0] < m[1] && m[0] >= sb && m[1] <= eb {
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
 /////////////////////////////////////////////////////////////////////////////
 //   TextPos, TextRegion, TextBufEdit
 
@@ -764,6 +905,36 @@ type TextBufEdit struct {
 	Reg    TextRegion `desc:"region for the edit (start is same for previous and current, end is in original pre-delete text for a delete, and in new lines data for an insert"`
 	Delete bool       `desc:"action is either a deletion or an insertion"`
 	Text   [][]rune   `desc:"text to be inserted"`
+	Group  int        `desc:"undo group of this edit -- consecutive edits in the same non-zero group are undone and redone together as one step"`
+
+	Cursor TextPos    `desc:"cursor position in the view making the edit, before it was made -- restored when the edit is undone -- Ln is -1 if not recorded (see SetUndoCursor)"`
+	Sel    TextRegion `desc:"selection in the view making the edit, before it was made -- restored when the edit is undone"`
+	Time   time.Time  `desc:"time when the edit was saved to the undo stack, for grouping typed characters"`
+}
+
+// AdjustPos returns given position adjusted for this edit having been made
+// -- positions after the edit move with the text, and positions within a
+// deleted region move to its start
+func (te *TextBufEdit) AdjustPos(pos TextPos) TextPos {
+	st, ed := te.Reg.Start, te.Reg.End
+	if pos.IsLess(st) {
+		return pos
+	}
+	if !te.Delete {
+		if pos.Ln == st.Ln {
+			pos.Ch += ed.Ch - st.Ch
+		}
+		pos.Ln += ed.Ln - st.Ln
+		return pos
+	}
+	if pos.IsLess(ed) {
+		return st
+	}
+	if pos.Ln == ed.Ln {
+		pos.Ch += st.Ch - ed.Ch
+	}
+	pos.Ln -= ed.Ln - st.Ln
+	return pos
 }
 
 // ToBytes returns the Text of this edit record to a byte string, with
@@ -809,6 +980,7 @@ func (tb *TextBuf) ValidPos(pos TextPos) TextPos {
 // DeleteText deletes region of text between start and end positions, signaling
 // views after text lines have been updated.
 func (tb *TextBuf) DeleteText(st, ed TextPos, saveUndo, signal bool) *TextBufEdit {
+	defer tb.undoCurDone()
 	st = tb.ValidPos(st)
 	ed = tb.ValidPos(ed)
 	if st == ed {
@@ -858,6 +1030,7 @@ func (tb *TextBuf) DeleteText(st, ed TextPos, saveUndo, signal bool) *TextBufEdi
 // Insert inserts new text at given starting position, signaling views after
 // text has been inserted
 func (tb *TextBuf) InsertText(st TextPos, text []byte, saveUndo, signal bool) *TextBufEdit {
+	defer tb.undoCurDone()
 	if len(text) == 0 {
 		return nil
 	}
@@ -1012,6 +1185,16 @@ func (tb *TextBuf) LinesInserted(tbe *TextBufEdit) {
 	copy(nmu[stln:], tmpmu)            // copy into position
 	tb.Markup = nmu
 
+	// hiStates
+	tmphs := make([]HiState, nsz)
+	nhs := append(tb.hiStates, tmphs...)
+	copy(nhs[stln+nsz:], nhs[stln:])
+	copy(nhs[stln:], tmphs)
+	tb.hiStates = nhs
+	if tb.hiStale >= stln {
+		tb.hiStale += nsz
+	}
+
 	// ByteOffs -- maintain mem updt
 	tmpof := make([]int, nsz)
 	nof := append(tb.ByteOffs, tmpof...)
@@ -1027,10 +1210,8 @@ func (tb *TextBuf) LinesInserted(tbe *TextBufEdit) {
 		tb.ByteOffs[ln] = bo
 		bo += len(tb.LineBytes[ln]) + 1
 	}
-	tb.MarkupLines(st, ed)
+	tb.markupEdited(st, ed)
 	tb.MarkupMu.Unlock()
-
-	go tb.MarkupAllLines() // always do global reformat in bg
 }
 
 // LinesDeleted deletes lines in Markup corresponding to lines
@@ -1044,13 +1225,19 @@ func (tb *TextBuf) LinesDeleted(tbe *TextBufEdit) {
 	tb.LineBytes = append(tb.LineBytes[:stln], tb.LineBytes[edln:]...)
 	tb.Markup = append(tb.Markup[:stln], tb.Markup[edln:]...)
 	tb.ByteOffs = append(tb.ByteOffs[:stln], tb.ByteOffs[edln:]...)
+	tb.hiStates = append(tb.hiStates[:stln], tb.hiStates[edln:]...)
+	switch {
+	case tb.hiStale > edln:
+		tb.hiStale -= edln - stln
+	case tb.hiStale > stln:
+		tb.hiStale = stln + 1
+	}
 
 	st := tbe.Reg.Start.Ln
 	tb.LineBytes[st] = []byte(string(tb.Lines[st]))
 	tb.Markup[st] = tb.LineBytes[st]
-	tb.MarkupLines(st, st)
+	tb.markupEdited(st, st)
 	tb.MarkupMu.Unlock()
-	// probably don't need to do global markup here..
 }
 
 // LinesEdited re-marks-up lines in edit (typically only 1).  Locks and
@@ -1063,37 +1250,128 @@ func (tb *TextBuf) LinesEdited(tbe *TextBufEdit) {
 		tb.LineBytes[ln] = []byte(string(tb.Lines[ln]))
 		tb.Markup[ln] = tb.LineBytes[ln]
 	}
-	tb.MarkupLines(st, ed)
+	tb.markupEdited(st, ed)
 	tb.MarkupMu.Unlock()
-	// probably don't need to do global markup here..
 }
 
 // MarkupAllLines does syntax highlighting markup for all lines in buffer,
-// calling MarkupMu mutex when setting the marked-up lines with the result --
-// designed to be called in a separate goroutine
+// using MarkupStale, which signals TextBufMarkUpdt when done -- designed to
+// be called in a separate goroutine
 func (tb *TextBuf) MarkupAllLines() {
-	if !tb.Hi.HasHi() || tb.NLines == 0 || tb.Hi.lexer == nil {
-		return
-	}
-	tb.LinesToBytes() // todo: could need another mutex here?
-	mtlns, err := tb.Hi.MarkupText(tb.Txt)
-	if err != nil {
+	tb.MarkupMu.Lock()
+	tb.hiStale = 0
+	tb.hiGen++ // any markup already running starts over
+	tb.MarkupMu.Unlock()
+	tb.MarkupStale()
+}
+
+// TextBufMarkupSyncLines is the maximum number of lines beyond an edit that
+// are marked up again right away while the lexer state differs from that of
+// the prior markup -- the rest are marked up in the background by
+// MarkupStale -- it is also the number of lines that MarkupStale marks up
+// between locks of the MarkupMu mutex.  Only the lines up to there are given
+// to the lexer right away, so as with the closing of a comment on a later
+// line (see HiState), a comment or string opened by an edit is only seen as
+// such if it closes within them
+var TextBufMarkupSyncLines = 500
+
+// MarkupStale does syntax highlighting markup of the lines that are not yet
+// up to date, after opening a file or an edit that changed the lexer state
+// for many lines, and then signals TextBufMarkUpdt with the lines updated --
+// the text is lexed without locking the MarkupMu mutex, and starts over from
+// the lines not yet done if it is edited meanwhile -- designed to be called
+// in a separate goroutine, and returns right away if it is already running
+func (tb *TextBuf) MarkupStale() {
+	tb.MarkupMu.Lock()
+	if tb.hiBusy {
+		tb.MarkupMu.Unlock()
 		return
 	}
+	tb.hiBusy = true
+	st, ed := -1, -1
+	for tb.Hi.HasHi() && tb.hiStale < tb.NLines {
+		ln := tb.markupStart(tb.hiStale)
+		gen := tb.hiGen
+		txt := tb.markupText(ln, tb.NLines-1)
+		tb.MarkupMu.Unlock()
+
+		var mus [][]byte
+		var hss []HiState
+		commit := func() bool {
+			tb.MarkupMu.Lock()
+			defer tb.MarkupMu.Unlock()
+			if tb.hiGen != gen {
+				return false // edited -- start over
+			}
+			for i := range mus {
+				tb.Markup[ln+i] = mus[i]
+				tb.hiStates[ln+i] = hss[i]
+			}
+			if len(mus) > 0 {
+				if st < 0 || ln < st {
+					st = ln
+				}
+				ed = ints.MaxInt(ed, ln+len(mus)-1)
+			}
+			ln += len(mus)
+			tb.hiStale = ints.MaxInt(tb.hiStale, ln)
+			mus, hss = mus[:0], hss[:0]
+			return true
+		}
+		ok := true
+		tb.Hi.MarkupLinesFunc(txt, func(i int, mu []byte, hs HiState) bool {
+			mus = append(mus, mu)
+			hss = append(hss, hs)
+			if len(mus) < TextBufMarkupSyncLines {
+				return true
+			}
+			ok = commit()
+			return ok
+		})
+		if ok {
+			ok = commit()
+		}
 
-	tb.MarkupMu.Lock()
-	maxln := ints.MinInt(len(mtlns)-1, tb.NLines)
-	for ln := 0; ln < maxln; ln++ {
-		mt := mtlns[ln]
-		tb.Markup[ln] = tb.Hi.FixMarkupLine(mt)
+		tb.MarkupMu.Lock()
+		if ok && tb.hiStale < tb.NLines { // lexer stopped short -- give up on the rest
+			tb.hiStale = tb.NLines
+		}
 	}
+	tb.hiBusy = false
 	tb.MarkupMu.Unlock()
-	tb.TextBufSig.Emit(tb.This, int64(TextBufMarkUpdt), tb.Txt)
+	if st >= 0 {
+		tb.TextBufSig.Emit(tb.This, int64(TextBufMarkUpdt), TextRegion{Start: TextPos{Ln: st}, End: TextPos{Ln: ed}})
+	}
+}
+
+// UpdateHiTheme sets the highlighting style to the current default from the
+// color theme (CurHiStyle), unless the style has been set explicitly to
+// something else, and re-does the markup of all lines in the background if
+// it changed -- returns true if changed.  Called by TextView when styling, so
+// buffers follow changes in the color theme.
+func (tb *TextBuf) UpdateHiTheme() bool {
+	if tb.Hi.Style != "" && tb.Hi.Style != tb.hiTheme {
+		return false // explicitly set
+	}
+	cur := CurHiStyle()
+	tb.hiTheme = cur
+	if tb.Hi.Style == cur {
+		return false
+	}
+	tb.Hi.Style = cur
+	if tb.NLines == 0 || !tb.Hi.HasHi() {
+		return true
+	}
+	tb.Hi.Init()
+	go tb.MarkupAllLines()
+	return true
 }
 
 // MarkupLines generates markup of given range of lines. end is *inclusive*
-// line.  returns true if all lines were marked up successfully.  This does
-// NOT lock the MarkupMu mutex (done at outer loop)
+// line.  The lexer starts at the nearest line before that starts afresh
+// (see HiState), so the lines from there are marked up too.  returns true if
+// all lines were marked up successfully.  This does NOT lock the MarkupMu
+// mutex (done at outer loop)
 func (tb *TextBuf) MarkupLines(st, ed int) bool {
 	if !tb.Hi.HasHi() || tb.NLines == 0 {
 		return false
@@ -1101,61 +1379,255 @@ func (tb *TextBuf) MarkupLines(st, ed int) bool {
 	if ed >= tb.NLines {
 		ed = tb.NLines - 1
 	}
-	allgood := true
-	for ln := st; ln <= ed; ln++ {
-		mu, err := tb.Hi.MarkupLine(tb.LineBytes[ln])
-		if err == nil {
-			tb.Markup[ln] = mu
-		} else {
-			allgood = false
+	cp := tb.markupStart(st)
+	last := cp - 1
+	tb.Hi.MarkupLinesFunc(tb.markupText(cp, ed), func(i int, mu []byte, hs HiState) bool {
+		ln := cp + i
+		tb.Markup[ln] = mu
+		tb.hiStates[ln] = hs
+		last = ln
+		return ln < ed
+	})
+	return last >= ed
+}
+
+// markupEdited re-does the markup of given edited lines (end is
+// *inclusive*), starting from the nearest line before that starts afresh,
+// and continuing after them until the lexer state converges with that of the
+// prior markup at the start of a line, up to TextBufMarkupSyncLines -- the
+// rest is left to MarkupStale in the background -- signals TextBufMarkUpdt
+// if lines other than the edited ones were marked up.  This does NOT lock
+// the MarkupMu mutex (done at outer loop)
+func (tb *TextBuf) markupEdited(st, ed int) {
+	tb.hiGen++
+	if !tb.Hi.HasHi() || tb.NLines == 0 {
+		return
+	}
+	if st >= tb.hiStale { // MarkupStale gets to the rest
+		tb.MarkupLines(st, ed)
+		return
+	}
+	cp := tb.markupStart(st)
+	mxln := ints.MinInt(ed+TextBufMarkupSyncLines, tb.NLines-1)
+	next := cp
+	conv := false
+	newFresh, oldFresh := true, true
+	tb.Hi.MarkupLinesFunc(tb.markupText(cp, mxln), func(i int, mu []byte, hs HiState) bool {
+		ln := cp + i
+		if ln >= tb.NLines || ln > mxln {
+			return false
+		}
+		if ln > ed && ln >= tb.hiStale {
+			return false // MarkupStale gets to the rest
 		}
+		if ln > ed && newFresh && oldFresh {
+			conv = true // same state as before from here on
+			return false
+		}
+		oldFresh = tb.hiStates[ln] == 0
+		newFresh = hs == 0
+		tb.Markup[ln] = mu
+		tb.hiStates[ln] = hs
+		next = ln + 1
+		return true
+	})
+	if !conv && next < tb.NLines {
+		tb.hiStale = next
+		go tb.MarkupStale()
 	}
-	return allgood
+	if cp < st || next > ed+1 {
+		tb.TextBufSig.Emit(tb.This, int64(TextBufMarkUpdt), TextRegion{Start: TextPos{Ln: cp}, End: TextPos{Ln: next - 1}})
+	}
+}
+
+// markupStart returns the nearest line at or before given line that starts
+// afresh, outside of any comment or string that spans lines, from which the
+// lexer can start (see HiState)
+func (tb *TextBuf) markupStart(ln int) int {
+	for ln > 0 && tb.hiStates[ln-1] != 0 {
+		ln--
+	}
+	return ln
+}
+
+// markupText returns the text of the lines from st to ed (*inclusive*), for
+// HiMarkup.MarkupLinesFunc
+func (tb *TextBuf) markupText(st, ed int) string {
+	txt := bytes.Join(tb.LineBytes[st:ed+1], []byte("\n"))
+	return string(append(txt, '\n'))
 }
 
 /////////////////////////////////////////////////////////////////////////////
 //   Undo
 
-// SaveUndo saves given edit to undo stack
+// TextBufUndoTypingMSec is the maximum time in msec between consecutive
+// typed characters for them to be undone together, along with the rest of
+// the same word
+var TextBufUndoTypingMSec = 1000
+
+// SaveUndo saves given edit to undo stack, in the current UndoGroup if any,
+// or else in the same group as the previous edit if both are typed
+// characters of the same word -- also saves the cursor position and
+// selection from SetUndoCursor with the edit
 func (tb *TextBuf) SaveUndo(tbe *TextBufEdit) {
 	if tb.UndoPos < len(tb.Undos) {
 		tb.Undos = tb.Undos[:tb.UndoPos]
 	}
+	tbe.Group = tb.UndoGroup
+	tbe.Time = time.Now()
+	if tb.undoCurSet {
+		tbe.Cursor, tbe.Sel = tb.undoCursor, tb.undoSel
+		tb.undoCurSet = false
+	} else {
+		tbe.Cursor = TextPos{Ln: -1}
+	}
+	if tb.UndoGroup == 0 && tb.UndoPos > 0 {
+		tb.groupTyping(tbe, tb.Undos[tb.UndoPos-1])
+	}
 	tb.Undos = append(tb.Undos, tbe)
 	tb.UndoPos = len(tb.Undos)
 }
 
-// Undo undoes next item on the undo stack, and returns that record -- nil if no more
+// SetUndoCursor sets the cursor position and selection of the view that is
+// about to edit the buffer, which are saved with the next edit and restored
+// by the view when it is undone -- they only apply to the next call of
+// InsertText or DeleteText, whether or not it saves an edit, or else to the
+// rest of the undo group, in which only the first call counts, as the group
+// is undone as a whole
+func (tb *TextBuf) SetUndoCursor(pos TextPos, sel TextRegion) {
+	if tb.UndoGroup != 0 && tb.undoCurGrp == tb.UndoGroup {
+		return
+	}
+	tb.undoCursor, tb.undoSel = pos, sel
+	tb.undoCurSet = true
+	tb.undoCurGrp = tb.UndoGroup
+}
+
+// undoCurDone clears the cursor position and selection set by SetUndoCursor
+// after the edit they were set for, including when it turns out to change
+// nothing, so they are not saved with a later, unrelated edit -- within an
+// undo group they are kept until EndUndoGroup
+func (tb *TextBuf) undoCurDone() {
+	if tb.UndoGroup == 0 {
+		tb.undoCurSet = false
+	}
+}
+
+// groupTyping puts given new edit in the same undo group as the previous
+// edit if both insert a single typed character, one right after the other
+// within TextBufUndoTypingMSec, and the new one does not start a new word
+func (tb *TextBuf) groupTyping(tbe, prv *TextBufEdit) {
+	r, ok := tbe.typedRune()
+	if !ok {
+		return
+	}
+	pr, ok := prv.typedRune()
+	if !ok || prv.Reg.End != tbe.Reg.Start {
+		return
+	}
+	if prv.Group != 0 && prv.Group != tb.typingGrp {
+		return
+	}
+	if tbe.Time.Sub(prv.Time) > time.Duration(TextBufUndoTypingMSec)*time.Millisecond {
+		return
+	}
+	if isWordRune(r) && !isWordRune(pr) {
+		return
+	}
+	if prv.Group == 0 {
+		tb.undoGroups++
+		tb.typingGrp = tb.undoGroups
+		prv.Group = tb.typingGrp
+	}
+	tbe.Group = prv.Group
+}
+
+// typedRune returns the rune inserted by this edit if it inserts a single
+// rune within a line, as when typing
+func (te *TextBufEdit) typedRune() (rune, bool) {
+	if te.Delete || len(te.Text) != 1 || len(te.Text[0]) != 1 {
+		return 0, false
+	}
+	return te.Text[0][0], true
+}
+
+// isWordRune returns true if given rune is part of a word for the purposes
+// of grouping typed characters
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// BeginUndoGroup starts a group of edits that are undone and redone together
+// as one step, until the matching EndUndoGroup -- groups can be nested, in
+// which case the outermost one defines the step
+func (tb *TextBuf) BeginUndoGroup() {
+	if tb.undoDepth == 0 {
+		tb.undoGroups++
+		tb.UndoGroup = tb.undoGroups
+	}
+	tb.undoDepth++
+}
+
+// EndUndoGroup ends a group of edits started by BeginUndoGroup
+func (tb *TextBuf) EndUndoGroup() {
+	if tb.undoDepth == 0 {
+		log.Printf("giv.TextBuf EndUndoGroup: no matching BeginUndoGroup\n")
+		return
+	}
+	tb.undoDepth--
+	if tb.undoDepth == 0 {
+		tb.UndoGroup = 0
+		tb.undoCurSet = false
+	}
+}
+
+// Undo undoes next item on the undo stack, along with the rest of its undo
+// group if any, and returns the last record undone, which is the first edit
+// of the group, with the cursor and selection from before it -- nil if no
+// more
 func (tb *TextBuf) Undo() *TextBufEdit {
 	if tb.UndoPos == 0 {
 		tb.Changed = false // should be!
 		tb.AutoSaveDelete()
 		return nil
 	}
-	tb.UndoPos--
-	tbe := tb.Undos[tb.UndoPos]
-	if tbe.Delete {
-		// fmt.Printf("undoing delete at: %v text: %v\n", tbe.Reg, string(tbe.ToBytes()))
-		tb.InsertText(tbe.Reg.Start, tbe.ToBytes(), false, true)
-	} else {
-		// fmt.Printf("undoing insert at: %v text: %v\n", tbe.Reg, string(tbe.ToBytes()))
-		tb.DeleteText(tbe.Reg.Start, tbe.Reg.End, false, true)
+	var tbe *TextBufEdit
+	for {
+		tb.UndoPos--
+		tbe = tb.Undos[tb.UndoPos]
+		if tbe.Delete {
+			// fmt.Printf("undoing delete at: %v text: %v\n", tbe.Reg, string(tbe.ToBytes()))
+			tb.InsertText(tbe.Reg.Start, tbe.ToBytes(), false, true)
+		} else {
+			// fmt.Printf("undoing insert at: %v text: %v\n", tbe.Reg, string(tbe.ToBytes()))
+			tb.DeleteText(tbe.Reg.Start, tbe.Reg.End, false, true)
+		}
+		if tbe.Group == 0 || tb.UndoPos == 0 || tb.Undos[tb.UndoPos-1].Group != tbe.Group {
+			break
+		}
 	}
 	return tbe
 }
 
-// Redo redoes next item on the undo stack, and returns that record, nil if no more
+// Redo redoes next item on the undo stack, along with the rest of its undo
+// group if any, and returns the last record redone, nil if no more
 func (tb *TextBuf) Redo() *TextBufEdit {
 	if tb.UndoPos >= len(tb.Undos) {
 		return nil
 	}
-	tbe := tb.Undos[tb.UndoPos]
-	if tbe.Delete {
-		tb.DeleteText(tbe.Reg.Start, tbe.Reg.End, false, true)
-	} else {
-		tb.InsertText(tbe.Reg.Start, tbe.ToBytes(), false, true)
+	var tbe *TextBufEdit
+	for {
+		tbe = tb.Undos[tb.UndoPos]
+		if tbe.Delete {
+			tb.DeleteText(tbe.Reg.Start, tbe.Reg.End, false, true)
+		} else {
+			tb.InsertText(tbe.Reg.Start, tbe.ToBytes(), false, true)
+		}
+		tb.UndoPos++
+		if tbe.Group == 0 || tb.UndoPos >= len(tb.Undos) || tb.Undos[tb.UndoPos].Group != tbe.Group {
+			break
+		}
 	}
-	tb.UndoPos++
 	return tbe
 }
 
@@ -1237,6 +1709,8 @@ func (tb *TextBuf) IndentLine(ln int, n, tabSz int, spc bool) *TextBufEdit {
 		cpos := IndentCharPos(curli, tabSz, spc)
 		tb.DeleteText(TextPos{Ln: ln, Ch: spos}, TextPos{Ln: ln, Ch: cpos}, true, true)
 		// fmt.Printf("IndentLine deleted: %v at: %v\n", string(tbe.ToBytes()), tbe.Reg)
+	} else {
+		tb.undoCurDone() // already indented
 	}
 	return nil
 }
@@ -1299,6 +1773,8 @@ func (tb *TextBuf) AutoIndent(ln int, spc bool, tabSz int, indents, unindents []
 
 // AutoIndentRegion does auto-indent over given region -- end is *exclusive*
 func (tb *TextBuf) AutoIndentRegion(st, ed int, spc bool, tabSz int, indents, unindents []string) {
+	tb.BeginUndoGroup()
+	defer tb.EndUndoGroup()
 	for ln := st; ln < ed; ln++ {
 		if ln >= tb.NLines {
 			break
@@ -1318,6 +1794,8 @@ func (tb *TextBuf) CommentRegion(st, ed int, comment []byte, tabSz int) {
 			ch = li
 		}
 	}
+	tb.BeginUndoGroup()
+	defer tb.EndUndoGroup()
 	for ln := st; ln < ed; ln++ {
 		if ln >= tb.NLines {
 			break
@@ -1326,6 +1804,150 @@ func (tb *TextBuf) CommentRegion(st, ed int, comment []byte, tabSz int) {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////
+//   Folding
+
+// TextFold is a range of lines that can be folded (hidden) in a TextView --
+// the first line, St, remains visible and the lines after it through Ed
+// (inclusive) are hidden
+type TextFold struct {
+	St, Ed int
+}
+
+// Contains returns true if given line is hidden when this range is folded
+func (tf *TextFold) Contains(ln int) bool {
+	return ln > tf.St && ln <= tf.Ed
+}
+
+// TextBufIndentFoldLangs are the languages (Hi.Lang) whose structure is
+// given by indentation rather than brackets, for FoldRanges
+var TextBufIndentFoldLangs = []string{"Python", "Python 3", "YAML", "Base Makefile", "markdown", "reStructuredText", "CoffeeScript", "Nim"}
+
+// FoldRanges returns the ranges of lines that can be folded, sorted by
+// starting line -- from the brackets in the code (BracketFoldRanges) for
+// languages with a lexer, or else from indentation (IndentFoldRanges), which
+// is also used for languages in TextBufIndentFoldLangs or if there are no
+// multi-line brackets -- the lines are read under the MarkupMu mutex, so
+// this can be called from another goroutine
+func (tb *TextBuf) FoldRanges(tabSz int) []TextFold {
+	indent := false
+	for _, lang := range TextBufIndentFoldLangs {
+		if tb.Hi.Lang == lang {
+			indent = true
+			break
+		}
+	}
+	if !indent {
+		if fr := tb.BracketFoldRanges(); len(fr) > 0 {
+			return fr
+		}
+	}
+	tb.MarkupMu.Lock()
+	defer tb.MarkupMu.Unlock()
+	return tb.IndentFoldRanges(tabSz)
+}
+
+// BracketFoldRanges returns the ranges of lines between matching brackets
+// that are on different lines, per the lexer for the current Hi.Lang --
+// brackets in strings and comments are ignored -- the line with the closing
+// bracket is not in the range, so it remains visible when folded -- only the
+// outermost range starting on a given line is included
+func (tb *TextBuf) BracketFoldRanges() []TextFold {
+	if !tb.Hi.HasHi() {
+		return nil
+	}
+	tb.MarkupMu.Lock()
+	txt := tb.LinesToBytesCopy()
+	nln := tb.NLines
+	tb.MarkupMu.Unlock()
+	brs := tb.Hi.Brackets(txt)
+	if brs == nil {
+		return nil
+	}
+	type open struct {
+		ln int
+		br rune
+	}
+	match := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var stack []open
+	eds := make(map[int]int)
+	for ln, lbrs := range brs {
+		for _, r := range lbrs {
+			opr, isClose := match[r]
+			if !isClose {
+				stack = append(stack, open{ln, r})
+				continue
+			}
+			n := len(stack)
+			if n == 0 || stack[n-1].br != opr {
+				continue // unbalanced -- just skip it
+			}
+			st := stack[n-1].ln
+			stack = stack[:n-1]
+			if ln-1 > st && ln-1 > eds[st] {
+				eds[st] = ln - 1
+			}
+		}
+	}
+	var fr []TextFold
+	for st, ed := range eds {
+		fr = append(fr, TextFold{St: st, Ed: ints.MinInt(ed, nln-1)})
+	}
+	sort.Slice(fr, func(i, j int) bool {
+		return fr[i].St < fr[j].St
+	})
+	return fr
+}
+
+// IndentFoldRanges returns the ranges of lines that are indented more than
+// the line before them, i.e., each line with the following more-indented
+// lines -- blank lines do not end a range, but are not included at its end
+func (tb *TextBuf) IndentFoldRanges(tabSz int) []TextFold {
+	type open struct {
+		ln, ind int
+	}
+	var stack []open
+	var fr []TextFold
+	lastln := -1 // last non-blank line
+	closeTo := func(ind int) {
+		for len(stack) > 0 && stack[len(stack)-1].ind >= ind {
+			st := stack[len(stack)-1].ln
+			stack = stack[:len(stack)-1]
+			if lastln > st {
+				fr = append(fr, TextFold{St: st, Ed: lastln})
+			}
+		}
+	}
+	for ln := 0; ln < tb.NLines; ln++ {
+		if tb.IsBlankLine(ln) {
+			continue
+		}
+		n, spc := tb.LineIndent(ln, tabSz)
+		ind := n
+		if !spc {
+			ind *= tabSz
+		}
+		closeTo(ind)
+		stack = append(stack, open{ln, ind})
+		lastln = ln
+	}
+	closeTo(0)
+	sort.Slice(fr, func(i, j int) bool {
+		return fr[i].St < fr[j].St
+	})
+	return fr
+}
+
+// IsBlankLine returns true if given line is empty or only has white space
+func (tb *TextBuf) IsBlankLine(ln int) bool {
+	for _, r := range tb.Lines[ln] {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
 ////////////////////////////////////////////////////////////////////////////
 //   Diffs
 
@@ -1384,22 +2006,25 @@ func (tb *TextBuf) DiffBufsUnified(ob *TextBuf, context int) []byte {
 // PatchFromBuf patches (edits) this buffer using content from other buffer,
 // according to diff operations (e.g., as generated from DiffBufs).  signal
 // determines whether each patch is signaled -- if an overall signal will be
-// sent at the end, then that would not be necessary (typical)
+// sent at the end, then that would not be necessary (typical) -- the patch
+// is a single undo step
 func (tb *TextBuf) PatchFromBuf(ob *TextBuf, diffs TextDiffs, signal bool) bool {
 	mods := false
+	tb.BeginUndoGroup()
+	defer tb.EndUndoGroup()
 	for _, df := range diffs {
 		switch df.Tag {
 		case 'r':
-			tb.DeleteText(TextPos{Ln: df.I1}, TextPos{Ln: df.I2}, false, signal)
+			tb.DeleteText(TextPos{Ln: df.I1}, TextPos{Ln: df.I2}, true, signal)
 			ot := ob.Region(TextPos{Ln: df.J1}, TextPos{Ln: df.J2})
-			tb.InsertText(TextPos{Ln: df.I1}, ot.ToBytes(), false, signal)
+			tb.InsertText(TextPos{Ln: df.I1}, ot.ToBytes(), true, signal)
 			mods = true
 		case 'd':
-			tb.DeleteText(TextPos{Ln: df.I1}, TextPos{Ln: df.I2}, false, signal)
+			tb.DeleteText(TextPos{Ln: df.I1}, TextPos{Ln: df.I2}, true, signal)
 			mods = true
 		case 'i':
 			ot := ob.Region(TextPos{Ln: df.J1}, TextPos{Ln: df.J2})
-			tb.InsertText(TextPos{Ln: df.I1}, ot.ToBytes(), false, signal)
+			tb.InsertText(TextPos{Ln: df.I1}, ot.ToBytes(), true, signal)
 			mods = true
 		}
 	}
@@ -0,0 +1,454 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+// testTextBuf returns a new buffer with given text, without a file or
+// syntax highlighting
+func testTextBuf(txt string) *TextBuf {
+	tb := &TextBuf{}
+	tb.InitName(tb, "testbuf")
+	tb.Txt = []byte(txt)
+	tb.BytesToLines()
+	return tb
+}
+
+// testTextBufHi returns a new buffer with given text, with syntax
+// highlighting for given language -- the lines are not yet marked up
+func testTextBufHi(txt, lang string) *TextBuf {
+	tb := &TextBuf{}
+	tb.InitName(tb, "testbuf")
+	tb.Hi.Lang = lang
+	tb.Hi.Style = "emacs"
+	tb.Txt = []byte(txt)
+	tb.BytesToLines()
+	return tb
+}
+
+// testTextReg returns the region from st to ed on line ln
+func testTextReg(ln, st, ed int) TextRegion {
+	return TextRegion{Start: TextPos{Ln: ln, Ch: st}, End: TextPos{Ln: ln, Ch: ed}}
+}
+
+func TestSearchRegexp(t *testing.T) {
+	src := "foo bar\nbäz foo\nfoofoo\n"
+	reg := TextRegion{Start: TextPos{Ln: 0, Ch: 2}, End: TextPos{Ln: 2, Ch: 3}}
+	tests := []struct {
+		re  string
+		reg TextRegion
+		cor []TextRegion
+	}{
+		{`foo`, TextRegionZero, []TextRegion{testTextReg(0, 0, 3), testTextReg(1, 4, 7), testTextReg(2, 0, 3), testTextReg(2, 3, 6)}},
+		{`b.[rz]`, TextRegionZero, []TextRegion{testTextReg(0, 4, 7), testTextReg(1, 0, 3)}},
+		{`x*`, TextRegionZero, nil},
+		{`foo`, reg, []TextRegion{testTextReg(1, 4, 7), testTextReg(2, 0, 3)}},
+	}
+	tb := testTextBuf(src)
+	for _, tt := range tests {
+		n, ms := tb.SearchRegexp(regexp.MustCompile(tt.re), tt.reg)
+		if n != len(tt.cor) || len(ms) != len(tt.cor) {
+			t.Errorf("SearchRegexp(%q, %v): got %v matches, expected %v\n", tt.re, tt.reg, n, len(tt.cor))
+			continue
+		}
+		for i := range ms {
+			if ms[i].Reg != tt.cor[i] {
+				t.Errorf("SearchRegexp(%q, %v) match %v: got %v, expected %v\n", tt.re, tt.reg, i, ms[i].Reg, tt.cor[i])
+			}
+		}
+	}
+	_, ms := tb.SearchRegexp(regexp.MustCompile(`foo`), TextRegionZero)
+	if cor := "bäz <mark>foo</mark>"; string(ms[1].Text) != cor {
+		t.Errorf("SearchRegexp match text: got %q, expected %q\n", ms[1].Text, cor)
+	}
+}
+
+func TestReplaceAll(t *testing.T) {
+	src := "Foo bar foo\nfoobar $x\n"
+	tests := []struct {
+		find string
+		opts TextFindOpts
+		repl string
+		reg  TextRegion
+		n    int
+		cor  string
+	}{
+		{"foo", TextFindOpts{UseCase: true}, "$1x", TextRegionZero, 2, "Foo bar $1x\n$1xbar $x\n"},
+		{"foo", TextFindOpts{}, "baz", TextRegionZero, 3, "baz bar baz\nbazbar $x\n"},
+		{"foo", TextFindOpts{WholeWord: true}, "baz", TextRegionZero, 2, "baz bar baz\nfoobar $x\n"},
+		{`(\w+) (\w+)`, TextFindOpts{UseCase: true, Regexp: true}, "$2 $1", TextRegionZero, 1, "bar Foo foo\nfoobar $x\n"},
+		{"$x", TextFindOpts{}, "y", TextRegionZero, 1, "Foo bar foo\nfoobar y\n"},
+		{"foo", TextFindOpts{}, "baz", TextRegion{Start: TextPos{Ln: 0, Ch: 4}, End: TextPos{Ln: 1, Ch: 3}}, 2, "Foo bar baz\nbazbar $x\n"},
+		{"nope", TextFindOpts{}, "baz", TextRegionZero, 0, src},
+	}
+	for _, tt := range tests {
+		re, err := tt.opts.Compile(tt.find)
+		if err != nil {
+			t.Errorf("Compile(%q): %v\n", tt.find, err)
+			continue
+		}
+		tb := testTextBuf(src)
+		n := tb.ReplaceAll(re, tt.opts.ReplTemplate(tt.repl), tt.reg)
+		if txt := string(tb.LinesToBytesCopy()); n != tt.n || txt != tt.cor {
+			t.Errorf("ReplaceAll(%q, %q, %+v): got %v: %q, expected %v: %q\n", tt.find, tt.repl, tt.opts, n, txt, tt.n, tt.cor)
+		}
+		if tt.n == 0 {
+			continue
+		}
+		tb.Undo()
+		if txt := string(tb.LinesToBytesCopy()); txt != src || tb.UndoPos != 0 {
+			t.Errorf("ReplaceAll(%q, %q, %+v): one Undo got %q at undo pos %v, expected %q at 0\n", tt.find, tt.repl, tt.opts, txt, tb.UndoPos, src)
+		}
+	}
+}
+
+func TestReplaceNext(t *testing.T) {
+	src := "a1 a2\na3\n"
+	tests := []struct {
+		src  string
+		repl string
+		pos  TextPos
+		reg  TextRegion
+		ok   bool
+		creg TextRegion
+		cor  string
+	}{
+		{src, "bb", TextPos{Ln: 0, Ch: 1}, TextRegionZero, true, testTextReg(0, 3, 5), "a1 bb\na3\n"},
+		{src, "b", TextPos{Ln: 0, Ch: 4}, TextRegionZero, true, testTextReg(1, 0, 1), "a1 a2\nb\n"},
+		{src, "b", TextPos{Ln: 1, Ch: 1}, TextRegionZero, true, testTextReg(0, 0, 1), "b a2\na3\n"},
+		{"a1 a2\n", "b", TextPos{Ln: 0, Ch: 4}, TextRegionZero, true, testTextReg(0, 0, 1), "b a2\n"},
+		{src, "b", TextPos{Ln: 0, Ch: 0}, testTextReg(0, 2, 5), true, testTextReg(0, 3, 4), "a1 b\na3\n"},
+		{src, "", TextPos{Ln: 0, Ch: 1}, TextRegionZero, true, testTextReg(0, 3, 3), "a1 \na3\n"},
+		{"xy\n", "b", TextPos{Ln: 0, Ch: 0}, TextRegionZero, false, TextRegionZero, "xy\n"},
+	}
+	re := regexp.MustCompile(`a\d`)
+	for _, tt := range tests {
+		tb := testTextBuf(tt.src)
+		reg, ok := tb.ReplaceNext(re, tt.repl, tt.pos, tt.reg)
+		if ok != tt.ok || reg != tt.creg {
+			t.Errorf("ReplaceNext(%q, %v, %v) in %q: got %v, %v, expected %v, %v\n", tt.repl, tt.pos, tt.reg, tt.src, reg, ok, tt.creg, tt.ok)
+		}
+		if txt := string(tb.LinesToBytesCopy()); txt != tt.cor {
+			t.Errorf("ReplaceNext(%q, %v, %v) in %q: got %q, expected %q\n", tt.repl, tt.pos, tt.reg, tt.src, txt, tt.cor)
+		}
+	}
+}
+
+func TestUndoTyping(t *testing.T) {
+	defer func(ms int) { TextBufUndoTypingMSec = ms }(TextBufUndoTypingMSec)
+	tests := []struct {
+		msec  int
+		typed string
+		cor   []string // text after each Undo
+	}{
+		{1000, "ab c", []string{"ab \n", "\n"}},
+		{1000, "a.b", []string{"a.\n", "\n"}},
+		{1000, "x_1", []string{"\n"}},
+		{-1, "ab", []string{"a\n", "\n"}},
+	}
+	for _, tt := range tests {
+		TextBufUndoTypingMSec = tt.msec
+		tb := testTextBuf("")
+		for i, r := range tt.typed {
+			tb.InsertText(TextPos{Ch: i}, []byte(string(r)), true, true)
+		}
+		for i, cor := range tt.cor {
+			tb.Undo()
+			if txt := string(tb.LinesToBytesCopy()); txt != cor {
+				t.Errorf("Undo %v of typing %q at %v msec: got %q, expected %q\n", i, tt.typed, tt.msec, txt, cor)
+			}
+		}
+		if tb.UndoPos != 0 {
+			t.Errorf("Undo of typing %q at %v msec: got undo pos %v after %v undos, expected 0\n", tt.typed, tt.msec, tb.UndoPos, len(tt.cor))
+		}
+	}
+}
+
+func TestUndoGroup(t *testing.T) {
+	tb := testTextBuf("abc\ndef\n")
+	tb.BeginUndoGroup()
+	tb.InsertText(TextPos{Ln: 0, Ch: 0}, []byte("x"), true, true)
+	tb.BeginUndoGroup()
+	tb.DeleteText(TextPos{Ln: 1, Ch: 0}, TextPos{Ln: 1, Ch: 1}, true, true)
+	tb.EndUndoGroup()
+	tb.EndUndoGroup()
+	tb.InsertText(TextPos{Ln: 0, Ch: 0}, []byte("y"), true, true)
+
+	steps := []struct {
+		op  string
+		cor string
+	}{
+		{"undo", "xabc\nef\n"},
+		{"undo", "abc\ndef\n"},
+		{"undo", "abc\ndef\n"},
+		{"redo", "xabc\nef\n"},
+		{"redo", "yxabc\nef\n"},
+		{"redo", "yxabc\nef\n"},
+		{"undo", "xabc\nef\n"},
+		{"undo", "abc\ndef\n"},
+		{"insert", "zabc\ndef\n"},
+		{"redo", "zabc\ndef\n"},
+	}
+	for i, st := range steps {
+		switch st.op {
+		case "undo":
+			tb.Undo()
+		case "redo":
+			tb.Redo()
+		case "insert":
+			tb.InsertText(TextPos{Ln: 0, Ch: 0}, []byte("z"), true, true)
+		}
+		if txt := string(tb.LinesToBytesCopy()); txt != st.cor {
+			t.Errorf("undo group step %v, %v: got %q, expected %q\n", i, st.op, txt, st.cor)
+		}
+	}
+}
+
+func TestUndoCursor(t *testing.T) {
+	sel := testTextReg(0, 1, 2)
+	notSet := TextPos{Ln: -1}
+	tests := []struct {
+		name string
+		edit func(tb *TextBuf)
+		cor  TextPos
+		csel TextRegion
+	}{
+		{"set", func(tb *TextBuf) {
+			tb.SetUndoCursor(TextPos{Ln: 0, Ch: 2}, sel)
+			tb.InsertText(TextPos{Ln: 0, Ch: 3}, []byte("d"), true, true)
+		}, TextPos{Ln: 0, Ch: 2}, sel},
+		{"not set", func(tb *TextBuf) {
+			tb.InsertText(TextPos{Ln: 0, Ch: 3}, []byte("d"), true, true)
+		}, notSet, TextRegionZero},
+		{"no-op edit", func(tb *TextBuf) {
+			tb.SetUndoCursor(TextPos{Ln: 0, Ch: 2}, sel)
+			tb.DeleteText(TextPos{Ln: 0, Ch: 1}, TextPos{Ln: 0, Ch: 1}, true, true)
+			tb.InsertText(TextPos{Ln: 0, Ch: 3}, []byte("d"), true, true)
+		}, notSet, TextRegionZero},
+		{"group", func(tb *TextBuf) {
+			tb.BeginUndoGroup()
+			tb.SetUndoCursor(TextPos{Ln: 0, Ch: 1}, sel)
+			tb.InsertText(TextPos{Ln: 0, Ch: 0}, []byte("x"), true, true)
+			tb.SetUndoCursor(TextPos{Ln: 0, Ch: 2}, TextRegionZero)
+			tb.InsertText(TextPos{Ln: 0, Ch: 0}, []byte("y"), true, true)
+			tb.EndUndoGroup()
+		}, TextPos{Ln: 0, Ch: 1}, sel},
+		{"after group", func(tb *TextBuf) {
+			tb.BeginUndoGroup()
+			tb.SetUndoCursor(TextPos{Ln: 0, Ch: 1}, sel)
+			tb.EndUndoGroup()
+			tb.InsertText(TextPos{Ln: 0, Ch: 3}, []byte("d"), true, true)
+		}, notSet, TextRegionZero},
+	}
+	for _, tt := range tests {
+		tb := testTextBuf("abc\n")
+		tt.edit(tb)
+		tbe := tb.Undo()
+		if tbe == nil {
+			t.Errorf("undo cursor %v: nothing to undo\n", tt.name)
+			continue
+		}
+		if tbe.Cursor != tt.cor || tbe.Sel != tt.csel {
+			t.Errorf("undo cursor %v: got %v, %v, expected %v, %v\n", tt.name, tbe.Cursor, tbe.Sel, tt.cor, tt.csel)
+		}
+		if txt := string(tb.LinesToBytesCopy()); txt != "abc\n" {
+			t.Errorf("undo cursor %v: got %q after Undo, expected %q\n", tt.name, txt, "abc\n")
+		}
+	}
+}
+
+func TestTextBufEditAdjustPos(t *testing.T) {
+	pos := func(ln, ch int) TextPos { return TextPos{Ln: ln, Ch: ch} }
+	ins := &TextBufEdit{Reg: TextRegion{Start: pos(1, 2), End: pos(1, 4)}}
+	insLns := &TextBufEdit{Reg: TextRegion{Start: pos(1, 2), End: pos(3, 1)}}
+	del := &TextBufEdit{Reg: TextRegion{Start: pos(1, 2), End: pos(1, 4)}, Delete: true}
+	delLns := &TextBufEdit{Reg: TextRegion{Start: pos(1, 2), End: pos(3, 1)}, Delete: true}
+	tests := []struct {
+		name string
+		tbe  *TextBufEdit
+		pos  TextPos
+		cor  TextPos
+	}{
+		{"insert, line before", ins, pos(0, 5), pos(0, 5)},
+		{"insert, before", ins, pos(1, 1), pos(1, 1)},
+		{"insert, at start", ins, pos(1, 2), pos(1, 4)},
+		{"insert, after", ins, pos(1, 5), pos(1, 7)},
+		{"insert, line after", ins, pos(2, 0), pos(2, 0)},
+		{"insert lines, before", insLns, pos(1, 1), pos(1, 1)},
+		{"insert lines, at start", insLns, pos(1, 2), pos(3, 1)},
+		{"insert lines, after", insLns, pos(1, 5), pos(3, 4)},
+		{"insert lines, line after", insLns, pos(2, 3), pos(4, 3)},
+		{"delete, before", del, pos(1, 1), pos(1, 1)},
+		{"delete, inside", del, pos(1, 3), pos(1, 2)},
+		{"delete, at end", del, pos(1, 4), pos(1, 2)},
+		{"delete, after", del, pos(1, 6), pos(1, 4)},
+		{"delete, line after", del, pos(2, 0), pos(2, 0)},
+		{"delete lines, line before", delLns, pos(0, 9), pos(0, 9)},
+		{"delete lines, inside", delLns, pos(2, 5), pos(1, 2)},
+		{"delete lines, at end", delLns, pos(3, 1), pos(1, 2)},
+		{"delete lines, after", delLns, pos(3, 4), pos(1, 5)},
+		{"delete lines, line after", delLns, pos(4, 0), pos(2, 0)},
+	}
+	for _, tt := range tests {
+		if adj := tt.tbe.AdjustPos(tt.pos); adj != tt.cor {
+			t.Errorf("AdjustPos %v, %v: got %v, expected %v\n", tt.name, tt.pos, adj, tt.cor)
+		}
+	}
+}
+
+// testFoldsEqual returns true if given fold ranges are the same
+func testFoldsEqual(fr, cor []TextFold) bool {
+	if len(fr) != len(cor) {
+		return false
+	}
+	for i := range fr {
+		if fr[i] != cor[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestIndentFoldRanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		tabSz int
+		cor   []TextFold
+	}{
+		{"spaces", "a\n  b\n  c\n    d\ne\n", 4, []TextFold{{0, 3}, {2, 3}}},
+		{"tabs", "a\n\tb\n\t\tc\n\td\n", 4, []TextFold{{0, 3}, {1, 2}}},
+		{"blank lines", "a\n  b\n\n  c\n\nd\n", 4, []TextFold{{0, 3}}},
+		{"tabs and spaces", "a\n\tb\n  c\n", 2, []TextFold{{0, 2}}},
+		{"flat", "a\nb\n", 4, nil},
+	}
+	for _, tt := range tests {
+		tb := testTextBuf(tt.src)
+		if fr := tb.IndentFoldRanges(tt.tabSz); !testFoldsEqual(fr, tt.cor) {
+			t.Errorf("IndentFoldRanges %v: got %v, expected %v\n", tt.name, fr, tt.cor)
+		}
+	}
+}
+
+func TestFoldRanges(t *testing.T) {
+	goSrc := `func f() {
+	x := []int{
+		1,
+	}
+	// { not a bracket
+	s := "("
+}
+g(1,
+	2,
+	3)
+`
+	pySrc := `def f():
+    x = (1,
+        2,
+        3)
+    return x
+`
+	tests := []struct {
+		name string
+		lang string
+		src  string
+		cor  []TextFold
+	}{
+		{"brackets", "Go", goSrc, []TextFold{{0, 5}, {1, 2}, {7, 8}}},
+		{"no multi-line brackets", "Go", "x := 1\n\ty := 2\n", []TextFold{{0, 1}}},
+		{"indent language", "Python", pySrc, []TextFold{{0, 4}, {1, 3}}},
+		{"no highlighting", "", goSrc, []TextFold{{0, 5}, {1, 2}, {7, 9}}},
+	}
+	for _, tt := range tests {
+		tb := testTextBufHi(tt.src, tt.lang)
+		if fr := tb.FoldRanges(4); !testFoldsEqual(fr, tt.cor) {
+			t.Errorf("FoldRanges %v: got %v, expected %v\n", tt.name, fr, tt.cor)
+		}
+	}
+	tb := testTextBufHi(goSrc, "Go")
+	if fr := tb.BracketFoldRanges(); !testFoldsEqual(fr, tests[0].cor) {
+		t.Errorf("BracketFoldRanges: got %v, expected %v\n", fr, tests[0].cor)
+	}
+	if fr := testTextBuf(goSrc).BracketFoldRanges(); fr != nil {
+		t.Errorf("BracketFoldRanges without highlighting: got %v, expected none\n", fr)
+	}
+}
+
+// testMarkupCheck checks that the Markup and lexer states of the lines of
+// given buffer are the same as from marking up all of its text afresh
+func testMarkupCheck(t *testing.T, name string, tb *TextBuf) {
+	tb.MarkupMu.Lock()
+	defer tb.MarkupMu.Unlock()
+	n := 0
+	tb.Hi.MarkupLinesFunc(tb.markupText(0, tb.NLines-1), func(ln int, mu []byte, st HiState) bool {
+		if ln >= tb.NLines {
+			return false
+		}
+		n++
+		if string(tb.Markup[ln]) != string(mu) || tb.hiStates[ln] != st {
+			t.Errorf("markup %v line %v: got state %v: %q, expected %v: %q\n", name, ln, tb.hiStates[ln], tb.Markup[ln], st, mu)
+		}
+		return true
+	})
+	if n != tb.NLines {
+		t.Errorf("markup %v: got %v lines from the lexer, expected %v\n", name, n, tb.NLines)
+	}
+}
+
+// testMarkupWait waits for MarkupStale running in the background to mark up
+// all the lines of given buffer
+func testMarkupWait(t *testing.T, name string, tb *TextBuf) {
+	for i := 0; i < 200; i++ {
+		tb.MarkupMu.Lock()
+		done := !tb.hiBusy && tb.hiStale >= tb.NLines
+		tb.MarkupMu.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("markup %v: MarkupStale did not finish\n", name)
+}
+
+func TestMarkupEdited(t *testing.T) {
+	defer func(n int) { TextBufMarkupSyncLines = n }(TextBufMarkupSyncLines)
+	src := "package main\n\nfunc f() int {\n\tx := 1\n\treturn x // */\n}\nvar s = \"a\"\n"
+	srcCm := "package main\n\nfunc f() int {\n\t/* x := 1\n\treturn x // */\n}\nvar s = \"a\"\n"
+	pos := func(ln, ch int) TextPos { return TextPos{Ln: ln, Ch: ch} }
+	tests := []struct {
+		name  string
+		src   string
+		sync  int
+		edit  func(tb *TextBuf)
+		stale bool // the rest of the lines are left to MarkupStale
+	}{
+		{"local edit", src, 500, func(tb *TextBuf) { tb.InsertText(pos(3, 7), []byte("2"), true, true) }, false},
+		{"open comment", src, 500, func(tb *TextBuf) { tb.InsertText(pos(3, 1), []byte("/* "), true, true) }, false},
+		{"open comment, past sync lines", src, 1, func(tb *TextBuf) { tb.InsertText(pos(3, 1), []byte("/* "), true, true) }, true},
+		{"close comment", srcCm, 500, func(tb *TextBuf) { tb.DeleteText(pos(3, 1), pos(3, 4), true, true) }, false},
+		{"insert lines", src, 500, func(tb *TextBuf) { tb.InsertText(pos(2, 0), []byte("/*\n\n*/\n"), true, true) }, false},
+		{"delete lines", srcCm, 500, func(tb *TextBuf) { tb.DeleteText(pos(2, 0), pos(4, 0), true, true) }, false},
+	}
+	for _, tt := range tests {
+		TextBufMarkupSyncLines = tt.sync
+		tb := testTextBufHi(tt.src, "Go")
+		tb.MarkupStale()
+		testMarkupCheck(t, tt.name+", before edit", tb)
+		tt.edit(tb)
+		if !tt.stale {
+			tb.MarkupMu.Lock()
+			if tb.hiStale != tb.NLines {
+				t.Errorf("markup %v: got stale from line %v, expected all %v lines done\n", tt.name, tb.hiStale, tb.NLines)
+			}
+			tb.MarkupMu.Unlock()
+		}
+		testMarkupWait(t, tt.name, tb)
+		testMarkupCheck(t, tt.name, tb)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// TextFindOpts are the options for finding and replacing text in a TextView
+type TextFindOpts struct {
+	UseCase   bool `desc:"match case -- otherwise case is ignored"`
+	WholeWord bool `desc:"only match whole words"`
+	Regexp    bool `desc:"the find string is a regular expression, and the replace string can refer to its submatches as $1, ${name} etc -- otherwise both are literal"`
+	InSel     bool `desc:"only find and replace within the selection at the start of the find"`
+}
+
+// Compile returns the regular expression for finding given string with
+// these options -- nil and an error if it is empty or not a valid regexp
+func (fo *TextFindOpts) Compile(find string) (*regexp.Regexp, error) {
+	if find == "" {
+		return nil, errors.New("giv.TextFindOpts: find string is empty")
+	}
+	if !fo.Regexp {
+		find = regexp.QuoteMeta(find)
+	}
+	if fo.WholeWord {
+		find = `\b(?:` + find + `)\b`
+	}
+	if !fo.UseCase {
+		find = `(?i)` + find
+	}
+	re, err := regexp.Compile(find)
+	if err != nil {
+		return nil, fmt.Errorf("giv.TextFindOpts: %v", err)
+	}
+	return re, nil
+}
+
+// ReplTemplate returns given replace string as a template for the
+// replacement of a match (see regexp.Expand) -- unless Regexp is set, any $
+// are escaped so that it is literal
+func (fo *TextFindOpts) ReplTemplate(repl string) string {
+	if fo.Regexp {
+		return repl
+	}
+	return strings.Replace(repl, "$", "$$", -1)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  TextFindBar
+
+// TextFindBar is a toolbar for finding and replacing text in a TextView,
+// using the Find*, Repl* fields and methods of the view -- it is shown in a
+// TextFindDialog by TextView.FindReplace (for KeyFunFind), and can also be
+// added to any layout and configured with Config
+type TextFindBar struct {
+	gi.ToolBar
+	View *TextView `json:"-" xml:"-" desc:"the text view that we find and replace within"`
+}
+
+var KiT_TextFindBar = kit.Types.AddType(&TextFindBar{}, gi.ToolBarProps)
+
+// Config configures the bar to find and replace within given view, starting
+// with its current FindString, ReplString and FindOpts
+func (fb *TextFindBar) Config(tv *TextView) {
+	fb.View = tv
+	fb.Lay = gi.LayoutHoriz
+	config := kit.TypeAndNameList{}
+	config.Add(gi.KiT_TextField, "find")
+	config.Add(gi.KiT_TextField, "repl")
+	config.Add(gi.KiT_CheckBox, "case")
+	config.Add(gi.KiT_CheckBox, "word")
+	config.Add(gi.KiT_CheckBox, "regexp")
+	config.Add(gi.KiT_CheckBox, "in-sel")
+	config.Add(gi.KiT_Action, "prev")
+	config.Add(gi.KiT_Action, "next")
+	config.Add(gi.KiT_Action, "replace")
+	config.Add(gi.KiT_Action, "replace-all")
+	config.Add(gi.KiT_Label, "status")
+	mods, updt := fb.ConfigChildren(config, false)
+
+	ff := fb.FindField()
+	ff.Placeholder = "Find"
+	ff.Tooltip = "text to find -- press Enter to find the next match"
+	ff.SetText(tv.FindString)
+	ff.SetMinPrefWidth(units.NewValue(20, units.Ch))
+	ff.TextFieldSig.Connect(fb.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(gi.TextFieldDone) {
+			fbb := recv.Embed(KiT_TextFindBar).(*TextFindBar)
+			fbb.FindNext(false)
+		}
+	})
+	rf := fb.ReplField()
+	rf.Placeholder = "Replace"
+	rf.Tooltip = "text to replace matches with -- if Regexp, $1, ${name} etc are replaced with submatches of the find regexp"
+	rf.SetText(tv.ReplString)
+	rf.SetMinPrefWidth(units.NewValue(20, units.Ch))
+	rf.SetInactiveState(tv.IsInactive())
+
+	opts := []struct {
+		nm, lbl, tip string
+		val          bool
+	}{
+		{"case", "Case", "match case", tv.FindOpts.UseCase},
+		{"word", "Word", "only match whole words", tv.FindOpts.WholeWord},
+		{"regexp", "Regexp", "find string is a regular expression", tv.FindOpts.Regexp},
+		{"in-sel", "In Sel", "only find and replace within the selection", tv.FindOpts.InSel},
+	}
+	for _, op := range opts {
+		cb := fb.KnownChildByName(op.nm, 0).(*gi.CheckBox)
+		cb.SetText(op.lbl)
+		cb.Tooltip = op.tip
+		cb.SetChecked(op.val)
+		cb.ButtonSig.ConnectOnly(fb.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig == int64(gi.ButtonToggled) {
+				fbb := recv.Embed(KiT_TextFindBar).(*TextFindBar)
+				fbb.FindOptsChanged()
+			}
+		})
+	}
+
+	acts := []struct {
+		nm, lbl, tip string
+		fun          func(fb *TextFindBar)
+	}{
+		{"prev", "Prev", "select the previous match", func(fb *TextFindBar) { fb.FindNext(true) }},
+		{"next", "Next", "select the next match", func(fb *TextFindBar) { fb.FindNext(false) }},
+		{"replace", "Replace", "replace the selected match and select the next one", func(fb *TextFindBar) { fb.ReplaceNext() }},
+		{"replace-all", "Replace All", "replace all the matches, as a single undo step", func(fb *TextFindBar) { fb.ReplaceAll() }},
+	}
+	for _, ac := range acts {
+		act := fb.KnownChildByName(ac.nm, 0).(*gi.Action)
+		act.SetText(ac.lbl)
+		act.Tooltip = ac.tip
+		act.Data = ac.fun
+		act.ActionSig.ConnectOnly(fb.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+			fbb := recv.Embed(KiT_TextFindBar).(*TextFindBar)
+			data.(func(fb *TextFindBar))(fbb)
+		})
+		if ac.nm == "replace" || ac.nm == "replace-all" {
+			act.SetInactiveState(tv.IsInactive())
+		}
+	}
+
+	fb.StatusLabel().SetText("")
+	if mods {
+		fb.UpdateEnd(updt)
+	}
+}
+
+// FindField returns the text field of the find string
+func (fb *TextFindBar) FindField() *gi.TextField {
+	return fb.KnownChildByName("find", 0).(*gi.TextField)
+}
+
+// ReplField returns the text field of the replace string
+func (fb *TextFindBar) ReplField() *gi.TextField {
+	return fb.KnownChildByName("repl", 1).(*gi.TextField)
+}
+
+// StatusLabel returns the label showing the number of matches or errors
+func (fb *TextFindBar) StatusLabel() *gi.Label {
+	return fb.KnownChildByName("status", 10).(*gi.Label)
+}
+
+// UpdateView sets the FindString, ReplString and FindOpts of the view from
+// the current values in the bar
+func (fb *TextFindBar) UpdateView() {
+	tv := fb.View
+	tv.FindString = fb.FindField().Text()
+	tv.ReplString = fb.ReplField().Text()
+	chk := func(nm string) bool {
+		return fb.KnownChildByName(nm, 0).(*gi.CheckBox).IsChecked()
+	}
+	tv.FindOpts.UseCase = chk("case")
+	tv.FindOpts.WholeWord = chk("word")
+	tv.FindOpts.Regexp = chk("regexp")
+	insel := chk("in-sel")
+	if insel != tv.FindOpts.InSel {
+		tv.FindOpts.InSel = insel
+		tv.SetFindScope()
+	}
+}
+
+// FindOptsChanged updates the view and its highlighted matches after the
+// options have been changed
+func (fb *TextFindBar) FindOptsChanged() {
+	if fb.View == nil {
+		return
+	}
+	fb.UpdateView()
+	n, err := fb.View.FindRegexpMatches()
+	fb.SetStatus(n, err)
+}
+
+// FindNext selects the next (or previous if back) match in the view
+func (fb *TextFindBar) FindNext(back bool) {
+	if fb.View == nil {
+		return
+	}
+	fb.UpdateView()
+	fb.View.FindNext(back)
+	_, err := fb.View.FindRegexp()
+	fb.SetStatus(len(fb.View.SearchMatches), err)
+}
+
+// ReplaceNext replaces the selected match in the view and selects the next
+func (fb *TextFindBar) ReplaceNext() {
+	if fb.View == nil || fb.View.IsInactive() {
+		return
+	}
+	fb.UpdateView()
+	fb.View.ReplaceNext()
+	_, err := fb.View.FindRegexp()
+	fb.SetStatus(len(fb.View.SearchMatches), err)
+}
+
+// ReplaceAll replaces all the matches in the view
+func (fb *TextFindBar) ReplaceAll() {
+	if fb.View == nil || fb.View.IsInactive() {
+		return
+	}
+	fb.UpdateView()
+	if _, err := fb.View.FindRegexp(); err != nil {
+		fb.SetStatus(0, err)
+		return
+	}
+	n := fb.View.ReplaceAll()
+	fb.StatusLabel().SetText(fmt.Sprintf("%d replaced", n))
+}
+
+// SetStatus shows the number of matches, or the error in the find string
+func (fb *TextFindBar) SetStatus(n int, err error) {
+	sl := fb.StatusLabel()
+	switch {
+	case err != nil && fb.View.FindString == "":
+		sl.SetText("")
+	case err != nil:
+		sl.SetText(err.Error())
+	case n == 1:
+		sl.SetText("1 match")
+	default:
+		sl.SetText(fmt.Sprintf("%d matches", n))
+	}
+}
+
+// TextFindDialog opens a modeless dialog with a TextFindBar for finding and
+// replacing text in given view -- optionally connects to given signal
+// receiving object and function for dialog signals (nil to ignore)
+func TextFindDialog(avp *gi.Viewport2D, tv *TextView, opts DlgOpts, recv ki.Ki, dlgFunc ki.RecvFunc) *gi.Dialog {
+	dlg := gi.NewStdDialog(opts.ToGiOpts(), false, true)
+
+	frame := dlg.Frame()
+	_, prIdx := dlg.PromptWidget(frame)
+
+	fb := frame.InsertNewChild(KiT_TextFindBar, prIdx+1, "find-bar").(*TextFindBar)
+	fb.Viewport = dlg.Embed(gi.KiT_Viewport2D).(*gi.Viewport2D)
+	fb.Config(tv)
+
+	bb, _ := dlg.ButtonBox(frame)
+	bb.KnownChildByName("cancel", 0).Embed(gi.KiT_Button).(*gi.Button).SetText("Close")
+
+	if recv != nil && dlgFunc != nil {
+		dlg.DialogSig.Connect(recv, dlgFunc)
+	}
+	dlg.UpdateEndNoSig(true)
+	dlg.Open(0, 0, avp, func() {
+		fb.FindField().GrabFocus()
+	})
+	return dlg
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import "testing"
+
+func TestTextFindOptsCompile(t *testing.T) {
+	tests := []struct {
+		opts  TextFindOpts
+		find  string
+		txt   string
+		match bool
+		err   bool
+	}{
+		{TextFindOpts{}, "", "", false, true},
+		{TextFindOpts{Regexp: true}, "a(", "", false, true},
+		{TextFindOpts{}, "a.b", "a.b", true, false},
+		{TextFindOpts{}, "a.b", "axb", false, false},
+		{TextFindOpts{Regexp: true}, "a.b", "axb", true, false},
+		{TextFindOpts{}, "Foo", "xfoo", true, false},
+		{TextFindOpts{UseCase: true}, "Foo", "xfoo", false, false},
+		{TextFindOpts{WholeWord: true}, "foo", "foobar", false, false},
+		{TextFindOpts{WholeWord: true}, "foo", "a foo.", true, false},
+		{TextFindOpts{WholeWord: true, Regexp: true}, "a|b", "ab", false, false},
+		{TextFindOpts{WholeWord: true, Regexp: true}, "a|b", "b", true, false},
+	}
+	for _, tt := range tests {
+		re, err := tt.opts.Compile(tt.find)
+		if tt.err {
+			if err == nil {
+				t.Errorf("Compile(%q, %+v): expected an error, got: %v\n", tt.find, tt.opts, re)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Compile(%q, %+v): %v\n", tt.find, tt.opts, err)
+			continue
+		}
+		if m := re.MatchString(tt.txt); m != tt.match {
+			t.Errorf("Compile(%q, %+v) match of %q: got %v, expected %v\n", tt.find, tt.opts, tt.txt, m, tt.match)
+		}
+	}
+}
+
+func TestTextFindOptsReplTemplate(t *testing.T) {
+	tests := []struct {
+		opts TextFindOpts
+		repl string
+		cor  string
+	}{
+		{TextFindOpts{}, "abc", "abc"},
+		{TextFindOpts{}, "$1 $$", "$$1 $$$$"},
+		{TextFindOpts{Regexp: true}, "$1 ${name}", "$1 ${name}"},
+	}
+	for _, tt := range tests {
+		if tmpl := tt.opts.ReplTemplate(tt.repl); tmpl != tt.cor {
+			t.Errorf("ReplTemplate(%q, %+v): got %q, expected %q\n", tt.repl, tt.opts, tmpl, tt.cor)
+		}
+	}
+}
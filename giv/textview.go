@@ -5,12 +5,16 @@
 package giv
 
 import (
+	"bytes"
 	"fmt"
 	"go/token"
 	"image"
 	"image/draw"
 	"log"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unicode"
@@ -94,6 +98,26 @@ type TextView struct {
 	lastRecenter      int
 	lastFilename      gi.FileName
 	lastWasTabAI      bool
+
+	FindString string       `json:"-" xml:"-" desc:"current find string, for find / replace -- see FindNext, ReplaceNext"`
+	ReplString string       `json:"-" xml:"-" desc:"current replace string, for find / replace -- a regexp template if FindOpts.Regexp"`
+	FindOpts   TextFindOpts `json:"-" xml:"-" desc:"options for find / replace"`
+	FindScope  TextRegion   `json:"-" xml:"-" desc:"region that find / replace is restricted to, if FindOpts.InSel -- TextRegionZero for the whole buffer"`
+
+	Cursors    []TextCursor `json:"-" xml:"-" desc:"additional cursors beyond CursorPos, each with its own selection -- added by AddCursor (Ctrl+click), AddCursorNext, AddCursorUp / Down, and SelectRect (Alt+drag) -- editing applies at all the cursors, as one undo step"`
+	RectStart  TextPos      `json:"-" xml:"-" desc:"starting corner of the rectangular selection being dragged with Alt"`
+	rectSelect bool         // dragging a rectangular selection from RectStart
+
+	Folds       []TextFold `json:"-" xml:"-" desc:"folded ranges of lines, sorted by starting line -- the lines after the start of each, through its end, are hidden -- change these with ToggleFold, Fold, Unfold, UnfoldAll"`
+	Foldable    []TextFold `json:"-" xml:"-" desc:"ranges of lines that can be folded, from Buf.FoldRanges -- updated in the background after edits, and as needed by the fold actions, see UpdateFoldable"`
+	foldsHidden []TextFold // Folds merged into disjoint ranges, for IsFoldedLine -- see updateFoldsHidden
+	foldsDirty  bool       // Foldable needs to be updated from the Buf
+	foldsGen    int        // incremented whenever Foldable goes out of date, see updateFoldableBg
+	foldsBusy   bool       // Foldable is being updated in the background
+
+	markupMu      sync.Mutex // protects markupUpdt, which is set from other goroutines
+	markupUpdt    TextRegion // lines whose markup has been updated by the Buf, see MarkupUpdated
+	hasMarkupUpdt bool       // markupUpdt is set
 }
 
 var KiT_TextView = kit.Types.AddType(&TextView{}, TextViewProps)
@@ -218,15 +242,68 @@ func (tv *TextView) ClearNeedsRefresh() {
 	atomic.StoreInt32(&tv.needsRefresh, 0)
 }
 
-// RefreshIfNeeded re-displays everything if SetNeedsRefresh was called --
-// returns true if refrehshed
+// RefreshIfNeeded re-displays everything if SetNeedsRefresh was called, or
+// else the lines recorded by MarkupUpdated -- returns true if refrehshed
 func (tv *TextView) RefreshIfNeeded() bool {
 	if tv.NeedsRefresh() {
 		tv.Refresh()
 		tv.ClearNeedsRefresh()
+		tv.markupMu.Lock()
+		tv.hasMarkupUpdt = false
+		tv.markupMu.Unlock()
 		return true
 	}
-	return false
+	return tv.RefreshMarkup()
+}
+
+// MarkupUpdated records that the markup of given lines has been updated by
+// the Buf (TextBufMarkUpdt), and has RefreshMarkup called for them within
+// the window event loop, unless that is already pending -- atomically safe
+// for other routines to call this
+func (tv *TextView) MarkupUpdated(reg TextRegion) {
+	tv.markupMu.Lock()
+	if tv.hasMarkupUpdt {
+		tv.markupUpdt.Start.Ln = ints.MinInt(tv.markupUpdt.Start.Ln, reg.Start.Ln)
+		tv.markupUpdt.End.Ln = ints.MaxInt(tv.markupUpdt.End.Ln, reg.End.Ln)
+		tv.markupMu.Unlock()
+		return
+	}
+	tv.markupUpdt = reg
+	tv.hasMarkupUpdt = true
+	tv.markupMu.Unlock()
+	if tv.Viewport == nil || tv.Viewport.Win == nil {
+		return
+	}
+	tv.Viewport.Win.PostInEventLoop(func() {
+		tv.RefreshMarkup()
+	})
+}
+
+// RefreshMarkup lays out and renders again the lines recorded by
+// MarkupUpdated, or everything if that is all the lines -- returns false if
+// there are none
+func (tv *TextView) RefreshMarkup() bool {
+	tv.markupMu.Lock()
+	reg, has := tv.markupUpdt, tv.hasMarkupUpdt
+	tv.hasMarkupUpdt = false
+	tv.markupMu.Unlock()
+	if !has || tv.Renders == nil {
+		return false
+	}
+	st, ed := reg.Start.Ln, ints.MinInt(reg.End.Ln, tv.NLines-1)
+	if st == 0 && ed == tv.NLines-1 {
+		tv.Refresh()
+		return true
+	}
+	if st > ed {
+		return false
+	}
+	if tv.LayoutLines(st, ed, false) {
+		tv.RenderAllLines()
+	} else {
+		tv.RenderLines(st, ed)
+	}
+	return true
 }
 
 func (tv *TextView) IsChanged() bool {
@@ -243,6 +320,10 @@ func (tv *TextView) IsChanged() bool {
 func (tv *TextView) ResetState() {
 	tv.SelectReset()
 	tv.Highlights = nil
+	tv.Cursors = nil
+	tv.Folds = nil
+	tv.foldsHidden = nil
+	tv.foldableStale()
 	tv.ISearchMode = false
 	if tv.Buf == nil || tv.lastFilename != tv.Buf.Filename { // don't reset if reopening..
 		tv.CursorPos = TextPos{}
@@ -281,6 +362,7 @@ func (tv *TextView) SetBuf(buf *TextBuf) {
 
 // LinesInserted inserts new lines of text and reformats them
 func (tv *TextView) LinesInserted(tbe *TextBufEdit) {
+	tv.foldsEdited(tbe)
 	stln := tbe.Reg.Start.Ln + 1
 	nsz := (tbe.Reg.End.Ln - tbe.Reg.Start.Ln)
 
@@ -306,6 +388,7 @@ func (tv *TextView) LinesInserted(tbe *TextBufEdit) {
 
 // LinesDeleted deletes lines of text and reformats remaining one
 func (tv *TextView) LinesDeleted(tbe *TextBufEdit) {
+	tv.foldsEdited(tbe)
 	stln := tbe.Reg.Start.Ln
 	edln := tbe.Reg.End.Ln
 	dsz := edln - stln
@@ -335,6 +418,7 @@ func TextViewBufSigRecv(rvwki, sbufki ki.Ki, sig int64, data interface{}) {
 			return
 		}
 		tbe := data.(*TextBufEdit)
+		tv.foldableStale()
 		// fmt.Printf("tv %v got %v\n", tv.Nm, tbe.Reg.Start)
 		if tbe.Reg.Start.Ln != tbe.Reg.End.Ln {
 			tv.LinesInserted(tbe)
@@ -346,11 +430,13 @@ func TextViewBufSigRecv(rvwki, sbufki ki.Ki, sig int64, data interface{}) {
 				tv.RenderLines(tbe.Reg.Start.Ln, tbe.Reg.End.Ln)
 			}
 		}
+		tv.RefreshMarkup()
 	case TextBufDelete:
 		if tv.Renders == nil { // not init yet
 			return
 		}
 		tbe := data.(*TextBufEdit)
+		tv.foldableStale()
 		if tbe.Reg.Start.Ln != tbe.Reg.End.Ln {
 			tv.LinesDeleted(tbe)
 		} else {
@@ -361,8 +447,9 @@ func TextViewBufSigRecv(rvwki, sbufki ki.Ki, sig int64, data interface{}) {
 				tv.RenderLines(tbe.Reg.Start.Ln, tbe.Reg.End.Ln)
 			}
 		}
+		tv.RefreshMarkup()
 	case TextBufMarkUpdt:
-		tv.SetNeedsRefresh() // comes from another goroutine
+		tv.MarkupUpdated(data.(TextRegion)) // can come from another goroutine
 	}
 }
 
@@ -381,7 +468,7 @@ func (tv *TextView) ParentLayout() *gi.Layout {
 
 // RenderSize is the size we should pass to text rendering, based on alloc
 func (tv *TextView) RenderSize() gi.Vec2D {
-	spc := tv.Sty.BoxSpace()
+	spc := tv.Sty.BoxSpaceSides()
 	if tv.Par == nil {
 		return gi.Vec2DZero
 	}
@@ -394,8 +481,8 @@ func (tv *TextView) RenderSize() gi.Vec2D {
 	paloc := parw.LayData.AllocSizeOrig
 	if !paloc.IsZero() {
 		// fmt.Printf("paloc: %v, pvp: %v  lineonoff: %v\n", paloc, parw.VpBBox, tv.LineNoOff)
-		tv.RenderSz = paloc.Sub(parw.ExtraSize).SubVal(spc * 2)
-		tv.RenderSz.X -= spc // extra space
+		tv.RenderSz = paloc.Sub(parw.ExtraSize).Sub(spc.Size())
+		tv.RenderSz.X -= spc.Pos().X // extra space
 		// fmt.Printf("alloc rendersz: %v\n", tv.RenderSz)
 	} else {
 		sz := tv.LayData.AllocSizeOrig
@@ -403,7 +490,7 @@ func (tv *TextView) RenderSize() gi.Vec2D {
 			sz = tv.LayData.SizePrefOrMax()
 		}
 		if !sz.IsZero() {
-			sz.SetSubVal(2 * spc)
+			sz.SetSub(spc.Size())
 		}
 		tv.RenderSz = sz
 		// fmt.Printf("fallback rendersz: %v\n", tv.RenderSz)
@@ -441,6 +528,7 @@ func (tv *TextView) LayoutAllLines(inLayout bool) bool {
 		tv.StyleTextView()
 	}
 	tv.lastFilename = tv.Buf.Filename
+	tv.foldableStale()
 
 	tv.Buf.Hi.TabSize = tv.Sty.Text.TabSize
 	tv.HiStyle()
@@ -473,6 +561,9 @@ func (tv *TextView) LayoutAllLines(inLayout bool) bool {
 		tv.Renders[ln].SetHTMLPre(tv.Buf.Markup[ln], &fst, &sty.Text, &sty.UnContext, tv.CSS)
 		tv.Renders[ln].LayoutStdLR(&sty.Text, &sty.Font, &sty.UnContext, sz)
 		tv.Offs[ln] = off
+		if tv.IsFoldedLine(ln) {
+			continue
+		}
 		lsz := gi.Max32(tv.Renders[ln].Size.Y, tv.LineHeight)
 		off += lsz
 		mxwd = gi.Max32(mxwd, tv.Renders[ln].Size.X)
@@ -492,11 +583,10 @@ func (tv *TextView) LayoutAllLines(inLayout bool) bool {
 // SetSize updates our size only if larger than our allocation
 func (tv *TextView) SetSize() bool {
 	sty := &tv.Sty
-	spc := sty.BoxSpace()
 	rndsz := tv.RenderSz
 	rndsz.X += tv.LineNoOff
 	netsz := gi.Vec2D{float32(tv.LinesSize.X) + tv.LineNoOff, float32(tv.LinesSize.Y)}
-	cursz := tv.LayData.AllocSize.SubVal(2 * spc)
+	cursz := tv.LayData.AllocSize.Sub(sty.BoxSpaceSides().Size())
 	if cursz.X < 10 || cursz.Y < 10 {
 		nwsz := netsz.Max(rndsz)
 		tv.Size2DFromWH(nwsz.X, nwsz.Y)
@@ -570,6 +660,9 @@ func (tv *TextView) LayoutLines(st, ed int, isDel bool) bool {
 		off := tv.Offs[ofst]
 		for ln := ofst; ln < tv.NLines; ln++ {
 			tv.Offs[ln] = off
+			if tv.IsFoldedLine(ln) {
+				continue
+			}
 			lsz := gi.Max32(tv.Renders[ln].Size.Y, tv.LineHeight)
 			off += lsz
 		}
@@ -626,6 +719,7 @@ func (tv *TextView) SetCursor(pos TextPos) {
 		return
 	}
 	tv.CursorPos = tv.Buf.ValidPos(pos)
+	tv.UnfoldLine(tv.CursorPos.Ln)
 	tv.CursorMovedSig()
 }
 
@@ -741,9 +835,9 @@ func (tv *TextView) CursorForward(steps int) {
 	for i := 0; i < steps; i++ {
 		tv.CursorPos.Ch++
 		if tv.CursorPos.Ch > len(tv.Buf.Lines[tv.CursorPos.Ln]) {
-			if tv.CursorPos.Ln < tv.NLines-1 {
+			if nln := tv.UnfoldedLine(tv.CursorPos.Ln+1, 1); nln < tv.NLines {
 				tv.CursorPos.Ch = 0
-				tv.CursorPos.Ln++
+				tv.CursorPos.Ln = nln
 			} else {
 				tv.CursorPos.Ch = len(tv.Buf.Lines[tv.CursorPos.Ln])
 			}
@@ -772,11 +866,11 @@ func (tv *TextView) CursorDown(steps int) {
 			}
 		}
 		if !gotwrap {
-			pos.Ln++
-			if pos.Ln >= tv.NLines {
-				pos.Ln = tv.NLines - 1
+			nln := tv.UnfoldedLine(pos.Ln+1, 1)
+			if nln >= tv.NLines {
 				break
 			}
+			pos.Ln = nln
 			mxlen := ints.MinInt(len(tv.Buf.Lines[pos.Ln]), tv.CursorCol)
 			if tv.CursorCol < mxlen {
 				pos.Ch = tv.CursorCol
@@ -820,7 +914,7 @@ func (tv *TextView) CursorBackward(steps int) {
 		tv.CursorPos.Ch--
 		if tv.CursorPos.Ch < 0 {
 			if tv.CursorPos.Ln > 0 {
-				tv.CursorPos.Ln--
+				tv.CursorPos.Ln = tv.UnfoldedLine(tv.CursorPos.Ln-1, -1)
 				tv.CursorPos.Ch = len(tv.Buf.Lines[tv.CursorPos.Ln])
 			} else {
 				tv.CursorPos.Ch = 0
@@ -852,7 +946,7 @@ func (tv *TextView) CursorUp(steps int) {
 			}
 		}
 		if !gotwrap {
-			pos.Ln--
+			pos.Ln = tv.UnfoldedLine(pos.Ln-1, -1)
 			if pos.Ln < 0 {
 				pos.Ln = 0
 				break
@@ -1001,7 +1095,7 @@ func (tv *TextView) CursorEndDoc() {
 	defer tv.Viewport.Win.UpdateEnd(updt)
 	tv.ValidateCursor()
 	org := tv.CursorPos
-	tv.CursorPos.Ln = ints.MaxInt(tv.NLines-1, 0)
+	tv.CursorPos.Ln = ints.MaxInt(tv.UnfoldedLine(tv.NLines-1, -1), 0)
 	tv.CursorPos.Ch = len(tv.Buf.Lines[tv.CursorPos.Ln])
 	tv.CursorCol = tv.CursorPos.Ch
 	tv.SetCursor(tv.CursorPos)
@@ -1016,9 +1110,14 @@ func (tv *TextView) CursorEndDoc() {
 
 // CursorBackspace deletes character(s) immediately before cursor
 func (tv *TextView) CursorBackspace(steps int) {
+	if tv.HasCursors() {
+		tv.editCursors(func(ci int) { tv.CursorBackspace(steps) })
+		return
+	}
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
 	tv.ValidateCursor()
+	tv.SaveUndoCursor()
 	org := tv.CursorPos
 	if tv.HasSelection() {
 		tv.DeleteSelection()
@@ -1034,9 +1133,14 @@ func (tv *TextView) CursorBackspace(steps int) {
 
 // CursorDelete deletes character(s) immediately after the cursor
 func (tv *TextView) CursorDelete(steps int) {
+	if tv.HasCursors() {
+		tv.editCursors(func(ci int) { tv.CursorDelete(steps) })
+		return
+	}
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
 	tv.ValidateCursor()
+	tv.SaveUndoCursor()
 	if tv.HasSelection() {
 		tv.DeleteSelection()
 		return
@@ -1050,9 +1154,14 @@ func (tv *TextView) CursorDelete(steps int) {
 
 // CursorKill deletes text from cursor to end of text
 func (tv *TextView) CursorKill() {
+	if tv.HasCursors() {
+		tv.editCursors(func(ci int) { tv.CursorKill() })
+		return
+	}
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
 	tv.ValidateCursor()
+	tv.SaveUndoCursor()
 	org := tv.CursorPos
 	if tv.CursorPos.Ch == 0 && len(tv.Buf.Lines[tv.CursorPos.Ln]) == 0 {
 		tv.CursorForward(1)
@@ -1202,15 +1311,25 @@ func (tv *TextView) CursorPrevLink(wraparound bool) bool {
 ///////////////////////////////////////////////////////////////////////////////
 //    Undo / Redo
 
-// Undo undoes previous action
+// Undo undoes previous action, which can be a group of edits, restoring the
+// cursor position and selection from before it if they were saved
 func (tv *TextView) Undo() {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
+	tv.ClearCursors()
+	tv.SelectReset()
 	tbe := tv.Buf.Undo()
 	if tbe != nil {
-		if tbe.Delete { // now an insert
+		switch {
+		case tbe.Cursor.Ln >= 0:
+			tv.SetCursorShow(tv.Buf.ValidPos(tbe.Cursor))
+			if tbe.Sel.Start.IsLess(tbe.Sel.End) {
+				tv.SelectReg = TextRegion{Start: tv.Buf.ValidPos(tbe.Sel.Start), End: tv.Buf.ValidPos(tbe.Sel.End)}
+				tv.RenderSelectLines()
+			}
+		case tbe.Delete: // now an insert
 			tv.SetCursorShow(tbe.Reg.End)
-		} else {
+		default:
 			tv.SetCursorShow(tbe.Reg.Start)
 		}
 	} else {
@@ -1223,6 +1342,8 @@ func (tv *TextView) Undo() {
 func (tv *TextView) Redo() {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
+	tv.ClearCursors()
+	tv.SelectReset()
 	tbe := tv.Buf.Redo()
 	if tbe != nil {
 		if tbe.Delete {
@@ -1236,6 +1357,12 @@ func (tv *TextView) Redo() {
 	tv.SavePosHistory(tv.CursorPos)
 }
 
+// SaveUndoCursor saves the current cursor position and selection in the
+// buffer, to be restored by Undo of the next edit
+func (tv *TextView) SaveUndoCursor() {
+	tv.Buf.SetUndoCursor(tv.CursorPos, tv.SelectReg)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 //    Search / Find
 
@@ -1252,6 +1379,12 @@ func (tv *TextView) FindMatches(find string, useCase bool) bool {
 		return false
 	}
 	_, tv.SearchMatches = tv.Buf.Search([]byte(find), !useCase)
+	return tv.HighlightMatches()
+}
+
+// HighlightMatches updates highlights for all the current SearchMatches (up
+// to TextViewMaxFindHighlights) -- returns false if there are none
+func (tv *TextView) HighlightMatches() bool {
 	matches := tv.SearchMatches
 	if len(matches) == 0 {
 		tv.Highlights = nil
@@ -1270,6 +1403,178 @@ func (tv *TextView) FindMatches(find string, useCase bool) bool {
 	return true
 }
 
+// FindRegexp returns the regular expression for the current FindString and
+// FindOpts -- nil and an error if it is empty or not a valid regexp
+func (tv *TextView) FindRegexp() (*regexp.Regexp, error) {
+	return tv.FindOpts.Compile(tv.FindString)
+}
+
+// FindRegexpMatches finds the matches of the current FindString with the
+// current FindOpts, within the FindScope, and updates highlights for all --
+// returns the number of matches and any error in the find regexp
+func (tv *TextView) FindRegexpMatches() (int, error) {
+	re, err := tv.FindRegexp()
+	if err != nil {
+		tv.SearchMatches = nil
+		tv.HighlightMatches()
+		return 0, err
+	}
+	_, tv.SearchMatches = tv.Buf.SearchRegexp(re, tv.FindScope)
+	tv.HighlightMatches()
+	return len(tv.SearchMatches), nil
+}
+
+// SetFindScope restricts find / replace to the current selection if
+// FindOpts.InSel is set and there is a selection, and otherwise to the whole
+// buffer
+func (tv *TextView) SetFindScope() {
+	if tv.FindOpts.InSel && tv.HasSelection() {
+		tv.FindScope = tv.SelectReg
+	} else {
+		tv.FindScope = TextRegionZero
+	}
+}
+
+// FindNext finds the matches of the current FindString (see
+// FindRegexpMatches), and selects the next one after the cursor or current
+// match, or the previous one if back is true, wrapping around at the ends --
+// returns false if there are no matches
+func (tv *TextView) FindNext(back bool) bool {
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	n, _ := tv.FindRegexpMatches()
+	if n == 0 {
+		return false
+	}
+	pos := tv.CursorPos
+	if tv.HasSelection() {
+		pos = tv.SelectReg.Start
+		if !back {
+			pos = tv.SelectReg.End
+		}
+	}
+	tv.SearchPos = 0
+	if back {
+		tv.SearchPos = n - 1
+		for i := n - 1; i >= 0; i-- {
+			if tv.SearchMatches[i].Reg.Start.IsLess(pos) {
+				tv.SearchPos = i
+				break
+			}
+		}
+	} else {
+		for i, m := range tv.SearchMatches {
+			if !m.Reg.Start.IsLess(pos) {
+				tv.SearchPos = i
+				break
+			}
+		}
+	}
+	tv.FindSelectMatch(tv.SearchPos)
+	return true
+}
+
+// FindSelectMatch selects match at given match index, for find / replace
+func (tv *TextView) FindSelectMatch(midx int) {
+	m := tv.SearchMatches[midx]
+	tv.SelectReg = m.Reg
+	tv.SetCursor(m.Reg.Start)
+	tv.SavePosHistory(tv.CursorPos)
+	tv.ScrollCursorToCenterIfHidden()
+	tv.RenderSelectLines()
+}
+
+// IsFindMatch returns true if given region is one of the current SearchMatches
+func (tv *TextView) IsFindMatch(reg TextRegion) bool {
+	for _, m := range tv.SearchMatches {
+		if m.Reg == reg {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceNext replaces the current match of the FindString, if it is
+// selected, with the ReplString, and then selects the next match (see
+// FindNext) -- the replacement is a single undo step -- returns false if
+// there are no more matches
+func (tv *TextView) ReplaceNext() bool {
+	re, err := tv.FindRegexp()
+	if err != nil {
+		return false
+	}
+	if tv.HasSelection() && tv.IsFindMatch(tv.SelectReg) {
+		updt := tv.Viewport.Win.UpdateStart()
+		tv.SaveUndoCursor()
+		repl := tv.FindOpts.ReplTemplate(tv.ReplString)
+		st := tv.SelectReg.Start
+		tv.SelectReset()
+		var nreg TextRegion
+		tv.editFindScope(func() {
+			nreg, _ = tv.Buf.ReplaceNext(re, repl, st, tv.FindScope)
+		})
+		tv.SetCursorShow(nreg.End)
+		tv.Viewport.Win.UpdateEnd(updt)
+	}
+	return tv.FindNext(false)
+}
+
+// ReplaceAll replaces all the matches of the FindString within the
+// FindScope with the ReplString, as a single undo step -- returns the number
+// of matches replaced
+func (tv *TextView) ReplaceAll() int {
+	re, err := tv.FindRegexp()
+	if err != nil {
+		return 0
+	}
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	tv.SaveUndoCursor()
+	tv.SelectReset()
+	n := 0
+	tv.editFindScope(func() {
+		n = tv.Buf.ReplaceAll(re, tv.FindOpts.ReplTemplate(tv.ReplString), tv.FindScope)
+	})
+	tv.FindRegexpMatches()
+	tv.SavePosHistory(tv.CursorPos)
+	return n
+}
+
+// editFindScope calls given function that edits the buffer within the
+// FindScope, and updates the end of the scope to the same position relative
+// to the end of the buffer, which is not affected by the edits
+func (tv *TextView) editFindScope(fun func()) {
+	if tv.FindScope == TextRegionZero {
+		fun()
+		return
+	}
+	ed := tv.Buf.ValidPos(tv.FindScope.End)
+	dln := tv.Buf.NLines - ed.Ln
+	dch := len(tv.Buf.Lines[ed.Ln]) - ed.Ch
+	fun()
+	tv.FindScope.End.Ln = ints.MaxInt(tv.Buf.NLines-dln, 0)
+	tv.FindScope.End.Ch = ints.MaxInt(len(tv.Buf.Lines[tv.FindScope.End.Ln])-dch, 0)
+}
+
+// FindReplace opens a TextFindDialog for finding and replacing text in this
+// view, starting with the selected text, or restricted to the selection if
+// it spans multiple lines -- this is called for KeyFunFind
+func (tv *TextView) FindReplace() {
+	if tv.HasSelection() {
+		if tv.SelectReg.Start.Ln == tv.SelectReg.End.Ln {
+			tv.FindString = string(tv.Selection().ToBytes())
+			if tv.FindOpts.Regexp {
+				tv.FindString = regexp.QuoteMeta(tv.FindString)
+			}
+			tv.FindOpts.InSel = false
+		} else {
+			tv.FindOpts.InSel = true
+		}
+	}
+	tv.SetFindScope()
+	TextFindDialog(tv.Viewport, tv, DlgOpts{Title: "Find / Replace"}, nil, nil)
+}
+
 // Matches finds ISearch matches -- returns true if there are any
 func (tv *TextView) ISearchMatches() bool {
 	return tv.FindMatches(tv.ISearchString, tv.ISearchCase)
@@ -1431,6 +1736,8 @@ func (tv *TextView) EscPressed() {
 	case tv.ISearchMode:
 		tv.ISearchCancel()
 		tv.SetCursorShow(tv.ISearchStartPos)
+	case tv.HasCursors():
+		tv.ClearCursors()
 	case tv.HasSelection():
 		tv.SelectReset()
 	}
@@ -1563,10 +1870,25 @@ func (tv *TextView) RenderSelectLines() {
 ///////////////////////////////////////////////////////////////////////////////
 //    Cut / Copy / Paste
 
-// Cut cuts any selected text and adds it to the clipboard, also returns cut text
+// Cut cuts any selected text and adds it to the clipboard, also returns cut
+// text -- with multiple cursors, the text of all their selections is cut,
+// one per line, and nil is returned
 func (tv *TextView) Cut() *TextBufEdit {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
+	if tv.HasCursors() {
+		if txt := tv.CursorsText(); txt != nil {
+			oswin.TheApp.ClipBoard(tv.Viewport.Win.OSWin).Write(mimedata.NewTextBytes(txt))
+			tv.editCursors(func(ci int) {
+				if tv.HasSelection() {
+					org := tv.SelectReg.Start
+					tv.DeleteSelection()
+					tv.SetCursor(org)
+				}
+			})
+		}
+		return nil
+	}
 	org := tv.SelectReg.Start
 	cut := tv.DeleteSelection()
 	if cut != nil {
@@ -1580,16 +1902,31 @@ func (tv *TextView) Cut() *TextBufEdit {
 // DeleteSelection deletes any selected text, without adding to clipboard --
 // returns text deleted as TextBufEdit (nil if none)
 func (tv *TextView) DeleteSelection() *TextBufEdit {
+	tv.SaveUndoCursor()
 	tbe := tv.Buf.DeleteText(tv.SelectReg.Start, tv.SelectReg.End, true, true)
 	tv.SelectReset()
 	return tbe
 }
 
 // Copy copies any selected text to the clipboard, and returns that text,
-// optionaly resetting the current selection
+// optionaly resetting the current selection -- with multiple cursors, the
+// text of all their selections is copied, one per line, and nil is returned
 func (tv *TextView) Copy(reset bool) *TextBufEdit {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
+	if tv.HasCursors() {
+		if txt := tv.CursorsText(); txt != nil {
+			oswin.TheApp.ClipBoard(tv.Viewport.Win.OSWin).Write(mimedata.NewTextBytes(txt))
+			if reset {
+				curs := append([]TextCursor(nil), tv.Cursors...)
+				for i := range curs {
+					curs[i].Sel = TextRegionZero
+				}
+				tv.SetCursors(TextCursor{Pos: tv.CursorPos}, curs)
+			}
+		}
+		return nil
+	}
 	tbe := tv.Selection()
 	if tbe == nil {
 		return nil
@@ -1603,25 +1940,60 @@ func (tv *TextView) Copy(reset bool) *TextBufEdit {
 }
 
 // Paste inserts text from the clipboard at current cursor position -- if
-// cursor is within a current selection, that selection is
+// cursor is within a current selection, that selection is replaced -- with
+// multiple cursors, the text is inserted at each of them, replacing their
+// selections, one line per cursor if it has as many lines as there are
+// cursors
 func (tv *TextView) Paste() {
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
 	data := oswin.TheApp.ClipBoard(tv.Viewport.Win.OSWin).Read([]string{mimedata.TextPlain})
+	if data != nil && tv.HasCursors() {
+		txt := data.TypeData(mimedata.TextPlain)
+		lns := bytes.Split(txt, []byte("\n"))
+		if len(lns) != len(tv.Cursors)+1 {
+			lns = nil
+		}
+		tv.editCursors(func(ci int) {
+			if tv.HasSelection() { // not cut to the clipboard, which we are pasting
+				org := tv.SelectReg.Start
+				tv.DeleteSelection()
+				tv.SetCursor(org)
+			}
+			if lns != nil {
+				tv.InsertAtCursor(lns[ci])
+			} else {
+				tv.InsertAtCursor(txt)
+			}
+		})
+		return
+	}
 	if data != nil {
+		tv.SaveUndoCursor()
+		tv.Buf.BeginUndoGroup()
 		if tv.SelectReg.Start.IsLess(tv.CursorPos) && tv.CursorPos.IsLess(tv.SelectReg.End) {
 			tv.DeleteSelection()
 		}
 		tv.InsertAtCursor(data.TypeData(mimedata.TextPlain))
+		tv.Buf.EndUndoGroup()
 		tv.SavePosHistory(tv.CursorPos)
 	}
 }
 
-// InsertAtCursor inserts given text at current cursor position
+// InsertAtCursor inserts given text at current cursor position, replacing
+// any selected text, which is cut, in the same undo step -- and likewise at
+// each of any additional Cursors
 func (tv *TextView) InsertAtCursor(txt []byte) {
+	if tv.HasCursors() {
+		tv.editCursors(func(ci int) { tv.InsertAtCursor(txt) })
+		return
+	}
 	updt := tv.Viewport.Win.UpdateStart()
 	defer tv.Viewport.Win.UpdateEnd(updt)
+	tv.SaveUndoCursor()
 	if tv.HasSelection() {
+		tv.Buf.BeginUndoGroup()
+		defer tv.Buf.EndUndoGroup()
 		tv.Cut()
 	}
 	tbe := tv.Buf.InsertText(tv.CursorPos, txt, true, true)
@@ -1633,6 +2005,54 @@ func (tv *TextView) InsertAtCursor(txt []byte) {
 	tv.SetCursorCol(tv.CursorPos)
 }
 
+// insertAtCursorIndent inserts given text at the cursor as InsertAtCursor
+// does, and then auto-indents the line that the cursor is on, as one undo
+// step -- at each of the cursors if there are multiple
+func (tv *TextView) insertAtCursorIndent(txt []byte) {
+	if tv.HasCursors() {
+		tv.editCursors(func(ci int) { tv.insertAtCursorIndent(txt) })
+		return
+	}
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	tv.Buf.BeginUndoGroup()
+	defer tv.Buf.EndUndoGroup()
+	tv.InsertAtCursor(txt)
+	tbe, _, cpos := tv.Buf.AutoIndent(tv.CursorPos.Ln, tv.Opts.SpaceIndent, tv.Sty.Text.TabSize, DefaultIndentStrings, DefaultUnindentStrings)
+	if tbe != nil {
+		tv.SetCursorShow(TextPos{Ln: tbe.Reg.End.Ln, Ch: cpos})
+	}
+}
+
+// tabAtCursor auto-indents the line that the cursor is on if autoIndent is
+// true, the cursor is at the start of the line and AutoIndent is on, and
+// otherwise inserts a tab (or spaces) at the cursor -- at each of the
+// cursors if there are multiple -- returns true if any line was auto-indented
+func (tv *TextView) tabAtCursor(autoIndent bool) bool {
+	if tv.HasCursors() {
+		gotAI := false
+		tv.editCursors(func(ci int) {
+			if tv.tabAtCursor(autoIndent) {
+				gotAI = true
+			}
+		})
+		return gotAI
+	}
+	if autoIndent && tv.CursorPos.Ch == 0 && tv.Opts.AutoIndent {
+		tv.SaveUndoCursor()
+		_, _, cpos := tv.Buf.AutoIndent(tv.CursorPos.Ln, tv.Opts.SpaceIndent, tv.Sty.Text.TabSize, DefaultIndentStrings, DefaultUnindentStrings)
+		tv.CursorPos.Ch = cpos
+		tv.RenderCursor(true)
+		return true
+	}
+	if tv.Opts.SpaceIndent {
+		tv.InsertAtCursor(IndentBytes(1, tv.Sty.Text.TabSize, true))
+	} else {
+		tv.InsertAtCursor([]byte("\t"))
+	}
+	return false
+}
+
 func (tv *TextView) MakeContextMenu(m *gi.Menu) {
 	cpsc := gi.ActiveKeyMap.ChordForFun(gi.KeyFunCopy)
 	ac := m.AddAction(gi.ActOpts{Label: "Copy", Shortcut: cpsc},
@@ -1657,6 +2077,737 @@ func (tv *TextView) MakeContextMenu(m *gi.Menu) {
 			})
 		ac.SetInactiveState(oswin.TheApp.ClipBoard(tv.Viewport.Win.OSWin).IsEmpty())
 	}
+	m.AddSeparator("sep-fold")
+	_, canFold := tv.FoldableAround(tv.CursorPos.Ln)
+	ac = m.AddAction(gi.ActOpts{Label: "Toggle Fold"},
+		tv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			txf.ToggleFoldAround(txf.CursorPos.Ln)
+		})
+	ac.SetActiveState(canFold || tv.FoldedAt(tv.CursorPos.Ln) >= 0)
+	m.AddAction(gi.ActOpts{Label: "Fold All"},
+		tv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			txf.FoldAll()
+		})
+	ac = m.AddAction(gi.ActOpts{Label: "Unfold All"},
+		tv.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			txf.UnfoldAll()
+		})
+	ac.SetActiveState(len(tv.Folds) > 0)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//    Multiple Cursors
+
+// TextCursor is an additional cursor in a TextView, with its own selection
+type TextCursor struct {
+	Pos TextPos    `desc:"position of the cursor"`
+	Sel TextRegion `desc:"selected region of the cursor -- TextRegionZero if none"`
+}
+
+// HasSel returns whether the cursor has a selected region of text
+func (tc *TextCursor) HasSel() bool {
+	return tc.Sel.Start.IsLess(tc.Sel.End)
+}
+
+// HasCursors returns whether there are additional Cursors beyond CursorPos
+func (tv *TextView) HasCursors() bool {
+	return len(tv.Cursors) > 0
+}
+
+// MainCursor returns the main cursor, at CursorPos, with the current selection
+func (tv *TextView) MainCursor() TextCursor {
+	tc := TextCursor{Pos: tv.CursorPos}
+	if tv.HasSelection() {
+		tc.Sel = tv.SelectReg
+	}
+	return tc
+}
+
+// AllCursors returns the main cursor followed by the additional Cursors
+func (tv *TextView) AllCursors() []TextCursor {
+	return append([]TextCursor{tv.MainCursor()}, tv.Cursors...)
+}
+
+// SetCursors sets the main cursor, with its selection, and the additional
+// Cursors, dropping any at the same position as another, and renders the
+// changes
+func (tv *TextView) SetCursors(main TextCursor, curs []TextCursor) {
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	prev := tv.AllCursors()
+	tv.SelectMode = false
+	tv.SelectReg = TextRegionZero
+	if main.HasSel() {
+		tv.SelectReg = TextRegion{Start: tv.Buf.ValidPos(main.Sel.Start), End: tv.Buf.ValidPos(main.Sel.End)}
+	}
+	tv.SetCursorShow(main.Pos)
+	tv.setCursors(curs, prev)
+}
+
+// setCursors sets the additional Cursors, dropping any at the same position
+// as another or CursorPos, and renders the lines of them and of given
+// previous cursors
+func (tv *TextView) setCursors(curs, prev []TextCursor) {
+	seen := map[TextPos]bool{tv.CursorPos: true}
+	ncs := make([]TextCursor, 0, len(curs))
+	for _, c := range curs {
+		c.Pos = tv.Buf.ValidPos(c.Pos)
+		if seen[c.Pos] {
+			continue
+		}
+		seen[c.Pos] = true
+		if c.HasSel() {
+			c.Sel = TextRegion{Start: tv.Buf.ValidPos(c.Sel.Start), End: tv.Buf.ValidPos(c.Sel.End)}
+		} else {
+			c.Sel = TextRegionZero
+		}
+		ncs = append(ncs, c)
+	}
+	sort.Slice(ncs, func(i, j int) bool {
+		return ncs[i].Pos.IsLess(ncs[j].Pos)
+	})
+	if len(ncs) == 0 {
+		ncs = nil
+	}
+	tv.Cursors = ncs
+	stln, edln := -1, -1
+	for _, cs := range [][]TextCursor{prev, tv.AllCursors()} {
+		for _, c := range cs {
+			for _, ln := range []int{c.Pos.Ln, c.Sel.Start.Ln, c.Sel.End.Ln} {
+				if stln < 0 || ln < stln {
+					stln = ln
+				}
+				edln = ints.MaxInt(edln, ln)
+			}
+		}
+	}
+	if stln >= 0 {
+		tv.RenderLines(stln, edln)
+	}
+}
+
+// ClearCursors removes all the additional Cursors
+func (tv *TextView) ClearCursors() {
+	if !tv.HasCursors() {
+		return
+	}
+	tv.SetCursors(tv.MainCursor(), nil)
+}
+
+// AddCursor adds a cursor at given position, which becomes the main cursor,
+// with the previous one added to Cursors -- if there is already a cursor
+// there, it is removed instead (e.g., Ctrl+click)
+func (tv *TextView) AddCursor(pos TextPos) {
+	pos = tv.Buf.ValidPos(pos)
+	curs := tv.AllCursors()
+	for i, c := range curs {
+		if c.Pos != pos {
+			continue
+		}
+		if len(curs) == 1 {
+			return
+		}
+		curs = append(curs[:i], curs[i+1:]...)
+		tv.SetCursors(curs[len(curs)-1], curs[:len(curs)-1])
+		return
+	}
+	tv.SetCursors(TextCursor{Pos: pos}, curs)
+	tv.SetCursorCol(tv.CursorPos)
+}
+
+// AddCursorUp adds a cursor on the line above the main cursor, at the
+// CursorCol, which becomes the main cursor
+func (tv *TextView) AddCursorUp() {
+	tv.addCursorLine(-1)
+}
+
+// AddCursorDown adds a cursor on the line below the main cursor, at the
+// CursorCol, which becomes the main cursor
+func (tv *TextView) AddCursorDown() {
+	tv.addCursorLine(1)
+}
+
+// addCursorLine adds a cursor on the line at given offset from the main
+// cursor, which becomes the main cursor
+func (tv *TextView) addCursorLine(dln int) {
+	ln := tv.UnfoldedLine(tv.CursorPos.Ln+dln, dln)
+	if ln < 0 || ln >= tv.Buf.NLines {
+		return
+	}
+	col := tv.CursorCol
+	pos := TextPos{Ln: ln, Ch: ints.MinInt(col, len(tv.Buf.Lines[ln]))}
+	tv.SetCursors(TextCursor{Pos: pos}, tv.AllCursors())
+	tv.CursorCol = col
+}
+
+// AddCursorNext selects the word at the cursor if nothing is selected, and
+// otherwise adds a cursor selecting the next occurrence of the selected text
+// after the main cursor, wrapping around, which becomes the main cursor --
+// only selections within a single line are found
+func (tv *TextView) AddCursorNext() {
+	if !tv.HasSelection() {
+		if reg := tv.WordRegion(tv.CursorPos); reg != TextRegionZero {
+			tv.SetCursors(TextCursor{Pos: reg.End, Sel: reg}, tv.Cursors)
+		}
+		return
+	}
+	if tv.SelectReg.Start.Ln != tv.SelectReg.End.Ln {
+		return
+	}
+	re := regexp.MustCompile(regexp.QuoteMeta(string(tv.Selection().ToBytes())))
+	_, matches := tv.Buf.SearchRegexp(re, TextRegionZero)
+	curs := tv.AllCursors()
+	used := func(reg TextRegion) bool {
+		for _, c := range curs {
+			if c.Sel == reg {
+				return true
+			}
+		}
+		return false
+	}
+	midx := -1
+	for i, m := range matches {
+		if used(m.Reg) {
+			continue
+		}
+		if midx < 0 {
+			midx = i // first one, for wrapping around
+		}
+		if !m.Reg.Start.IsLess(tv.SelectReg.End) {
+			midx = i
+			break
+		}
+	}
+	if midx < 0 {
+		return
+	}
+	reg := matches[midx].Reg
+	tv.SetCursors(TextCursor{Pos: reg.End, Sel: reg}, curs)
+}
+
+// WordRegion returns the region of the word at given position, as defined
+// by IsWordBreak -- TextRegionZero if there is no word there
+func (tv *TextView) WordRegion(pos TextPos) TextRegion {
+	pos = tv.Buf.ValidPos(pos)
+	lr := tv.Buf.Lines[pos.Ln]
+	st, ed := pos.Ch, pos.Ch
+	for st > 0 && !tv.IsWordBreak(lr[st-1]) {
+		st--
+	}
+	for ed < len(lr) && !tv.IsWordBreak(lr[ed]) {
+		ed++
+	}
+	if st == ed {
+		return TextRegionZero
+	}
+	return TextRegion{Start: TextPos{Ln: pos.Ln, Ch: st}, End: TextPos{Ln: pos.Ln, Ch: ed}}
+}
+
+// SelectRect makes a rectangular (column) selection between given corner
+// positions, with a cursor on each line, at the column of the ed corner --
+// the cursor on the line of ed is the main one -- columns are in characters
+// (runes), and lines that are too short get shorter or empty selections
+func (tv *TextView) SelectRect(st, ed TextPos) {
+	st = tv.Buf.ValidPos(st)
+	ed = tv.Buf.ValidPos(ed)
+	c0, c1 := ints.MinInt(st.Ch, ed.Ch), ints.MaxInt(st.Ch, ed.Ch)
+	var main TextCursor
+	var curs []TextCursor
+	for ln := ints.MinInt(st.Ln, ed.Ln); ln <= ints.MaxInt(st.Ln, ed.Ln); ln++ {
+		if ln != ed.Ln && tv.IsFoldedLine(ln) {
+			continue
+		}
+		sz := len(tv.Buf.Lines[ln])
+		tc := TextCursor{Pos: TextPos{Ln: ln, Ch: ints.MinInt(ed.Ch, sz)}}
+		if sc, ec := ints.MinInt(c0, sz), ints.MinInt(c1, sz); sc < ec {
+			tc.Sel = TextRegion{Start: TextPos{Ln: ln, Ch: sc}, End: TextPos{Ln: ln, Ch: ec}}
+		}
+		if ln == ed.Ln {
+			main = tc
+		} else {
+			curs = append(curs, tc)
+		}
+	}
+	tv.SetCursors(main, curs)
+}
+
+// moveCursors moves the additional Cursors for given cursor motion key
+// function, extending their selections if sel (shift) -- they move by
+// characters and lines of the buffer, regardless of line wrapping
+func (tv *TextView) moveCursors(kf gi.KeyFuns, sel bool) {
+	prev := tv.AllCursors()
+	curs := make([]TextCursor, len(tv.Cursors))
+	for i, c := range tv.Cursors {
+		anc := c.Pos
+		if c.HasSel() {
+			if c.Sel.End == c.Pos {
+				anc = c.Sel.Start
+			} else {
+				anc = c.Sel.End
+			}
+		}
+		pos := tv.Buf.ValidPos(c.Pos)
+		switch kf {
+		case gi.KeyFunMoveRight:
+			if pos.Ch < len(tv.Buf.Lines[pos.Ln]) {
+				pos.Ch++
+			} else if nln := tv.UnfoldedLine(pos.Ln+1, 1); nln < tv.Buf.NLines {
+				pos = TextPos{Ln: nln}
+			}
+		case gi.KeyFunMoveLeft:
+			if pos.Ch > 0 {
+				pos.Ch--
+			} else if pos.Ln > 0 {
+				pln := tv.UnfoldedLine(pos.Ln-1, -1)
+				pos = TextPos{Ln: pln, Ch: len(tv.Buf.Lines[pln])}
+			}
+		case gi.KeyFunMoveUp:
+			if pos.Ln > 0 {
+				pos.Ln = tv.UnfoldedLine(pos.Ln-1, -1)
+			}
+		case gi.KeyFunMoveDown:
+			if nln := tv.UnfoldedLine(pos.Ln+1, 1); nln < tv.Buf.NLines {
+				pos.Ln = nln
+			}
+		case gi.KeyFunHome:
+			pos.Ch = 0
+		case gi.KeyFunEnd:
+			pos.Ch = len(tv.Buf.Lines[pos.Ln])
+		}
+		pos = tv.Buf.ValidPos(pos)
+		curs[i] = TextCursor{Pos: pos}
+		if sel && anc != pos {
+			if pos.IsLess(anc) {
+				curs[i].Sel = TextRegion{Start: pos, End: anc}
+			} else {
+				curs[i].Sel = TextRegion{Start: anc, End: pos}
+			}
+		}
+	}
+	tv.setCursors(curs, prev)
+}
+
+// editCursors calls given edit function at the main cursor and each of the
+// additional Cursors in turn, with CursorPos and the selection set to each
+// one, as one undo step -- the function gets the index of the cursor in
+// order within the buffer -- the cursors are edited from the end of the
+// buffer back, so that each edit only moves the cursors already edited,
+// which are adjusted for it
+func (tv *TextView) editCursors(fun func(ci int)) {
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	mpos := tv.CursorPos
+	curs := tv.AllCursors()
+	sort.Slice(curs, func(i, j int) bool {
+		return curs[i].Pos.IsLess(curs[j].Pos)
+	})
+	tv.Cursors = nil
+	tv.SelectMode = false
+	tv.Buf.BeginUndoGroup()
+	tv.SaveUndoCursor()
+	mi := 0
+	for ci := len(curs) - 1; ci >= 0; ci-- {
+		c := curs[ci]
+		if c.Pos == mpos {
+			mi = ci
+		}
+		tv.CursorPos = tv.Buf.ValidPos(c.Pos)
+		tv.SelectReg = c.Sel
+		n0 := tv.Buf.UndoPos
+		fun(ci)
+		curs[ci] = tv.MainCursor()
+		for _, tbe := range tv.Buf.Undos[n0:tv.Buf.UndoPos] {
+			for j := ci + 1; j < len(curs); j++ {
+				cj := &curs[j]
+				cj.Pos = tbe.AdjustPos(cj.Pos)
+				if cj.HasSel() {
+					cj.Sel.Start = tbe.AdjustPos(cj.Sel.Start)
+					cj.Sel.End = tbe.AdjustPos(cj.Sel.End)
+				}
+			}
+		}
+	}
+	tv.Buf.EndUndoGroup()
+	main := curs[mi]
+	tv.SetCursors(main, append(curs[:mi:mi], curs[mi+1:]...))
+}
+
+// CursorsText returns the text selected by all the cursors, in order within
+// the buffer, one per line -- nil if nothing is selected
+func (tv *TextView) CursorsText() []byte {
+	curs := tv.AllCursors()
+	sort.Slice(curs, func(i, j int) bool {
+		return curs[i].Pos.IsLess(curs[j].Pos)
+	})
+	sel := false
+	txt := make([][]byte, len(curs))
+	for i, c := range curs {
+		if !c.HasSel() {
+			continue
+		}
+		sel = true
+		if tbe := tv.Buf.Region(c.Sel.Start, c.Sel.End); tbe != nil {
+			txt[i] = tbe.ToBytes()
+		}
+	}
+	if !sel {
+		return nil
+	}
+	return bytes.Join(txt, []byte("\n"))
+}
+
+// RenderCursors renders the selections and cursors of the additional
+// Cursors, which do not blink -- always called within context of outer
+// RenderLines or RenderAllLines
+func (tv *TextView) RenderCursors() {
+	if !tv.HasCursors() {
+		return
+	}
+	rs := &tv.Viewport.Render
+	pc := &rs.Paint
+	sty := &tv.StateStyles[TextViewActive]
+	for _, c := range tv.Cursors {
+		if c.HasSel() {
+			tv.RenderRegionBox(c.Sel, TextViewSel)
+		}
+	}
+	sz := gi.Vec2D{X: math32.Max(tv.CursorWidth.Dots, 2), Y: tv.FontHeight}
+	for _, c := range tv.Cursors {
+		pc.FillBoxColor(rs, tv.CharStartPos(c.Pos), sz, sty.Font.Color)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//    Folding
+
+// TextViewFoldMarker is shown after the line number of a line that starts a
+// foldable range -- click on it to fold the range
+var TextViewFoldMarker = "▼"
+
+// TextViewFoldedMarker is shown after the line number of a line that starts
+// a folded range -- click on it to unfold the range
+var TextViewFoldedMarker = "►"
+
+// IsFoldedLine returns true if given line is hidden in a folded range --
+// this is a binary search of the merged folded ranges, as it is called for
+// each line in layout and rendering
+func (tv *TextView) IsFoldedLine(ln int) bool {
+	fh := tv.foldsHidden
+	i := sort.Search(len(fh), func(i int) bool {
+		return fh[i].Ed >= ln
+	})
+	return i < len(fh) && fh[i].Contains(ln)
+}
+
+// updateFoldsHidden merges the Folds into the disjoint, sorted ranges of
+// hidden lines used by IsFoldedLine -- called whenever the Folds change
+func (tv *TextView) updateFoldsHidden() {
+	tv.foldsHidden = tv.foldsHidden[:0]
+	for _, fd := range tv.Folds {
+		n := len(tv.foldsHidden)
+		if n > 0 && fd.St <= tv.foldsHidden[n-1].Ed { // starts on a hidden line
+			lf := &tv.foldsHidden[n-1]
+			lf.Ed = ints.MaxInt(lf.Ed, fd.Ed)
+			continue
+		}
+		tv.foldsHidden = append(tv.foldsHidden, fd)
+	}
+}
+
+// UnfoldedLine returns the first line at or after (dir > 0), or at or
+// before (dir < 0), given line that is not hidden in a folded range -- it is
+// out of range if there is no such line
+func (tv *TextView) UnfoldedLine(ln, dir int) int {
+	for ln >= 0 && ln < tv.NLines && tv.IsFoldedLine(ln) {
+		ln += dir
+	}
+	return ln
+}
+
+// UpdateFoldable updates the Foldable ranges from the buffer right away, if
+// they are out of date after edits -- used by the fold actions, which need
+// them to be current
+func (tv *TextView) UpdateFoldable() {
+	if !tv.foldsDirty {
+		return
+	}
+	tv.foldsDirty = false
+	if tv.Buf == nil {
+		tv.Foldable = nil
+		return
+	}
+	tv.Foldable = tv.Buf.FoldRanges(tv.Sty.Text.TabSize)
+}
+
+// foldableStale marks the Foldable ranges as out of date, after an edit or
+// a new layout of all the lines, and has them updated in the background
+func (tv *TextView) foldableStale() {
+	tv.foldsDirty = true
+	tv.foldsGen++
+	tv.updateFoldableBg()
+}
+
+// updateFoldableBg updates the Foldable ranges from the buffer in a separate
+// goroutine, for the fold markers shown with the line numbers, which are
+// rendered again if they changed -- Buf.FoldRanges lexes the whole buffer,
+// so it is only run once at a time, and again when done if there were edits
+// meanwhile
+func (tv *TextView) updateFoldableBg() {
+	if !tv.foldsDirty || tv.foldsBusy || !tv.Opts.LineNos || tv.Buf == nil || tv.Viewport == nil || tv.Viewport.Win == nil {
+		return
+	}
+	tv.foldsBusy = true
+	buf, tabSz, gen, win := tv.Buf, tv.Sty.Text.TabSize, tv.foldsGen, tv.Viewport.Win
+	go func() {
+		fr := buf.FoldRanges(tabSz)
+		win.PostInEventLoop(func() {
+			tv.foldsBusy = false
+			if tv.Buf != buf || tv.foldsGen != gen {
+				tv.updateFoldableBg() // edited meanwhile
+				return
+			}
+			if !tv.foldsDirty { // done by UpdateFoldable meanwhile
+				return
+			}
+			tv.foldsDirty = false
+			same := len(fr) == len(tv.Foldable)
+			for i := 0; same && i < len(fr); i++ {
+				same = fr[i] == tv.Foldable[i]
+			}
+			tv.Foldable = fr
+			if !same && tv.Renders != nil {
+				tv.RenderAllLines()
+			}
+		})
+	}()
+}
+
+// FoldableAt returns the foldable range that starts at given line, and false
+// if there is none -- this uses the Foldable ranges as they are, which can
+// be out of date right after edits, so call UpdateFoldable first if needed
+func (tv *TextView) FoldableAt(ln int) (TextFold, bool) {
+	i := sort.Search(len(tv.Foldable), func(i int) bool {
+		return tv.Foldable[i].St >= ln
+	})
+	if i < len(tv.Foldable) && tv.Foldable[i].St == ln {
+		return tv.Foldable[i], true
+	}
+	return TextFold{}, false
+}
+
+// FoldableAround returns the innermost foldable range that starts at or
+// contains given line, and false if there is none
+func (tv *TextView) FoldableAround(ln int) (TextFold, bool) {
+	tv.UpdateFoldable()
+	var fd TextFold
+	got := false
+	for _, fr := range tv.Foldable {
+		if fr.St > ln {
+			break
+		}
+		if fr.St == ln || fr.Contains(ln) {
+			fd = fr
+			got = true
+		}
+	}
+	return fd, got
+}
+
+// FoldedAt returns the index in Folds of the folded range that starts at
+// given line, and -1 if there is none
+func (tv *TextView) FoldedAt(ln int) int {
+	for i, fd := range tv.Folds {
+		if fd.St == ln {
+			return i
+		}
+		if fd.St > ln {
+			break
+		}
+	}
+	return -1
+}
+
+// Fold folds given range of lines, hiding all but its first line -- the
+// cursor is moved out of the range if it was within it, and any additional
+// Cursors within it are removed
+func (tv *TextView) Fold(fd TextFold) {
+	if fd.St < 0 || fd.Ed <= fd.St || fd.St >= tv.NLines || tv.FoldedAt(fd.St) >= 0 {
+		return
+	}
+	tv.Folds = append(tv.Folds, fd)
+	sort.Slice(tv.Folds, func(i, j int) bool {
+		return tv.Folds[i].St < tv.Folds[j].St
+	})
+	tv.foldsUpdated(fd.St)
+}
+
+// Unfold unfolds the folded range that starts at given line -- returns
+// false if there is none
+func (tv *TextView) Unfold(ln int) bool {
+	i := tv.FoldedAt(ln)
+	if i < 0 {
+		return false
+	}
+	tv.Folds = append(tv.Folds[:i], tv.Folds[i+1:]...)
+	tv.foldsUpdated(ln)
+	return true
+}
+
+// UnfoldLine unfolds all the folded ranges that hide given line, so that it
+// is visible -- returns false if it was not hidden
+func (tv *TextView) UnfoldLine(ln int) bool {
+	if !tv.IsFoldedLine(ln) {
+		return false
+	}
+	st := ln
+	nf := tv.Folds[:0]
+	for _, fd := range tv.Folds {
+		if fd.Contains(ln) {
+			st = ints.MinInt(st, fd.St)
+		} else {
+			nf = append(nf, fd)
+		}
+	}
+	tv.Folds = nf
+	tv.foldsUpdated(st)
+	return true
+}
+
+// ToggleFold unfolds the folded range that starts at given line, or else
+// folds the foldable range that starts there -- returns false if there is
+// neither
+func (tv *TextView) ToggleFold(ln int) bool {
+	if tv.Unfold(ln) {
+		return true
+	}
+	tv.UpdateFoldable()
+	fd, ok := tv.FoldableAt(ln)
+	if !ok {
+		return false
+	}
+	tv.Fold(fd)
+	return true
+}
+
+// ToggleFoldAround unfolds the folded range that starts at given line, or
+// else folds the innermost foldable range around it -- returns false if
+// there is neither
+func (tv *TextView) ToggleFoldAround(ln int) bool {
+	if tv.Unfold(ln) {
+		return true
+	}
+	fd, ok := tv.FoldableAround(ln)
+	if !ok {
+		return false
+	}
+	tv.Fold(fd)
+	return true
+}
+
+// FoldAll folds all the foldable ranges
+func (tv *TextView) FoldAll() {
+	tv.UpdateFoldable()
+	if len(tv.Foldable) == 0 {
+		return
+	}
+	tv.Folds = make([]TextFold, len(tv.Foldable))
+	copy(tv.Folds, tv.Foldable)
+	tv.foldsUpdated(0)
+}
+
+// UnfoldAll unfolds all the folded ranges
+func (tv *TextView) UnfoldAll() {
+	if len(tv.Folds) == 0 {
+		return
+	}
+	tv.Folds = nil
+	tv.foldsUpdated(0)
+}
+
+// foldsUpdated moves the cursors out of any folded ranges and lays out and
+// renders the lines again from given line, after Folds have been updated
+func (tv *TextView) foldsUpdated(ln int) {
+	tv.updateFoldsHidden()
+	if tv.Renders == nil || ln >= tv.NLines {
+		return
+	}
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	if tv.IsFoldedLine(tv.CursorPos.Ln) {
+		cln := tv.UnfoldedLine(tv.CursorPos.Ln, -1)
+		tv.CursorPos = TextPos{Ln: cln, Ch: len(tv.Buf.Lines[cln])}
+		tv.SetCursorCol(tv.CursorPos)
+		tv.CursorMovedSig()
+	}
+	if tv.HasCursors() {
+		curs := tv.Cursors[:0]
+		for _, c := range tv.Cursors {
+			if !tv.IsFoldedLine(c.Pos.Ln) {
+				curs = append(curs, c)
+			}
+		}
+		tv.Cursors = curs
+	}
+	tv.LayoutLines(ln, ln, true)
+	tv.RenderAllLines()
+}
+
+// foldsEdited updates the Folds for lines inserted or deleted by given edit,
+// before they are laid out again -- folded ranges that contain the edit are
+// unfolded, and those after it are moved -- the Foldable ranges are moved in
+// the same way until they are updated in the background
+func (tv *TextView) foldsEdited(tbe *TextBufEdit) {
+	st, ed := tbe.Reg.Start.Ln, tbe.Reg.End.Ln
+	dln, after := ed-st, st
+	if tbe.Delete {
+		dln, after = -dln, ed
+	}
+	fa := make([]TextFold, 0, len(tv.Foldable))
+	for _, fd := range tv.Foldable {
+		switch {
+		case fd.Ed < st:
+			fa = append(fa, fd)
+		case fd.St > after:
+			fa = append(fa, TextFold{St: fd.St + dln, Ed: fd.Ed + dln})
+		case fd.St < st:
+			fa = append(fa, TextFold{St: fd.St, Ed: ints.MaxInt(fd.Ed+dln, st)})
+		}
+	}
+	tv.Foldable = fa
+	tv.foldableStale()
+	if len(tv.Folds) == 0 {
+		return
+	}
+	unst := -1
+	nf := tv.Folds[:0]
+	for _, fd := range tv.Folds {
+		switch {
+		case fd.Ed < st:
+			nf = append(nf, fd)
+		case fd.St > after:
+			nf = append(nf, TextFold{St: fd.St + dln, Ed: fd.Ed + dln})
+		case unst < 0:
+			unst = fd.St
+		}
+	}
+	tv.Folds = nf
+	tv.updateFoldsHidden()
+	if unst >= 0 && unst < st {
+		tv.LayoutLines(unst, unst, true) // lines before the edit are visible again
+	}
+}
+
+// InLineNos returns true if given pixel location, relative to the upper left
+// of the text area as for PixelToCursor, is within the line numbers
+func (tv *TextView) InLineNos(pt image.Point) bool {
+	if !tv.Opts.LineNos {
+		return false
+	}
+	scrl := tv.WinBBox.Min.X - tv.ObjBBox.Min.X
+	return float32(pt.X+scrl) < tv.LineNoOff
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -1724,7 +2875,7 @@ func (tv *TextView) MakeContextMenu(m *gi.Menu) {
 
 // OfferComplete pops up a menu of possible completions
 func (tv *TextView) OfferComplete(forcecomplete bool) {
-	if tv.Complete == nil || tv.ISearchMode {
+	if tv.Complete == nil || tv.ISearchMode || tv.HasCursors() {
 		return
 	}
 	if !tv.Opts.Completion && !forcecomplete {
@@ -1773,10 +2924,13 @@ func (tv *TextView) CompleteText(s string) {
 	}
 	ns, _ := tv.Complete.EditFunc(tv.Complete.Context, tbes, tv.CursorPos.Ch, s, tv.Complete.Seed)
 	//fmt.Println(ns)
+	tv.SaveUndoCursor()
+	tv.Buf.BeginUndoGroup()
 	tv.SetCursor(TextPos{tv.CursorPos.Ln, len(ns) - 1})
 	tv.Buf.DeleteText(st, en, true, true)
 	tv.CursorPos = st
 	tv.InsertAtCursor([]byte(ns))
+	tv.Buf.EndUndoGroup()
 }
 
 // SetCompleter sets completion functions so that completions will
@@ -1948,7 +3102,7 @@ func (tv *TextView) ScrollToLeft(pos int) bool {
 func (tv *TextView) ScrollCursorToLeft() bool {
 	_, ri, _ := tv.WrappedLineNo(tv.CursorPos)
 	if ri == 0 {
-		return tv.ScrollToLeft(tv.ObjBBox.Min.X - int(tv.Sty.BoxSpace()) - 2)
+		return tv.ScrollToLeft(tv.ObjBBox.Min.X - int(tv.Sty.BoxSpaceSides().Pos().X) - 2)
 	}
 	curBBox := tv.CursorBBox(tv.CursorPos)
 	return tv.ScrollToLeft(curBBox.Min.X)
@@ -2113,8 +3267,9 @@ func (tv *TextView) StopCursor() {
 func (tv *TextView) CursorBBox(pos TextPos) image.Rectangle {
 	st := &tv.Sty
 	cpos := tv.CharStartPos(pos)
-	cbmin := cpos.SubVal(st.Border.Width.Dots)
-	cbmax := cpos.AddVal(st.Border.Width.Dots)
+	bw := st.Border.WidthDots()
+	cbmin := cpos.Sub(bw.Pos())
+	cbmax := cpos.Add(bw.Size().Sub(bw.Pos()))
 	cbmax.Y += tv.FontHeight
 	curBBox := image.Rectangle{cbmin.ToPointFloor(), cbmax.ToPointCeil()}
 	return curBBox
@@ -2200,6 +3355,9 @@ func (tv *TextView) UpdateHighlights(prev []TextRegion) {
 func (tv *TextView) RenderRegionBox(reg TextRegion, state TextViewStates) {
 	st := reg.Start
 	ed := reg.End
+	if st.Ln == ed.Ln && tv.IsFoldedLine(st.Ln) {
+		return
+	}
 	spos := tv.CharStartPos(st)
 	epos := tv.CharStartPos(ed)
 	epos.Y += tv.LineHeight
@@ -2210,11 +3368,11 @@ func (tv *TextView) RenderRegionBox(reg TextRegion, state TextViewStates) {
 	rs := &tv.Viewport.Render
 	pc := &rs.Paint
 	sty := &tv.StateStyles[state]
-	spc := sty.BoxSpace()
+	spc := sty.BoxSpaceSides()
 
 	ed.Ch-- // end is exclusive
 	rst := tv.RenderStartPos()
-	ex := float32(tv.VpBBox.Max.X) - spc
+	ex := float32(tv.VpBBox.Max.X) - spc[gi.BoxRight]
 	sx := rst.X + tv.LineNoOff
 
 	// fmt.Printf("select: %v -- %v\n", st, ed)
@@ -2247,8 +3405,7 @@ func (tv *TextView) RenderRegionBox(reg TextRegion, state TextViewStates) {
 // RenderStartPos is absolute rendering start position from our allocpos
 func (tv *TextView) RenderStartPos() gi.Vec2D {
 	st := &tv.Sty
-	spc := st.BoxSpace()
-	pos := tv.LayData.AllocPos.AddVal(spc)
+	pos := tv.LayData.AllocPos.Add(st.BoxSpaceSides().Pos())
 	return pos
 }
 
@@ -2258,7 +3415,7 @@ func (tv *TextView) VisSizes() {
 		tv.StyleTextView()
 	}
 	sty := &tv.Sty
-	spc := sty.BoxSpace()
+	spc := sty.BoxSpaceSides().Pos()
 	sty.Font.OpenFont(&sty.UnContext)
 	tv.FontHeight = sty.Font.Height
 	tv.LineHeight = tv.FontHeight * sty.Text.EffLineHeight()
@@ -2272,7 +3429,7 @@ func (tv *TextView) VisSizes() {
 	}
 	tv.LineNoDigs = ints.MaxInt(1+int(math32.Log10(float32(tv.NLines))), 3)
 	if tv.Opts.LineNos {
-		tv.LineNoOff = float32(tv.LineNoDigs+3)*sty.Font.Ch + spc // space for icon
+		tv.LineNoOff = float32(tv.LineNoDigs+3)*sty.Font.Ch + spc.X // space for icon
 	} else {
 		tv.LineNoOff = 0
 	}
@@ -2313,8 +3470,12 @@ func (tv *TextView) RenderAllLinesInBounds() {
 	tv.RenderLineNosBoxAll()
 	tv.RenderHighlights(-1, -1) // all
 	tv.RenderSelect()
+	tv.RenderCursors()
 	pos := tv.RenderStartPos()
 	for ln := 0; ln < tv.NLines; ln++ {
+		if tv.IsFoldedLine(ln) {
+			continue
+		}
 		lst := pos.Y + tv.Offs[ln]
 		led := lst + math32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
 		if int(math32.Ceil(led)) < tv.VpBBox.Min.Y {
@@ -2339,11 +3500,11 @@ func (tv *TextView) RenderLineNosBoxAll() {
 	rs := &tv.Viewport.Render
 	pc := &rs.Paint
 	sty := &tv.Sty
-	spc := sty.BoxSpace()
+	spc := sty.BoxSpaceSides().Pos()
 	clr := sty.Font.BgColor.Color.Highlight(10)
-	spos := gi.NewVec2DFmPoint(tv.VpBBox.Min).AddVal(spc)
+	spos := gi.NewVec2DFmPoint(tv.VpBBox.Min).Add(spc)
 	epos := gi.NewVec2DFmPoint(tv.VpBBox.Max)
-	epos.X = spos.X + tv.LineNoOff - spc
+	epos.X = spos.X + tv.LineNoOff - spc.X
 	pc.FillBoxColor(rs, spos, epos.Sub(spos), clr)
 }
 
@@ -2355,13 +3516,13 @@ func (tv *TextView) RenderLineNosBox(st, ed int) {
 	rs := &tv.Viewport.Render
 	pc := &rs.Paint
 	sty := &tv.Sty
-	spc := sty.BoxSpace()
+	spc := sty.BoxSpaceSides().Pos()
 	clr := sty.Font.BgColor.Color.Highlight(10)
 	spos := tv.CharStartPos(TextPos{Ln: st})
-	spos.X = float32(tv.VpBBox.Min.X) + spc
+	spos.X = float32(tv.VpBBox.Min.X) + spc.X
 	epos := tv.CharEndPos(TextPos{Ln: ed + 1})
 	epos.Y -= tv.LineHeight
-	epos.X = spos.X + tv.LineNoOff - spc
+	epos.X = spos.X + tv.LineNoOff - spc.X
 	// fmt.Printf("line box: st %v ed: %v spos %v  epos %v\n", st, ed, spos, epos)
 	pc.FillBoxColor(rs, spos, epos.Sub(spos), clr)
 }
@@ -2379,6 +3540,11 @@ func (tv *TextView) RenderLineNo(ln int) {
 	lfmt := fmt.Sprintf("%v", tv.LineNoDigs)
 	lfmt = "%0" + lfmt + "d"
 	lnstr := fmt.Sprintf(lfmt, ln+1)
+	if tv.FoldedAt(ln) >= 0 {
+		lnstr += " " + TextViewFoldedMarker
+	} else if _, ok := tv.FoldableAt(ln); ok {
+		lnstr += " " + TextViewFoldMarker
+	}
 	tv.LineNoRender.SetString(lnstr, &fst, &sty.UnContext, &sty.Text, true, 0, 0)
 	pos := tv.RenderStartPos()
 	lst := tv.CharStartPos(TextPos{Ln: ln}).Y // note: charstart pos includes descent
@@ -2414,6 +3580,9 @@ func (tv *TextView) RenderLines(st, ed int) bool {
 		visSt := -1
 		visEd := -1
 		for ln := st; ln <= ed; ln++ {
+			if tv.IsFoldedLine(ln) {
+				continue
+			}
 			lst := tv.CharStartPos(TextPos{Ln: ln}).Y // note: charstart pos includes descent
 			led := lst + math32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
 			if int(math32.Ceil(led)) < tv.VpBBox.Min.Y {
@@ -2440,9 +3609,13 @@ func (tv *TextView) RenderLines(st, ed int) bool {
 
 			tv.RenderHighlights(visSt, visEd)
 			tv.RenderSelect()
+			tv.RenderCursors()
 			tv.RenderLineNosBox(visSt, visEd)
 
 			for ln := visSt; ln <= visEd; ln++ {
+				if tv.IsFoldedLine(ln) {
+					continue
+				}
 				lst := pos.Y + tv.Offs[ln]
 				lp := pos
 				lp.Y = lst
@@ -2477,6 +3650,9 @@ func (tv *TextView) FirstVisibleLine(stln int) int {
 			stln = 0
 		}
 		for ln := stln; ln < tv.NLines; ln++ {
+			if tv.IsFoldedLine(ln) {
+				continue
+			}
 			cpos := tv.CharStartPos(TextPos{Ln: ln})
 			if int(math32.Floor(cpos.Y)) >= tv.VpBBox.Min.Y { // top definitely on screen
 				stln = ln
@@ -2486,6 +3662,9 @@ func (tv *TextView) FirstVisibleLine(stln int) int {
 	}
 	lastln := stln
 	for ln := stln - 1; ln >= 0; ln-- {
+		if tv.IsFoldedLine(ln) {
+			continue
+		}
 		cpos := tv.CharStartPos(TextPos{Ln: ln})
 		if int(math32.Ceil(cpos.Y)) < tv.VpBBox.Min.Y { // top just offscreen
 			break
@@ -2500,6 +3679,9 @@ func (tv *TextView) FirstVisibleLine(stln int) int {
 func (tv *TextView) LastVisibleLine(stln int) int {
 	lastln := stln
 	for ln := stln + 1; ln < tv.NLines; ln++ {
+		if tv.IsFoldedLine(ln) {
+			continue
+		}
 		pos := TextPos{Ln: ln}
 		cpos := tv.CharStartPos(pos)
 		if int(math32.Floor(cpos.Y)) > tv.VpBBox.Max.Y { // just offscreen
@@ -2525,10 +3707,13 @@ func (tv *TextView) PixelToCursor(pt image.Point) TextPos {
 	if pt.Y < int(math32.Floor(fls)) {
 		cln = stln
 	} else if pt.Y > tv.WinBBox.Max.Y {
-		cln = tv.NLines - 1
+		cln = tv.UnfoldedLine(tv.NLines-1, -1)
 	} else {
 		got := false
 		for ln := stln; ln < tv.NLines; ln++ {
+			if tv.IsFoldedLine(ln) {
+				continue
+			}
 			ls := tv.CharStartPos(TextPos{Ln: ln}).Y - yoff
 			es := ls
 			es += math32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
@@ -2539,7 +3724,7 @@ func (tv *TextView) PixelToCursor(pt image.Point) TextPos {
 			}
 		}
 		if !got {
-			cln = tv.NLines - 1
+			cln = tv.UnfoldedLine(tv.NLines-1, -1)
 		}
 	}
 	// fmt.Printf("cln: %v  pt: %v\n", cln, pt)
@@ -2789,6 +3974,10 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 		kt.SetProcessed()
 		tv.CloseCompleter()
 		tv.ISearch()
+	case gi.KeyFunFind:
+		cancelAll()
+		kt.SetProcessed()
+		tv.FindReplace()
 	case gi.KeyFunAbort:
 		kt.SetProcessed()
 		tv.EscPressed()
@@ -2802,6 +3991,14 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 		kt.SetProcessed()
 		tv.CursorToHistNext()
 	}
+	if tv.HasCursors() {
+		switch kf {
+		case gi.KeyFunMoveRight, gi.KeyFunMoveLeft, gi.KeyFunMoveUp, gi.KeyFunMoveDown, gi.KeyFunHome, gi.KeyFunEnd:
+			tv.moveCursors(kf, kt.HasAnyModifier(key.Shift))
+		case gi.KeyFunPageUp, gi.KeyFunPageDown, gi.KeyFunDocHome, gi.KeyFunDocEnd, gi.KeyFunSelectAll, gi.KeyFunSearch, gi.KeyFunJump, gi.KeyFunHistPrev, gi.KeyFunHistNext:
+			tv.ClearCursors()
+		}
+	}
 	if tv.IsInactive() {
 		switch {
 		case kf == gi.KeyFunFocusNext: // tab
@@ -2860,6 +4057,18 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 		cancelAll()
 		kt.SetProcessed()
 		tv.Redo()
+	case gi.KeyFunAddCursorNext:
+		cancelAll()
+		kt.SetProcessed()
+		tv.AddCursorNext()
+	case gi.KeyFunAddCursorUp:
+		cancelAll()
+		kt.SetProcessed()
+		tv.AddCursorUp()
+	case gi.KeyFunAddCursorDown:
+		cancelAll()
+		kt.SetProcessed()
+		tv.AddCursorDown()
 	case gi.KeyFunComplete:
 		tv.ISearchCancel()
 		kt.SetProcessed()
@@ -2868,15 +4077,11 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 		tv.ISearchCancel()
 		if !kt.HasAnyModifier(key.Control, key.Meta) {
 			kt.SetProcessed()
-			updt := tv.Viewport.Win.UpdateStart()
-			tv.InsertAtCursor([]byte("\n"))
 			if tv.Opts.AutoIndent {
-				tbe, _, cpos := tv.Buf.AutoIndent(tv.CursorPos.Ln, tv.Opts.SpaceIndent, tv.Sty.Text.TabSize, DefaultIndentStrings, DefaultUnindentStrings)
-				if tbe != nil {
-					tv.SetCursorShow(TextPos{Ln: tbe.Reg.End.Ln, Ch: cpos})
-				}
+				tv.insertAtCursorIndent([]byte("\n"))
+			} else {
+				tv.InsertAtCursor([]byte("\n"))
 			}
-			tv.Viewport.Win.UpdateEnd(updt)
 		}
 		// todo: KeFunFocusPrev -- unindent
 	case gi.KeyFunFocusNext: // tab
@@ -2884,17 +4089,8 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 		if !kt.HasAnyModifier(key.Control, key.Meta) {
 			kt.SetProcessed()
 			updt := tv.Viewport.Win.UpdateStart()
-			if !tv.lastWasTabAI && tv.CursorPos.Ch == 0 && tv.Opts.AutoIndent { // todo: only at 1st pos now
-				_, _, cpos := tv.Buf.AutoIndent(tv.CursorPos.Ln, tv.Opts.SpaceIndent, tv.Sty.Text.TabSize, DefaultIndentStrings, DefaultUnindentStrings)
-				tv.CursorPos.Ch = cpos
-				tv.RenderCursor(true)
+			if tv.tabAtCursor(!tv.lastWasTabAI) {
 				gotTabAI = true
-			} else {
-				if tv.Opts.SpaceIndent {
-					tv.InsertAtCursor(IndentBytes(1, tv.Sty.Text.TabSize, true))
-				} else {
-					tv.InsertAtCursor([]byte("\t"))
-				}
 			}
 			tv.Viewport.Win.UpdateEnd(updt)
 		}
@@ -2905,12 +4101,10 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 				if tv.ISearchMode { // todo: need this in inactive mode
 					tv.ISearchKeyInput(kt.Rune)
 				} else {
-					tv.InsertAtCursor([]byte(string(kt.Rune)))
 					if kt.Rune == '}' && tv.Opts.AutoIndent {
-						tbe, _, cpos := tv.Buf.AutoIndent(tv.CursorPos.Ln, tv.Opts.SpaceIndent, tv.Sty.Text.TabSize, DefaultIndentStrings, DefaultUnindentStrings)
-						if tbe != nil {
-							tv.SetCursorShow(TextPos{Ln: tbe.Reg.End.Ln, Ch: cpos})
-						}
+						tv.insertAtCursorIndent([]byte(string(kt.Rune)))
+					} else {
+						tv.InsertAtCursor([]byte(string(kt.Rune)))
 					}
 				}
 				tv.OfferComplete(dontforce)
@@ -2996,10 +4190,25 @@ func (tv *TextView) MouseEvent(me *mouse.Event) {
 	case mouse.Left:
 		if me.Action == mouse.Press {
 			me.SetProcessed()
-			if _, got := tv.OpenLinkAt(newPos); got {
-			} else {
-				tv.SetCursorFromMouse(pt, newPos, me.SelectMode())
+			if tv.InLineNos(pt) && tv.ToggleFold(newPos.Ln) {
+				return
 			}
+			switch {
+			case !tv.IsInactive() && me.HasAnyModifier(key.Alt):
+				tv.rectSelect = true
+				tv.RectStart = newPos
+				tv.SelectRect(newPos, newPos)
+			case !tv.IsInactive() && me.HasAnyModifier(key.Control):
+				tv.AddCursor(newPos)
+			default:
+				tv.ClearCursors()
+				if _, got := tv.OpenLinkAt(newPos); got {
+				} else {
+					tv.SetCursorFromMouse(pt, newPos, me.SelectMode())
+				}
+			}
+		} else if me.Action == mouse.Release {
+			tv.rectSelect = false
 		} else if me.Action == mouse.DoubleClick {
 			me.SetProcessed()
 			// if tv.HasSelection() {
@@ -3033,11 +4242,16 @@ func (tv *TextView) TextViewEvents() {
 		me := d.(*mouse.DragEvent)
 		me.SetProcessed()
 		txf := recv.Embed(KiT_TextView).(*TextView)
+		pt := txf.PointToRelPos(me.Pos())
+		newPos := txf.PixelToCursor(pt)
+		if txf.rectSelect {
+			txf.SelectRect(txf.RectStart, newPos)
+			txf.AutoScroll(pt.Add(txf.WinBBox.Min))
+			return
+		}
 		if !txf.SelectMode {
 			txf.SelectModeToggle()
 		}
-		pt := txf.PointToRelPos(me.Pos())
-		newPos := txf.PixelToCursor(pt)
 		txf.SetCursorFromMouse(pt, newPos, mouse.NoSelectMode)
 	})
 	tv.ConnectEvent(oswin.MouseEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
@@ -3082,6 +4296,9 @@ func (tv *TextView) Init2D() {
 }
 
 func (tv *TextView) StyleTextView() {
+	if tv.Buf != nil {
+		tv.Buf.UpdateHiTheme()
+	}
 	tv.Style2DWidget()
 	pst := &(tv.Par.(gi.Node2D).AsWidget().Sty)
 	for i := 0; i < int(TextViewStatesN); i++ {
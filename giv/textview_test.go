@@ -0,0 +1,164 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import "testing"
+
+// TestEditCursorsAdjust edits at each of several cursors from the end of the
+// buffer back, adjusting the positions of the cursors already edited for
+// each edit, as in TextView.editCursors
+func TestEditCursorsAdjust(t *testing.T) {
+	cur := func(ln, ch int) TextCursor { return TextCursor{Pos: TextPos{Ln: ln, Ch: ch}} }
+	sel := func(ln, st, ed int) TextCursor {
+		return TextCursor{Pos: TextPos{Ln: ln, Ch: ed}, Sel: testTextReg(ln, st, ed)}
+	}
+	tests := []struct {
+		name string
+		src  string
+		curs []TextCursor
+		ins  string
+		cor  string
+		ccur []TextPos
+	}{
+		{"insert lines", "ab\nab\nab\n", []TextCursor{cur(0, 1), cur(1, 1), cur(2, 1)}, "x\n",
+			"ax\nb\nax\nb\nax\nb\n", []TextPos{{1, 0}, {3, 0}, {5, 0}}},
+		{"same line", "abcd\n", []TextCursor{cur(0, 1), cur(0, 3)}, "xy",
+			"axybcxyd\n", []TextPos{{0, 3}, {0, 7}}},
+		{"replace selections", "foo bar foo\n", []TextCursor{sel(0, 0, 3), sel(0, 8, 11)}, "x",
+			"x bar x\n", []TextPos{{0, 1}, {0, 7}}},
+		{"delete lines", "a1\nb2\nc3\nd4\n", []TextCursor{{Pos: TextPos{1, 1}, Sel: TextRegion{Start: TextPos{0, 1}, End: TextPos{1, 1}}},
+			{Pos: TextPos{3, 1}, Sel: TextRegion{Start: TextPos{2, 1}, End: TextPos{3, 1}}}}, "",
+			"a2\nc4\n", []TextPos{{0, 1}, {1, 1}}},
+	}
+	for _, tt := range tests {
+		tb := testTextBuf(tt.src)
+		curs := append([]TextCursor{}, tt.curs...)
+		tb.BeginUndoGroup()
+		for ci := len(curs) - 1; ci >= 0; ci-- {
+			c := curs[ci]
+			n0 := tb.UndoPos
+			pos := c.Pos
+			if c.HasSel() {
+				tb.DeleteText(c.Sel.Start, c.Sel.End, true, true)
+				pos = c.Sel.Start
+			}
+			if tbe := tb.InsertText(pos, []byte(tt.ins), true, true); tbe != nil {
+				pos = tbe.Reg.End
+			}
+			curs[ci] = TextCursor{Pos: pos}
+			for _, tbe := range tb.Undos[n0:tb.UndoPos] {
+				for j := ci + 1; j < len(curs); j++ {
+					curs[j].Pos = tbe.AdjustPos(curs[j].Pos)
+				}
+			}
+		}
+		tb.EndUndoGroup()
+		if txt := string(tb.LinesToBytesCopy()); txt != tt.cor {
+			t.Errorf("edit cursors %v: got %q, expected %q\n", tt.name, txt, tt.cor)
+		}
+		for i, c := range curs {
+			if c.Pos != tt.ccur[i] {
+				t.Errorf("edit cursors %v cursor %v: got %v, expected %v\n", tt.name, i, c.Pos, tt.ccur[i])
+			}
+		}
+		tb.Undo()
+		if txt := string(tb.LinesToBytesCopy()); txt != tt.src {
+			t.Errorf("edit cursors %v: got %q after one Undo, expected %q\n", tt.name, txt, tt.src)
+		}
+	}
+}
+
+func TestCursorsText(t *testing.T) {
+	tests := []struct {
+		name string
+		pos  TextPos
+		sel  TextRegion
+		curs []TextCursor
+		cor  string
+	}{
+		{"no selection", TextPos{1, 2}, TextRegionZero, []TextCursor{{Pos: TextPos{0, 1}}}, ""},
+		{"main only", TextPos{1, 2}, testTextReg(1, 0, 2), nil, "ba"},
+		{"in order", TextPos{1, 2}, testTextReg(1, 0, 2), []TextCursor{{Pos: TextPos{2, 1}}, {Pos: TextPos{0, 3}, Sel: testTextReg(0, 0, 3)}}, "foo\nba\n"},
+	}
+	for _, tt := range tests {
+		tv := &TextView{}
+		tv.Buf = testTextBuf("foo\nbar\nbaz\n")
+		tv.CursorPos, tv.SelectReg, tv.Cursors = tt.pos, tt.sel, tt.curs
+		txt := tv.CursorsText()
+		if string(txt) != tt.cor || (tt.cor == "") != (txt == nil) {
+			t.Errorf("CursorsText %v: got %q, expected %q\n", tt.name, txt, tt.cor)
+		}
+	}
+}
+
+func TestFoldsEdited(t *testing.T) {
+	ins := &TextBufEdit{Reg: TextRegion{Start: TextPos{Ln: 4, Ch: 2}, End: TextPos{Ln: 6, Ch: 1}}}
+	del := &TextBufEdit{Reg: TextRegion{Start: TextPos{Ln: 4, Ch: 2}, End: TextPos{Ln: 6, Ch: 1}}, Delete: true}
+	tests := []struct {
+		name      string
+		tbe       *TextBufEdit
+		foldable  []TextFold
+		folds     []TextFold
+		cfoldable []TextFold
+		cfolds    []TextFold
+	}{
+		{"insert", ins, []TextFold{{0, 5}, {2, 3}, {4, 6}, {7, 9}}, []TextFold{{2, 3}, {4, 6}, {7, 9}},
+			[]TextFold{{0, 7}, {2, 3}, {9, 11}}, []TextFold{{2, 3}, {9, 11}}},
+		{"delete", del, []TextFold{{0, 9}, {2, 3}, {4, 8}, {5, 6}, {7, 9}}, []TextFold{{2, 3}, {5, 6}, {7, 9}},
+			[]TextFold{{0, 7}, {2, 3}, {5, 7}}, []TextFold{{2, 3}, {5, 7}}},
+		{"delete to end of range", del, []TextFold{{1, 5}}, nil,
+			[]TextFold{{1, 4}}, nil},
+	}
+	for _, tt := range tests {
+		tv := &TextView{}
+		tv.Foldable = append([]TextFold{}, tt.foldable...)
+		tv.Folds = append([]TextFold{}, tt.folds...)
+		tv.foldsEdited(tt.tbe)
+		if !testFoldsEqual(tv.Foldable, tt.cfoldable) {
+			t.Errorf("foldsEdited %v Foldable: got %v, expected %v\n", tt.name, tv.Foldable, tt.cfoldable)
+		}
+		if !testFoldsEqual(tv.Folds, tt.cfolds) {
+			t.Errorf("foldsEdited %v Folds: got %v, expected %v\n", tt.name, tv.Folds, tt.cfolds)
+		}
+		for ln := 0; ln < 12; ln++ {
+			if got, cor := tv.IsFoldedLine(ln), testFoldsContain(tt.cfolds, ln); got != cor {
+				t.Errorf("foldsEdited %v IsFoldedLine(%v): got %v, expected %v\n", tt.name, ln, got, cor)
+			}
+		}
+	}
+}
+
+func TestIsFoldedLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		folds []TextFold
+	}{
+		{"none", nil},
+		{"disjoint", []TextFold{{1, 3}, {5, 6}, {8, 10}}},
+		{"nested", []TextFold{{1, 9}, {2, 4}, {5, 6}}},
+		{"overlapping", []TextFold{{1, 4}, {3, 7}, {7, 8}}},
+		{"adjacent", []TextFold{{1, 3}, {4, 6}}},
+	}
+	for _, tt := range tests {
+		tv := &TextView{}
+		tv.Folds = tt.folds
+		tv.updateFoldsHidden()
+		for ln := -1; ln < 12; ln++ {
+			if got, cor := tv.IsFoldedLine(ln), testFoldsContain(tt.folds, ln); got != cor {
+				t.Errorf("IsFoldedLine %v (%v): got %v, expected %v\n", tt.name, ln, got, cor)
+			}
+		}
+	}
+}
+
+// testFoldsContain returns true if any of the folds contains given line
+func testFoldsContain(folds []TextFold, ln int) bool {
+	for _, fd := range folds {
+		if fd.Contains(ln) {
+			return true
+		}
+	}
+	return false
+}
@@ -11,6 +11,7 @@ import (
 	"image/color"
 	"log"
 	"reflect"
+	"time"
 
 	"github.com/chewxy/math32"
 	"github.com/goki/gi"
@@ -45,6 +46,7 @@ type TreeView struct {
 	WidgetSize       gi.Vec2D                  `desc:"just the size of our widget -- our alloc includes all of our children, but we only draw us"`
 	Icon             gi.IconName               `json:"-" xml:"icon" view:"show-name" desc:"optional icon, displayed to the the left of the text label"`
 	RootView         *TreeView                 `json:"-" xml:"-" desc:"cached root of the view"`
+	openAnim         *treeViewOpenAnim
 }
 
 var KiT_TreeView = kit.Types.AddType(&TreeView{}, TreeViewProps)
@@ -542,7 +544,7 @@ func (tv *TreeView) MoveDown(selMode mouse.SelectModes) *TreeView {
 			selMode = mouse.ExtendContinuous
 		}
 	}
-	if tv.IsClosed() || !tv.HasChildren() { // next sibling
+	if tv.IsClosed() || tv.IsClosing() || !tv.HasChildren() { // next sibling -- closing counts as closed
 		return tv.MoveDownSibling(selMode)
 	} else {
 		if tv.HasChildren() {
@@ -637,7 +639,7 @@ func (tv *TreeView) MoveToLastChild(selMode mouse.SelectModes) *TreeView {
 	if tv.Par == nil || tv == tv.RootView {
 		return nil
 	}
-	if !tv.IsClosed() && tv.HasChildren() {
+	if !tv.IsClosed() && !tv.IsClosing() && tv.HasChildren() {
 		nnk, ok := tv.Children().ElemFromEnd(0)
 		if ok {
 			nn := nnk.Embed(KiT_TreeView).(*TreeView)
@@ -650,28 +652,34 @@ func (tv *TreeView) MoveToLastChild(selMode mouse.SelectModes) *TreeView {
 	return nil
 }
 
-// Close closes the given node and updates the view accordingly (if it is not already closed)
+// Close closes the given node and updates the view accordingly (if it is not
+// already closed) -- the children are hidden over TreeViewOpenMSec, after
+// which the node is closed and the TreeViewClosed signal is sent
 func (tv *TreeView) Close() {
-	if !tv.IsClosed() {
+	if !tv.IsClosed() && !tv.IsClosing() {
 		updt := tv.UpdateStart()
 		if tv.HasChildren() {
 			tv.SetFullReRender()
 		}
-		tv.SetClosed()
-		tv.RootView.TreeViewSig.Emit(tv.RootView.This, int64(TreeViewClosed), tv.This)
+		if !tv.startOpenAnim(true) {
+			tv.SetClosed()
+			tv.RootView.TreeViewSig.Emit(tv.RootView.This, int64(TreeViewClosed), tv.This)
+		}
 		tv.UpdateEnd(updt)
 	}
 }
 
-// Open opens the given node and updates the view accordingly (if it is not already opened)
+// Open opens the given node and updates the view accordingly (if it is not
+// already opened) -- the children are revealed over TreeViewOpenMSec
 func (tv *TreeView) Open() {
-	if tv.IsClosed() {
+	if tv.IsClosed() || tv.IsClosing() {
 		updt := tv.UpdateStart()
 		if tv.HasChildren() {
 			tv.SetFullReRender()
 		}
 		if tv.HasChildren() {
 			tv.SetClosedState(false)
+			tv.startOpenAnim(false)
 		}
 		// send signal in any case -- dynamic trees can open a node here!
 		tv.RootView.TreeViewSig.Emit(tv.RootView.This, int64(TreeViewOpened), tv.This)
@@ -681,13 +689,85 @@ func (tv *TreeView) Open() {
 
 // ToggleClose toggles the close / open status: if closed, opens, and vice-versa
 func (tv *TreeView) ToggleClose() {
-	if tv.IsClosed() {
+	if tv.IsClosed() || tv.IsClosing() {
 		tv.Open()
 	} else {
 		tv.Close()
 	}
 }
 
+// TreeViewOpenMSec is the number of milliseconds over which the children of
+// a node are revealed or hidden when it is opened or closed -- 0 = instantly
+var TreeViewOpenMSec = 150
+
+// treeViewOpenAnim animates the opening or closing of a node, by revealing
+// or hiding the height of its children -- implements gi.Animator
+type treeViewOpenAnim struct {
+	tv      *TreeView
+	start   time.Time
+	closing bool
+	frac    float32 // fraction of the height of the children that is shown
+}
+
+// IsClosing returns true if the node is in the process of closing
+func (tv *TreeView) IsClosing() bool {
+	return tv.openAnim != nil && tv.openAnim.closing
+}
+
+// startOpenAnim starts animating the opening or closing of the node,
+// continuing from any current animation -- returns false if not animated
+func (tv *TreeView) startOpenAnim(closing bool) bool {
+	win := tv.ParentWindow()
+	if TreeViewOpenMSec <= 0 || gi.AnimFPS <= 0 || win == nil || !tv.HasChildren() {
+		tv.openAnim = nil
+		return false
+	}
+	dur := time.Duration(TreeViewOpenMSec) * time.Millisecond
+	an := &treeViewOpenAnim{tv: tv, start: time.Now(), closing: closing}
+	if closing {
+		an.frac = 1
+	}
+	if old := tv.openAnim; old != nil { // continue from the current point
+		an.frac = old.frac
+		t0 := an.frac
+		if closing {
+			t0 = 1 - t0
+		}
+		an.start = an.start.Add(-time.Duration(t0 * float32(dur)))
+	}
+	tv.openAnim = an
+	win.AddAnim(an)
+	return true
+}
+
+// Animate updates the fraction of the children shown and re-renders the
+// tree -- implements gi.Animator
+func (an *treeViewOpenAnim) Animate(now time.Time) bool {
+	tv := an.tv
+	if tv.IsDeleted() || tv.IsDestroyed() || tv.openAnim != an {
+		return true
+	}
+	t := float32(now.Sub(an.start)) / float32(time.Duration(TreeViewOpenMSec)*time.Millisecond)
+	done := t >= 1
+	e := gi.EaseOut.Eval(t)
+	updt := tv.UpdateStart()
+	tv.SetFullReRender()
+	switch {
+	case done:
+		tv.openAnim = nil
+		if an.closing {
+			tv.SetClosed()
+			tv.RootView.TreeViewSig.Emit(tv.RootView.This, int64(TreeViewClosed), tv.This)
+		}
+	case an.closing:
+		an.frac = 1 - e
+	default:
+		an.frac = e
+	}
+	tv.UpdateEnd(updt)
+	return done
+}
+
 //////////////////////////////////////////////////////////////////////////////
 //    Modifying Source Tree
 
@@ -1615,6 +1695,7 @@ func (tv *TreeView) StyleTreeView() {
 	for i := 0; i < int(TreeViewStatesN); i++ {
 		tv.StateStyles[i].CopyFrom(&tv.Sty)
 		tv.StateStyles[i].SetStyleProps(pst, tv.StyleProps(TreeViewSelectors[i]))
+		tv.StateStyles[i].StyleCSS(tv.This.(gi.Node2D), tv.CSSAgg, TreeViewSelectors[i])
 		tv.StateStyles[i].CopyUnitContext(&tv.Sty.UnContext)
 	}
 	tv.Indent.SetFmInheritProp("indent", tv.This, false, true) // no inherit, yes type defaults
@@ -1645,24 +1726,29 @@ func (tv *TreeView) Size2D(iter int) {
 
 	if !tv.IsClosed() {
 		// we layout children under us
+		kh := float32(0)
 		for _, kid := range tv.Kids {
 			gis := kid.(gi.Node2D).AsWidget()
 			if gis == nil {
 				continue
 			}
-			h += math32.Ceil(gis.LayData.AllocSize.Y)
+			kh += math32.Ceil(gis.LayData.AllocSize.Y)
 			w = gi.Max32(w, tv.Indent.Dots+gis.LayData.AllocSize.X)
 		}
+		if tv.openAnim != nil { // children beyond our alloc are clipped
+			kh = math32.Ceil(kh * tv.openAnim.frac)
+		}
+		h += kh
 	}
 	tv.LayData.AllocSize = gi.Vec2D{w, h}
 	tv.WidgetSize.X = w // stretch
 }
 
 func (tv *TreeView) Layout2DParts(parBBox image.Rectangle, iter int) {
-	spc := tv.Sty.BoxSpace()
-	tv.Parts.LayData.AllocPos = tv.LayData.AllocPos.AddVal(spc)
+	spc := tv.Sty.BoxSpaceSides()
+	tv.Parts.LayData.AllocPos = tv.LayData.AllocPos.Add(spc.Pos())
 	tv.Parts.LayData.AllocPosOrig = tv.Parts.LayData.AllocPos
-	tv.Parts.LayData.AllocSize = tv.WidgetSize.AddVal(-2.0 * spc)
+	tv.Parts.LayData.AllocSize = tv.WidgetSize.Sub(spc.Size())
 	tv.Parts.Layout2D(parBBox, iter)
 }
 
@@ -1734,11 +1820,11 @@ func (tv *TreeView) Render2D() {
 	// }
 	if tv.PushBounds() {
 		if tv.IsSelected() {
-			tv.Sty = tv.StateStyles[TreeViewSel]
+			tv.TransitionStyle(&tv.StateStyles[TreeViewSel])
 		} else if tv.HasFocus() {
-			tv.Sty = tv.StateStyles[TreeViewFocus]
+			tv.TransitionStyle(&tv.StateStyles[TreeViewFocus])
 		} else {
-			tv.Sty = tv.StateStyles[TreeViewActive]
+			tv.TransitionStyle(&tv.StateStyles[TreeViewActive])
 		}
 		tv.ConfigPartsIfNeeded()
 		tv.TreeViewEvents()
@@ -1748,13 +1834,15 @@ func (tv *TreeView) Render2D() {
 		pc := &rs.Paint
 		st := &tv.Sty
 		pc.FontStyle = st.Font
-		pc.StrokeStyle.SetColor(&st.Border.Color)
-		pc.StrokeStyle.Width = st.Border.Width
+		pc.StrokeStyle.SetColor(nil)
 		pc.FillStyle.SetColorSpec(&st.Font.BgColor)
 		// tv.RenderStdBox()
-		pos := tv.LayData.AllocPos.AddVal(st.Layout.Margin.Dots)
-		sz := tv.WidgetSize.AddVal(-2.0 * st.Layout.Margin.Dots)
-		tv.RenderBoxImpl(pos, sz, st.Border.Radius.Dots)
+		mrg := st.Layout.MarginDots()
+		pos := tv.LayData.AllocPos.Add(mrg.Pos())
+		sz := tv.WidgetSize.Sub(mrg.Size())
+		rad := st.Border.RadiusDots()
+		tv.RenderBoxRadiiImpl(pos, sz, rad)
+		tv.RenderBorder(st, pos, sz, rad)
 		tv.Render2DParts()
 		tv.PopBounds()
 	} else {
@@ -77,6 +77,9 @@ const (
 	KeyFunJump   // jump to line
 	KeyFunHistPrev
 	KeyFunHistNext
+	KeyFunAddCursorNext // add a cursor at the next occurrence of the selection
+	KeyFunAddCursorUp   // add a cursor on the line above
+	KeyFunAddCursorDown // add a cursor on the line below
 	KeyFunsN
 )
 
@@ -540,6 +543,10 @@ var StdKeyMaps = KeyMaps{
 		"Meta+]":                  KeyFunHistNext,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
+		// multiple cursors
+		"Meta+D":             KeyFunAddCursorNext,
+		"Alt+Meta+UpArrow":   KeyFunAddCursorUp,
+		"Alt+Meta+DownArrow": KeyFunAddCursorDown,
 	}},
 	{"MacEmacs", "Mac with emacs-style navigation -- emacs wins in conflicts", KeyMap{
 		"UpArrow":                 KeyFunMoveUp,
@@ -640,6 +647,10 @@ var StdKeyMaps = KeyMaps{
 		"Meta+]":                  KeyFunHistNext,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
+		// multiple cursors
+		"Meta+D":             KeyFunAddCursorNext,
+		"Alt+Meta+UpArrow":   KeyFunAddCursorUp,
+		"Alt+Meta+DownArrow": KeyFunAddCursorDown,
 	}},
 	{"LinuxStd", "Standard Linux KeyMap", KeyMap{
 		"UpArrow": KeyFunMoveUp,
@@ -702,6 +713,10 @@ var StdKeyMaps = KeyMaps{
 		"Control+J":       KeyFunJump,
 		"Control+[":       KeyFunHistPrev,
 		"Control+]":       KeyFunHistNext,
+		// multiple cursors
+		"Shift+Control+D":       KeyFunAddCursorNext,
+		"Control+Alt+UpArrow":   KeyFunAddCursorUp,
+		"Control+Alt+DownArrow": KeyFunAddCursorDown,
 	}},
 	{"LinuxEmacs", "Linux with emacs-style navigation -- emacs wins in conflicts", KeyMap{
 		"UpArrow":            KeyFunMoveUp,
@@ -791,6 +806,10 @@ var StdKeyMaps = KeyMaps{
 		"Control+J":               KeyFunJump,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
+		// multiple cursors
+		"Shift+Control+D":       KeyFunAddCursorNext,
+		"Control+Alt+UpArrow":   KeyFunAddCursorUp,
+		"Control+Alt+DownArrow": KeyFunAddCursorDown,
 	}},
 	{"WindowsStd", "Standard Windows KeyMap", KeyMap{
 		"UpArrow": KeyFunMoveUp,
@@ -849,6 +868,10 @@ var StdKeyMaps = KeyMaps{
 		"Control+.":       KeyFunComplete,
 		"Control+[":       KeyFunHistPrev,
 		"Control+]":       KeyFunHistNext,
+		// multiple cursors
+		"Shift+Control+D":       KeyFunAddCursorNext,
+		"Control+Alt+UpArrow":   KeyFunAddCursorUp,
+		"Control+Alt+DownArrow": KeyFunAddCursorDown,
 	}},
 	{"ChromeStd", "Standard chrome-browser and linux-under-chrome bindings", KeyMap{
 		"UpArrow": KeyFunMoveUp,
@@ -909,5 +932,9 @@ var StdKeyMaps = KeyMaps{
 		"Control+J":       KeyFunJump,
 		"Control+[":       KeyFunHistPrev,
 		"Control+]":       KeyFunHistNext,
+		// multiple cursors
+		"Shift+Control+D":       KeyFunAddCursorNext,
+		"Control+Alt+UpArrow":   KeyFunAddCursorUp,
+		"Control+Alt+DownArrow": KeyFunAddCursorDown,
 	}},
 }
@@ -83,7 +83,7 @@ var LabelProps = ki.Props{
 		"background-color": color.Transparent,
 	},
 	LabelSelectors[LabelInactive]: ki.Props{
-		"color": "lighter-50",
+		"color": "highlight-50",
 	},
 	LabelSelectors[LabelSelected]: ki.Props{
 		"background-color": &Prefs.Colors.Select,
@@ -129,13 +129,13 @@ func (lb *Label) SetText(txt string) {
 	} else {
 		lb.Render.SetHTML(lb.Text, &lb.Sty.Font, &lb.Sty.Text, &lb.Sty.UnContext, lb.CSSAgg)
 	}
-	spc := lb.Sty.BoxSpace()
+	spc := lb.Sty.BoxSpaceSides().Size()
 	sz := lb.LayData.AllocSize
 	if sz.IsZero() {
 		sz = lb.LayData.SizePrefOrMax()
 	}
 	if !sz.IsZero() {
-		sz.SetSubVal(2 * spc)
+		sz.SetSub(spc)
 	}
 	lb.Render.LayoutStdLR(&lb.Sty.Text, &lb.Sty.Font, &lb.Sty.UnContext, sz)
 	lb.UpdateEnd(updt)
@@ -290,7 +290,7 @@ func (lb *Label) GrabCurBgColor() {
 
 func (lb *Label) TextPos() Vec2D {
 	sty := &lb.Sty
-	pos := lb.LayData.AllocPos.AddVal(sty.BoxSpace())
+	pos := lb.LayData.AllocPos.Add(sty.BoxSpaceSides().Pos())
 	if !sty.Text.HasWordWrap() { // word-wrap case already deals with this b/c it has final alloc size -- otherwise it lays out "blind" and can't do this.
 		if lb.LayData.AllocSize.X > lb.Render.Size.X {
 			if IsAlignMiddle(sty.Layout.AlignH) {
@@ -321,16 +321,17 @@ func (lb *Label) StyleLabel() {
 	for i := 0; i < int(LabelStatesN); i++ {
 		lb.StateStyles[i].CopyFrom(&lb.Sty)
 		lb.StateStyles[i].SetStyleProps(pst, lb.StyleProps(LabelSelectors[i]))
+		lb.StateStyles[i].StyleCSS(lb.This.(Node2D), lb.CSSAgg, LabelSelectors[i])
 		lb.StateStyles[i].CopyUnitContext(&lb.Sty.UnContext)
 	}
 }
 
 func (lb *Label) LayoutLabel() {
 	lb.Render.SetHTML(lb.Text, &lb.Sty.Font, &lb.Sty.Text, &lb.Sty.UnContext, lb.CSSAgg)
-	spc := lb.Sty.BoxSpace()
+	spc := lb.Sty.BoxSpaceSides().Size()
 	sz := lb.LayData.SizePrefOrMax()
 	if !sz.IsZero() {
-		sz.SetSubVal(2 * spc)
+		sz.SetSub(spc)
 	}
 	lb.Render.LayoutStdLR(&lb.Sty.Text, &lb.Sty.Font, &lb.Sty.UnContext, sz)
 }
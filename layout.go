@@ -53,15 +53,41 @@ type LayoutStyle struct {
 	MaxHeight      units.Value `xml:"max-height" desc:"specified maximum size of element -- 0 means just use other values, negative means stretch"`
 	MinWidth       units.Value `xml:"min-width" desc:"specified mimimum size of element -- 0 if not specified"`
 	MinHeight      units.Value `xml:"min-height" desc:"specified mimimum size of element -- 0 if not specified"`
-	Margin         units.Value `xml:"margin" desc:"outer-most transparent space around box element -- todo: can be specified per side"`
-	Padding        units.Value `xml:"padding" desc:"transparent space around central content of box -- todo: if 4 values it is top, right, bottom, left; 3 is top, right&left, bottom; 2 is top & bottom, right and left"`
+	Margin         units.Value `xml:"margin" desc:"outer-most transparent space around box element -- if 4 values it is top, right, bottom, left; 3 is top, right&left, bottom; 2 is top & bottom, right and left -- this is the first value -- see MarginTop etc for the margin used on each side"`
+	Padding        units.Value `xml:"padding" desc:"transparent space around central content of box -- if 4 values it is top, right, bottom, left; 3 is top, right&left, bottom; 2 is top & bottom, right and left -- this is the first value -- see PaddingTop etc for the padding used on each side"`
 	Overflow       Overflow    `xml:"overflow" desc:"what to do with content that overflows -- default is Auto add of scrollbars as needed -- todo: can have separate -x -y values"`
 	Columns        int         `xml:"columns" alt:"grid-cols" desc:"number of columns to use in a grid layout -- used as a constraint in layout if individual elements do not specify their row, column positions"`
-	Row            int         `xml:"row" desc:"specifies the row that this element should appear within a grid layout"`
-	Col            int         `xml:"col" desc:"specifies the column that this element should appear within a grid layout"`
-	RowSpan        int         `xml:"row-span" desc:"specifies the number of sequential rows that this element should occupy within a grid layout (todo: not currently supported)"`
-	ColSpan        int         `xml:"col-span" desc:"specifies the number of sequential columns that this element should occupy within a grid layout"`
+	Row            int         `xml:"row" desc:"specifies the row that this element should appear within a grid layout -- -1 = not set"`
+	Col            int         `xml:"col" desc:"specifies the column that this element should appear within a grid layout -- -1 = not set"`
+	RowSpan        int         `xml:"row-span" desc:"specifies the number of sequential rows that this element should occupy within a grid layout -- only supported in LayoutGridIrreg"`
+	ColSpan        int         `xml:"col-span" desc:"specifies the number of sequential columns that this element should occupy within a grid layout -- only supported in LayoutGridIrreg"`
 	ScrollBarWidth units.Value `xml:"scrollbar-width" desc:"width of a layout scrollbar"`
+
+	MarginTop     units.Value `xml:"margin-top" desc:"margin on the top side"`
+	MarginRight   units.Value `xml:"margin-right" desc:"margin on the right side"`
+	MarginBottom  units.Value `xml:"margin-bottom" desc:"margin on the bottom side"`
+	MarginLeft    units.Value `xml:"margin-left" desc:"margin on the left side"`
+	PaddingTop    units.Value `xml:"padding-top" desc:"padding on the top side"`
+	PaddingRight  units.Value `xml:"padding-right" desc:"padding on the right side"`
+	PaddingBottom units.Value `xml:"padding-bottom" desc:"padding on the bottom side"`
+	PaddingLeft   units.Value `xml:"padding-left" desc:"padding on the left side"`
+
+	GridArea          string                     `xml:"grid-area" desc:"name of the area within the grid-template-areas of a LayoutGridIrreg parent layout that this element should occupy -- overrides row, col and spans"`
+	GridTemplateCols  string                     `xml:"grid-template-columns" desc:"for LayoutGridIrreg layouts, the sizing of each column: fixed (e.g., 10em or 20%), fraction of remaining space (e.g., 1fr), or auto, and repeat(n, ...) -- unspecified columns are auto"`
+	GridTemplateRows  string                     `xml:"grid-template-rows" desc:"for LayoutGridIrreg layouts, the sizing of each row: fixed (e.g., 10em or 20%), fraction of remaining space (e.g., 1fr), or auto, and repeat(n, ...) -- unspecified rows are auto"`
+	GridTemplateAreas string                     `xml:"grid-template-areas" desc:"for LayoutGridIrreg layouts, named areas as a quoted string for each row, with a name for each column, e.g., \"head head\" \"side main\" -- . is an empty cell -- children use grid-area to occupy an area"`
+	GridTracks        [RowColN][]GridTrack       `xml:"-" json:"-" desc:"parsed GridTemplateRows, GridTemplateCols"`
+	GridAreas         map[string]image.Rectangle `xml:"-" json:"-" desc:"parsed GridTemplateAreas, with X = col, Y = row"`
+	gridTemplates     [3]string
+
+	FlexDirection  FlexDirections `xml:"flex-direction" desc:"for LayoutFlex layouts, the main axis along which items are arranged: row or column"`
+	FlexWrap       FlexWraps      `xml:"flex-wrap" desc:"for LayoutFlex layouts, whether items wrap onto multiple lines when they do not fit along the main axis"`
+	JustifyContent Align          `xml:"justify-content" desc:"for LayoutFlex layouts, how extra space along the main axis is used: flex-start, center, flex-end, space-between, space-around, space-evenly"`
+	AlignItems     Align          `xml:"align-items" desc:"for LayoutFlex layouts, how items are aligned along the cross axis within each line: stretch (default), flex-start, center, flex-end"`
+	AlignContent   Align          `xml:"align-content" desc:"for LayoutFlex layouts with wrapping, how extra space along the cross axis is distributed among lines: stretch (default), flex-start, center, flex-end, space-between, space-around, space-evenly"`
+	FlexGrow       float32        `xml:"flex-grow" desc:"for items in a LayoutFlex layout, the relative amount of the extra space along the main axis that this element gets -- 0 = does not grow"`
+	FlexShrink     float32        `xml:"flex-shrink" desc:"for items in a LayoutFlex layout, the relative amount this element shrinks (in proportion to its basis size) when there is not enough space along the main axis -- 0 = does not shrink below basis"`
+	FlexBasis      units.Value    `xml:"flex-basis" desc:"for items in a LayoutFlex layout, the initial size of the element along the main axis, before growing or shrinking -- 0 = auto, i.e., its preferred size"`
 }
 
 func (ls *LayoutStyle) Defaults() {
@@ -69,9 +95,15 @@ func (ls *LayoutStyle) Defaults() {
 	ls.MinWidth.Set(2.0, units.Px)
 	ls.MinHeight.Set(2.0, units.Px)
 	ls.ScrollBarWidth.Set(16.0, units.Px)
+	ls.AlignItems = AlignStretch
+	ls.AlignContent = AlignStretch
+	ls.FlexShrink = 1
+	ls.Row = -1
+	ls.Col = -1
 }
 
 func (ls *LayoutStyle) SetStylePost(props ki.Props) {
+	ls.SetGridTemplates()
 }
 
 // return the alignment for given dimension
@@ -130,6 +162,13 @@ const (
 	AlignSub
 	// align to superscript
 	AlignSuper
+	// same as AlignJustify -- for CSS space-between in flex layouts
+	AlignSpaceBetween
+	// CSS space-evenly: equal space between and around each item
+	AlignSpaceEvenly
+	// stretch items to fill the available space -- for align-items and
+	// align-content in flex layouts
+	AlignStretch
 	AlignN
 )
 
@@ -303,6 +342,7 @@ type Layout struct {
 	Scrolls       [Dims2DN]*ScrollBar `json:"-" xml:"-" desc:"scroll bars -- we fully manage them as needed"`
 	GridSize      image.Point         `json:"-" xml:"-" desc:"computed size of a grid layout based on all the constraints -- computed during Size2D pass"`
 	GridData      [RowColN][]GridData `json:"-" xml:"-" desc:"grid data for rows in [0] and cols in [1]"`
+	GridCells     []image.Rectangle   `json:"-" xml:"-" desc:"for LayoutGridIrreg, the cells occupied by each child, with X = col, Y = row -- computed during Size2D pass"`
 	NeedsRedo     bool                `json:"-" xml:"-" desc:"true if this layout got a redo = true on previous iteration -- otherwise it just skips any re-layout on subsequent iteration"`
 	FocusName     string              `json:"-" xml:"-" desc:"accumulated name to search for when keys are typed"`
 	FocusNameTime time.Time           `json:"-" xml:"-" desc:"time of last focus name event -- for timeout"`
@@ -325,8 +365,11 @@ const (
 	// LayoutGrid arranges items according to a regular grid
 	LayoutGrid
 
-	// todo: add LayoutGridIrreg that deals with irregular grids with spans etc -- keep
-	// the basic grid for fully regular cases -- need high performance for large grids
+	// LayoutGridIrreg arranges items in an irregular grid, where items can
+	// span multiple rows and columns, and rows and columns can have fixed,
+	// fractional (fr) or auto sizes -- see GridTemplateCols etc -- the basic
+	// LayoutGrid is faster for large regular grids
+	LayoutGridIrreg
 
 	// LayoutHorizFlow arranges items horizontally across a row, overflowing
 	// vertically as needed
@@ -336,6 +379,12 @@ const (
 	// horizontally as needed
 	LayoutVertFlow
 
+	// LayoutFlex arranges items according to the CSS flexbox model, along
+	// the FlexDirection with optional FlexWrap, using flex-grow, flex-shrink
+	// and flex-basis of each item, and justify-content, align-items and
+	// align-content of the layout
+	LayoutFlex
+
 	// LayoutStacked arranges items stacked on top of each other -- Top index
 	// indicates which to show -- overall size accommodates largest in each
 	// dimension
@@ -451,9 +500,9 @@ func (ly *Layout) GatherSizes() {
 		}
 	}
 
-	spc := ly.Sty.BoxSpace()
-	ly.LayData.Size.Need.SetAddVal(2.0 * spc)
-	ly.LayData.Size.Pref.SetAddVal(2.0 * spc)
+	spc := ly.Sty.BoxSpaceSides()
+	ly.LayData.Size.Need.SetAdd(spc.Size())
+	ly.LayData.Size.Pref.SetAdd(spc.Size())
 
 	elspc := float32(0.0)
 	if sz >= 2 {
@@ -615,9 +664,9 @@ func (ly *Layout) GatherSizesGrid() {
 		ly.LayData.Size.Need.Y = ly.LayData.Size.Pref.Y
 	}
 
-	spc := ly.Sty.BoxSpace()
-	ly.LayData.Size.Need.SetAddVal(2.0 * spc)
-	ly.LayData.Size.Pref.SetAddVal(2.0 * spc)
+	spc := ly.Sty.BoxSpaceSides()
+	ly.LayData.Size.Need.SetAdd(spc.Size())
+	ly.LayData.Size.Pref.SetAdd(spc.Size())
 
 	ly.LayData.Size.Need.X += float32(cols-1) * ly.Spacing.Dots
 	ly.LayData.Size.Pref.X += float32(cols-1) * ly.Spacing.Dots
@@ -719,8 +768,8 @@ func (ly *Layout) LayoutSharedDimImpl(avail, need, pref, max, spc float32, al Al
 // LayoutSharedDim lays out items along a shared dimension, where all elements
 // share the same space, e.g., Horiz for a Vert layout, and vice-versa.
 func (ly *Layout) LayoutSharedDim(dim Dims2D) {
-	spc := ly.Sty.BoxSpace()
-	avail := ly.LayData.AllocSize.Dim(dim) - 2.0*spc
+	spc := ly.Sty.BoxSpaceSides()
+	avail := ly.LayData.AllocSize.Dim(dim) - spc.Size().Dim(dim)
 	for _, c := range ly.Kids {
 		ni := c.(Node2D).AsWidget()
 		if ni == nil {
@@ -730,7 +779,7 @@ func (ly *Layout) LayoutSharedDim(dim Dims2D) {
 		pref := ni.LayData.Size.Pref.Dim(dim)
 		need := ni.LayData.Size.Need.Dim(dim)
 		max := ni.LayData.Size.Max.Dim(dim)
-		pos, size := ly.LayoutSharedDimImpl(avail, need, pref, max, spc, al)
+		pos, size := ly.LayoutSharedDimImpl(avail, need, pref, max, spc.Pos().Dim(dim), al)
 		ni.LayData.AllocSize.SetDim(dim, size)
 		ni.LayData.AllocPosRel.SetDim(dim, pos)
 	}
@@ -746,8 +795,8 @@ func (ly *Layout) LayoutAlongDim(dim Dims2D) {
 
 	elspc := float32(sz-1) * ly.Spacing.Dots
 	al := ly.Sty.Layout.AlignDim(dim)
-	spc := ly.Sty.BoxSpace()
-	exspc := 2.0*spc + elspc
+	spc := ly.Sty.BoxSpaceSides()
+	exspc := spc.Size().Dim(dim) + elspc
 	avail := ly.LayData.AllocSize.Dim(dim) - exspc
 	pref := ly.LayData.Size.Pref.Dim(dim) - exspc
 	need := ly.LayData.Size.Need.Dim(dim) - exspc
@@ -805,7 +854,7 @@ func (ly *Layout) LayoutAlongDim(dim Dims2D) {
 	}
 
 	// now arrange everyone
-	pos := spc
+	pos := spc.Pos().Dim(dim)
 
 	// todo: need a direction setting too
 	if IsAlignEnd(al) && !stretchNeed && !stretchMax {
@@ -859,8 +908,8 @@ func (ly *Layout) LayoutGridDim(rowcol RowCol, dim Dims2D) {
 	}
 	elspc := float32(sz-1) * ly.Spacing.Dots
 	al := ly.Sty.Layout.AlignDim(dim)
-	spc := ly.Sty.BoxSpace()
-	exspc := 2.0*spc + elspc
+	spc := ly.Sty.BoxSpaceSides()
+	exspc := spc.Size().Dim(dim) + elspc
 	avail := ly.LayData.AllocSize.Dim(dim) - exspc
 	pref := ly.LayData.Size.Pref.Dim(dim) - exspc
 	need := ly.LayData.Size.Need.Dim(dim) - exspc
@@ -910,7 +959,7 @@ func (ly *Layout) LayoutGridDim(rowcol RowCol, dim Dims2D) {
 	}
 
 	// now arrange everyone
-	pos := spc
+	pos := spc.Pos().Dim(dim)
 
 	// todo: need a direction setting too
 	if IsAlignEnd(al) && !stretchNeed && !stretchMax {
@@ -1037,14 +1086,15 @@ func (ly *Layout) FinalizeLayout() {
 // AllocSize except for top-level layout which uses VpBBox in case less is
 // avail
 func (ly *Layout) AvailSize() Vec2D {
-	spc := ly.Sty.BoxSpace()
-	avail := ly.LayData.AllocSize.SubVal(spc) // spc is for right size space
+	spc := ly.Sty.BoxSpaceSides()
+	rbspc := spc.Size().Sub(spc.Pos()) // space on the right and bottom sides
+	avail := ly.LayData.AllocSize.Sub(rbspc)
 	parni, _ := KiToNode2D(ly.Par)
 	if parni != nil {
 		vp := parni.AsViewport2D()
 		if vp != nil {
 			if vp.Viewport == nil {
-				avail = NewVec2DFmPoint(ly.VpBBox.Size()).SubVal(spc)
+				avail = NewVec2DFmPoint(ly.VpBBox.Size()).Sub(rbspc)
 				// fmt.Printf("non-nil par ly: %v vp: %v %v\n", ly.PathUnique(), vp.PathUnique(), avail)
 			}
 		}
@@ -1108,8 +1158,8 @@ func (ly *Layout) SetScroll(d Dims2D) {
 		sc.Tracking = true
 		sc.Min = 0.0
 	}
-	spc := ly.Sty.BoxSpace()
-	avail := ly.AvailSize().SubVal(spc * 2.0)
+	spc := ly.Sty.BoxSpaceSides()
+	avail := ly.AvailSize().Sub(spc.Size())
 	sc := ly.Scrolls[d]
 	if d == X {
 		sc.SetFixedHeight(ly.Sty.Layout.ScrollBarWidth)
@@ -1122,7 +1172,7 @@ func (ly *Layout) SetScroll(d Dims2D) {
 	sc.Max = ly.ChildSize.Dim(d) + ly.ExtraSize.Dim(d) // only scrollbar
 	sc.Step = ly.Sty.Font.Size.Dots                    // step by lines
 	sc.PageStep = 10.0 * sc.Step                       // todo: more dynamic
-	sc.ThumbVal = avail.Dim(d) - spc
+	sc.ThumbVal = avail.Dim(d) - spc.Pos().Dim(d)
 	sc.TrackThr = sc.Step
 	sc.Value = Min32(sc.Value, sc.Max-sc.ThumbVal) // keep in range
 	sc.SliderSig.ConnectOnly(ly.This, func(recv, send ki.Ki, sig int64, data interface{}) {
@@ -1166,16 +1216,16 @@ func (ly *Layout) DeactivateScroll(sc *ScrollBar) {
 func (ly *Layout) LayoutScrolls() {
 	sbw := ly.Sty.Layout.ScrollBarWidth.Dots
 
-	spc := ly.Sty.BoxSpace()
+	spc := ly.Sty.BoxSpaceSides().Pos()
 	avail := ly.AvailSize()
 	for d := X; d < Dims2DN; d++ {
 		odim := OtherDim(d)
 		if ly.HasScroll[d] {
 			sc := ly.Scrolls[d]
 			sc.Size2D(0)
-			sc.LayData.AllocPosRel.SetDim(d, spc)
+			sc.LayData.AllocPosRel.SetDim(d, spc.Dim(d))
 			sc.LayData.AllocPosRel.SetDim(odim, avail.Dim(odim)-sbw-2.0)
-			sc.LayData.AllocSize.SetDim(d, avail.Dim(d)-spc)
+			sc.LayData.AllocSize.SetDim(d, avail.Dim(d)-spc.Dim(d))
 			if ly.HasScroll[odim] { // make room for other
 				sc.LayData.AllocSize.SetSubDim(d, sbw)
 			}
@@ -1536,6 +1586,11 @@ func (ly *Layout) FocusNextChild(updn bool) bool {
 	if ly.Lay == LayoutGrid && updn {
 		nxti = idx + ly.Sty.Layout.Columns
 	}
+	if ly.Lay == LayoutGridIrreg {
+		if nxti = ly.GridIrregNeighbor(idx, updn, true); nxti < 0 {
+			return false
+		}
+	}
 	did := false
 	if nxti < sz {
 		did = win.FocusOnOrNext(ly.KnownChild(nxti))
@@ -1565,6 +1620,11 @@ func (ly *Layout) FocusPrevChild(updn bool) bool {
 	if ly.Lay == LayoutGrid && updn {
 		nxti = idx - ly.Sty.Layout.Columns
 	}
+	if ly.Lay == LayoutGridIrreg {
+		if nxti = ly.GridIrregNeighbor(idx, updn, false); nxti < 0 {
+			return false
+		}
+	}
 	did := false
 	if nxti >= 0 {
 		did = win.FocusOnOrNext(ly.KnownChild(nxti))
@@ -1580,7 +1640,7 @@ func (ly *Layout) FocusPrevChild(updn bool) bool {
 // LayoutKeys is key processing for layouts -- focus name and arrow keys
 func (ly *Layout) LayoutKeys(kt *key.ChordEvent) {
 	kf := KeyFun(kt.Chord())
-	if ly.Lay == LayoutHoriz || ly.Lay == LayoutGrid || ly.Lay == LayoutHorizFlow {
+	if ly.Lay == LayoutHoriz || ly.Lay == LayoutGrid || ly.Lay == LayoutGridIrreg || ly.Lay == LayoutHorizFlow || (ly.Lay == LayoutFlex && ly.Sty.Layout.FlexDirection == FlexRow) {
 		switch kf {
 		case KeyFunMoveRight:
 			if ly.FocusNextChild(false) { // allow higher layers to try..
@@ -1594,7 +1654,7 @@ func (ly *Layout) LayoutKeys(kt *key.ChordEvent) {
 			return
 		}
 	}
-	if ly.Lay == LayoutVert || ly.Lay == LayoutGrid || ly.Lay == LayoutVertFlow {
+	if ly.Lay == LayoutVert || ly.Lay == LayoutGrid || ly.Lay == LayoutGridIrreg || ly.Lay == LayoutVertFlow || (ly.Lay == LayoutFlex && ly.Sty.Layout.FlexDirection == FlexColumn) {
 		switch kf {
 		case KeyFunMoveDown:
 			if ly.FocusNextChild(true) {
@@ -1760,9 +1820,14 @@ func (ly *Layout) Style2D() {
 
 func (ly *Layout) Size2D(iter int) {
 	ly.InitLayout2D()
-	if ly.Lay == LayoutGrid {
+	switch ly.Lay {
+	case LayoutGrid:
 		ly.GatherSizesGrid()
-	} else {
+	case LayoutGridIrreg:
+		ly.GatherSizesGridIrreg()
+	case LayoutFlex:
+		ly.GatherSizesFlex()
+	default:
 		ly.GatherSizes()
 	}
 }
@@ -1784,6 +1849,10 @@ func (ly *Layout) Layout2D(parBBox image.Rectangle, iter int) bool {
 		ly.LayoutSharedDim(X)
 	case LayoutGrid:
 		ly.LayoutGrid()
+	case LayoutGridIrreg:
+		ly.LayoutGridIrreg()
+	case LayoutFlex:
+		ly.LayoutFlex()
 	case LayoutStacked:
 		ly.LayoutSharedDim(X)
 		ly.LayoutSharedDim(Y)
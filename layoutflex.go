@@ -0,0 +1,402 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/ki/kit"
+)
+
+// LayoutFlex follows the CSS flexbox model: items are arranged along the main
+// axis (FlexDirection), starting from their flex-basis size (or their
+// preferred size if not set), and then grow in proportion to their flex-grow
+// if there is extra space, or shrink in proportion to their flex-shrink times
+// their basis if there is not enough -- never below their Need size nor above
+// a positive Max size.  If no item on a line has a flex-grow, items with a
+// negative (stretch) Max grow equally, as in LayoutHoriz / LayoutVert.  With
+// FlexWrap, items that do not fit on a line go onto the next line, and
+// align-content distributes any extra space along the cross axis among the
+// lines.
+
+// FlexDirections are the directions of the main axis of a LayoutFlex layout
+type FlexDirections int32
+
+const (
+	// FlexRow arranges items horizontally, in rows
+	FlexRow FlexDirections = iota
+
+	// FlexColumn arranges items vertically, in columns
+	FlexColumn
+
+	FlexDirectionsN
+)
+
+//go:generate stringer -type=FlexDirections
+
+var KiT_FlexDirections = kit.Enums.AddEnumAltLower(FlexDirectionsN, false, StylePropProps, "Flex")
+
+func (ev FlexDirections) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *FlexDirections) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// Dims returns the main and cross dimensions for this direction
+func (fd FlexDirections) Dims() (main, cross Dims2D) {
+	if fd == FlexColumn {
+		return Y, X
+	}
+	return X, Y
+}
+
+// FlexWraps determines whether items in a LayoutFlex layout wrap onto
+// multiple lines
+type FlexWraps int32
+
+const (
+	// FlexNoWrap keeps all items on one line, shrinking them as needed
+	FlexNoWrap FlexWraps = iota
+
+	// FlexWrap puts items that do not fit on a line onto the next line
+	FlexWrap
+
+	FlexWrapsN
+)
+
+//go:generate stringer -type=FlexWraps
+
+var KiT_FlexWraps = kit.Enums.AddEnumAltLower(FlexWrapsN, false, StylePropProps, "Flex")
+
+func (ev FlexWraps) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *FlexWraps) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// flexItem is the working data for one item in a LayoutFlex layout, along the
+// main axis
+type flexItem struct {
+	ni     *WidgetBase
+	basis  float32 // flex base size
+	hyp    float32 // hypothetical size: basis clamped to min, max
+	min    float32 // minimum size: Need, or hyp if it cannot shrink
+	max    float32 // maximum size, 0 = none
+	grow   float32 // effective flex-grow factor
+	shrink float32 // flex-shrink factor
+	size   float32 // resolved size
+	viol   float32 // min / max violation in current resolve pass
+	frozen bool
+}
+
+// newFlexItem returns the flexItem for given widget along main dim
+func newFlexItem(ni *WidgetBase, md Dims2D) flexItem {
+	lst := &ni.Sty.Layout
+	sp := &ni.LayData.Size
+	it := flexItem{ni: ni, grow: lst.FlexGrow, shrink: lst.FlexShrink}
+	it.basis = sp.Pref.Dim(md)
+	if lst.FlexBasis.Dots > 0 {
+		it.basis = lst.FlexBasis.Dots
+	}
+	it.min = sp.Need.Dim(md)
+	it.max = Max32(sp.Max.Dim(md), 0)
+	it.hyp = it.clamp(it.basis)
+	if it.shrink <= 0 {
+		it.min = it.hyp
+	}
+	return it
+}
+
+// clamp returns size clamped to the min and max of the item
+func (it *flexItem) clamp(size float32) float32 {
+	if it.max > 0 && size > it.max {
+		size = it.max
+	}
+	return Max32(size, it.min)
+}
+
+// factor returns the flex factor for growing or shrinking
+func (it *flexItem) factor(grow bool) float32 {
+	if grow {
+		return it.grow
+	}
+	return it.shrink * it.basis
+}
+
+// flexLine is one line of items in a LayoutFlex layout
+type flexLine struct {
+	items []flexItem
+	cross float32 // size along cross axis
+	pos   float32 // position along cross axis
+}
+
+// flexSpacing returns the starting offset and the additional space between
+// items for distributing given free space among n items, according to given
+// justify-content or align-content alignment
+func flexSpacing(al Align, free float32, n int) (start, between float32) {
+	if free <= 0 || n == 0 {
+		return 0, 0
+	}
+	switch {
+	case al == AlignJustify || al == AlignSpaceBetween:
+		if n > 1 {
+			between = free / float32(n-1)
+		}
+	case al == AlignSpaceAround:
+		between = free / float32(n)
+		start = 0.5 * between
+	case al == AlignSpaceEvenly:
+		between = free / float32(n+1)
+		start = between
+	case IsAlignMiddle(al):
+		start = 0.5 * free
+	case IsAlignEnd(al):
+		start = free
+	}
+	return
+}
+
+// flexResolve resolves the flexible sizes of the items on one line given the
+// available size along main axis md (excluding spacing), according to the
+// CSS flexbox algorithm: items are grown or shrunk in proportion to their
+// flex factors, and those that would violate their min or max are frozen at
+// that size, with the remaining space redistributed among the others
+func flexResolve(items []flexItem, avail float32, md Dims2D) {
+	sumHyp := float32(0)
+	anyGrow := false
+	for i := range items {
+		sumHyp += items[i].hyp
+		if items[i].grow > 0 {
+			anyGrow = true
+		}
+	}
+	grow := sumHyp < avail
+	for i := range items {
+		it := &items[i]
+		if grow && !anyGrow && it.ni.LayData.Size.HasMaxStretch(md) {
+			it.grow = 1
+		}
+		it.size = it.hyp
+		it.frozen = it.factor(grow) <= 0 || (grow && it.basis > it.hyp) || (!grow && it.basis < it.hyp)
+	}
+	for {
+		free := avail
+		sumFact := float32(0)
+		nflex := 0
+		for i := range items {
+			it := &items[i]
+			if it.frozen {
+				free -= it.size
+			} else {
+				free -= it.basis
+				sumFact += it.factor(grow)
+				nflex++
+			}
+		}
+		if nflex == 0 {
+			return
+		}
+		if grow && sumFact < 1 { // less than full growth
+			free *= sumFact
+		}
+		totViol := float32(0)
+		for i := range items {
+			it := &items[i]
+			if it.frozen {
+				continue
+			}
+			targ := it.basis + free*it.factor(grow)/sumFact
+			it.size = it.clamp(targ)
+			it.viol = it.size - targ
+			totViol += it.viol
+		}
+		for i := range items {
+			it := &items[i]
+			if it.frozen {
+				continue
+			}
+			switch {
+			case math32.Abs(totViol) < 0.01:
+				it.frozen = true
+			case totViol > 0:
+				it.frozen = it.viol > 0
+			default:
+				it.frozen = it.viol < 0
+			}
+		}
+	}
+}
+
+// GatherSizesFlex is size first pass: gather the size information from the
+// children, LayoutFlex version -- along the main axis, the preferred size is
+// the sum of the item basis sizes, and the needed size is the sum of their
+// needed sizes, or the largest one if wrapping
+func (ly *Layout) GatherSizesFlex() {
+	sz := len(ly.Kids)
+	if sz == 0 {
+		return
+	}
+	lst := &ly.Sty.Layout
+	md, cd := lst.FlexDirection.Dims()
+
+	var sumNeed, sumPref, maxNeed float32
+	var crossNeed, crossPref float32
+	n := 0
+	for _, c := range ly.Kids {
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		ni.LayData.UpdateSizes()
+		it := newFlexItem(ni, md)
+		sumNeed += it.min
+		sumPref += it.hyp
+		maxNeed = Max32(maxNeed, it.min)
+		crossNeed = Max32(crossNeed, ni.LayData.Size.Need.Dim(cd))
+		crossPref = Max32(crossPref, ni.LayData.Size.Pref.Dim(cd))
+		n++
+	}
+	elspc := float32(0)
+	if n >= 2 {
+		elspc = float32(n-1) * ly.Spacing.Dots
+	}
+	var need, pref Vec2D
+	if lst.FlexWrap == FlexWrap {
+		need.SetDim(md, maxNeed)
+	} else {
+		need.SetDim(md, sumNeed+elspc)
+	}
+	pref.SetDim(md, sumPref+elspc)
+	need.SetDim(cd, crossNeed)
+	pref.SetDim(cd, crossPref)
+
+	for d := X; d <= Y; d++ {
+		if ly.LayData.Size.Pref.Dim(d) == 0 {
+			ly.LayData.Size.Need.SetMaxDim(d, need.Dim(d))
+			ly.LayData.Size.Pref.SetMaxDim(d, pref.Dim(d))
+		} else { // use target size from style
+			ly.LayData.Size.Need.SetDim(d, ly.LayData.Size.Pref.Dim(d))
+		}
+	}
+
+	spc := ly.Sty.BoxSpaceSides()
+	ly.LayData.Size.Need.SetAdd(spc.Size())
+	ly.LayData.Size.Pref.SetAdd(spc.Size())
+
+	ly.LayData.UpdateSizes() // enforce max and normal ordering, etc
+	if Layout2DTrace {
+		fmt.Printf("Size:   %v gather sizes flex need: %v, pref: %v, elspc: %v\n", ly.PathUnique(), ly.LayData.Size.Need, ly.LayData.Size.Pref, elspc)
+	}
+}
+
+// flexLines collects the children into lines that fit within given
+// available size along the main axis, if wrapping, else into one line
+func (ly *Layout) flexLines(md Dims2D, avail float32) []flexLine {
+	wrap := ly.Sty.Layout.FlexWrap == FlexWrap
+	var lines []flexLine
+	var cur flexLine
+	lsz := float32(0)
+	for _, c := range ly.Kids {
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		it := newFlexItem(ni, md)
+		if wrap && len(cur.items) > 0 && lsz+ly.Spacing.Dots+it.hyp > avail+0.1 {
+			lines = append(lines, cur)
+			cur = flexLine{}
+			lsz = 0
+		}
+		if len(cur.items) > 0 {
+			lsz += ly.Spacing.Dots
+		}
+		lsz += it.hyp
+		cur.items = append(cur.items, it)
+	}
+	if len(cur.items) > 0 {
+		lines = append(lines, cur)
+	}
+	return lines
+}
+
+// LayoutFlex manages overall LayoutFlex layout of children
+func (ly *Layout) LayoutFlex() {
+	if len(ly.Kids) == 0 {
+		return
+	}
+	lst := &ly.Sty.Layout
+	md, cd := lst.FlexDirection.Dims()
+	spc := ly.Sty.BoxSpaceSides()
+	availM := ly.LayData.AllocSize.Dim(md) - spc.Size().Dim(md)
+	availC := ly.LayData.AllocSize.Dim(cd) - spc.Size().Dim(cd)
+
+	lines := ly.flexLines(md, availM)
+	nl := len(lines)
+	sumCross := float32(0)
+	for li := range lines {
+		ln := &lines[li]
+		elspc := float32(len(ln.items)-1) * ly.Spacing.Dots
+		flexResolve(ln.items, availM-elspc, md)
+		for i := range ln.items {
+			ni := ln.items[i].ni
+			ln.cross = Max32(ln.cross, ni.LayData.Size.Pref.Dim(cd))
+		}
+		sumCross += ln.cross
+	}
+
+	// cross axis: a single line fills the container, otherwise align-content
+	if nl == 1 && lst.FlexWrap != FlexWrap {
+		lines[0].cross = Max32(availC, lines[0].cross)
+		lines[0].pos = spc.Pos().Dim(cd)
+	} else {
+		free := availC - sumCross - float32(nl-1)*ly.Spacing.Dots
+		start, between := float32(0), float32(0)
+		if lst.AlignContent == AlignStretch {
+			if free > 0 {
+				for li := range lines {
+					lines[li].cross += free / float32(nl)
+				}
+			}
+		} else {
+			start, between = flexSpacing(lst.AlignContent, free, nl)
+		}
+		pos := spc.Pos().Dim(cd) + start
+		for li := range lines {
+			lines[li].pos = pos
+			pos += lines[li].cross + ly.Spacing.Dots + between
+		}
+	}
+
+	for li := range lines {
+		ln := &lines[li]
+		used := float32(len(ln.items)-1) * ly.Spacing.Dots
+		for i := range ln.items {
+			used += ln.items[i].size
+		}
+		start, between := flexSpacing(lst.JustifyContent, availM-used, len(ln.items))
+		pos := spc.Pos().Dim(md) + start
+		for i := range ln.items {
+			it := &ln.items[i]
+			ni := it.ni
+			ni.LayData.AllocSize.SetDim(md, it.size)
+			ni.LayData.AllocPosRel.SetDim(md, pos)
+			pos += it.size + ly.Spacing.Dots + between
+
+			need := ni.LayData.Size.Need.Dim(cd)
+			pref := ni.LayData.Size.Pref.Dim(cd)
+			max := ni.LayData.Size.Max.Dim(cd)
+			var cpos, csize float32
+			if lst.AlignItems == AlignStretch {
+				csize = ln.cross
+				if max > 0 {
+					csize = Min32(csize, max)
+				}
+				csize = Max32(csize, need)
+			} else {
+				cpos, csize = ly.LayoutSharedDimImpl(ln.cross, need, pref, max, 0, lst.AlignItems)
+			}
+			ni.LayData.AllocSize.SetDim(cd, csize)
+			ni.LayData.AllocPosRel.SetDim(cd, ln.pos+cpos)
+			if Layout2DTrace {
+				fmt.Printf("Layout: %v flex line: %v Child: %v, pos: %v, size: %v\n", ly.PathUnique(), li, ni.UniqueNm, ni.LayData.AllocPosRel, ni.LayData.AllocSize)
+			}
+		}
+	}
+}
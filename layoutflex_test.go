@@ -0,0 +1,76 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+
+	"github.com/chewxy/math32"
+)
+
+func TestFlexSpacing(t *testing.T) {
+	tests := []struct {
+		al      Align
+		free    float32
+		n       int
+		start   float32
+		between float32
+	}{
+		{AlignLeft, 100, 3, 0, 0},
+		{AlignCenter, 100, 3, 50, 0},
+		{AlignRight, 100, 3, 100, 0},
+		{AlignJustify, 100, 3, 0, 50},
+		{AlignSpaceBetween, 100, 1, 0, 0},
+		{AlignSpaceAround, 90, 3, 15, 30},
+		{AlignSpaceEvenly, 100, 3, 25, 25},
+		{AlignCenter, -10, 3, 0, 0},
+		{AlignSpaceEvenly, 100, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		start, between := flexSpacing(tt.al, tt.free, tt.n)
+		if start != tt.start || between != tt.between {
+			t.Errorf("flexSpacing(%v, %v, %v): got %v, %v, expected %v, %v\n", tt.al, tt.free, tt.n, start, between, tt.start, tt.between)
+		}
+	}
+}
+
+// testFlexItem is the size and flex style of an item along X for flexResolve
+type testFlexItem struct {
+	need, pref, max, grow, shrink float32
+}
+
+func TestFlexResolve(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []testFlexItem
+		avail float32
+		cor   []float32
+	}{
+		{"grow", []testFlexItem{{0, 50, 0, 1, 1}, {0, 50, 0, 2, 1}}, 200, []float32{50 + 100.0/3, 50 + 200.0/3}},
+		{"grow to max", []testFlexItem{{0, 50, 60, 1, 1}, {0, 50, 0, 1, 1}}, 200, []float32{60, 140}},
+		{"partial grow", []testFlexItem{{0, 50, 0, 0.25, 1}, {0, 50, 0, 0.25, 1}}, 200, []float32{75, 75}},
+		{"stretch", []testFlexItem{{0, 50, 0, 0, 1}, {0, 50, -1, 0, 1}}, 200, []float32{50, 150}},
+		{"shrink", []testFlexItem{{0, 100, 0, 0, 1}, {0, 100, 0, 0, 1}}, 150, []float32{75, 75}},
+		{"shrink by basis", []testFlexItem{{0, 100, 0, 0, 1}, {0, 50, 0, 0, 1}}, 120, []float32{80, 40}},
+		{"shrink to need", []testFlexItem{{90, 100, 0, 0, 1}, {0, 100, 0, 0, 1}}, 150, []float32{90, 60}},
+		{"no shrink", []testFlexItem{{0, 100, 0, 0, 0}, {0, 100, 0, 0, 1}}, 150, []float32{100, 50}},
+		{"fits", []testFlexItem{{0, 100, 0, 0, 1}, {0, 100, 0, 0, 1}}, 200, []float32{100, 100}},
+	}
+	for _, tt := range tests {
+		items := make([]flexItem, len(tt.items))
+		for i, ti := range tt.items {
+			ni := &WidgetBase{}
+			ni.Sty.Layout.FlexGrow, ni.Sty.Layout.FlexShrink = ti.grow, ti.shrink
+			ni.LayData.Size.Need.X, ni.LayData.Size.Pref.X, ni.LayData.Size.Max.X = ti.need, ti.pref, ti.max
+			items[i] = newFlexItem(ni, X)
+		}
+		flexResolve(items, tt.avail, X)
+		for i := range items {
+			if math32.Abs(items[i].size-tt.cor[i]) > 0.01 {
+				t.Errorf("flexResolve %v item %v: got %v, expected %v\n", tt.name, i, items[i].size, tt.cor[i])
+			}
+		}
+	}
+}
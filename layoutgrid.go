@@ -0,0 +1,655 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ints"
+)
+
+// LayoutGridIrreg is a grid layout where children can span multiple rows and
+// columns, and the rows and columns (tracks) can have different sizes,
+// following the CSS grid layout model:
+//
+// * children are placed using row, col, row-span and col-span (a row or col
+//   >= 0 places the child explicitly, with 0 for the other if it is not set,
+//   otherwise it goes into the next free cells, row by row), or using
+//   grid-area with the name of an area in the
+//   grid-template-areas of the layout, e.g., "head head" "side main".
+//
+// * grid-template-columns and grid-template-rows specify the sizing of each
+//   track: a fixed size (e.g., 10em or 20%), a fraction of the remaining
+//   space (e.g., 1fr, 2fr), or auto (sized by the content), and repeat(n,
+//   tracks) -- additional tracks beyond those specified are auto.
+
+// GridTrack specifies the sizing of one row or column in a LayoutGridIrreg
+// layout
+type GridTrack struct {
+	Size units.Value `desc:"fixed size of the track, if not Auto and Fr is 0"`
+	Fr   float32     `desc:"fraction of the remaining space (fr units), if > 0"`
+	Auto bool        `desc:"track is sized by its content (auto)"`
+}
+
+// IsFixed returns true if the track has a fixed size
+func (gt *GridTrack) IsFixed() bool {
+	return !gt.Auto && gt.Fr == 0
+}
+
+// String implements the fmt.Stringer interface
+func (gt *GridTrack) String() string {
+	switch {
+	case gt.Auto:
+		return "auto"
+	case gt.Fr > 0:
+		return fmt.Sprintf("%gfr", gt.Fr)
+	}
+	return gt.Size.String()
+}
+
+// ParseGridTracks parses a grid-template-columns or grid-template-rows
+// track list, e.g., "10em 1fr auto 2fr" or "repeat(3, 1fr) 20%"
+func ParseGridTracks(str string) ([]GridTrack, error) {
+	var trs []GridTrack
+	rest := strings.TrimSpace(strings.ToLower(str))
+	for rest != "" {
+		if strings.HasPrefix(rest, "repeat(") {
+			ed := matchParen(rest, 6)
+			if ed < 0 {
+				return nil, fmt.Errorf("gi.ParseGridTracks: missing ) in: %v", str)
+			}
+			args := rest[7:ed]
+			ci := strings.Index(args, ",")
+			if ci < 0 {
+				return nil, fmt.Errorf("gi.ParseGridTracks: repeat needs count and tracks in: %v", str)
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(args[:ci]))
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("gi.ParseGridTracks: invalid repeat count in: %v", str)
+			}
+			rtrs, err := ParseGridTracks(args[ci+1:])
+			if err != nil {
+				return nil, err
+			}
+			for i := 0; i < n; i++ {
+				trs = append(trs, rtrs...)
+			}
+			rest = strings.TrimSpace(rest[ed+1:])
+			continue
+		}
+		tok := rest
+		if si := strings.IndexAny(rest, " \t"); si >= 0 {
+			tok = rest[:si]
+		}
+		rest = strings.TrimSpace(rest[len(tok):])
+		var gt GridTrack
+		switch {
+		case tok == "auto":
+			gt.Auto = true
+		case strings.HasSuffix(tok, "fr"):
+			fr, err := strconv.ParseFloat(strings.TrimSuffix(tok, "fr"), 32)
+			if err != nil || fr <= 0 {
+				return nil, fmt.Errorf("gi.ParseGridTracks: invalid fr value: %v in: %v", tok, str)
+			}
+			gt.Fr = float32(fr)
+		default:
+			gt.Size.SetString(tok)
+		}
+		trs = append(trs, gt)
+	}
+	return trs, nil
+}
+
+// ParseGridAreas parses grid-template-areas, which has a quoted string for
+// each row, with a name for each column in the row, e.g., "head head" "side
+// main" -- a . is an empty cell.  The area for each name is the rectangle
+// spanning all of its cells, with X = column, Y = row.
+func ParseGridAreas(str string) (map[string]image.Rectangle, error) {
+	var rows []string
+	rest := strings.TrimSpace(str)
+	if !strings.ContainsAny(rest, "\"'") {
+		rows = []string{rest}
+	} else {
+		for rest != "" {
+			q := rest[0]
+			if q != '"' && q != '\'' {
+				return nil, fmt.Errorf("gi.ParseGridAreas: rows must be quoted in: %v", str)
+			}
+			ed := strings.IndexByte(rest[1:], q)
+			if ed < 0 {
+				return nil, fmt.Errorf("gi.ParseGridAreas: missing quote in: %v", str)
+			}
+			rows = append(rows, rest[1:ed+1])
+			rest = strings.TrimSpace(rest[ed+2:])
+		}
+	}
+	areas := make(map[string]image.Rectangle)
+	for r, row := range rows {
+		for c, nm := range strings.Fields(row) {
+			if nm == "." {
+				continue
+			}
+			cell := image.Rect(c, r, c+1, r+1)
+			if ar, has := areas[nm]; has {
+				areas[nm] = ar.Union(cell)
+			} else {
+				areas[nm] = cell
+			}
+		}
+	}
+	return areas, nil
+}
+
+// SetGridTemplates parses the grid template strings into GridTracks and
+// GridAreas, if they have changed since last parsed -- called in
+// SetStylePost
+func (ls *LayoutStyle) SetGridTemplates() {
+	tmpls := [3]string{ls.GridTemplateRows, ls.GridTemplateCols, ls.GridTemplateAreas}
+	if tmpls == ls.gridTemplates {
+		return
+	}
+	ls.gridTemplates = tmpls
+	for rc := Row; rc < RowColN; rc++ {
+		ls.GridTracks[rc] = nil
+		if tmpls[rc] == "" {
+			continue
+		}
+		trs, err := ParseGridTracks(tmpls[rc])
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		ls.GridTracks[rc] = trs
+	}
+	ls.GridAreas = nil
+	if tmpls[2] != "" {
+		areas, err := ParseGridAreas(tmpls[2])
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		ls.GridAreas = areas
+	}
+}
+
+// GridCell returns the cell of a child in the grid, based on its style
+// settings: its named grid-area if set and found in given areas, or its row,
+// col (where negative, e.g., the default -1, for both means it is not placed
+// explicitly, returning false, and negative for one means 0), and its spans
+// (with a minimum of 1)
+func (ls *LayoutStyle) GridCell(areas map[string]image.Rectangle) (image.Rectangle, bool) {
+	if ls.GridArea != "" {
+		if ar, has := areas[ls.GridArea]; has {
+			return ar, true
+		}
+	}
+	span := image.Point{ints.MaxInt(ls.ColSpan, 1), ints.MaxInt(ls.RowSpan, 1)}
+	pos := image.Point{ints.MaxInt(ls.Col, 0), ints.MaxInt(ls.Row, 0)}
+	return image.Rectangle{Min: pos, Max: pos.Add(span)}, ls.Col >= 0 || ls.Row >= 0
+}
+
+// PlaceGridIrreg places the children in the cells of a LayoutGridIrreg grid,
+// setting GridCells and GridSize -- explicitly placed children first, and
+// then the rest in the first free cells that fit them, row by row
+func (ly *Layout) PlaceGridIrreg() {
+	sz := len(ly.Kids)
+	lst := &ly.Sty.Layout
+	cols := ints.MaxInt(lst.Columns, len(lst.GridTracks[Col]))
+	for _, ar := range lst.GridAreas {
+		cols = ints.MaxInt(cols, ar.Max.X)
+	}
+	if cap(ly.GridCells) >= sz {
+		ly.GridCells = ly.GridCells[:sz]
+	} else {
+		ly.GridCells = make([]image.Rectangle, sz)
+	}
+	auto := make([]bool, sz)
+	nauto := 0
+	for i, c := range ly.Kids {
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			ly.GridCells[i] = image.ZR
+			continue
+		}
+		cell, expl := ni.Sty.Layout.GridCell(lst.GridAreas)
+		ly.GridCells[i] = cell
+		if expl {
+			cols = ints.MaxInt(cols, cell.Max.X)
+		} else {
+			auto[i] = true
+			nauto++
+			cols = ints.MaxInt(cols, cell.Dx())
+		}
+	}
+	if cols == 0 {
+		cols = ints.MaxInt(int(math32.Sqrt(float32(nauto))), 1) // as in LayoutGrid
+	}
+
+	var occ [][]bool // occupied cells, by row
+	fill := func(cell image.Rectangle) {
+		for len(occ) < cell.Max.Y {
+			occ = append(occ, make([]bool, cols))
+		}
+		for r := cell.Min.Y; r < cell.Max.Y; r++ {
+			for c := cell.Min.X; c < cell.Max.X && c < cols; c++ {
+				occ[r][c] = true
+			}
+		}
+	}
+	free := func(cell image.Rectangle) bool {
+		for r := cell.Min.Y; r < cell.Max.Y && r < len(occ); r++ {
+			for c := cell.Min.X; c < cell.Max.X; c++ {
+				if occ[r][c] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	for i, cell := range ly.GridCells {
+		if !auto[i] && !cell.Empty() {
+			fill(cell)
+		}
+	}
+	pos := image.Point{} // auto-placement cursor
+	for i, cell := range ly.GridCells {
+		if !auto[i] {
+			continue
+		}
+		span := cell.Size()
+		if span.X > cols {
+			span.X = cols
+		}
+		for {
+			if pos.X+span.X > cols {
+				pos.X = 0
+				pos.Y++
+			}
+			cell = image.Rectangle{Min: pos, Max: pos.Add(span)}
+			if free(cell) {
+				break
+			}
+			pos.X++
+		}
+		ly.GridCells[i] = cell
+		fill(cell)
+		pos.X += span.X
+	}
+	rows := ints.MaxInt(len(occ), len(lst.GridTracks[Row]))
+	ly.GridSize = image.Point{cols, rows}
+}
+
+// gridTrack returns the track spec for given track index, which is auto if
+// not specified
+func (ly *Layout) gridTrack(rowcol RowCol, idx int) GridTrack {
+	trs := ly.Sty.Layout.GridTracks[rowcol]
+	if idx < len(trs) {
+		return trs[idx]
+	}
+	return GridTrack{Auto: true}
+}
+
+// gridTrackDots returns the size in dots of a fixed track, given the
+// available size in its dimension, for percent units
+func (ly *Layout) gridTrackDots(gt *GridTrack, avail float32) float32 {
+	if gt.Size.Un == units.Pct {
+		return 0.01 * gt.Size.Val * avail
+	}
+	return gt.Size.ToDots(&ly.Sty.UnContext)
+}
+
+// GatherSizesGridIrreg is size first pass: gather the size information from
+// the children, LayoutGridIrreg version -- each track gets the max of the
+// sizes of the children that only span it, and children that span multiple
+// tracks distribute whatever additional size they need among those tracks
+func (ly *Layout) GatherSizesGridIrreg() {
+	if len(ly.Kids) == 0 {
+		return
+	}
+	ly.Sty.Layout.SetGridTemplates()
+	ly.PlaceGridIrreg()
+	rows, cols := ly.GridSize.Y, ly.GridSize.X
+	for rc := Row; rc < RowColN; rc++ {
+		n := rows
+		if rc == Col {
+			n = cols
+		}
+		if len(ly.GridData[rc]) != n {
+			ly.GridData[rc] = make([]GridData, n)
+		}
+		dim := X
+		if rc == Row {
+			dim = Y
+		}
+		pref := ly.LayData.Size.Pref.Dim(dim)
+		for i := range ly.GridData[rc] {
+			gd := &ly.GridData[rc][i]
+			*gd = GridData{}
+			gt := ly.gridTrack(rc, i)
+			switch {
+			case gt.Fr > 0:
+				gd.SizeMax = -1
+			case gt.IsFixed():
+				fs := ly.gridTrackDots(&gt, pref)
+				gd.SizeNeed, gd.SizePref, gd.SizeMax = fs, fs, fs
+			}
+		}
+	}
+
+	for pass := 0; pass < 2; pass++ { // single spans first, then multiple
+		for i, c := range ly.Kids {
+			ni := c.(Node2D).AsWidget()
+			if ni == nil {
+				continue
+			}
+			if pass == 0 {
+				ni.LayData.UpdateSizes()
+			}
+			cell := ly.GridCells[i]
+			for rc := Row; rc < RowColN; rc++ {
+				dim, st, ed := Y, cell.Min.Y, cell.Max.Y
+				if rc == Col {
+					dim, st, ed = X, cell.Min.X, cell.Max.X
+				}
+				if (ed-st == 1) != (pass == 0) {
+					continue
+				}
+				ly.gridSpanSizes(rc, st, ed, ni.LayData.Size.Need.Dim(dim), ni.LayData.Size.Pref.Dim(dim), ni.LayData.Size.Max.Dim(dim))
+			}
+		}
+	}
+
+	var sumPref, sumNeed Vec2D
+	for _, gd := range ly.GridData[Row] {
+		sumNeed.SetAddDim(Y, gd.SizeNeed)
+		sumPref.SetAddDim(Y, gd.SizePref)
+	}
+	for _, gd := range ly.GridData[Col] {
+		sumNeed.SetAddDim(X, gd.SizeNeed)
+		sumPref.SetAddDim(X, gd.SizePref)
+	}
+	for d := X; d <= Y; d++ {
+		if ly.LayData.Size.Pref.Dim(d) == 0 {
+			ly.LayData.Size.Need.SetMaxDim(d, sumNeed.Dim(d))
+			ly.LayData.Size.Pref.SetMaxDim(d, sumPref.Dim(d))
+		} else { // use target size from style otherwise
+			ly.LayData.Size.Need.SetDim(d, ly.LayData.Size.Pref.Dim(d))
+		}
+	}
+
+	spc := ly.Sty.BoxSpaceSides()
+	ly.LayData.Size.Need.SetAdd(spc.Size())
+	ly.LayData.Size.Pref.SetAdd(spc.Size())
+	elspc := NewVec2D(float32(ints.MaxInt(cols-1, 0)), float32(ints.MaxInt(rows-1, 0))).MulVal(ly.Spacing.Dots)
+	ly.LayData.Size.Need.SetAdd(elspc)
+	ly.LayData.Size.Pref.SetAdd(elspc)
+
+	ly.LayData.UpdateSizes() // enforce max and normal ordering, etc
+	if Layout2DTrace {
+		fmt.Printf("Size:   %v gather sizes grid irreg: %v need: %v, pref: %v\n", ly.PathUnique(), ly.GridSize, ly.LayData.Size.Need, ly.LayData.Size.Pref)
+	}
+}
+
+// gridSpanSizes updates the sizes of the tracks from st to ed (exclusive) to
+// accommodate a child with given need, pref and max sizes -- a single track
+// takes the max, and any additional size needed across multiple tracks is
+// distributed equally among the non-fixed tracks (or all if all are fixed)
+func (ly *Layout) gridSpanSizes(rowcol RowCol, st, ed int, need, pref, max float32) {
+	gds := ly.GridData[rowcol]
+	if ed-st == 1 {
+		gd := &gds[st]
+		if gt := ly.gridTrack(rowcol, st); gt.IsFixed() {
+			return
+		}
+		SetMax32(&gd.SizeNeed, need)
+		SetMax32(&gd.SizePref, pref)
+		if gd.SizeMax >= 0 { // any -1 stretch dominates, else accumulate any max
+			if max < 0 {
+				gd.SizeMax = -1
+			} else {
+				SetMax32(&gd.SizeMax, max)
+			}
+		}
+		return
+	}
+	spc := float32(ed-st-1) * ly.Spacing.Dots
+	var curNeed, curPref float32
+	nflex := 0
+	for i := st; i < ed; i++ {
+		curNeed += gds[i].SizeNeed
+		curPref += gds[i].SizePref
+		if gt := ly.gridTrack(rowcol, i); !gt.IsFixed() {
+			nflex++
+		}
+	}
+	n := nflex
+	if n == 0 {
+		n = ed - st
+	}
+	dneed := (need - spc - curNeed) / float32(n)
+	dpref := (pref - spc - curPref) / float32(n)
+	for i := st; i < ed; i++ {
+		if gt := ly.gridTrack(rowcol, i); nflex > 0 && gt.IsFixed() {
+			continue
+		}
+		gd := &gds[i]
+		if dneed > 0 {
+			gd.SizeNeed += dneed
+		}
+		if dpref > 0 {
+			gd.SizePref += dpref
+		}
+		gd.SizePref = Max32(gd.SizePref, gd.SizeNeed)
+		if max < 0 && gd.SizeMax >= 0 {
+			gd.SizeMax = -1
+		}
+	}
+}
+
+// LayoutGridIrregDim allocates the sizes and positions of the tracks along
+// given dimension: fixed tracks get their size, auto tracks their preferred
+// (or needed, if there is not enough space) size, and fr tracks share the
+// remaining space in proportion to their fr values, while still getting at
+// least their content size.  If there are no fr tracks, any extra space goes
+// to auto tracks with stretchy content, or is used according to the
+// alignment of the layout.
+func (ly *Layout) LayoutGridIrregDim(rowcol RowCol, dim Dims2D) {
+	gds := ly.GridData[rowcol]
+	sz := len(gds)
+	if sz == 0 {
+		return
+	}
+	elspc := float32(sz-1) * ly.Spacing.Dots
+	spc := ly.Sty.BoxSpaceSides()
+	avail := ly.LayData.AllocSize.Dim(dim) - (spc.Size().Dim(dim) + elspc)
+
+	var sumPref, sumNeed float32
+	for i := range gds {
+		gt := ly.gridTrack(rowcol, i)
+		if gt.IsFixed() && gt.Size.Un == units.Pct {
+			fs := ly.gridTrackDots(&gt, avail)
+			gds[i].SizeNeed, gds[i].SizePref = fs, fs
+		}
+		sumPref += gds[i].SizePref
+		sumNeed += gds[i].SizeNeed
+	}
+	usePref := sumPref <= avail+0.1
+	var frTot, inflex float32
+	flex := make([]bool, sz)
+	for i := range gds {
+		gd := &gds[i]
+		gd.AllocSize = gd.SizeNeed
+		if usePref {
+			gd.AllocSize = gd.SizePref
+		}
+		if gt := ly.gridTrack(rowcol, i); gt.Fr > 0 {
+			flex[i] = true
+			frTot += gt.Fr
+		} else {
+			inflex += gd.AllocSize
+		}
+	}
+	// find the size of 1fr: tracks whose content is larger than their share
+	// are sized to their content, and the rest share what remains
+	for frTot > 0 {
+		frSize := Max32(avail-inflex, 0) / frTot
+		changed := false
+		for i := range gds {
+			if !flex[i] {
+				continue
+			}
+			gt := ly.gridTrack(rowcol, i)
+			if gds[i].AllocSize > gt.Fr*frSize {
+				flex[i] = false
+				frTot -= gt.Fr
+				inflex += gds[i].AllocSize
+				changed = true
+			}
+		}
+		if !changed {
+			for i := range gds {
+				if flex[i] {
+					gds[i].AllocSize = ly.gridTrack(rowcol, i).Fr * frSize
+				}
+			}
+			break
+		}
+	}
+
+	used := float32(0)
+	hasFr := false
+	for i := range gds {
+		used += gds[i].AllocSize
+		if ly.gridTrack(rowcol, i).Fr > 0 {
+			hasFr = true
+		}
+	}
+	extra := Max32(avail-used, 0)
+	al := ly.Sty.Layout.AlignDim(dim)
+	pos := spc.Pos().Dim(dim)
+	extraSpace := float32(0)
+	if extra > 0 && !hasFr {
+		stretchTot := float32(0)
+		for i := range gds {
+			if gds[i].SizeMax < 0 {
+				stretchTot += Max32(gds[i].AllocSize, 1)
+			}
+		}
+		switch {
+		case stretchTot > 0:
+			for i := range gds {
+				if gds[i].SizeMax < 0 {
+					gds[i].AllocSize += extra * Max32(gds[i].AllocSize, 1) / stretchTot
+				}
+			}
+		case al == AlignJustify && sz > 1:
+			extraSpace = extra / float32(sz-1)
+		case IsAlignMiddle(al):
+			pos += 0.5 * extra
+		case IsAlignEnd(al):
+			pos += extra
+		}
+	}
+	if Layout2DTrace {
+		fmt.Printf("Layout Grid Irreg Dim: %v dim %v, avail: %v need: %v pref: %v usePref: %v extra: %v\n", ly.PathUnique(), dim, avail, sumNeed, sumPref, usePref, extra)
+	}
+	for i := range gds {
+		gd := &gds[i]
+		gd.AllocPosRel = pos
+		pos += gd.AllocSize + ly.Spacing.Dots + extraSpace
+	}
+}
+
+// LayoutGridIrreg manages overall LayoutGridIrreg layout of children --
+// each child is laid out within the region spanned by its cells
+func (ly *Layout) LayoutGridIrreg() {
+	if len(ly.Kids) == 0 || len(ly.GridCells) != len(ly.Kids) {
+		return
+	}
+	ly.LayoutGridIrregDim(Row, Y)
+	ly.LayoutGridIrregDim(Col, X)
+
+	for i, c := range ly.Kids {
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		cell := ly.GridCells[i]
+		lst := ni.Sty.Layout
+		for rc := Row; rc < RowColN; rc++ {
+			dim, st, ed := Y, cell.Min.Y, cell.Max.Y
+			if rc == Col {
+				dim, st, ed = X, cell.Min.X, cell.Max.X
+			}
+			gds := ly.GridData[rc]
+			if st >= len(gds) {
+				continue
+			}
+			ed = ints.MinInt(ed, len(gds))
+			gpos := gds[st].AllocPosRel
+			avail := gds[ed-1].AllocPosRel + gds[ed-1].AllocSize - gpos
+			al := lst.AlignDim(dim)
+			pref := ni.LayData.Size.Pref.Dim(dim)
+			need := ni.LayData.Size.Need.Dim(dim)
+			max := ni.LayData.Size.Max.Dim(dim)
+			pos, size := ly.LayoutSharedDimImpl(avail, need, pref, max, 0, al)
+			ni.LayData.AllocSize.SetDim(dim, size)
+			ni.LayData.AllocPosRel.SetDim(dim, pos+gpos)
+		}
+		if Layout2DTrace {
+			fmt.Printf("Layout: %v grid irreg cell: %v pos: %v size: %v\n", ly.PathUnique(), cell, ni.LayData.AllocPosRel, ni.LayData.AllocSize)
+		}
+	}
+}
+
+// GridIrregNeighbor returns the index of the child whose grid cell is next
+// to that of the child at given index, in given direction along the rows
+// (updn) or columns, with wraparound -- for keyboard navigation -- returns -1
+// if none
+func (ly *Layout) GridIrregNeighbor(idx int, updn bool, next bool) int {
+	if idx < 0 || idx >= len(ly.GridCells) {
+		return -1
+	}
+	cur := ly.GridCells[idx]
+	best := -1
+	bestDist := 0
+	n := ly.GridSize.X
+	if updn {
+		n = ly.GridSize.Y
+	}
+	for i, cell := range ly.GridCells {
+		if i == idx || cell.Empty() {
+			continue
+		}
+		var dist int
+		if updn {
+			if cell.Max.X <= cur.Min.X || cell.Min.X >= cur.Max.X { // not in same cols
+				continue
+			}
+			dist = cell.Min.Y - cur.Min.Y
+		} else {
+			if cell.Max.Y <= cur.Min.Y || cell.Min.Y >= cur.Max.Y { // not in same rows
+				continue
+			}
+			dist = cell.Min.X - cur.Min.X
+		}
+		if !next {
+			dist = -dist
+		}
+		if dist <= 0 {
+			dist += n + 1 // wraparound
+		}
+		if best < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
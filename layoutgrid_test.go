@@ -0,0 +1,201 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"testing"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/gi/units"
+)
+
+func TestParseGridTracks(t *testing.T) {
+	px := func(v float32) GridTrack { return GridTrack{Size: units.NewValue(v, units.Px)} }
+	fr := func(v float32) GridTrack { return GridTrack{Fr: v} }
+	auto := GridTrack{Auto: true}
+	tests := []struct {
+		str string
+		cor []GridTrack
+		err bool
+	}{
+		{"10px 1fr auto", []GridTrack{px(10), fr(1), auto}, false},
+		{"  2fr   AUTO ", []GridTrack{fr(2), auto}, false},
+		{"repeat(3, 1fr) 20%", []GridTrack{fr(1), fr(1), fr(1), {Size: units.NewValue(20, units.Pct)}}, false},
+		{"auto repeat(2, 5px auto)", []GridTrack{auto, px(5), auto, px(5), auto}, false},
+		{"", nil, false},
+		{"repeat(0, 1fr)", nil, true},
+		{"repeat(2 1fr)", nil, true},
+		{"repeat(2, 1fr", nil, true},
+		{"0fr", nil, true},
+		{"xfr", nil, true},
+	}
+	for _, tt := range tests {
+		trs, err := ParseGridTracks(tt.str)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseGridTracks(%q): expected an error, got: %v\n", tt.str, trs)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseGridTracks(%q): %v\n", tt.str, err)
+			continue
+		}
+		if len(trs) != len(tt.cor) {
+			t.Errorf("ParseGridTracks(%q): got %v, expected %v\n", tt.str, trs, tt.cor)
+			continue
+		}
+		for i := range trs {
+			if trs[i] != tt.cor[i] {
+				t.Errorf("ParseGridTracks(%q) track %v: got %v, expected %v\n", tt.str, i, trs[i], tt.cor[i])
+			}
+		}
+	}
+}
+
+func TestParseGridAreas(t *testing.T) {
+	tests := []struct {
+		str string
+		cor map[string]image.Rectangle
+		err bool
+	}{
+		{`"head head" "side main"`, map[string]image.Rectangle{
+			"head": image.Rect(0, 0, 2, 1), "side": image.Rect(0, 1, 1, 2), "main": image.Rect(1, 1, 2, 2)}, false},
+		{`'a . b' 'a . b'`, map[string]image.Rectangle{
+			"a": image.Rect(0, 0, 1, 2), "b": image.Rect(2, 0, 3, 2)}, false},
+		{`one two`, map[string]image.Rectangle{
+			"one": image.Rect(0, 0, 1, 1), "two": image.Rect(1, 0, 2, 1)}, false},
+		{`"a b" c`, nil, true},
+		{`"a b`, nil, true},
+	}
+	for _, tt := range tests {
+		areas, err := ParseGridAreas(tt.str)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseGridAreas(%q): expected an error, got: %v\n", tt.str, areas)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseGridAreas(%q): %v\n", tt.str, err)
+			continue
+		}
+		if len(areas) != len(tt.cor) {
+			t.Errorf("ParseGridAreas(%q): got %v, expected %v\n", tt.str, areas, tt.cor)
+			continue
+		}
+		for nm, ar := range tt.cor {
+			if areas[nm] != ar {
+				t.Errorf("ParseGridAreas(%q) area %v: got %v, expected %v\n", tt.str, nm, areas[nm], ar)
+			}
+		}
+	}
+}
+
+func TestGridCell(t *testing.T) {
+	areas := map[string]image.Rectangle{"main": image.Rect(1, 1, 3, 2)}
+	tests := []struct {
+		row, col, rspan, cspan int
+		area                   string
+		cor                    image.Rectangle
+		expl                   bool
+	}{
+		{-1, -1, 0, 0, "", image.Rect(0, 0, 1, 1), false},
+		{-1, -1, 2, 3, "", image.Rect(0, 0, 3, 2), false},
+		{0, 0, 0, 0, "", image.Rect(0, 0, 1, 1), true},
+		{2, -1, 0, 0, "", image.Rect(0, 2, 1, 3), true},
+		{-1, 3, 2, 0, "", image.Rect(3, 0, 4, 2), true},
+		{-1, -1, 0, 0, "main", image.Rect(1, 1, 3, 2), true},
+		{1, 0, 0, 0, "nosuch", image.Rect(0, 1, 1, 2), true},
+	}
+	for _, tt := range tests {
+		ls := LayoutStyle{Row: tt.row, Col: tt.col, RowSpan: tt.rspan, ColSpan: tt.cspan, GridArea: tt.area}
+		cell, expl := ls.GridCell(areas)
+		if cell != tt.cor || expl != tt.expl {
+			t.Errorf("GridCell(row %v, col %v, spans %v, %v, area %q): got %v, %v, expected %v, %v\n", tt.row, tt.col, tt.rspan, tt.cspan, tt.area, cell, expl, tt.cor, tt.expl)
+		}
+	}
+}
+
+func TestPlaceGridIrreg(t *testing.T) {
+	ly := &Layout{}
+	ly.InitName(ly, "grid")
+	ly.Sty.Layout.GridTemplateCols = "1fr 1fr 1fr"
+	ly.Sty.Layout.GridTemplateAreas = `"head head head"`
+	ly.Sty.Layout.SetGridTemplates()
+	type place struct {
+		row, col, rspan, cspan int
+		area                   string
+	}
+	places := []place{
+		{-1, -1, 0, 0, ""},     // a: first free cell after the head
+		{-1, -1, 0, 0, "head"}, // b: head area
+		{1, 2, 2, 0, ""},       // c: explicit, spans 2 rows
+		{-1, -1, 0, 2, ""},     // d: needs 2 free columns, so goes to next row
+		{-1, -1, 0, 0, ""},     // e: next free cell after d
+	}
+	cor := []image.Rectangle{
+		image.Rect(0, 1, 1, 2),
+		image.Rect(0, 0, 3, 1),
+		image.Rect(2, 1, 3, 3),
+		image.Rect(0, 2, 2, 3),
+		image.Rect(0, 3, 1, 4),
+	}
+	for i, pl := range places {
+		lb := ly.AddNewChild(KiT_Label, string(rune('a'+i))).(*Label)
+		lst := &lb.Sty.Layout
+		lst.Row, lst.Col, lst.RowSpan, lst.ColSpan, lst.GridArea = pl.row, pl.col, pl.rspan, pl.cspan, pl.area
+	}
+	ly.PlaceGridIrreg()
+	if ly.GridSize != image.Pt(3, 4) {
+		t.Errorf("PlaceGridIrreg GridSize: got %v, expected %v\n", ly.GridSize, image.Pt(3, 4))
+	}
+	for i, cell := range ly.GridCells {
+		if cell != cor[i] {
+			t.Errorf("PlaceGridIrreg child %v: got %v, expected %v\n", i, cell, cor[i])
+		}
+	}
+}
+
+func TestLayoutGridIrregDim(t *testing.T) {
+	tests := []struct {
+		tracks string
+		align  Align
+		gds    []GridData // SizeNeed, SizePref, SizeMax
+		sizes  []float32
+		poss   []float32
+	}{
+		{"100px 1fr 2fr", AlignLeft, []GridData{{SizeNeed: 100, SizePref: 100, SizeMax: 100}, {SizeMax: -1}, {SizeMax: -1}},
+			[]float32{100, 200.0 / 3, 400.0 / 3}, []float32{0, 100, 100 + 200.0/3}},
+		{"1fr 1fr", AlignLeft, []GridData{{SizeNeed: 200, SizePref: 200, SizeMax: -1}, {SizeMax: -1}},
+			[]float32{200, 100}, []float32{0, 200}},
+		{"auto auto", AlignLeft, []GridData{{SizeNeed: 50, SizePref: 50}, {SizeNeed: 50, SizePref: 50, SizeMax: -1}},
+			[]float32{50, 250}, []float32{0, 50}},
+		{"auto auto", AlignLeft, []GridData{{SizeNeed: 100, SizePref: 200, SizeMax: -1}, {SizeNeed: 100, SizePref: 200, SizeMax: -1}},
+			[]float32{150, 150}, []float32{0, 150}},
+		{"20% auto", AlignCenter, []GridData{{}, {SizeNeed: 40, SizePref: 40}},
+			[]float32{60, 40}, []float32{100, 160}},
+		{"auto auto", AlignRight, []GridData{{SizeNeed: 50, SizePref: 50}, {SizeNeed: 50, SizePref: 50}},
+			[]float32{50, 50}, []float32{200, 250}},
+		{"auto auto auto", AlignJustify, []GridData{{SizeNeed: 50, SizePref: 50}, {SizeNeed: 50, SizePref: 50}, {SizeNeed: 50, SizePref: 50}},
+			[]float32{50, 50, 50}, []float32{0, 125, 250}},
+	}
+	for _, tt := range tests {
+		ly := &Layout{}
+		ly.InitName(ly, "grid")
+		ly.Sty.Layout.GridTemplateCols = tt.tracks
+		ly.Sty.Layout.SetGridTemplates()
+		ly.Sty.Layout.AlignH = tt.align
+		ly.LayData.AllocSize.X = 300
+		ly.GridData[Col] = tt.gds
+		ly.LayoutGridIrregDim(Col, X)
+		for i, gd := range ly.GridData[Col] {
+			if math32.Abs(gd.AllocSize-tt.sizes[i]) > 0.01 || math32.Abs(gd.AllocPosRel-tt.poss[i]) > 0.01 {
+				t.Errorf("LayoutGridIrregDim(%q) track %v: got size %v pos %v, expected size %v pos %v\n", tt.tracks, i, gd.AllocSize, gd.AllocPosRel, tt.sizes[i], tt.poss[i])
+			}
+		}
+	}
+}
@@ -7,9 +7,9 @@ import (
 	"strconv"
 )
 
-const _Layouts_name = "LayoutHorizLayoutVertLayoutGridLayoutHorizFlowLayoutVertFlowLayoutStackedLayoutNilLayoutsN"
+const _Layouts_name = "LayoutHorizLayoutVertLayoutGridLayoutGridIrregLayoutHorizFlowLayoutVertFlowLayoutFlexLayoutStackedLayoutNilLayoutsN"
 
-var _Layouts_index = [...]uint8{0, 11, 21, 31, 46, 60, 73, 82, 90}
+var _Layouts_index = [...]uint8{0, 11, 21, 31, 46, 61, 75, 85, 98, 107, 115}
 
 func (i Layouts) String() string {
 	if i < 0 || i >= Layouts(len(_Layouts_index)-1) {
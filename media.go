@@ -0,0 +1,332 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+)
+
+// CSS @media rules: StyleSheet.CSSProps stores the rules within an @media
+// rule under a key of "@media " + the query, e.g., "@media (max-width:
+// 600px)", as a ki.Props of selectors and their properties, just like the
+// top-level rules.  MatchCSS evaluates the query for each node against the
+// MediaContext of its window, so the rules are re-evaluated whenever the
+// window is re-styled, which includes whenever it is resized (Window.Resized)
+// or zoomed (Window.ZoomDPI).  The same keys can be used directly in ki.Props
+// style sheets, e.g., in the CSS of a node or in Prefs CustomStyles.
+
+// MediaPrefix is the prefix of @media rule keys in css props
+const MediaPrefix = "@media"
+
+// MediaContext has the values against which @media queries are evaluated
+type MediaContext struct {
+	Width  float32 `desc:"width of the window viewport in raw display dots"`
+	Height float32 `desc:"height of the window viewport in raw display dots"`
+	DPI    float32 `desc:"logical DPI of the window"`
+	Dark   bool    `desc:"true if the current color theme is dark (i.e., the background color is dark) -- for prefers-color-scheme"`
+}
+
+// MediaContextForNode returns the MediaContext for given node, from the
+// main viewport of its window, or its own viewport if not in a window
+func MediaContextForNode(node ki.Ki) *MediaContext {
+	mc := &MediaContext{DPI: units.PxPerInch}
+	var vp *Viewport2D
+	if _, nb := KiToNode2D(node); nb != nil {
+		vp = nb.Viewport
+	}
+	if vp != nil && vp.Win != nil {
+		mc.DPI = vp.Win.LogicalDPI()
+		if vp.Win.Viewport != nil {
+			vp = vp.Win.Viewport
+		}
+	}
+	if vp != nil {
+		mc.Width = float32(vp.Geom.Size.X)
+		mc.Height = float32(vp.Geom.Size.Y)
+	}
+	_, _, l, _ := Prefs.Colors.Background.ToHSLA()
+	mc.Dark = l < 0.5
+	return mc
+}
+
+// unitContext returns a units.Context for converting lengths in media
+// queries: relative font units are relative to the default 12pt font, and
+// viewport units to the viewport size
+func (mc *MediaContext) unitContext() *units.Context {
+	uc := &units.Context{}
+	uc.Defaults()
+	uc.DPI = mc.DPI
+	em := 12 * mc.DPI / units.PtPerInch
+	uc.SetFont(em, 0.5*em, 0.5*em, em)
+	uc.SetSizes(mc.Width, mc.Height, mc.Width, mc.Height)
+	return uc
+}
+
+// MediaFeature is one (feature: value) condition in a media query
+type MediaFeature struct {
+	Name string `desc:"name of the feature, e.g., max-width"`
+	Val  string `desc:"the value to compare with, e.g., 600px"`
+}
+
+// MediaQueryPart is one of the comma-separated alternatives in a media
+// query, e.g., screen and (max-width: 600px), all of whose parts must match
+type MediaQueryPart struct {
+	Not      bool           `desc:"not -- negates the entire alternative"`
+	Type     string         `desc:"media type: all, screen, or print -- we only ever match all and screen"`
+	Features []MediaFeature `desc:"features, all of which must match"`
+}
+
+// MediaQuery is a parsed @media query, which matches if any of its Parts do
+type MediaQuery struct {
+	Query string           `desc:"the original query string"`
+	Parts []MediaQueryPart `desc:"comma-separated alternatives, any of which can match"`
+}
+
+// MediaFeatures are the supported media query features -- the min- and max-
+// versions of the numeric features are also supported
+var MediaFeatures = map[string]bool{
+	"width":                true,
+	"height":               true,
+	"resolution":           true,
+	"aspect-ratio":         true,
+	"orientation":          true,
+	"prefers-color-scheme": true,
+}
+
+// ParseMediaQuery parses a media query, e.g., "screen and (max-width:
+// 600px), (prefers-color-scheme: dark)"
+func ParseMediaQuery(query string) (*MediaQuery, error) {
+	mq := &MediaQuery{Query: strings.TrimSpace(query)}
+	for _, alt := range strings.Split(mq.Query, ",") {
+		var pt MediaQueryPart
+		rest := strings.TrimSpace(strings.ToLower(alt))
+		if rest == "" {
+			return nil, fmt.Errorf("gi.ParseMediaQuery: empty alternative in: %v", query)
+		}
+		first := true
+		for rest != "" {
+			if rest[0] == '(' {
+				ed := strings.Index(rest, ")")
+				if ed < 0 {
+					return nil, fmt.Errorf("gi.ParseMediaQuery: missing ) in: %v", query)
+				}
+				ft := rest[1:ed]
+				var mf MediaFeature
+				if ci := strings.Index(ft, ":"); ci >= 0 {
+					mf.Name = strings.TrimSpace(ft[:ci])
+					mf.Val = strings.TrimSpace(ft[ci+1:])
+				} else {
+					mf.Name = strings.TrimSpace(ft)
+				}
+				if !MediaFeatures[strings.TrimPrefix(strings.TrimPrefix(mf.Name, "min-"), "max-")] {
+					return nil, fmt.Errorf("gi.ParseMediaQuery: feature %v not supported in: %v", mf.Name, query)
+				}
+				pt.Features = append(pt.Features, mf)
+				rest = strings.TrimSpace(rest[ed+1:])
+				first = false
+				continue
+			}
+			wd := rest
+			if si := strings.IndexAny(rest, " ("); si >= 0 {
+				wd = rest[:si]
+			}
+			rest = strings.TrimSpace(rest[len(wd):])
+			switch {
+			case wd == "and" && !first:
+			case wd == "not" && first:
+				pt.Not = true
+			case wd == "only" && first:
+			case (wd == "all" || wd == "screen" || wd == "print") && pt.Type == "":
+				pt.Type = wd
+				first = false
+			default:
+				return nil, fmt.Errorf("gi.ParseMediaQuery: unexpected %q in: %v", wd, query)
+			}
+		}
+		mq.Parts = append(mq.Parts, pt)
+	}
+	return mq, nil
+}
+
+// String implements the fmt.Stringer interface
+func (mq *MediaQuery) String() string {
+	return mq.Query
+}
+
+// Matches returns true if the query matches given media context
+func (mq *MediaQuery) Matches(mc *MediaContext) bool {
+	for i := range mq.Parts {
+		if mq.Parts[i].Matches(mc) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if this alternative matches given media context
+func (pt *MediaQueryPart) Matches(mc *MediaContext) bool {
+	match := pt.Type != "print"
+	if match {
+		for i := range pt.Features {
+			if !pt.Features[i].Matches(mc) {
+				match = false
+				break
+			}
+		}
+	}
+	if pt.Not {
+		return !match
+	}
+	return match
+}
+
+// Matches returns true if the feature matches given media context -- a
+// feature without a value matches if it is non-zero
+func (mf *MediaFeature) Matches(mc *MediaContext) bool {
+	name := mf.Name
+	cmp := 0 // 0 = equal, -1 = max (<=), +1 = min (>=)
+	switch {
+	case strings.HasPrefix(name, "min-"):
+		cmp = 1
+		name = name[4:]
+	case strings.HasPrefix(name, "max-"):
+		cmp = -1
+		name = name[4:]
+	}
+	var cur, val float32
+	switch name {
+	case "prefers-color-scheme":
+		switch mf.Val {
+		case "dark":
+			return mc.Dark
+		case "light":
+			return !mc.Dark
+		}
+		return mf.Val == ""
+	case "orientation":
+		switch mf.Val {
+		case "portrait":
+			return mc.Height >= mc.Width
+		case "landscape":
+			return mc.Width > mc.Height
+		}
+		return mf.Val == ""
+	case "width", "height":
+		cur = mc.Width
+		if name == "height" {
+			cur = mc.Height
+		}
+		if mf.Val == "" {
+			return cur > 0
+		}
+		uv := units.StringToValue(mf.Val)
+		val = uv.ToDots(mc.unitContext())
+	case "resolution":
+		cur = mc.DPI
+		if mf.Val == "" {
+			return cur > 0
+		}
+		var ok bool
+		if val, ok = parseResolution(mf.Val); !ok {
+			return false
+		}
+	case "aspect-ratio":
+		if mc.Height == 0 {
+			return false
+		}
+		cur = mc.Width / mc.Height
+		if mf.Val == "" {
+			return cur > 0
+		}
+		var ok bool
+		if val, ok = parseRatio(mf.Val); !ok {
+			return false
+		}
+	default:
+		return false
+	}
+	switch cmp {
+	case 1:
+		return cur >= val
+	case -1:
+		return cur <= val
+	}
+	return cur == val
+}
+
+// parseResolution parses a resolution value in dpi, dpcm, dppx or x (=
+// dppx), returning dots per inch
+func parseResolution(str string) (float32, bool) {
+	fact := float32(1)
+	num := str
+	switch {
+	case strings.HasSuffix(str, "dpi"):
+		num = str[:len(str)-3]
+	case strings.HasSuffix(str, "dpcm"):
+		num = str[:len(str)-4]
+		fact = units.CmPerInch
+	case strings.HasSuffix(str, "dppx"):
+		num = str[:len(str)-4]
+		fact = units.PxPerInch
+	case strings.HasSuffix(str, "x"):
+		num = str[:len(str)-1]
+		fact = units.PxPerInch
+	}
+	val, err := strconv.ParseFloat(strings.TrimSpace(num), 32)
+	if err != nil {
+		return 0, false
+	}
+	return float32(val) * fact, true
+}
+
+// parseRatio parses a ratio value, e.g., 16/9, or a single number
+func parseRatio(str string) (float32, bool) {
+	nd := strings.Split(str, "/")
+	n, err := strconv.ParseFloat(strings.TrimSpace(nd[0]), 32)
+	if err != nil {
+		return 0, false
+	}
+	if len(nd) == 1 {
+		return float32(n), true
+	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(nd[1]), 32)
+	if err != nil || d == 0 {
+		return 0, false
+	}
+	return float32(n / d), true
+}
+
+// mediaCache caches the parsed media queries for @media css keys -- invalid
+// queries are stored as nil
+var mediaCache = map[string]*MediaQuery{}
+var mediaCacheMu sync.Mutex
+
+// IsMediaKey returns true if the css key is an @media rule
+func IsMediaKey(key string) bool {
+	return strings.HasPrefix(key, MediaPrefix)
+}
+
+// MediaQueryForKey returns the parsed media query for given @media css key,
+// or nil if it is not a valid query (which is logged the first time)
+func MediaQueryForKey(key string) *MediaQuery {
+	mediaCacheMu.Lock()
+	defer mediaCacheMu.Unlock()
+	if mq, ok := mediaCache[key]; ok {
+		return mq
+	}
+	mq, err := ParseMediaQuery(strings.TrimPrefix(key, MediaPrefix))
+	if err != nil {
+		log.Println(err)
+	}
+	mediaCache[key] = mq
+	return mq
+}
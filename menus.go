@@ -304,11 +304,11 @@ func (m *Menu) AddWindowsMenu(win *Window) {
 var MenuFrameProps = ki.Props{
 	"border-width":        units.NewValue(0, units.Px),
 	"border-color":        "none",
-	"margin":              units.NewValue(4, units.Px),
+	"margin":              units.NewValue(6, units.Px),
 	"padding":             units.NewValue(2, units.Px),
 	"box-shadow.h-offset": units.NewValue(2, units.Px),
 	"box-shadow.v-offset": units.NewValue(2, units.Px),
-	"box-shadow.blur":     units.NewValue(2, units.Px),
+	"box-shadow.blur":     units.NewValue(4, units.Px),
 	"box-shadow.color":    &Prefs.Colors.Shadow,
 }
 
@@ -491,7 +491,7 @@ var SeparatorProps = ki.Props{
 	"border-color":     &Prefs.Colors.Border,
 	"border-width":     units.NewValue(2, units.Px),
 	"background-color": &Prefs.Colors.Control,
-	// todo: dotted
+	"border-style":     BorderSolid,
 }
 
 func (sp *Separator) Style2D() {
@@ -511,21 +511,27 @@ func (sp *Separator) Render2D() {
 		pc := &rs.Paint
 		st := &sp.Sty
 
-		pos := sp.LayData.AllocPos.AddVal(st.Layout.Margin.Dots)
-		sz := sp.LayData.AllocSize.AddVal(-2.0 * st.Layout.Margin.Dots)
+		mrg := st.Layout.MarginDots()
+		pos := sp.LayData.AllocPos.Add(mrg.Pos())
+		sz := sp.LayData.AllocSize.Sub(mrg.Size())
 
 		if !st.Font.BgColor.IsNil() {
 			pc.FillBox(rs, pos, sz, &st.Font.BgColor)
 		}
 
-		pc.StrokeStyle.Width = st.Border.Width
-		pc.StrokeStyle.SetColor(&st.Border.Color)
-		if sp.Horiz {
-			pc.DrawLine(rs, pos.X, pos.Y+0.5*sz.Y, pos.X+sz.X, pos.Y+0.5*sz.Y)
-		} else {
-			pc.DrawLine(rs, pos.X+0.5*sz.X, pos.Y, pos.X+0.5*sz.X, pos.Y+sz.Y)
+		if bs := st.Border.Style; bs != BorderNone && bs != BorderHidden {
+			pc.StrokeStyle.SetColor(&st.Border.Color)
+			pc.StrokeStyle.SetBorderDashes(bs) // border-style can be dotted or dashed
+			if sp.Horiz {
+				pc.StrokeStyle.Width = st.Border.TopWidth
+				pc.DrawLine(rs, pos.X, pos.Y+0.5*sz.Y, pos.X+sz.X, pos.Y+0.5*sz.Y)
+			} else {
+				pc.StrokeStyle.Width = st.Border.LeftWidth
+				pc.DrawLine(rs, pos.X+0.5*sz.X, pos.Y, pos.X+0.5*sz.X, pos.Y+sz.Y)
+			}
+			pc.FillStrokeClear(rs)
+			pc.StrokeStyle.SetBorderDashes(BorderSolid)
 		}
-		pc.FillStrokeClear(rs)
 		sp.Render2DChildren()
 		sp.PopBounds()
 	}
@@ -152,7 +152,7 @@ type Node2D interface {
 	ComputeBBox2D(parBBox image.Rectangle, delta image.Point)
 
 	// ChildrenBBox2D: compute the bbox available to my children (content),
-	// adjusting for margins, border, padding (BoxSpace) taken up by me --
+	// adjusting for margins, border, padding (BoxSpaceSides) taken up by me --
 	// operates on the existing VpBBox for this node -- this is what is passed
 	// down as parBBox do the children's Layout2D.
 	ChildrenBBox2D() image.Rectangle
@@ -99,6 +99,10 @@ type App interface {
 	// FontPaths returns the default system font paths.
 	FontPaths() []string
 
+	// IsDark returns true if the OS is set to use a dark color scheme (dark
+	// mode) -- used for following the OS preference in color themes.
+	IsDark() bool
+
 	// About is an informative message about the app.  Can use HTML
 	// formatting, including links.
 	About() string
@@ -10,7 +10,12 @@
 // Package driver provides the default driver for accessing a screen.
 package driver
 
-import "github.com/goki/gi/oswin"
+import (
+	"os"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+)
 
 // TODO: figure out what to say about the responsibility for users of this
 // package to check any implicit dependencies' LICENSEs. For example, the
@@ -23,6 +28,14 @@ import "github.com/goki/gi/oswin"
 // It calls f on the Screen, possibly in a separate goroutine, as some OS-
 // specific libraries require being on 'the main thread'. It returns when f
 // returns.
+//
+// If the GOGI_DRIVER environment variable is set to "offscreen", the
+// headless offscreen driver is used instead of the platform driver, which
+// allows windows to be created and rendered with no display available.
 func Main(f func(oswin.App)) {
+	if os.Getenv("GOGI_DRIVER") == "offscreen" {
+		offscreen.Main(f)
+		return
+	}
 	main(f)
 }
@@ -0,0 +1,16 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build offscreen
+
+package driver
+
+import (
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+)
+
+func main(f func(oswin.App)) {
+	offscreen.Main(f)
+}
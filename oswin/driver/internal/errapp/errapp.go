@@ -47,6 +47,7 @@ func (s stub) PrefsDir() string            { return "" }
 func (s stub) GoGiPrefsDir() string        { return "" }
 func (s stub) AppPrefsDir() string         { return "" }
 func (s stub) FontPaths() []string         { return nil }
+func (s stub) IsDark() bool                { return false }
 func (s stub) About() string               { return "" }
 func (s stub) SetAbout(about string)       {}
 func (s stub) OpenURL(url string)          {}
@@ -19,6 +19,7 @@ import (
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/goki/gi/oswin"
@@ -271,6 +272,12 @@ func (app *appImpl) FontPaths() []string {
 	return []string{"/System/Library/Fonts", "/Library/Fonts"}
 }
 
+func (app *appImpl) IsDark() bool {
+	// only set when in dark mode -- otherwise this is an error
+	out, err := exec.Command("defaults", "read", "-g", "AppleInterfaceStyle").Output()
+	return err == nil && strings.Contains(string(out), "Dark")
+}
+
 func (app *appImpl) ClipBoard(win oswin.Window) clip.Board {
 	app.ctxtwin = win.(*windowImpl)
 	return &theClip
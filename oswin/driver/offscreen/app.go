@@ -0,0 +1,317 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/clip"
+	"github.com/goki/gi/oswin/cursor"
+	"github.com/goki/gi/oswin/window"
+)
+
+// PrefsDir is the directory used as the OS preferences directory -- defaults
+// to a directory under the system temp dir, so that headless runs never read
+// or modify the user's actual GoGi preferences.
+var PrefsDir = filepath.Join(os.TempDir(), "gogi-offscreen")
+
+// maxImageSide is the maximum size of any image or texture dimension
+const maxImageSide = 0x00007fff
+
+type appImpl struct {
+	mu            sync.Mutex
+	winlist       []*windowImpl
+	screens       []*oswin.Screen
+	ctxtwin       *windowImpl
+	name          string
+	about         string
+	quitting      bool // set to true when quitting and closing windows
+	quitReqFunc   func()
+	quitCleanFunc func()
+}
+
+var theApp *appImpl
+
+func newAppImpl() *appImpl {
+	app := &appImpl{
+		winlist: make([]*windowImpl, 0),
+		name:    "GoGi",
+	}
+	sc := &oswin.Screen{
+		ScreenNumber:     0,
+		Geometry:         image.Rectangle{Max: ScreenSize},
+		Depth:            32,
+		LogicalDPI:       ScreenDPI,
+		PhysicalDPI:      ScreenDPI,
+		DevicePixelRatio: 1,
+		RefreshRate:      60,
+		Name:             "offscreen:0",
+	}
+	sc.PhysicalSize = image.Point{int(25.4 * float32(ScreenSize.X) / ScreenDPI), int(25.4 * float32(ScreenSize.Y) / ScreenDPI)}
+	app.screens = []*oswin.Screen{sc}
+
+	oswin.TheApp = app
+	theApp = app
+	return app
+}
+
+func (app *appImpl) NewImage(size image.Point) (oswin.Image, error) {
+	if size.X < 0 || maxImageSide < size.X || size.Y < 0 || maxImageSide < size.Y {
+		return nil, fmt.Errorf("offscreen: invalid image size %v", size)
+	}
+	return &imageImpl{
+		rgba: image.NewRGBA(image.Rectangle{Max: size}),
+		size: size,
+	}, nil
+}
+
+func (app *appImpl) NewTexture(win oswin.Window, size image.Point) (oswin.Texture, error) {
+	if size.X < 0 || maxImageSide < size.X || size.Y < 0 || maxImageSide < size.Y {
+		return nil, fmt.Errorf("offscreen: invalid texture size %v", size)
+	}
+	t := &textureImpl{
+		rgba: image.NewRGBA(image.Rectangle{Max: size}),
+		size: size,
+	}
+	if w, ok := win.(*windowImpl); ok {
+		t.w = w
+		w.AddTexture(t)
+	}
+	return t, nil
+}
+
+func (app *appImpl) NewWindow(opts *oswin.NewWindowOptions) (oswin.Window, error) {
+	if opts == nil {
+		opts = &oswin.NewWindowOptions{}
+	}
+	opts.Fixup()
+
+	sc := app.Screen(0)
+	w := &windowImpl{
+		app:  app,
+		back: image.NewRGBA(image.Rectangle{Max: opts.Size}),
+		WindowBase: oswin.WindowBase{
+			Titl:    opts.GetTitle(),
+			Sz:      opts.Size,
+			Pos:     opts.Pos,
+			PhysDPI: sc.PhysicalDPI,
+			LogDPI:  sc.LogicalDPI,
+			Scrn:    sc,
+			Flag:    opts.Flags,
+		},
+	}
+	w.front = image.NewRGBA(w.back.Bounds())
+
+	app.mu.Lock()
+	app.winlist = append(app.winlist, w)
+	app.mu.Unlock()
+
+	// there is no window manager, so the new window immediately has the
+	// focus, and gets its first paint event
+	app.setFocus(w)
+	sendWindowEvent(w, window.Paint)
+	return w, nil
+}
+
+// setFocus gives the focus to the given window, taking it away from any
+// other window that had it.
+func (app *appImpl) setFocus(fw *windowImpl) {
+	app.mu.Lock()
+	var defoc []*windowImpl
+	for _, w := range app.winlist {
+		if w != fw && w.IsFocus() {
+			defoc = append(defoc, w)
+		}
+	}
+	app.mu.Unlock()
+	for _, w := range defoc {
+		w.setFlag(oswin.Focus, false)
+		sendWindowEvent(w, window.DeFocus)
+	}
+	if !fw.IsFocus() {
+		fw.setFlag(oswin.Minimized, false)
+		fw.setFlag(oswin.Focus, true)
+		sendWindowEvent(fw, window.Focus)
+	}
+}
+
+func (app *appImpl) DeleteWin(win *windowImpl) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for i, w := range app.winlist {
+		if w == win {
+			app.winlist = append(app.winlist[:i], app.winlist[i+1:]...)
+			break
+		}
+	}
+	if app.ctxtwin == win {
+		app.ctxtwin = nil
+	}
+}
+
+func (app *appImpl) NScreens() int {
+	return len(app.screens)
+}
+
+func (app *appImpl) Screen(scrN int) *oswin.Screen {
+	sz := len(app.screens)
+	if scrN < sz {
+		return app.screens[scrN]
+	}
+	return nil
+}
+
+func (app *appImpl) NWindows() int {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	return len(app.winlist)
+}
+
+func (app *appImpl) Window(win int) oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	sz := len(app.winlist)
+	if win < sz {
+		return app.winlist[win]
+	}
+	return nil
+}
+
+func (app *appImpl) WindowByName(name string) oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for _, win := range app.winlist {
+		if win.Name() == name {
+			return win
+		}
+	}
+	return nil
+}
+
+func (app *appImpl) WindowInFocus() oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for _, win := range app.winlist {
+		if win.IsFocus() {
+			return win
+		}
+	}
+	return nil
+}
+
+func (app *appImpl) ContextWindow() oswin.Window {
+	return app.ctxtwin
+}
+
+func (app *appImpl) Platform() oswin.Platforms {
+	return Platform
+}
+
+func (app *appImpl) Name() string {
+	return app.name
+}
+
+func (app *appImpl) SetName(name string) {
+	app.name = name
+}
+
+func (app *appImpl) PrefsDir() string {
+	os.MkdirAll(PrefsDir, 0755)
+	return PrefsDir
+}
+
+func (app *appImpl) GoGiPrefsDir() string {
+	pdir := filepath.Join(app.PrefsDir(), "GoGi")
+	os.MkdirAll(pdir, 0755)
+	return pdir
+}
+
+func (app *appImpl) AppPrefsDir() string {
+	pdir := filepath.Join(app.PrefsDir(), app.Name())
+	os.MkdirAll(pdir, 0755)
+	return pdir
+}
+
+func (app *appImpl) FontPaths() []string {
+	switch Platform {
+	case oswin.MacOS:
+		return []string{"/System/Library/Fonts", "/Library/Fonts"}
+	case oswin.Windows:
+		return []string{"C:\\Windows\\Fonts"}
+	}
+	return []string{"/usr/share/fonts/truetype"}
+}
+
+func (app *appImpl) IsDark() bool {
+	return DarkMode
+}
+
+func (app *appImpl) ClipBoard(win oswin.Window) clip.Board {
+	app.ctxtwin, _ = win.(*windowImpl)
+	return &theClip
+}
+
+func (app *appImpl) Cursor(win oswin.Window) cursor.Cursor {
+	app.ctxtwin, _ = win.(*windowImpl)
+	return &theCursor
+}
+
+func (app *appImpl) About() string {
+	return app.about
+}
+
+func (app *appImpl) SetAbout(about string) {
+	app.about = about
+}
+
+func (app *appImpl) OpenURL(url string) {
+	// nothing to open a url in
+}
+
+func (app *appImpl) SetQuitReqFunc(fun func()) {
+	app.quitReqFunc = fun
+}
+
+func (app *appImpl) SetQuitCleanFunc(fun func()) {
+	app.quitCleanFunc = fun
+}
+
+func (app *appImpl) QuitReq() {
+	if app.quitting {
+		return
+	}
+	if app.quitReqFunc != nil {
+		app.quitReqFunc()
+	} else {
+		app.Quit()
+	}
+}
+
+func (app *appImpl) IsQuitting() bool {
+	return app.quitting
+}
+
+func (app *appImpl) QuitClean() {
+	app.quitting = true
+	if app.quitCleanFunc != nil {
+		app.quitCleanFunc()
+	}
+	app.mu.Lock()
+	wins := make([]*windowImpl, len(app.winlist))
+	copy(wins, app.winlist)
+	app.mu.Unlock()
+	for i := len(wins) - 1; i >= 0; i-- {
+		wins[i].Close()
+	}
+}
+
+func (app *appImpl) Quit() {
+	app.QuitClean()
+}
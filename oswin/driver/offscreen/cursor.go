@@ -0,0 +1,53 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"github.com/goki/gi/oswin/cursor"
+)
+
+// cursorImpl just keeps track of the cursor state, which can be inspected
+// by tests, as there is nothing to display it on.
+type cursorImpl struct {
+	cursor.CursorBase
+}
+
+var theCursor = cursorImpl{CursorBase: cursor.CursorBase{Vis: true}}
+
+func (c *cursorImpl) Set(sh cursor.Shapes) {
+	c.Cur = sh
+}
+
+func (c *cursorImpl) Push(sh cursor.Shapes) {
+	c.PushStack(sh)
+}
+
+func (c *cursorImpl) Pop() {
+	c.PopStack()
+}
+
+func (c *cursorImpl) Hide() {
+	c.Vis = false
+}
+
+func (c *cursorImpl) Show() {
+	c.Vis = true
+}
+
+func (c *cursorImpl) PushIfNot(sh cursor.Shapes) bool {
+	if c.Cur == sh {
+		return false
+	}
+	c.Push(sh)
+	return true
+}
+
+func (c *cursorImpl) PopIf(sh cursor.Shapes) bool {
+	if c.Cur == sh {
+		c.Pop()
+		return true
+	}
+	return false
+}
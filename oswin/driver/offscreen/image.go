@@ -0,0 +1,22 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+)
+
+type imageImpl struct {
+	rgba *image.RGBA
+	size image.Point
+}
+
+func (b *imageImpl) Size() image.Point       { return b.size }
+func (b *imageImpl) Bounds() image.Rectangle { return image.Rectangle{Max: b.size} }
+func (b *imageImpl) RGBA() *image.RGBA       { return b.rgba }
+
+func (b *imageImpl) Release() {
+	// memory is reclaimed by the garbage collector
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package offscreen provides a headless oswin driver that renders windows
+// into in-memory RGBA buffers, with no connection to any display server.
+// It is intended for running complete gi.Window trees in tests and other
+// display-less environments (e.g., CI machines) -- events are only generated
+// by the driver itself (window paint, resize, close) or sent explicitly by
+// the program via the window's Send method.
+//
+// It is selected by driver.Main when the GOGI_DRIVER environment variable is
+// set to "offscreen", or when building with the "offscreen" build tag.
+package offscreen
+
+import (
+	"image"
+	"runtime"
+
+	"github.com/goki/gi/oswin"
+)
+
+// ScreenSize is the size of the single virtual screen, in raw dots -- can be
+// set prior to calling Main.
+var ScreenSize = image.Point{1920, 1280}
+
+// ScreenDPI is the logical and physical DPI of the virtual screen -- the
+// default of 96 makes standard pixel units map 1:1 onto dots.
+var ScreenDPI = float32(96)
+
+// DarkMode is the OS dark mode setting reported by the app's IsDark method
+var DarkMode = false
+
+// Platform is the platform reported by the app -- defaults to the one that
+// we are actually running on, so that platform-specific key maps and other
+// behavior match what would happen with a real display.
+var Platform = defaultPlatform()
+
+func defaultPlatform() oswin.Platforms {
+	switch runtime.GOOS {
+	case "darwin":
+		return oswin.MacOS
+	case "windows":
+		return oswin.Windows
+	}
+	return oswin.LinuxX11
+}
+
+// Main is called by the program's main function to run the graphical
+// application.
+//
+// It calls f on the App in the same goroutine, and returns when f returns.
+func Main(f func(oswin.App)) {
+	app := newAppImpl()
+	f(app)
+}
+
+// Capture returns a copy of the most recently published contents of the
+// given window, which must have been created by this driver -- returns nil
+// otherwise.
+func Capture(win oswin.Window) *image.RGBA {
+	w, ok := win.(*windowImpl)
+	if !ok {
+		return nil
+	}
+	return w.capture()
+}
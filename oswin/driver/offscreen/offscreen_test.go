@@ -0,0 +1,56 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/window"
+)
+
+func TestWindowPublish(t *testing.T) {
+	Main(func(app oswin.App) {
+		w, err := app.NewWindow(&oswin.NewWindowOptions{Size: image.Point{100, 80}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.Size() != (image.Point{100, 80}) {
+			t.Errorf("window size: %v", w.Size())
+		}
+		gotPaint := false
+		for i := 0; i < 2; i++ {
+			ev := w.NextEvent()
+			if we, ok := ev.(*window.Event); ok && we.Action == window.Paint {
+				gotPaint = true
+			}
+		}
+		if !gotPaint {
+			t.Errorf("did not get initial paint event")
+		}
+
+		tex, _ := app.NewTexture(w, w.Size())
+		img, _ := app.NewImage(image.Point{10, 10})
+		red := color.RGBA{255, 0, 0, 255}
+		img.RGBA().Set(1, 1, red)
+		tex.Upload(image.Point{5, 5}, img, img.Bounds())
+		w.Copy(image.ZP, tex, tex.Bounds(), oswin.Src, nil)
+
+		if c := Capture(w).RGBAAt(6, 6); c == red {
+			t.Errorf("window contents visible before Publish")
+		}
+		w.Publish()
+		if c := Capture(w).RGBAAt(6, 6); c != red {
+			t.Errorf("published pixel: %v, expected: %v", c, red)
+		}
+
+		w.Close()
+		if app.NWindows() != 0 {
+			t.Errorf("window not removed on Close")
+		}
+	})
+}
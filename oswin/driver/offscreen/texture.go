@@ -0,0 +1,52 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/goki/gi/oswin"
+)
+
+type textureImpl struct {
+	w    *windowImpl
+	rgba *image.RGBA
+	size image.Point
+
+	mu       sync.Mutex
+	released bool
+}
+
+func (t *textureImpl) Size() image.Point       { return t.size }
+func (t *textureImpl) Bounds() image.Rectangle { return image.Rectangle{Max: t.size} }
+
+func (t *textureImpl) Release() {
+	t.mu.Lock()
+	released := t.released
+	t.released = true
+	t.mu.Unlock()
+
+	if released {
+		return
+	}
+	if t.w != nil {
+		t.w.DeleteTexture(t)
+	}
+}
+
+func (t *textureImpl) Upload(dp image.Point, src oswin.Image, sr image.Rectangle) {
+	t.mu.Lock()
+	upload(t.rgba, dp, src.RGBA(), sr)
+	t.mu.Unlock()
+}
+
+func (t *textureImpl) Fill(dr image.Rectangle, src color.Color, op draw.Op) {
+	t.mu.Lock()
+	draw.Draw(t.rgba, dr, &image.Uniform{src}, image.ZP, op)
+	t.mu.Unlock()
+}
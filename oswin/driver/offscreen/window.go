@@ -0,0 +1,255 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/internal/drawer"
+	"github.com/goki/gi/oswin/driver/internal/event"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki/bitflag"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+type windowImpl struct {
+	oswin.WindowBase
+
+	app *appImpl
+
+	event.Deque
+
+	// back is the back buffer that all Upload, Fill and Draw calls render
+	// into -- it is copied to front on Publish
+	back *image.RGBA
+
+	// front holds the most recently published window contents
+	front *image.RGBA
+
+	// textures are the textures created for this window -- they are released
+	// when the window is closed
+	textures map[*textureImpl]struct{}
+
+	mu             sync.Mutex
+	released       bool
+	closeReqFunc   func(win oswin.Window)
+	closeCleanFunc func(win oswin.Window)
+}
+
+// for sending window.Event's
+func sendWindowEvent(w *windowImpl, act window.Actions) {
+	winEv := window.Event{
+		Action: act,
+	}
+	winEv.Init()
+	w.Send(&winEv)
+}
+
+func (w *windowImpl) setFlag(flag oswin.WindowFlags, on bool) {
+	w.mu.Lock()
+	bitflag.SetState(&w.Flag, on, int(flag))
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) capture() *image.RGBA {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	img := image.NewRGBA(w.front.Bounds())
+	copy(img.Pix, w.front.Pix)
+	return img
+}
+
+func (w *windowImpl) Upload(dp image.Point, src oswin.Image, sr image.Rectangle) {
+	w.mu.Lock()
+	upload(w.back, dp, src.RGBA(), sr)
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) Fill(dr image.Rectangle, src color.Color, op draw.Op) {
+	w.mu.Lock()
+	draw.Draw(w.back, dr, &image.Uniform{src}, image.ZP, op)
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) DrawUniform(src2dst f64.Aff3, src color.Color, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	w.mu.Lock()
+	drawAff(w.back, &src2dst, &image.Uniform{src}, sr, op)
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) Draw(src2dst f64.Aff3, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	t := src.(*textureImpl)
+	sr = sr.Intersect(t.Bounds())
+	if sr.Empty() {
+		return
+	}
+	w.mu.Lock()
+	t.mu.Lock()
+	drawAff(w.back, &src2dst, t.rgba, sr, op)
+	t.mu.Unlock()
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) Copy(dp image.Point, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	drawer.Copy(w, dp, src, sr, op, opts)
+}
+
+func (w *windowImpl) Scale(dr image.Rectangle, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	drawer.Scale(w, dr, src, sr, op, opts)
+}
+
+func (w *windowImpl) Publish() oswin.PublishResult {
+	w.mu.Lock()
+	copy(w.front.Pix, w.back.Pix)
+	w.mu.Unlock()
+	return oswin.PublishResult{BackImagePreserved: true}
+}
+
+// upload copies the sr region of src into dst at dp, with draw.Src semantics.
+func upload(dst *image.RGBA, dp image.Point, src *image.RGBA, sr image.Rectangle) {
+	originalSRMin := sr.Min
+	sr = sr.Intersect(src.Bounds())
+	if sr.Empty() {
+		return
+	}
+	dp = dp.Add(sr.Min.Sub(originalSRMin))
+	draw.Draw(dst, image.Rectangle{Min: dp, Max: dp.Add(sr.Size())}, src, sr.Min, draw.Src)
+}
+
+// drawAff draws the sr region of src onto dst through the src2dst affine
+// transform -- pure translations are done as exact pixel copies.
+func drawAff(dst *image.RGBA, src2dst *f64.Aff3, src image.Image, sr image.Rectangle, op draw.Op) {
+	if src2dst[0] == 1 && src2dst[1] == 0 && src2dst[3] == 0 && src2dst[4] == 1 &&
+		src2dst[2] == float64(int(src2dst[2])) && src2dst[5] == float64(int(src2dst[5])) {
+		dp := image.Point{int(src2dst[2]), int(src2dst[5])}
+		dr := sr.Add(dp)
+		draw.Draw(dst, dr, src, sr.Min, op)
+		return
+	}
+	xdraw.ApproxBiLinear.Transform(dst, *src2dst, src, sr, op, nil)
+}
+
+func (w *windowImpl) SetTitle(title string) {
+	w.Titl = title
+}
+
+func (w *windowImpl) SetSize(sz image.Point) {
+	w.SetGeom(w.Pos, sz)
+}
+
+func (w *windowImpl) SetPos(pos image.Point) {
+	w.SetGeom(pos, w.Sz)
+}
+
+func (w *windowImpl) SetGeom(pos image.Point, sz image.Point) {
+	w.mu.Lock()
+	resized := sz != w.Sz
+	moved := pos != w.Pos
+	w.Pos = pos
+	if resized {
+		w.Sz = sz
+		w.back = image.NewRGBA(image.Rectangle{Max: sz})
+		w.front = image.NewRGBA(image.Rectangle{Max: sz})
+	}
+	w.mu.Unlock()
+	if resized {
+		sendWindowEvent(w, window.Resize)
+		sendWindowEvent(w, window.Paint)
+	} else if moved {
+		sendWindowEvent(w, window.Move)
+	}
+}
+
+func (w *windowImpl) MainMenu() oswin.MainMenu {
+	return nil
+}
+
+func (w *windowImpl) Raise() {
+	minimized := w.IsMinimized()
+	w.app.setFocus(w)
+	if minimized {
+		sendWindowEvent(w, window.Paint)
+	}
+}
+
+func (w *windowImpl) Minimize() {
+	w.setFlag(oswin.Minimized, true)
+	if w.IsFocus() {
+		w.setFlag(oswin.Focus, false)
+		sendWindowEvent(w, window.DeFocus)
+	}
+}
+
+func (w *windowImpl) SetCloseReqFunc(fun func(win oswin.Window)) {
+	w.closeReqFunc = fun
+}
+
+func (w *windowImpl) SetCloseCleanFunc(fun func(win oswin.Window)) {
+	w.closeCleanFunc = fun
+}
+
+func (w *windowImpl) CloseReq() {
+	if theApp.quitting {
+		w.Close()
+		return
+	}
+	if w.closeReqFunc != nil {
+		w.closeReqFunc(w)
+	} else {
+		w.Close()
+	}
+}
+
+func (w *windowImpl) CloseClean() {
+	if w.closeCleanFunc != nil {
+		w.closeCleanFunc(w)
+	}
+}
+
+func (w *windowImpl) AddTexture(t *textureImpl) {
+	w.mu.Lock()
+	if w.textures == nil {
+		w.textures = make(map[*textureImpl]struct{})
+	}
+	w.textures[t] = struct{}{}
+	w.mu.Unlock()
+}
+
+// DeleteTexture just deletes it from our list -- does not Release -- is called during t.Release
+func (w *windowImpl) DeleteTexture(t *textureImpl) {
+	w.mu.Lock()
+	if w.textures != nil {
+		delete(w.textures, t)
+	}
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) Close() {
+	w.mu.Lock()
+	released := w.released
+	w.released = true
+	w.mu.Unlock()
+
+	if released {
+		return
+	}
+	w.CloseClean()
+	sendWindowEvent(w, window.Close)
+	w.mu.Lock()
+	texs := make([]*textureImpl, 0, len(w.textures))
+	for t := range w.textures {
+		texs = append(texs, t)
+	}
+	w.mu.Unlock()
+	for _, t := range texs {
+		t.Release() // deletes from map
+	}
+	w.app.DeleteWin(w)
+}
@@ -303,6 +303,14 @@ func (app *appImpl) FontPaths() []string {
 	return []string{"C:\\Windows\\Fonts"}
 }
 
+func (app *appImpl) IsDark() bool {
+	out, err := exec.Command("reg", "query", `HKCU\Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`, "/v", "AppsUseLightTheme").Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(out), "0x0")
+}
+
 func (app *appImpl) ClipBoard(win oswin.Window) clip.Board {
 	app.ctxtwin = win.(*windowImpl)
 	return &theClip
@@ -21,6 +21,7 @@ import (
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/BurntSushi/xgb"
@@ -900,6 +901,19 @@ func (app *appImpl) FontPaths() []string {
 	return []string{"/usr/share/fonts/truetype"}
 }
 
+func (app *appImpl) IsDark() bool {
+	// GTK_THEME is e.g., Adwaita:dark
+	if strings.HasSuffix(strings.ToLower(os.Getenv("GTK_THEME")), ":dark") {
+		return true
+	}
+	out, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "color-scheme").Output()
+	if err == nil && strings.Contains(string(out), "dark") {
+		return true
+	}
+	out, err = exec.Command("gsettings", "get", "org.gnome.desktop.interface", "gtk-theme").Output()
+	return err == nil && strings.Contains(strings.ToLower(string(out)), "dark")
+}
+
 func (app *appImpl) ClipBoard(win oswin.Window) clip.Board {
 	app.ctxtwin = win.(*windowImpl)
 	return &theClip
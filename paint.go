@@ -6,7 +6,6 @@ package gi
 
 import (
 	"errors"
-	"fmt"
 	"image"
 	"image/color"
 	"log"
@@ -70,6 +69,7 @@ type Paint struct {
 	TextStyle   TextStyle    `desc:"font also has global opacity setting, along with generic color, background-color settings, which can be copied into stroke / fill as needed"`
 	VecEff      VectorEffect `xml:"vector-effect" desc:"various rendering special effects settings"`
 	XForm       Matrix2D     `xml:"transform" desc:"our additions to transform -- pushed to render state"`
+	Vars        ki.Props     `xml:"-" desc:"CSS custom properties (variables, e.g., --gap) in effect for this element: inherited from the parent, and declared on the element itself -- used to resolve var() references"`
 	dotsSet     bool
 	lastUnCtxt  units.Context
 }
@@ -119,6 +119,7 @@ func (pc *Paint) CopyStyleFrom(cp *Paint) {
 	pc.FontStyle = cp.FontStyle
 	pc.TextStyle = cp.TextStyle
 	pc.VecEff = cp.VecEff
+	pc.Vars = cp.Vars
 }
 
 // InheritFields from parent: Manual inheriting of values is much faster than
@@ -126,6 +127,7 @@ func (pc *Paint) CopyStyleFrom(cp *Paint) {
 func (pc *Paint) InheritFields(par *Paint) {
 	pc.FontStyle.InheritFields(&par.FontStyle)
 	pc.TextStyle.InheritFields(&par.TextStyle)
+	pc.Vars = par.Vars
 }
 
 // SetStyleProps sets paint values based on given property map (name: value
@@ -135,8 +137,11 @@ func (pc *Paint) SetStyleProps(par *Paint, props ki.Props) {
 	if !pc.StyleSet && par != nil { // first time
 		// PaintFields.Inherit(pc, par) // very slow..
 		pc.InheritFields(par)
+	} else if !pc.StyleSet {
+		pc.Vars = nil
 	}
-	PaintFields.Style(pc, par, props)
+	SetStyleVars(&pc.Vars, props)
+	PaintFields.StyleWithVars(pc, par, props, pc.Vars)
 	pc.StrokeStyle.SetStylePost(props)
 	pc.FillStyle.SetStylePost(props)
 	pc.FontStyle.SetStylePost(props)
@@ -242,6 +247,9 @@ type RenderState struct {
 	ClipStack      []*image.Alpha    `desc:"stack of clips, if needed"`
 	PaintBack      Paint             `desc:"backup of paint -- don't need a full stack but sometimes safer to backup and restore"`
 	RasterMu       sync.Mutex        `desc:"mutex for final rasterx rendering -- only one at a time"`
+
+	DamageBounds image.Rectangle `desc:"if non-empty, all bounds pushed by PushBounds are restricted to this region -- set while re-rendering only a damaged region of the image -- see Viewport2D.RenderDamage"`
+	Rec          *DisplayList    `desc:"if non-nil, all rendering operations are also recorded into this display list -- see StartRecording"`
 }
 
 // Init initializes RenderState -- must be called whenever image size changes
@@ -264,6 +272,9 @@ func (rs *RenderState) PushXForm(xf Matrix2D) {
 	}
 	rs.XFormStack = append(rs.XFormStack, rs.XForm)
 	rs.XForm = xf.Multiply(rs.XForm)
+	if rs.Rec != nil {
+		rs.Rec.Add(DisplayOp{Op: DisplayPushXForm, XForm: xf})
+	}
 }
 
 // PopXForm pops xform off the stack and set to current xform
@@ -276,9 +287,13 @@ func (rs *RenderState) PopXForm() {
 	}
 	rs.XForm = rs.XFormStack[sz-1]
 	rs.XFormStack = rs.XFormStack[:sz-1]
+	if rs.Rec != nil {
+		rs.Rec.Add(DisplayOp{Op: DisplayPopXForm})
+	}
 }
 
-// PushBounds pushes current bounds onto stack and set new bounds
+// PushBounds pushes current bounds onto stack and set new bounds -- the new
+// bounds are restricted to DamageBounds if set
 func (rs *RenderState) PushBounds(b image.Rectangle) {
 	if rs.BoundsStack == nil {
 		rs.BoundsStack = make([]image.Rectangle, 0, 100)
@@ -289,7 +304,13 @@ func (rs *RenderState) PushBounds(b image.Rectangle) {
 	rs.BoundsStack = append(rs.BoundsStack, rs.Bounds)
 	// note: this does not fix the ghost trace from rendering..
 	// bp1 := image.Rectangle{Min: image.Point{X: b.Min.X - 1, Y: b.Min.Y - 1}, Max: image.Point{X: b.Max.X + 1, Y: b.Max.Y + 1}}
+	if !rs.DamageBounds.Empty() {
+		b = b.Intersect(rs.DamageBounds)
+	}
 	rs.Bounds = b
+	if rs.Rec != nil {
+		rs.Rec.Add(DisplayOp{Op: DisplayPushBounds, Rect: b})
+	}
 }
 
 // PopBounds pops bounds off the stack and set to current bounds
@@ -302,6 +323,9 @@ func (rs *RenderState) PopBounds() {
 	}
 	rs.Bounds = rs.BoundsStack[sz-1]
 	rs.BoundsStack = rs.BoundsStack[:sz-1]
+	if rs.Rec != nil {
+		rs.Rec.Add(DisplayOp{Op: DisplayPopBounds})
+	}
 }
 
 // PushClip pushes current Mask onto the clip stack
@@ -388,6 +412,9 @@ func (pc *Paint) MoveTo(rs *RenderState, x, y float32) {
 	rs.Start = p
 	rs.Current = p
 	rs.HasCurrent = true
+	if rs.Rec != nil {
+		rs.Rec.AddPts(DisplayMoveTo, p)
+	}
 }
 
 // LineTo adds a line segment to the current path starting at the current
@@ -399,6 +426,9 @@ func (pc *Paint) LineTo(rs *RenderState, x, y float32) {
 		p := pc.TransformPoint(rs, x, y)
 		rs.Path.Line(p.Fixed())
 		rs.Current = p
+		if rs.Rec != nil {
+			rs.Rec.AddPts(DisplayLineTo, p)
+		}
 	}
 }
 
@@ -413,6 +443,9 @@ func (pc *Paint) QuadraticTo(rs *RenderState, x1, y1, x2, y2 float32) {
 	p2 := pc.TransformPoint(rs, x2, y2)
 	rs.Path.QuadBezier(p1.Fixed(), p2.Fixed())
 	rs.Current = p2
+	if rs.Rec != nil {
+		rs.Rec.AddPts(DisplayQuadTo, p1, p2)
+	}
 }
 
 // CubicTo adds a cubic bezier curve to the current path starting at the
@@ -429,6 +462,9 @@ func (pc *Paint) CubicTo(rs *RenderState, x1, y1, x2, y2, x3, y3 float32) {
 
 	rs.Path.CubeBezier(b.Fixed(), c.Fixed(), d.Fixed())
 	rs.Current = d
+	if rs.Rec != nil {
+		rs.Rec.AddPts(DisplayCubicTo, b, c, d)
+	}
 }
 
 // ClosePath adds a line segment from the current point to the beginning
@@ -437,6 +473,9 @@ func (pc *Paint) ClosePath(rs *RenderState) {
 	if rs.HasCurrent {
 		rs.Path.Stop(true)
 		rs.Current = rs.Start
+		if rs.Rec != nil {
+			rs.Rec.AddPts(DisplayClosePath)
+		}
 	}
 }
 
@@ -445,6 +484,9 @@ func (pc *Paint) ClosePath(rs *RenderState) {
 func (pc *Paint) ClearPath(rs *RenderState) {
 	rs.Path.Clear()
 	rs.HasCurrent = false
+	if rs.Rec != nil {
+		rs.Rec.AddPts(DisplayClearPath)
+	}
 }
 
 // NewSubPath starts a new subpath within the current path. There is no current
@@ -454,6 +496,9 @@ func (pc *Paint) NewSubPath(rs *RenderState) {
 	// 	rs.FillPath.Add1(rs.Start.Fixed())
 	// }
 	rs.HasCurrent = false
+	if rs.Rec != nil {
+		rs.Rec.AddPts(DisplayNewSubPath)
+	}
 }
 
 // Path Drawing
@@ -508,29 +553,36 @@ func (pc *Paint) StrokeWidth(rs *RenderState) float32 {
 	return lw
 }
 
+// StrokeDashes obtains the current dash pattern and offset subject to
+// transform (or not depending on VecEffNonScalingStroke) -- the pattern is
+// nil for a solid line
+func (pc *Paint) StrokeDashes(rs *RenderState) ([]float64, float64) {
+	if len(pc.StrokeStyle.Dashes) == 0 {
+		return nil, 0
+	}
+	if pc.VecEff == VecEffNonScalingStroke {
+		return pc.StrokeStyle.DashPattern(1)
+	}
+	scx, scy := rs.XForm.ExtractScale()
+	sc := 0.5 * (math.Abs(float64(scx)) + math.Abs(float64(scy)))
+	return pc.StrokeStyle.DashPattern(sc)
+}
+
 func (pc *Paint) stroke(rs *RenderState) {
 	pr := prof.Start("Paint.stroke")
 
-	dash := pc.StrokeStyle.Dashes
-	if dash != nil {
-		scx, scy := rs.XForm.ExtractScale()
-		sc := 0.5 * (math.Abs(float64(scx)) + math.Abs(float64(scy)))
-		if sc != 0 {
-			for i := range dash {
-				dash[i] *= sc
-			}
-		} else {
-			fmt.Printf("scale is zero\n")
-		}
+	if rs.Rec != nil {
+		rs.Rec.AddPaint(DisplayStroke, pc, rs.XForm)
 	}
+	dash, doff := pc.StrokeDashes(rs)
 	rs.RasterMu.Lock()
 	defer rs.RasterMu.Unlock()
 
 	rs.Raster.SetStroke(
 		Float32ToFixed(pc.StrokeWidth(rs)),
 		Float32ToFixed(pc.StrokeStyle.MiterLimit),
-		pc.capfunc(), nil, nil, pc.joinmode(), // todo: supports leading / trailing caps, and "gaps"
-		dash, 0,
+		pc.capfunc(), nil, nil, pc.joinmode(), // same cap at both ends, default gaps for the join
+		dash, doff,
 	)
 	rs.Scanner.SetClip(rs.Bounds)
 	rs.Path.AddTo(rs.Raster)
@@ -548,6 +600,10 @@ func (pc *Paint) stroke(rs *RenderState) {
 func (pc *Paint) fill(rs *RenderState) {
 	pr := prof.Start("Paint.fill")
 
+	if rs.Rec != nil {
+		rs.Rec.AddPaint(DisplayFill, pc, rs.XForm)
+	}
+
 	rs.RasterMu.Lock()
 	defer rs.RasterMu.Unlock()
 
@@ -604,6 +660,9 @@ func (pc *Paint) FillBox(rs *RenderState, pos, size Vec2D, clr *ColorSpec) {
 	if clr.Source == SolidColor {
 		b := rs.Bounds.Intersect(RectFromPosSizeMax(pos, size))
 		draw.Draw(rs.Image, b, &image.Uniform{clr.Color}, image.ZP, draw.Src)
+		if rs.Rec != nil {
+			rs.Rec.Add(DisplayOp{Op: DisplayFillBox, Rect: b, Color: clr.Color})
+		}
 	} else {
 		pc.FillStyle.SetColorSpec(clr)
 		pc.DrawRectangle(rs, pos.X, pos.Y, size.X, size.Y)
@@ -615,6 +674,9 @@ func (pc *Paint) FillBox(rs *RenderState, pos, size Vec2D, clr *ColorSpec) {
 func (pc *Paint) FillBoxColor(rs *RenderState, pos, size Vec2D, clr color.Color) {
 	b := rs.Bounds.Intersect(RectFromPosSizeMax(pos, size))
 	draw.Draw(rs.Image, b, &image.Uniform{clr}, image.ZP, draw.Src)
+	if rs.Rec != nil {
+		rs.Rec.Add(DisplayOp{Op: DisplayFillBox, Rect: b, Color: clr})
+	}
 }
 
 // ClipPreserve updates the clipping region by intersecting the current
@@ -623,7 +685,13 @@ func (pc *Paint) FillBoxColor(rs *RenderState, pos, size Vec2D, clr color.Color)
 func (pc *Paint) ClipPreserve(rs *RenderState) {
 	clip := image.NewAlpha(rs.Image.Bounds())
 	// painter := raster.NewAlphaOverPainter(clip) // todo!
+	rec := rs.Rec
+	if rec != nil {
+		rec.AddPaint(DisplayClip, pc, rs.XForm)
+		rs.Rec = nil // not a fill
+	}
 	pc.fill(rs)
+	rs.Rec = rec
 	if rs.Mask == nil {
 		rs.Mask = clip
 	} else { // todo: this one operation MASSIVELY slows down clip usage -- unclear why
@@ -665,6 +733,9 @@ func (pc *Paint) Clip(rs *RenderState) {
 // ResetClip clears the clipping region.
 func (pc *Paint) ResetClip(rs *RenderState) {
 	rs.Mask = nil
+	if rs.Rec != nil {
+		rs.Rec.AddPts(DisplayResetClip)
+	}
 }
 
 //////////////////////////////////////////////////////////////////////////////////
@@ -674,11 +745,17 @@ func (pc *Paint) ResetClip(rs *RenderState) {
 func (pc *Paint) Clear(rs *RenderState) {
 	src := image.NewUniform(&pc.FillStyle.Color.Color)
 	draw.Draw(rs.Image, rs.Image.Bounds(), src, image.ZP, draw.Src)
+	if rs.Rec != nil {
+		rs.Rec.Add(DisplayOp{Op: DisplayFillBox, Rect: rs.Image.Bounds(), Color: pc.FillStyle.Color.Color})
+	}
 }
 
 // SetPixel sets the color of the specified pixel using the current stroke color.
 func (pc *Paint) SetPixel(rs *RenderState, x, y int) {
 	rs.Image.Set(x, y, &pc.StrokeStyle.Color.Color)
+	if rs.Rec != nil {
+		rs.Rec.Add(DisplayOp{Op: DisplayFillBox, Rect: image.Rect(x, y, x+1, y+1), Color: pc.StrokeStyle.Color.Color})
+	}
 }
 
 func (pc *Paint) DrawLine(rs *RenderState, x1, y1, x2, y2 float32) {
@@ -744,6 +821,51 @@ func (pc *Paint) DrawRoundedRectangle(rs *RenderState, x, y, w, h, r float32) {
 	pc.ClosePath(rs)
 }
 
+// DrawRoundedRectangleRadii draws a rectangle with a different radius for
+// each corner, in CSS border-radius order: top-left, top-right, bottom-right,
+// bottom-left -- the radii are scaled down proportionally if adjacent ones do
+// not fit within the sides, as in CSS
+func (pc *Paint) DrawRoundedRectangleRadii(rs *RenderState, x, y, w, h float32, r SideFloats) {
+	if r.IsUniform() {
+		if r[0] == 0 {
+			pc.DrawRectangle(rs, x, y, w, h)
+		} else {
+			pc.DrawRoundedRectangle(rs, x, y, w, h, r[0])
+		}
+		return
+	}
+	f := float32(1)
+	fit := func(sum, side float32) {
+		if sum > side {
+			f = Min32(f, Max32(side, 0)/sum)
+		}
+	}
+	fit(r[0]+r[1], w)
+	fit(r[3]+r[2], w)
+	fit(r[0]+r[3], h)
+	fit(r[1]+r[2], h)
+	tl, tr, br, bl := f*r[0], f*r[1], f*r[2], f*r[3]
+	pc.NewSubPath(rs)
+	pc.MoveTo(rs, x+tl, y)
+	pc.LineTo(rs, x+w-tr, y)
+	if tr > 0 {
+		pc.DrawArc(rs, x+w-tr, y+tr, tr, Radians(270), Radians(360))
+	}
+	pc.LineTo(rs, x+w, y+h-br)
+	if br > 0 {
+		pc.DrawArc(rs, x+w-br, y+h-br, br, Radians(0), Radians(90))
+	}
+	pc.LineTo(rs, x+bl, y+h)
+	if bl > 0 {
+		pc.DrawArc(rs, x+bl, y+h-bl, bl, Radians(90), Radians(180))
+	}
+	pc.LineTo(rs, x, y+tl)
+	if tl > 0 {
+		pc.DrawArc(rs, x+tl, y+tl, tl, Radians(180), Radians(270))
+	}
+	pc.ClosePath(rs)
+}
+
 // DrawElllipticalArc draws arc between angle1 and angle2 along an ellipse,
 // using quadratic bezier curves -- centers of ellipse are at cx, cy with
 // radii rx, ry -- see DrawEllipticalArcPath for a version compatible with SVG
@@ -950,6 +1072,9 @@ func (pc *Paint) DrawImageAnchored(rs *RenderState, fmIm image.Image, x, y int,
 	transformer := draw.BiLinear
 	fx, fy := float32(x), float32(y)
 	m := rs.XForm.Translate(fx, fy)
+	if rs.Rec != nil {
+		rs.Rec.Add(DisplayOp{Op: DisplayImage, Image: fmIm, XForm: m})
+	}
 	s2d := f64.Aff3{float64(m.XX), float64(m.XY), float64(m.X0), float64(m.YX), float64(m.YY), float64(m.Y0)}
 	if rs.Mask == nil {
 		transformer.Transform(rs.Image, s2d, fmIm, fmIm.Bounds(), draw.Over, nil)
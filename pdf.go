@@ -0,0 +1,579 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/goki/gi/units"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/font"
+)
+
+// PDF export: the rendering of a viewport or widget subtree is recorded in a
+// DisplayList, which is then written as a single-page PDF document with true
+// vector paths for all fills, strokes and clipping, shadings for linear and
+// radial gradients, and text drawn with the actual fonts from the
+// FontLibrary, embedded as subsets of just the glyphs used, so the text
+// remains selectable and searchable.  Images and masks (e.g., box shadows)
+// are embedded as images.  The page has the size of the rendered region at
+// the logical DPI of the viewport, so it prints at the same physical size as
+// shown on the screen.  Limitations: gradient stop opacities are not
+// exported (only the overall opacity), reflect and repeat gradient spreads
+// are exported as pad, and fonts that are not TrueType outline fonts are
+// replaced by Helvetica.
+
+// SavePDF renders the viewport and saves it as a vector PDF file at given
+// path -- see also SavePNG for a bitmap image
+func (vp *Viewport2D) SavePDF(path string) error {
+	if vp.Pixels == nil {
+		return fmt.Errorf("gi.SavePDF: viewport %v has not been rendered", vp.PathUnique())
+	}
+	dl := vp.RecordRender2DTree()
+	return dl.SavePDF(path, vp.Pixels.Bounds(), vp.ExportDPI())
+}
+
+// SavePDF renders this node and its children and saves them as a vector PDF
+// file at given path, on a page with the size of the node's region in its
+// viewport
+func (nb *Node2DBase) SavePDF(path string) error {
+	if nb.Viewport == nil || nb.VpBBox.Empty() {
+		return fmt.Errorf("gi.SavePDF: node %v has not been rendered", nb.PathUnique())
+	}
+	dl := nb.RecordRender2DTree()
+	return dl.SavePDF(path, nb.VpBBox, nb.Viewport.ExportDPI())
+}
+
+// SavePDF saves the given region of the list (in device coordinates) as a
+// single-page vector PDF file at given path, with the page size set from the
+// dpi (dots per inch) of the device coordinates
+func (dl *DisplayList) SavePDF(path string, bounds image.Rectangle, dpi float32) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	err = dl.WritePDF(bw, bounds, dpi)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// WritePDF writes the given region of the list (in device coordinates) as a
+// single-page vector PDF document to w, with the page size set from the dpi
+// (dots per inch) of the device coordinates
+func (dl *DisplayList) WritePDF(w io.Writer, bounds image.Rectangle, dpi float32) error {
+	if bounds.Empty() {
+		return fmt.Errorf("gi.WritePDF: empty page bounds")
+	}
+	if dpi <= 0 {
+		dpi = units.PxPerInch
+	}
+	sc := 72 / dpi
+	sz := bounds.Size()
+	pw := &pdfWriter{}
+	pw.init()
+	pgw, pgh := sc*float32(sz.X), sc*float32(sz.Y)
+	pw.page = Matrix2D{sc, 0, 0, -sc, -sc * float32(bounds.Min.X), pgh + sc*float32(bounds.Min.Y)}
+
+	catID, pagesID, pageID := pw.reserve(), pw.reserve(), pw.reserve()
+	fmt.Fprintf(&pw.cont, "q %s cm\n", pdfMatrix(pw.page))
+	vw := &vectorWalker{ex: pw}
+	vw.list(dl, Identity2D())
+	vw.popClips(0)
+	pw.cont.WriteString("Q\n")
+	contID := pw.stream("", pw.cont.Bytes())
+
+	var res bytes.Buffer
+	res.WriteString("<< /ProcSet [/PDF /Text /ImageB /ImageC]")
+	if len(pw.fontList) > 0 {
+		res.WriteString(" /Font <<")
+		for _, pf := range pw.fontList {
+			id, err := pw.writeFont(pf)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&res, " /%s %d 0 R", pf.name, id)
+		}
+		res.WriteString(" >>")
+	}
+	pdfResDict(&res, "ExtGState", pw.gsRes)
+	pdfResDict(&res, "Pattern", pw.patRes)
+	pdfResDict(&res, "XObject", pw.imgRes)
+	res.WriteString(" >>")
+
+	pw.set(catID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	pw.set(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", pageID))
+	pw.set(pageID, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+		pagesID, vecNum(pgw), vecNum(pgh), res.String(), contID))
+	return pw.write(w, catID)
+}
+
+// pdfWriter writes a DisplayList as a PDF document
+type pdfWriter struct {
+	objs     [][]byte               // contents of each object, for ids starting at 1
+	page     Matrix2D               // transform from device to page coordinates
+	cont     bytes.Buffer           // page content stream
+	path     bytes.Buffer           // operators for the current path
+	pathBox  vectorBBox             // bounding box of the current path
+	cur      Vec2D                  // current point
+	start    Vec2D                  // start of current subpath
+	faces    map[font.Face]pdfFace  // fonts and sizes of faces
+	fonts    map[string]*pdfFont    // fonts by path
+	fontList []*pdfFont             // fonts in order of resource names
+	gsRes    map[string]int         // ExtGState resources by name
+	gsNames  map[string]string      // ExtGState names by parameters
+	patRes   map[string]int         // Pattern resources by name
+	imgRes   map[string]int         // XObject image resources by name
+	imgNames map[image.Image]string // image resource names by source image
+}
+
+func (pw *pdfWriter) init() {
+	pw.faces = make(map[font.Face]pdfFace)
+	pw.fonts = make(map[string]*pdfFont)
+	pw.gsRes = make(map[string]int)
+	pw.gsNames = make(map[string]string)
+	pw.patRes = make(map[string]int)
+	pw.imgRes = make(map[string]int)
+	pw.imgNames = make(map[image.Image]string)
+}
+
+// reserve reserves an object id, for an object that is set later
+func (pw *pdfWriter) reserve() int {
+	pw.objs = append(pw.objs, nil)
+	return len(pw.objs)
+}
+
+// set sets the contents of the object with given id
+func (pw *pdfWriter) set(id int, obj string) {
+	pw.objs[id-1] = []byte(obj)
+}
+
+// add adds an object, returning its id
+func (pw *pdfWriter) add(obj string) int {
+	pw.objs = append(pw.objs, []byte(obj))
+	return len(pw.objs)
+}
+
+// stream adds a stream object with given data, compressed, and any extra
+// dictionary entries, returning its id
+func (pw *pdfWriter) stream(dict string, data []byte) int {
+	var zb bytes.Buffer
+	zw := zlib.NewWriter(&zb)
+	zw.Write(data)
+	zw.Close()
+	var obj bytes.Buffer
+	fmt.Fprintf(&obj, "<< %s /Length %d /Filter /FlateDecode >>\nstream\n", dict, zb.Len())
+	obj.Write(zb.Bytes())
+	obj.WriteString("\nendstream")
+	pw.objs = append(pw.objs, obj.Bytes())
+	return len(pw.objs)
+}
+
+// write writes the document, with all the objects and cross-reference table
+func (pw *pdfWriter) write(w io.Writer, rootID int) error {
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offs := make([]int, len(pw.objs))
+	for i, obj := range pw.objs {
+		offs[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(obj)
+		out.WriteString("\nendobj\n")
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(pw.objs)+1)
+	for _, off := range offs {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.objs)+1, rootID, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func (pw *pdfWriter) moveTo(p Vec2D) {
+	pw.pathBox.add(p)
+	fmt.Fprintf(&pw.path, "%s %s m\n", vecNum(p.X), vecNum(p.Y))
+	pw.cur, pw.start = p, p
+}
+
+func (pw *pdfWriter) lineTo(p Vec2D) {
+	pw.pathBox.add(p)
+	fmt.Fprintf(&pw.path, "%s %s l\n", vecNum(p.X), vecNum(p.Y))
+	pw.cur = p
+}
+
+// quadTo adds a quadratic curve, as the equivalent cubic curve
+func (pw *pdfWriter) quadTo(q, p Vec2D) {
+	c1 := pw.cur.Add(q.Sub(pw.cur).MulVal(2.0 / 3.0))
+	c2 := p.Add(q.Sub(p).MulVal(2.0 / 3.0))
+	pw.cubicTo(c1, c2, p)
+}
+
+func (pw *pdfWriter) cubicTo(c1, c2, p Vec2D) {
+	pw.pathBox.add(c1, c2, p)
+	fmt.Fprintf(&pw.path, "%s %s %s %s %s %s c\n", vecNum(c1.X), vecNum(c1.Y), vecNum(c2.X), vecNum(c2.Y), vecNum(p.X), vecNum(p.Y))
+	pw.cur = p
+}
+
+func (pw *pdfWriter) closePath() {
+	if pw.path.Len() > 0 {
+		pw.path.WriteString("h\n")
+		pw.cur = pw.start
+	}
+}
+
+func (pw *pdfWriter) clearPath() {
+	pw.path.Reset()
+	pw.pathBox.reset()
+}
+
+func (pw *pdfWriter) pathData() string {
+	return pw.path.String()
+}
+
+// beginClip sets the clipping path within its own graphics state
+func (pw *pdfWriter) beginClip(c *vectorClip) {
+	pw.cont.WriteString("q\n")
+	if c.bounds {
+		r := c.rect
+		fmt.Fprintf(&pw.cont, "%d %d %d %d re W n\n", r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+		return
+	}
+	pw.cont.WriteString(c.path)
+	if c.evenOdd {
+		pw.cont.WriteString("W* n\n")
+	} else {
+		pw.cont.WriteString("W n\n")
+	}
+}
+
+func (pw *pdfWriter) endClip(c *vectorClip) {
+	pw.cont.WriteString("Q\n")
+}
+
+// paintColor writes the operators to set the fill (or stroke) color to
+// given color spec, with given opacity, for the current path under given
+// transform -- returns false if there is nothing to paint
+func (pw *pdfWriter) paintColor(cs *ColorSpec, opacity float32, xf Matrix2D, stroke bool) bool {
+	if opacity <= 0 {
+		return false
+	}
+	clrop, csop, scnop := "rg", "cs", "scn"
+	if stroke {
+		clrop, csop, scnop = "RG", "CS", "SCN"
+	}
+	pw.alpha(opacity, stroke)
+	if cs.Source == SolidColor || cs.Gradient == nil || len(cs.Gradient.Stops) < 2 {
+		clr := color.Color(cs.Color)
+		if cs.Source != SolidColor && cs.Gradient != nil && len(cs.Gradient.Stops) == 1 {
+			clr = cs.Gradient.Stops[0].StopColor
+		}
+		fmt.Fprintf(&pw.cont, "%s %s\n", pdfColor(clr), clrop)
+		return true
+	}
+	pat := pw.gradient(cs, xf)
+	fmt.Fprintf(&pw.cont, "/Pattern %s /%s %s\n", csop, pat, scnop)
+	return true
+}
+
+// alpha sets the fill or stroke alpha to given opacity, if less than 1 --
+// the alpha is otherwise left at the default of 1 within the enclosing q / Q
+func (pw *pdfWriter) alpha(opacity float32, stroke bool) {
+	if opacity >= 1 {
+		return
+	}
+	pw.setAlpha(opacity, stroke)
+}
+
+// setAlpha sets the fill or stroke alpha to given opacity, using a graphics
+// state resource for it
+func (pw *pdfWriter) setAlpha(opacity float32, stroke bool) {
+	key := "ca"
+	if stroke {
+		key = "CA"
+	}
+	key += " " + vecNum(opacity)
+	nm, has := pw.gsNames[key]
+	if !has {
+		nm = fmt.Sprintf("GS%d", len(pw.gsNames)+1)
+		pw.gsNames[key] = nm
+		pw.gsRes[nm] = pw.add(fmt.Sprintf("<< /Type /ExtGState /%s >>", key))
+	}
+	fmt.Fprintf(&pw.cont, "/%s gs\n", nm)
+}
+
+// gradient adds a shading pattern for the gradient in given color spec, for
+// the current path under given transform, returning its resource name
+func (pw *pdfWriter) gradient(cs *ColorSpec, xf Matrix2D) string {
+	g := cs.Gradient
+	stops := append([]rasterx.GradStop(nil), g.Stops...)
+	sort.SliceStable(stops, func(i, j int) bool { return stops[i].Offset < stops[j].Offset })
+	type tclr struct {
+		t   float32
+		clr string
+	}
+	var pts []tclr
+	for _, s := range stops {
+		t := Min32(Max32(float32(s.Offset), 0), 1)
+		if len(pts) == 0 && t > 0 {
+			pts = append(pts, tclr{0, pdfColor(s.StopColor)})
+		}
+		pts = append(pts, tclr{t, pdfColor(s.StopColor)})
+	}
+	if last := pts[len(pts)-1]; last.t < 1 {
+		pts = append(pts, tclr{1, last.clr})
+	}
+	var funcs, bnds, enc []string
+	for i := 1; i < len(pts); i++ {
+		if pts[i].t == pts[i-1].t {
+			continue
+		}
+		if len(funcs) > 0 {
+			bnds = append(bnds, vecNum(pts[i-1].t))
+		}
+		funcs = append(funcs, fmt.Sprintf("<< /FunctionType 2 /Domain [0 1] /C0 [%s] /C1 [%s] /N 1 >>", pts[i-1].clr, pts[i].clr))
+		enc = append(enc, "0 1")
+	}
+	fn := funcs[0]
+	if len(funcs) > 1 {
+		fn = fmt.Sprintf("<< /FunctionType 3 /Domain [0 1] /Functions [%s] /Bounds [%s] /Encode [%s] >>",
+			strings.Join(funcs, " "), strings.Join(bnds, " "), strings.Join(enc, " "))
+	}
+
+	gm := vectorGradXForm(g, xf, &pw.pathBox).Multiply(pw.page) // pattern space is relative to the page
+	p := g.Points
+	var sh string
+	if cs.Source == RadialGradient {
+		sh = fmt.Sprintf("/ShadingType 3 /Coords [%s %s 0 %s %s %s]", vecNum(float32(p[2])), vecNum(float32(p[3])),
+			vecNum(float32(p[0])), vecNum(float32(p[1])), vecNum(float32(p[4])))
+	} else {
+		sh = fmt.Sprintf("/ShadingType 2 /Coords [%s %s %s %s]", vecNum(float32(p[0])), vecNum(float32(p[1])),
+			vecNum(float32(p[2])), vecNum(float32(p[3])))
+	}
+	nm := fmt.Sprintf("P%d", len(pw.patRes)+1)
+	pw.patRes[nm] = pw.add(fmt.Sprintf("<< /Type /Pattern /PatternType 2 /Shading << %s /ColorSpace /DeviceRGB /Function %s /Extend [true true] >> /Matrix [%s] >>",
+		sh, fn, pdfMatrix(gm)))
+	return nm
+}
+
+// fill fills the current path with given paint, under given transform
+func (pw *pdfWriter) fill(pc *Paint, xf Matrix2D) {
+	if pw.path.Len() == 0 {
+		return
+	}
+	pw.cont.WriteString("q\n")
+	if pw.paintColor(&pc.FillStyle.Color, pc.FontStyle.Opacity*pc.FillStyle.Opacity, xf, false) {
+		pw.cont.Write(pw.path.Bytes())
+		if pc.FillStyle.Rule == FillRuleEvenOdd {
+			pw.cont.WriteString("f*\n")
+		} else {
+			pw.cont.WriteString("f\n")
+		}
+	}
+	pw.cont.WriteString("Q\n")
+}
+
+// stroke strokes the current path with given paint, under given transform
+func (pw *pdfWriter) stroke(pc *Paint, xf Matrix2D) {
+	if pw.path.Len() == 0 {
+		return
+	}
+	lw := pc.StrokeWidth(&RenderState{XForm: xf})
+	if lw <= 0 {
+		return
+	}
+	pw.cont.WriteString("q\n")
+	if pw.paintColor(&pc.StrokeStyle.Color, pc.FontStyle.Opacity*pc.StrokeStyle.Opacity, xf, true) {
+		lcap, ljoin := 0, 0
+		switch pc.StrokeStyle.Cap {
+		case LineCapRound, LineCapCubic, LineCapQuadratic:
+			lcap = 1
+		case LineCapSquare:
+			lcap = 2
+		}
+		switch pc.StrokeStyle.Join {
+		case LineJoinRound, LineJoinArcs, LineJoinArcsClip:
+			ljoin = 1
+		case LineJoinBevel:
+			ljoin = 2
+		}
+		fmt.Fprintf(&pw.cont, "%s w %d J %d j %s M\n", vecNum(lw), lcap, ljoin, vecNum(Max32(pc.StrokeStyle.MiterLimit, 1)))
+		if dash, doff := pc.StrokeDashes(&RenderState{XForm: xf}); dash != nil {
+			fmt.Fprintf(&pw.cont, "[%s] %s d\n", vecDashes(dash), vecNum(float32(doff)))
+		}
+		pw.cont.Write(pw.path.Bytes())
+		pw.cont.WriteString("S\n")
+	}
+	pw.cont.WriteString("Q\n")
+}
+
+// fillBox fills the rectangle with given color, under given transform
+func (pw *pdfWriter) fillBox(r image.Rectangle, clr color.Color, xf Matrix2D) {
+	nc := color.NRGBAModel.Convert(clr).(color.NRGBA)
+	if nc.A == 0 {
+		return
+	}
+	pw.cont.WriteString("q\n")
+	pw.alpha(float32(nc.A)/255, false)
+	fmt.Fprintf(&pw.cont, "%s rg\n", pdfColor(color.RGBA{nc.R, nc.G, nc.B, 255}))
+	pts := [4]Vec2D{{float32(r.Min.X), float32(r.Min.Y)}, {float32(r.Max.X), float32(r.Min.Y)},
+		{float32(r.Max.X), float32(r.Max.Y)}, {float32(r.Min.X), float32(r.Max.Y)}}
+	for i, p := range pts {
+		tp := xf.TransformPointVec2D(p)
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(&pw.cont, "%s %s %s\n", vecNum(tp.X), vecNum(tp.Y), op)
+	}
+	pw.cont.WriteString("h f\nQ\n")
+}
+
+// image draws the src image, or src through the alpha mask if non-nil, with
+// given transform from source to user coordinates
+func (pw *pdfWriter) image(src image.Image, mask image.Image, xf Matrix2D) {
+	sr := src.Bounds()
+	if mask != nil {
+		sr = mask.Bounds()
+	}
+	if sr.Empty() {
+		return
+	}
+	nm := ""
+	if mask == nil {
+		nm = pw.imgNames[src]
+	}
+	if nm == "" {
+		nm = pw.addImage(src, mask, sr)
+		if mask == nil {
+			pw.imgNames[src] = nm
+		}
+	}
+	w, h := float32(sr.Dx()), float32(sr.Dy())
+	im := Matrix2D{w, 0, 0, -h, float32(sr.Min.X), float32(sr.Min.Y) + h} // unit square, rows top down
+	fmt.Fprintf(&pw.cont, "q %s cm /%s Do Q\n", pdfMatrix(im.Multiply(xf)), nm)
+}
+
+// addImage adds an image resource for region sr of src, with the alpha from
+// the mask if non-nil, returning its name
+func (pw *pdfWriter) addImage(src image.Image, mask image.Image, sr image.Rectangle) string {
+	w, h := sr.Dx(), sr.Dy()
+	rgb := make([]byte, 0, 3*w*h)
+	alpha := make([]byte, 0, w*h)
+	opaque := true
+	for y := sr.Min.Y; y < sr.Max.Y; y++ {
+		for x := sr.Min.X; x < sr.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			if mask != nil {
+				_, _, _, ma := mask.At(x, y).RGBA()
+				c.A = uint8((uint32(c.A) * (ma >> 8)) / 255)
+			}
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			if c.A != 255 {
+				opaque = false
+			}
+		}
+	}
+	smask := ""
+	if !opaque {
+		id := pw.stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8", w, h), alpha)
+		smask = fmt.Sprintf(" /SMask %d 0 R", id)
+	}
+	nm := fmt.Sprintf("Im%d", len(pw.imgRes)+1)
+	pw.imgRes[nm] = pw.stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8%s", w, h, smask), rgb)
+	return nm
+}
+
+// text draws the glyphs of the span at given position, under given
+// transform, as text in the fonts of the span
+func (pw *pdfWriter) text(sr *SpanRender, tpos Vec2D, xf Matrix2D) {
+	curFace := sr.Render[0].Face
+	curColor := sr.Render[0].Color
+	var curFont *pdfFont
+	lastColor := color.Color(nil)
+	lastAlpha := float32(1)
+	pw.cont.WriteString("q\nBT\n")
+	for i, r := range sr.Text {
+		rr := &sr.Render[i]
+		if rr.Color != nil {
+			curColor = rr.Color
+		}
+		curFace = rr.CurFace(curFace)
+		if !unicode.IsPrint(r) {
+			continue
+		}
+		if curColor != lastColor {
+			nc := color.NRGBAModel.Convert(curColor).(color.NRGBA)
+			if a := float32(nc.A) / 255; a != lastAlpha { // includes back to opaque
+				pw.setAlpha(a, false)
+				lastAlpha = a
+			}
+			fmt.Fprintf(&pw.cont, "%s rg\n", pdfColor(color.RGBA{nc.R, nc.G, nc.B, 255}))
+			lastColor = curColor
+		}
+		pf := pw.faceFont(curFace)
+		if pf.font != curFont {
+			fmt.Fprintf(&pw.cont, "/%s 1 Tf\n", pf.font.name)
+			curFont = pf.font
+		}
+		scx := float32(1)
+		if rr.ScaleX != 0 {
+			scx = rr.ScaleX
+		}
+		rp := tpos.Add(rr.RelPos)
+		tm := Scale2D(pf.size, -pf.size).Multiply(Rotate2D(rr.RotRad)).Multiply(Scale2D(scx, 1)).Multiply(Translate2D(rp.X, rp.Y)).Multiply(xf)
+		fmt.Fprintf(&pw.cont, "%s Tm %s Tj\n", pdfMatrix(tm), pf.font.glyph(r))
+	}
+	pw.cont.WriteString("ET\nQ\n")
+}
+
+// pdfResDict writes a resource sub-dictionary of given type, with the
+// resources in name order
+func pdfResDict(b *bytes.Buffer, typ string, res map[string]int) {
+	if len(res) == 0 {
+		return
+	}
+	nms := make([]string, 0, len(res))
+	for nm := range res {
+		nms = append(nms, nm)
+	}
+	sort.Strings(nms)
+	fmt.Fprintf(b, " /%s <<", typ)
+	for _, nm := range nms {
+		fmt.Fprintf(b, " /%s %d 0 R", nm, res[nm])
+	}
+	b.WriteString(" >>")
+}
+
+// pdfMatrix formats a transform as the 6 operands of a PDF matrix
+func pdfMatrix(m Matrix2D) string {
+	return strings.Join([]string{vecNum(m.XX), vecNum(m.YX), vecNum(m.XY), vecNum(m.YY), vecNum(m.X0), vecNum(m.Y0)}, " ")
+}
+
+// pdfColor formats the RGB components of a color (ignoring alpha) as the
+// operands of a PDF color
+func pdfColor(clr color.Color) string {
+	if clr == nil {
+		return "0 0 0"
+	}
+	r, g, b, _ := clr.RGBA()
+	return fmt.Sprintf("%s %s %s", vecNum(float32(r>>8)/255), vecNum(float32(g>>8)/255), vecNum(float32(b>>8)/255))
+}
@@ -0,0 +1,288 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/goki/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// testPDFObjects checks the overall structure of a PDF document: the header,
+// trailer and the cross-reference table, which must point at each object in
+// turn -- returns the contents of the objects by id
+func testPDFObjects(t *testing.T, data []byte) map[int][]byte {
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("PDF header or end of file marker missing\n")
+	}
+	var xref, n int
+	si := bytes.LastIndex(data, []byte("startxref\n"))
+	if si < 0 {
+		t.Fatalf("PDF startxref missing\n")
+	}
+	fmt.Sscanf(string(data[si+10:]), "%d", &xref)
+	if _, err := fmt.Sscanf(string(data[xref:]), "xref\n0 %d\n", &n); err != nil {
+		t.Fatalf("PDF xref table not found at startxref offset %v: %v\n", xref, err)
+	}
+	ents := data[xref+len(fmt.Sprintf("xref\n0 %d\n", n)):]
+	objs := make(map[int][]byte)
+	for id := 1; id < n; id++ {
+		off, _ := strconv.Atoi(string(ents[20*id : 20*id+10]))
+		hdr := fmt.Sprintf("%d 0 obj\n", id)
+		if off >= len(data) || !bytes.HasPrefix(data[off:], []byte(hdr)) {
+			t.Errorf("PDF xref entry for object %v does not point at it: offset %v\n", id, off)
+			continue
+		}
+		body := data[off+len(hdr):]
+		objs[id] = body[:bytes.Index(body, []byte("\nendobj\n"))]
+	}
+	return objs
+}
+
+// testPDFStream returns the decompressed data of a stream object
+func testPDFStream(t *testing.T, obj []byte) []byte {
+	st := bytes.Index(obj, []byte("stream\n"))
+	ed := bytes.LastIndex(obj, []byte("\nendstream"))
+	if st < 0 || ed < st {
+		t.Fatalf("PDF object is not a stream: %.40q\n", obj)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(obj[st+7 : ed]))
+	if err != nil {
+		t.Fatalf("PDF stream: %v\n", err)
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("PDF stream: %v\n", err)
+	}
+	return data
+}
+
+// testPDFRef returns the id of the object referenced by given key in obj
+func testPDFRef(t *testing.T, obj []byte, key string) int {
+	m := regexp.MustCompile(key + `\s*(\d+) 0 R`).FindSubmatch(obj)
+	if m == nil {
+		t.Fatalf("PDF object has no %v reference: %q\n", key, obj)
+	}
+	id, _ := strconv.Atoi(string(m[1]))
+	return id
+}
+
+func TestWritePDF(t *testing.T) {
+	dl := &DisplayList{}
+	dl.Add(DisplayOp{Op: DisplayFillBox, Rect: image.Rect(10, 10, 50, 30), Color: color.RGBA{255, 0, 0, 255}})
+	dl.Add(DisplayOp{Op: DisplayFillBox, Rect: image.Rect(0, 0, 20, 20), Color: color.NRGBA{0, 0, 255, 128}})
+	dl.Add(DisplayOp{Op: DisplayFillBox, Rect: image.Rect(0, 0, 20, 20), Color: color.Transparent})
+	var b bytes.Buffer
+	if err := dl.WritePDF(&b, image.Rect(0, 0, 200, 100), 144); err != nil {
+		t.Fatalf("WritePDF: %v\n", err)
+	}
+	objs := testPDFObjects(t, b.Bytes())
+	cat := objs[1] // the catalog is the root
+	if !bytes.Contains(b.Bytes(), []byte("/Root 1 0 R")) || !bytes.Contains(cat, []byte("/Type /Catalog")) {
+		t.Fatalf("WritePDF: catalog is not object 1: %q\n", cat)
+	}
+	pages := objs[testPDFRef(t, cat, "/Pages")]
+	page := objs[testPDFRef(t, pages, "/Kids \\[")]
+	cont := string(testPDFStream(t, objs[testPDFRef(t, page, "/Contents")]))
+	gs := objs[testPDFRef(t, page, "/GS1")]
+
+	tests := []struct {
+		what string
+		obj  string
+		cor  string
+	}{
+		{"page size, in points at 144 dpi", string(page), "/MediaBox [0 0 100 50]"},
+		{"flip to page coordinates", cont, "q 0.5 0 0 -0.5 0 50 cm\n"},
+		{"opaque box", cont, "q\n1 0 0 rg\n10 10 m\n50 10 l\n50 30 l\n10 30 l\nh f\nQ\n"},
+		{"translucent box", cont, "q\n/GS1 gs\n0 0 1 rg\n0 0 m\n"},
+		{"alpha graphics state", string(gs), "/ca 0.502"},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(tt.obj, tt.cor) {
+			t.Errorf("WritePDF %v: got %q, expected it to contain %q\n", tt.what, tt.obj, tt.cor)
+		}
+	}
+	if n := strings.Count(cont, " rg\n"); n != 2 {
+		t.Errorf("WritePDF: got %v filled boxes, expected 2 (the transparent one is skipped)\n", n)
+	}
+	if err := dl.WritePDF(&b, image.Rectangle{}, 96); err == nil {
+		t.Errorf("WritePDF with empty bounds: expected an error\n")
+	}
+}
+
+func TestPDFFont(t *testing.T) {
+	pw := &pdfWriter{}
+	pw.init()
+	pf := pw.loadFont("gofont/goregular")
+	if pf.ttf == nil {
+		t.Fatalf("loadFont: Go font not loaded for embedding\n")
+	}
+	for _, r := range "Hi" {
+		if g, cor := pf.glyph(r), fmt.Sprintf("<%04X>", pf.ttf.Index(r)); g != cor {
+			t.Errorf("glyph(%c): got %v, expected %v\n", r, g, cor)
+		}
+	}
+	id, err := pw.writeFont(pf)
+	if err != nil {
+		t.Fatalf("writeFont: %v\n", err)
+	}
+	var b bytes.Buffer
+	pw.write(&b, id)
+	objs := testPDFObjects(t, b.Bytes())
+	fnt := objs[id]
+	if !bytes.Contains(fnt, []byte("/Subtype /Type0")) || !bytes.Contains(fnt, []byte("/Encoding /Identity-H")) {
+		t.Errorf("writeFont: unexpected font dictionary: %q\n", fnt)
+	}
+	cmap := string(testPDFStream(t, objs[testPDFRef(t, fnt, "/ToUnicode")]))
+	for _, r := range "Hi" {
+		if ent := fmt.Sprintf("<%04X> <%04X>", pf.ttf.Index(r), r); !strings.Contains(cmap, ent) {
+			t.Errorf("writeFont: ToUnicode CMap does not map %c: expected %q in %q\n", r, ent, cmap)
+		}
+	}
+	cid := objs[testPDFRef(t, fnt, "/DescendantFonts \\[")]
+	desc := objs[testPDFRef(t, cid, "/FontDescriptor")]
+	sub := testPDFStream(t, objs[testPDFRef(t, desc, "/FontFile2")])
+	st, err := truetype.Parse(sub)
+	if err != nil {
+		t.Fatalf("writeFont: embedded font file: %v\n", err)
+	}
+	if st.Index('H') != pf.ttf.Index('H') {
+		t.Errorf("writeFont: embedded font glyph index for H: got %v, expected %v\n", st.Index('H'), pf.ttf.Index('H'))
+	}
+
+	fb := pw.fallbackFont()
+	if fb != pw.fallbackFont() || fb.name != "F2" {
+		t.Errorf("fallbackFont: expected a single F2 font, got %v\n", fb.name)
+	}
+	for _, tt := range []struct {
+		r   rune
+		cor string
+	}{{'A', "<41>"}, {'é', "<E9>"}, {'€', "<3F>"}} {
+		if g := fb.glyph(tt.r); g != tt.cor {
+			t.Errorf("fallback glyph(%c): got %v, expected %v\n", tt.r, g, tt.cor)
+		}
+	}
+}
+
+func TestPDFTextAlpha(t *testing.T) {
+	pw := &pdfWriter{}
+	pw.init()
+	face := basicfont.Face7x13
+	pw.faces[face] = pdfFace{font: pw.fallbackFont(), size: 13}
+	sr := &SpanRender{Text: []rune("abc")}
+	sr.Render = []RuneRender{
+		{Face: face, Color: color.NRGBA{0, 0, 0, 128}},
+		{Color: color.NRGBA{0, 0, 0, 255}},
+		{Color: color.NRGBA{255, 0, 0, 255}},
+	}
+	pw.text(sr, Vec2D{}, Identity2D())
+	cont := pw.cont.String()
+	if n := strings.Count(cont, " gs\n"); n != 2 {
+		t.Errorf("text: got %v graphics states, expected 2 (translucent, then back to opaque): %q\n", n, cont)
+	}
+	if !strings.Contains(cont, "/GS1 gs\n0 0 0 rg\n") || !strings.Contains(cont, "/GS2 gs\n0 0 0 rg\n") {
+		t.Errorf("text: expected alpha to be set with the color of each run: %q\n", cont)
+	}
+	if _, has := pw.gsNames["ca 1"]; !has {
+		t.Errorf("text: no opaque graphics state to reset the alpha after the translucent run: %v\n", pw.gsNames)
+	}
+	if n := strings.Count(cont, "/GS2 gs\n"); n != 1 {
+		t.Errorf("text: got %v opaque graphics states, expected 1 (the red run is also opaque): %q\n", n, cont)
+	}
+}
+
+func TestSubsetTrueType(t *testing.T) {
+	data := GoFonts["gofont/goregular"].ttf
+	ttf, err := truetype.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gids := []int{int(ttf.Index('H')), int(ttf.Index('i'))}
+	sub, err := subsetTrueType(data, gids)
+	if err != nil {
+		t.Fatalf("subsetTrueType: %v\n", err)
+	}
+	if len(sub) >= len(data) {
+		t.Errorf("subsetTrueType: subset is not smaller: %v vs. %v bytes\n", len(sub), len(data))
+	}
+	if sum := ttfChecksum(sub); sum != 0xB1B0AFBA {
+		t.Errorf("subsetTrueType: font checksum: got %X, expected B1B0AFBA\n", sum)
+	}
+	st, err := truetype.Parse(sub)
+	if err != nil {
+		t.Fatalf("subsetTrueType: parsing subset: %v\n", err)
+	}
+	upem := fixed.Int26_6(ttf.FUnitsPerEm())
+	var gb, sgb truetype.GlyphBuf
+	tests := []struct {
+		nm   string
+		idx  truetype.Index
+		kept bool
+	}{
+		{".notdef", 0, true},
+		{"H", ttf.Index('H'), true},
+		{"i", ttf.Index('i'), true},
+		{"Z", ttf.Index('Z'), false},
+	}
+	for _, tt := range tests {
+		if err := gb.Load(ttf, upem, tt.idx, font.HintingNone); err != nil {
+			t.Fatal(err)
+		}
+		if err := sgb.Load(st, upem, tt.idx, font.HintingNone); err != nil {
+			t.Errorf("subsetTrueType glyph %v: %v\n", tt.nm, err)
+			continue
+		}
+		cor := 0
+		if tt.kept {
+			cor = len(gb.Points)
+		}
+		if len(sgb.Points) != cor {
+			t.Errorf("subsetTrueType glyph %v: got %v points, expected %v\n", tt.nm, len(sgb.Points), cor)
+		}
+	}
+
+	bad := []struct {
+		nm   string
+		data []byte
+	}{
+		{"too short", data[:8]},
+		{"no tables", []byte{0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"truncated directory", data[:20]},
+	}
+	for _, tt := range bad {
+		if _, err := subsetTrueType(tt.data, gids); err == nil {
+			t.Errorf("subsetTrueType %v: expected an error\n", tt.nm)
+		}
+	}
+}
+
+func TestUTF16Units(t *testing.T) {
+	tests := []struct {
+		r   rune
+		cor []uint16
+	}{
+		{'A', []uint16{0x41}},
+		{'€', []uint16{0x20AC}},
+		{0x1F600, []uint16{0xD83D, 0xDE00}},
+	}
+	for _, tt := range tests {
+		us := utf16Units(tt.r)
+		if fmt.Sprint(us) != fmt.Sprint(tt.cor) {
+			t.Errorf("utf16Units(%U): got %X, expected %X\n", tt.r, us, tt.cor)
+		}
+	}
+}
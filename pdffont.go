@@ -0,0 +1,362 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goki/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// pdfFont is a font resource in a PDF document: a TrueType font file from
+// the FontLibrary, embedded as a subset with just the glyphs that are used,
+// or the standard Helvetica font as a fallback for fonts that cannot be
+// embedded (e.g., OpenType CFF fonts)
+type pdfFont struct {
+	name string                  // resource name, e.g., F1
+	ttf  *truetype.Font          // parsed font -- nil for the fallback font
+	data []byte                  // font file
+	used map[truetype.Index]rune // glyphs used, with the rune for each
+}
+
+// pdfFace is a font face used in the document, with its font size in dots
+type pdfFace struct {
+	font *pdfFont
+	size float32
+}
+
+// faceFont returns the font and size for given face, which is looked up in
+// the FontLibrary cache of faces
+func (pw *pdfWriter) faceFont(face font.Face) pdfFace {
+	if pf, ok := pw.faces[face]; ok {
+		return pf
+	}
+	fontnm, size := vectorFaceName(face)
+	pf := pdfFace{size: size}
+	path := FontLibrary.FontsAvail[fontnm]
+	if path != "" {
+		pf.font = pw.fonts[path]
+		if pf.font == nil {
+			pf.font = pw.loadFont(path)
+			pw.fonts[path] = pf.font
+		}
+	} else {
+		pf.font = pw.fallbackFont()
+	}
+	pw.faces[face] = pf
+	return pf
+}
+
+// loadFont loads the TrueType font at given path for embedding, returning
+// the fallback font if it cannot be embedded
+func (pw *pdfWriter) loadFont(path string) *pdfFont {
+	var data []byte
+	if gf, ok := GoFonts[path]; ok {
+		data = gf.ttf
+	} else if strings.ToLower(filepath.Ext(path)) != ".otf" {
+		data, _ = ioutil.ReadFile(path)
+	}
+	if data == nil {
+		return pw.fallbackFont()
+	}
+	ttf, err := truetype.Parse(data)
+	if err != nil {
+		return pw.fallbackFont()
+	}
+	pf := &pdfFont{ttf: ttf, data: data, used: make(map[truetype.Index]rune)}
+	pf.name = fmt.Sprintf("F%d", len(pw.fontList)+1)
+	pw.fontList = append(pw.fontList, pf)
+	return pf
+}
+
+// fallbackFont returns the standard Helvetica font
+func (pw *pdfWriter) fallbackFont() *pdfFont {
+	if pf := pw.fonts[""]; pf != nil {
+		return pf
+	}
+	pf := &pdfFont{}
+	pf.name = fmt.Sprintf("F%d", len(pw.fontList)+1)
+	pw.fontList = append(pw.fontList, pf)
+	pw.fonts[""] = pf
+	return pf
+}
+
+// glyph returns the hex string that shows the given rune in the font
+func (pf *pdfFont) glyph(r rune) string {
+	if pf.ttf == nil { // WinAnsiEncoding
+		if r > 255 {
+			r = '?'
+		}
+		return fmt.Sprintf("<%02X>", r)
+	}
+	idx := pf.ttf.Index(r)
+	if _, has := pf.used[idx]; !has || idx == 0 {
+		pf.used[idx] = r
+	}
+	return fmt.Sprintf("<%04X>", idx)
+}
+
+// writeFont adds the objects for the font to the document, returning the
+// object id of the font
+func (pw *pdfWriter) writeFont(pf *pdfFont) (int, error) {
+	if pf.ttf == nil {
+		return pw.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"), nil
+	}
+	ttf := pf.ttf
+	gids := make([]int, 0, len(pf.used))
+	for idx := range pf.used {
+		gids = append(gids, int(idx))
+	}
+	sort.Ints(gids)
+	sub, err := subsetTrueType(pf.data, gids)
+	if err != nil {
+		return 0, err
+	}
+
+	upem := ttf.FUnitsPerEm()
+	scale := func(v fixed.Int26_6) int { return int(v) * 1000 / int(upem) }
+	h := fnv.New32a()
+	for _, g := range gids {
+		h.Write([]byte{byte(g >> 8), byte(g)})
+	}
+	tag := make([]byte, 6)
+	for i, hv := 0, h.Sum32(); i < 6; i++ {
+		tag[i] = 'A' + byte(hv%26)
+		hv /= 26
+	}
+	psnm := strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' || strings.ContainsRune("()<>[]{}/%#", r) {
+			return -1
+		}
+		return r
+	}, ttf.Name(truetype.NameIDPostscriptName))
+	if psnm == "" {
+		psnm = "Font"
+	}
+	basenm := string(tag) + "+" + psnm
+
+	fileID := pw.stream(fmt.Sprintf("/Length1 %d", len(sub)), sub)
+	bb := ttf.Bounds(fixed.Int26_6(upem))
+	descID := pw.add(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		basenm, scale(bb.Min.X), scale(bb.Min.Y), scale(bb.Max.X), scale(bb.Max.Y), scale(bb.Max.Y), scale(bb.Min.Y), scale(bb.Max.Y), fileID))
+
+	var w bytes.Buffer
+	for _, g := range gids {
+		adv := ttf.HMetric(fixed.Int26_6(upem), truetype.Index(g)).AdvanceWidth
+		fmt.Fprintf(&w, "%d [%d] ", g, scale(adv))
+	}
+	cidID := pw.add(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W [%s] /CIDToGIDMap /Identity >>",
+		basenm, descID, w.String()))
+
+	var cm bytes.Buffer
+	cm.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for st := 0; st < len(gids); st += 100 { // at most 100 entries per block
+		ed := st + 100
+		if ed > len(gids) {
+			ed = len(gids)
+		}
+		fmt.Fprintf(&cm, "%d beginbfchar\n", ed-st)
+		for _, g := range gids[st:ed] {
+			fmt.Fprintf(&cm, "<%04X> <", g)
+			for _, u := range utf16Units(pf.used[truetype.Index(g)]) {
+				fmt.Fprintf(&cm, "%04X", u)
+			}
+			cm.WriteString(">\n")
+		}
+		cm.WriteString("endbfchar\n")
+	}
+	cm.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	uniID := pw.stream("", cm.Bytes())
+
+	return pw.add(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		basenm, cidID, uniID)), nil
+}
+
+// utf16Units returns the UTF-16 encoding of the rune
+func utf16Units(r rune) []uint16 {
+	if r < 0x10000 {
+		return []uint16{uint16(r)}
+	}
+	r -= 0x10000
+	return []uint16{uint16(0xd800 + (r>>10)&0x3ff), uint16(0xdc00 + r&0x3ff)}
+}
+
+// subsetTableTags are the TrueType tables kept in a subset font, as needed
+// for embedding in PDF
+var subsetTableTags = []string{"cmap", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// subsetTrueType returns a copy of the TrueType font file data with only the
+// given glyphs (along with the .notdef glyph and any components of composite
+// glyphs), and only the tables needed for embedding in PDF -- glyph indexes
+// are preserved, and the other glyphs are left empty
+func subsetTrueType(data []byte, gids []int) ([]byte, error) {
+	be := binary.BigEndian
+	if len(data) < 12 {
+		return nil, fmt.Errorf("gi.subsetTrueType: font file too short")
+	}
+	tables := make(map[string][]byte)
+	ntab := int(be.Uint16(data[4:]))
+	for i := 0; i < ntab; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, fmt.Errorf("gi.subsetTrueType: bad table directory")
+		}
+		tag := string(data[rec : rec+4])
+		off, ln := int(be.Uint32(data[rec+8:])), int(be.Uint32(data[rec+12:]))
+		if off+ln > len(data) {
+			return nil, fmt.Errorf("gi.subsetTrueType: table %v out of range", tag)
+		}
+		tables[tag] = data[off : off+ln]
+	}
+	for _, tag := range []string{"head", "loca", "glyf", "maxp"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("gi.subsetTrueType: no %v table -- not a TrueType outline font", tag)
+		}
+	}
+	head, loca, glyf := tables["head"], tables["loca"], tables["glyf"]
+	if len(head) < 54 || len(tables["maxp"]) < 6 {
+		return nil, fmt.Errorf("gi.subsetTrueType: bad head or maxp table")
+	}
+	nglyph := int(be.Uint16(tables["maxp"][4:]))
+	longLoca := be.Uint16(head[50:]) != 0
+	glyph := func(g int) []byte {
+		var st, ed int
+		if longLoca {
+			if 4*g+8 > len(loca) {
+				return nil
+			}
+			st, ed = int(be.Uint32(loca[4*g:])), int(be.Uint32(loca[4*g+4:]))
+		} else {
+			if 2*g+4 > len(loca) {
+				return nil
+			}
+			st, ed = 2*int(be.Uint16(loca[2*g:])), 2*int(be.Uint16(loca[2*g+2:]))
+		}
+		if st >= ed || ed > len(glyf) {
+			return nil
+		}
+		return glyf[st:ed]
+	}
+
+	keep := make(map[int]bool)
+	todo := append([]int{0}, gids...)
+	for len(todo) > 0 {
+		g := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+		if g >= nglyph || keep[g] {
+			continue
+		}
+		keep[g] = true
+		gd := glyph(g)
+		if len(gd) < 10 || int16(be.Uint16(gd)) >= 0 { // not composite
+			continue
+		}
+		for off := 10; off+4 <= len(gd); {
+			flags := be.Uint16(gd[off:])
+			todo = append(todo, int(be.Uint16(gd[off+2:])))
+			off += 4
+			if flags&0x0001 != 0 { // ARG_1_AND_2_ARE_WORDS
+				off += 4
+			} else {
+				off += 2
+			}
+			switch {
+			case flags&0x0008 != 0: // WE_HAVE_A_SCALE
+				off += 2
+			case flags&0x0040 != 0: // WE_HAVE_AN_X_AND_Y_SCALE
+				off += 4
+			case flags&0x0080 != 0: // WE_HAVE_A_TWO_BY_TWO
+				off += 8
+			}
+			if flags&0x0020 == 0 { // MORE_COMPONENTS
+				break
+			}
+		}
+	}
+
+	var nglyf bytes.Buffer
+	nloca := make([]byte, 4*(nglyph+1))
+	for g := 0; g < nglyph; g++ {
+		be.PutUint32(nloca[4*g:], uint32(nglyf.Len()))
+		if !keep[g] {
+			continue
+		}
+		gd := glyph(g)
+		nglyf.Write(gd)
+		for nglyf.Len()%4 != 0 {
+			nglyf.WriteByte(0)
+		}
+	}
+	be.PutUint32(nloca[4*nglyph:], uint32(nglyf.Len()))
+	nhead := append([]byte(nil), head...)
+	be.PutUint32(nhead[8:], 0)  // checkSumAdjustment, set below
+	be.PutUint16(nhead[50:], 1) // long loca
+	tables["glyf"], tables["loca"], tables["head"] = nglyf.Bytes(), nloca, nhead
+
+	var tags []string
+	for _, tag := range subsetTableTags {
+		if tables[tag] != nil {
+			tags = append(tags, tag)
+		}
+	}
+	nt := len(tags)
+	sr, es := 1, 0
+	for sr*2 <= nt {
+		sr *= 2
+		es++
+	}
+	var out bytes.Buffer
+	hdr := make([]byte, 12+16*nt)
+	be.PutUint32(hdr, 0x00010000)
+	be.PutUint16(hdr[4:], uint16(nt))
+	be.PutUint16(hdr[6:], uint16(sr*16))
+	be.PutUint16(hdr[8:], uint16(es))
+	be.PutUint16(hdr[10:], uint16((nt-sr)*16))
+	off := len(hdr)
+	headOff := 0
+	for i, tag := range tags {
+		tb := tables[tag]
+		rec := hdr[12+16*i:]
+		copy(rec, tag)
+		be.PutUint32(rec[4:], ttfChecksum(tb))
+		be.PutUint32(rec[8:], uint32(off))
+		be.PutUint32(rec[12:], uint32(len(tb)))
+		if tag == "head" {
+			headOff = off
+		}
+		off += (len(tb) + 3) &^ 3
+	}
+	out.Write(hdr)
+	for _, tag := range tags {
+		tb := tables[tag]
+		out.Write(tb)
+		for i := len(tb); i%4 != 0; i++ {
+			out.WriteByte(0)
+		}
+	}
+	fdata := out.Bytes()
+	be.PutUint32(fdata[headOff+8:], 0xB1B0AFBA-ttfChecksum(fdata))
+	return fdata, nil
+}
+
+// ttfChecksum returns the TrueType checksum of given table data
+func ttfChecksum(b []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(b); i += 4 {
+		var v [4]byte
+		copy(v[:], b[i:])
+		sum += binary.BigEndian.Uint32(v[:])
+	}
+	return sum
+}
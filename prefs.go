@@ -41,15 +41,16 @@ type ScreenPrefs struct {
 // ColorPrefs specify colors for all major categories of GUI elements, and are
 // used in the default styles.
 type ColorPrefs struct {
-	Font       Color `desc:"default font / pen color"`
-	Background Color `desc:"default background color"`
-	Shadow     Color `desc:"color for shadows -- should generally be a darker shade of the background color"`
-	Border     Color `desc:"default border color, for button, frame borders, etc"`
-	Control    Color `desc:"default main color for controls: buttons, etc"`
-	Icon       Color `desc:"color for icons or other solidly-colored, small elements"`
-	Select     Color `desc:"color for selected elements"`
-	Highlight  Color `desc:"color for highlight background"`
-	Link       Color `desc:"color for links in text etc"`
+	Font       Color  `desc:"default font / pen color"`
+	Background Color  `desc:"default background color"`
+	Shadow     Color  `desc:"color for shadows -- should generally be a darker shade of the background color"`
+	Border     Color  `desc:"default border color, for button, frame borders, etc"`
+	Control    Color  `desc:"default main color for controls: buttons, etc"`
+	Icon       Color  `desc:"color for icons or other solidly-colored, small elements"`
+	Select     Color  `desc:"color for selected elements"`
+	Highlight  Color  `desc:"color for highlight background"`
+	Link       Color  `desc:"color for links in text etc"`
+	HiStyle    string `desc:"name of the syntax highlighting style (see giv.HiStyles) that goes with these colors -- used by default in TextView's"`
 }
 
 // ParamPrefs contains misc parameters controlling GUI behavior.
@@ -72,12 +73,15 @@ type User struct {
 type Preferences struct {
 	LogicalDPIScale float32                `min:"0.1" step:"0.1" desc:"overall scaling factor for Logical DPI as a multiplier on Physical DPI -- smaller numbers produce smaller font sizes etc"`
 	ScreenPrefs     map[string]ScreenPrefs `desc:"screen-specific preferences -- will override overall defaults if set"`
-	Colors          ColorPrefs             `desc:"color preferences"`
+	ActiveTheme     string                 `desc:"name of the active color theme, which sets the Colors when preferences are applied: light, dark, or high-contrast, or one of your own ColorThemes -- auto follows the dark mode setting of the OS, switching between light and dark -- if blank, Colors are used as-is"`
+	ColorThemes     ColorThemes            `desc:"your own named color themes -- these are added to the standard themes (and override any standard theme of the same name) -- use SaveColorsAsTheme to add the current Colors as a theme"`
+	Colors          ColorPrefs             `desc:"color preferences -- set from the ActiveTheme if that is set, so edit these and then SaveColorsAsTheme to customize"`
 	Params          ParamPrefs             `desc:"parameters controlling GUI behavior"`
 	KeyMap          KeyMapName             `desc:"select the active keymap from list of available keymaps -- see Edit KeyMaps for editing / saving / loading that list"`
 	SaveKeyMaps     bool                   `desc:"if set, the current available set of key maps is saved to your preferences directory, and automatically loaded at startup -- this should be set if you are using custom key maps, but it may be safer to keep it <i>OFF</i> if you are <i>not</i> using custom key maps, so that you'll always have the latest compiled-in standard key maps with all the current key functions bound to standard key chords"`
 	PrefsOverride   bool                   `desc:"if true my custom style preferences override other styling -- otherwise they provide defaults that can be overriden by app-specific styling"`
 	CustomStyles    ki.Props               `desc:"a custom style sheet -- add a separate Props entry for each type of object, e.g., button, or class using .classname, or specific named element using #name -- all are case insensitive"`
+	StyleFiles      []FileName             `ext:".css" desc:"external CSS style sheet (.css) files, which are merged into the CustomStyles (overriding them, with later files overriding earlier ones) -- these files are monitored while the app is running, and all windows are restyled whenever they change"`
 	FontFamily      FontName               `desc:"default font family when otherwise not specified"`
 	FontPaths       []string               `desc:"extra font paths, beyond system defaults -- searched first"`
 	User            User                   `desc:"user info -- partially filled-out automatically if empty / when prefs first created"`
@@ -103,6 +107,7 @@ func (pf *ColorPrefs) Defaults() {
 	pf.Select.SetString("#CFC", nil)
 	pf.Highlight.SetString("#FFA", nil)
 	pf.Link.SetString("#00F", nil)
+	pf.HiStyle = "emacs"
 }
 
 // PrefColor returns preference color of given name (case insensitive)
@@ -141,6 +146,7 @@ func (pf *ParamPrefs) Defaults() {
 func (pf *Preferences) Defaults() {
 	pf.LogicalDPIScale = 1.0
 	pf.Colors.Defaults()
+	pf.ActiveTheme = ThemeAuto
 	pf.Params.Defaults()
 	pf.FavPaths.SetToDefaults()
 	pf.FontFamily = "Go"
@@ -161,6 +167,7 @@ func (pf *Preferences) Open() error {
 		// log.Println(err) // ok to be non-existant
 		return err
 	}
+	pf.ActiveTheme = "" // prefs saved without a theme keep their Colors
 	err = json.Unmarshal(b, pf)
 	if pf.SaveKeyMaps {
 		AvailKeyMaps.OpenPrefs()
@@ -194,9 +201,13 @@ func (pf *Preferences) Save() error {
 	return err
 }
 
-// OpenColors colors from a JSON-formatted file.
+// OpenColors colors from a JSON-formatted file -- clears the ActiveTheme so
+// these colors are used as-is (see SaveColorsAsTheme to keep them as a theme).
 func (pf *Preferences) OpenColors(filename FileName) error {
 	err := pf.Colors.OpenJSON(filename)
+	if err == nil {
+		pf.ActiveTheme = ""
+	}
 	// if err == nil {
 	// 	pf.Update() // no!  this recolors the dialog as it is closing!  do it separately
 	// }
@@ -233,6 +244,8 @@ func (pf *Preferences) Apply() {
 	} else {
 		FontLibrary.InitFontPaths(oswin.TheApp.FontPaths()...)
 	}
+	pf.ApplyTheme()
+	pf.ApplyStyleFiles()
 	pf.ApplyDPI()
 }
 
@@ -389,6 +402,18 @@ var PreferencesProps = ki.Props{
 					}},
 				},
 			}},
+			{"SetTheme", ki.Props{
+				"Args": ki.PropSlice{
+					{"Theme Name", ki.Props{
+						"default-field": "ActiveTheme",
+					}},
+				},
+			}},
+			{"SaveColorsAsTheme", ki.Props{
+				"Args": ki.PropSlice{
+					{"Theme Name", ki.Props{}},
+				},
+			}},
 			{"sep-misc", ki.BlankProp{}},
 			{"SaveZoom", ki.Props{
 				"desc": "Save current zoom magnification factor, either for all screens or for the current screen only",
@@ -424,6 +449,23 @@ var PreferencesProps = ki.Props{
 		}},
 		{"sep-color", ki.BlankProp{}},
 		{"Colors", ki.PropSlice{ // sub-menu
+			{"SetTheme", ki.Props{
+				"desc": "sets the active color theme and updates all windows -- light, dark, high-contrast, auto (follows the OS dark mode setting), or one of your own ColorThemes",
+				"icon": "update",
+				"Args": ki.PropSlice{
+					{"Theme Name", ki.Props{
+						"default-field": "ActiveTheme",
+					}},
+				},
+			}},
+			{"SaveColorsAsTheme", ki.Props{
+				"desc": "saves the current Colors as one of your own ColorThemes, and makes it the active theme",
+				"icon": "file-save",
+				"Args": ki.PropSlice{
+					{"Theme Name", ki.Props{}},
+				},
+			}},
+			{"sep-theme", ki.BlankProp{}},
 			{"OpenColors", ki.Props{
 				"icon": "file-open",
 				"Args": ki.PropSlice{
@@ -0,0 +1,714 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goki/ki"
+	"github.com/goki/ki/bitflag"
+	"github.com/goki/ki/kit"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Selector
+
+// Selector is a parsed CSS-style selector, which can be matched against ki
+// nodes, using the same semantics as Style.StyleCSS: type names are the
+// lower-case node type name (e.g., button, textfield), .class matches the
+// node Class, and #name matches the node Name, all case-insensitive.  A
+// selector is a sequence of compound selectors (Parts), each related to the
+// previous one by a descendant (space) or child (>) combinator -- the last
+// part is the one that must match the node itself.
+type Selector struct {
+	Parts []*SelectorPart `desc:"compound selectors, from outer-most ancestor to the node itself"`
+}
+
+// SelCombinators are the ways in which a SelectorPart is related to the
+// previous part in the Selector
+type SelCombinators int32
+
+const (
+	// SelDescendant requires the previous part to match any ancestor (a b)
+	SelDescendant SelCombinators = iota
+
+	// SelChild requires the previous part to match the parent (a > b)
+	SelChild
+
+	SelCombinatorsN
+)
+
+//go:generate stringer -type=SelCombinators
+
+var KiT_SelCombinators = kit.Enums.AddEnum(SelCombinatorsN, false, nil)
+
+// SelectorPart is one compound selector within a Selector, e.g.,
+// button.primary:focus -- all elements must match
+type SelectorPart struct {
+	Comb    SelCombinators `desc:"combinator relating this part to the previous one -- ignored for the first part"`
+	Type    string         `desc:"lower-case type name -- empty or * matches any type"`
+	Name    string         `desc:"#name to match, lower-case"`
+	Classes []string       `desc:".class names to match, lower-case"`
+	Attrs   []SelAttr      `desc:"[attr] filters, matched against node properties"`
+	States  []string       `desc:":state filters, e.g., focus, selected, inactive (without the :) -- see SelectorStates"`
+	Nths    []SelNth       `desc:"structural :nth-child, :first-child etc filters"`
+}
+
+// SelAttr is an [attr] filter in a SelectorPart, which is matched against
+// the properties (ki.Props) of the node -- Op is one of "" (property is
+// set), =, ~= (one of space-separated words), ^= (prefix), $= (suffix), or
+// *= (contains)
+type SelAttr struct {
+	Name  string
+	Op    string
+	Value string
+}
+
+// SelectorStates are the :state pseudo-classes supported in selectors, with
+// the functions that test for them on a node -- states with a nil function
+// are only used for selecting the state styles of widgets (e.g., :down for
+// buttons -- see MatchCSS), and never match otherwise.  Note that, following
+// the widget *Selectors conventions, :active means NOT inactive.
+var SelectorStates = map[string]func(nb *NodeBase) bool{
+	"active":    func(nb *NodeBase) bool { return nb.IsActive() },
+	"inactive":  func(nb *NodeBase) bool { return nb.IsInactive() },
+	"focus":     func(nb *NodeBase) bool { return nb.HasFocus() },
+	"selected":  func(nb *NodeBase) bool { return nb.IsSelected() },
+	"hover":     func(nb *NodeBase) bool { return bitflag.Has(nb.Flag, int(MouseHasEntered)) },
+	"dragging":  func(nb *NodeBase) bool { return nb.IsDragging() },
+	"down":      nil,
+	"value":     nil,
+	"box":       nil,
+	"highlight": nil,
+}
+
+// SelectorStateAliases are alternative names for SelectorStates
+var SelectorStateAliases = map[string]string{
+	"disabled": "inactive",
+	"enabled":  "active",
+}
+
+// SelNth is a structural :nth-child(An+B) pseudo-class filter -- also used
+// for :first-child (0n+1), :last-child (FromEnd 0n+1) and :nth-last-child
+type SelNth struct {
+	A       int
+	B       int
+	FromEnd bool
+}
+
+// Matches returns true if the 1-based child index (counted from the end if
+// FromEnd) matches An+B for some n >= 0
+func (sn *SelNth) Matches(idx int) bool {
+	if sn.A == 0 {
+		return idx == sn.B
+	}
+	d := idx - sn.B
+	return d%sn.A == 0 && d/sn.A >= 0
+}
+
+// String returns the selector syntax for the filter
+func (sn *SelNth) String() string {
+	nm := ":nth-child"
+	if sn.FromEnd {
+		nm = ":nth-last-child"
+	}
+	if sn.A == 0 {
+		return fmt.Sprintf("%v(%v)", nm, sn.B)
+	}
+	return fmt.Sprintf("%v(%vn%+d)", nm, sn.A, sn.B)
+}
+
+// ParseSelectors parses a comma-separated list of selectors
+func ParseSelectors(sels string) ([]*Selector, error) {
+	var rval []*Selector
+	for _, ss := range strings.Split(sels, ",") {
+		sel, err := ParseSelector(ss)
+		if err != nil {
+			return nil, err
+		}
+		rval = append(rval, sel)
+	}
+	return rval, nil
+}
+
+// ParseSelector parses a single selector (no commas), e.g.,
+// "dialog #buttons > button.primary:focus"
+func ParseSelector(sel string) (*Selector, error) {
+	sp := &selParser{src: []rune(strings.TrimSpace(sel))}
+	if len(sp.src) == 0 {
+		return nil, fmt.Errorf("gi.ParseSelector: empty selector")
+	}
+	s := &Selector{}
+	comb := SelDescendant
+	for {
+		pt, err := sp.parsePart()
+		if err != nil {
+			return nil, fmt.Errorf("gi.ParseSelector: %v in selector: %v", err, sel)
+		}
+		pt.Comb = comb
+		s.Parts = append(s.Parts, pt)
+		sawSpace := sp.skipSpace()
+		if sp.done() {
+			break
+		}
+		switch {
+		case sp.peek() == '>':
+			sp.pos++
+			sp.skipSpace()
+			comb = SelChild
+		case sawSpace:
+			comb = SelDescendant
+		default:
+			return nil, fmt.Errorf("gi.ParseSelector: unexpected %q at position %v in selector: %v", sp.peek(), sp.pos, sel)
+		}
+		if sp.done() {
+			return nil, fmt.Errorf("gi.ParseSelector: selector ends with a combinator: %v", sel)
+		}
+	}
+	return s, nil
+}
+
+// String returns the canonical string representation of the selector
+func (s *Selector) String() string {
+	var sb strings.Builder
+	for i, pt := range s.Parts {
+		if i > 0 {
+			if pt.Comb == SelChild {
+				sb.WriteString(" > ")
+			} else {
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString(pt.String())
+	}
+	return sb.String()
+}
+
+// String returns the canonical string representation of the part
+func (pt *SelectorPart) String() string {
+	var sb strings.Builder
+	sb.WriteString(pt.Type)
+	if pt.Name != "" {
+		sb.WriteString("#" + pt.Name)
+	}
+	for _, cl := range pt.Classes {
+		sb.WriteString("." + cl)
+	}
+	for _, at := range pt.Attrs {
+		if at.Op == "" {
+			sb.WriteString("[" + at.Name + "]")
+		} else {
+			sb.WriteString(fmt.Sprintf("[%v%v%q]", at.Name, at.Op, at.Value))
+		}
+	}
+	for _, st := range pt.States {
+		sb.WriteString(":" + st)
+	}
+	for i := range pt.Nths {
+		sb.WriteString(pt.Nths[i].String())
+	}
+	if sb.Len() == 0 {
+		return "*"
+	}
+	return sb.String()
+}
+
+// Matches returns true if the selector matches given node, in its current
+// state
+func (s *Selector) Matches(k ki.Ki) bool {
+	return s.MatchesState(k, "")
+}
+
+// MatchesState returns true if the selector matches given node in the given
+// style state (e.g., hover, without the :), as used for the StateStyles of
+// widgets: the last (subject) part of the selector must then have state
+// pseudo-classes, all equal to that state, instead of being matched against
+// the current state of the node.  If state is empty, the current state is
+// used, as in Matches.  Ancestors are always matched in their current state.
+func (s *Selector) MatchesState(k ki.Ki, state string) bool {
+	if len(s.Parts) == 0 {
+		return false
+	}
+	return s.matchFrom(k, len(s.Parts)-1, state)
+}
+
+// HasState returns true if the subject (last) part of the selector has any
+// state pseudo-classes
+func (s *Selector) HasState() bool {
+	return len(s.Parts) > 0 && len(s.Parts[len(s.Parts)-1].States) > 0
+}
+
+// Specificity returns the CSS specificity of the selector, as
+// 10000 * #names + 100 * (.classes + [attrs] + :pseudo-classes) + types --
+// more specific selectors override less specific ones
+func (s *Selector) Specificity() int {
+	spec := 0
+	for _, pt := range s.Parts {
+		if pt.Name != "" {
+			spec += 10000
+		}
+		spec += 100 * (len(pt.Classes) + len(pt.Attrs) + len(pt.States) + len(pt.Nths))
+		if pt.Type != "" && pt.Type != "*" {
+			spec++
+		}
+	}
+	return spec
+}
+
+// matchFrom returns true if part pi matches node k, and the parts before it
+// match its ancestors according to their combinators -- state is only used
+// for the subject part
+func (s *Selector) matchFrom(k ki.Ki, pi int, state string) bool {
+	pt := s.Parts[pi]
+	if pi == len(s.Parts)-1 && state != "" {
+		if !pt.matchesStruct(k) || len(pt.States) == 0 {
+			return false
+		}
+		for _, st := range pt.States {
+			if st != state {
+				return false
+			}
+		}
+	} else if !pt.Matches(k) {
+		return false
+	}
+	if pi == 0 {
+		return true
+	}
+	if pt.Comb == SelChild {
+		par := k.Parent()
+		if par == nil {
+			return false
+		}
+		return s.matchFrom(par, pi-1, "")
+	}
+	for par := k.Parent(); par != nil; par = par.Parent() {
+		if s.matchFrom(par, pi-1, "") {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if this compound selector part matches given node in
+// its current state, not considering any combinators
+func (pt *SelectorPart) Matches(k ki.Ki) bool {
+	if !pt.matchesStruct(k) {
+		return false
+	}
+	if len(pt.States) == 0 {
+		return true
+	}
+	nb, _ := k.Embed(KiT_NodeBase).(*NodeBase)
+	if nb == nil {
+		return false
+	}
+	for _, st := range pt.States {
+		sf := SelectorStates[st]
+		if sf == nil || !sf(nb) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesStruct matches everything except the state pseudo-classes
+func (pt *SelectorPart) matchesStruct(k ki.Ki) bool {
+	if pt.Type != "" && pt.Type != "*" && pt.Type != strings.ToLower(k.Type().Name()) {
+		return false
+	}
+	if pt.Name != "" && pt.Name != strings.ToLower(k.Name()) {
+		return false
+	}
+	if len(pt.Classes) > 0 {
+		nb, _ := k.Embed(KiT_NodeBase).(*NodeBase)
+		if nb == nil {
+			return false
+		}
+		for _, cl := range pt.Classes {
+			if !classMatches(nb.Class, cl) {
+				return false
+			}
+		}
+	}
+	for _, at := range pt.Attrs {
+		if !at.Matches(k) {
+			return false
+		}
+	}
+	if len(pt.Nths) > 0 {
+		par := k.Parent()
+		if par == nil {
+			return false
+		}
+		idx, ok := k.IndexInParent()
+		if !ok {
+			return false
+		}
+		n := len(*par.Children())
+		for i := range pt.Nths {
+			sn := &pt.Nths[i]
+			ci := idx + 1
+			if sn.FromEnd {
+				ci = n - idx
+			}
+			if !sn.Matches(ci) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// classMatches returns true if the node class string (which can contain
+// multiple space-separated classes) matches given lower-case class name
+func classMatches(class, cl string) bool {
+	lc := strings.ToLower(class)
+	if lc == cl {
+		return true
+	}
+	for _, c := range strings.Fields(lc) {
+		if c == cl {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if the node has a property matching the attribute filter
+func (at *SelAttr) Matches(k ki.Ki) bool {
+	pv, ok := k.Prop(at.Name)
+	if !ok {
+		return false
+	}
+	if at.Op == "" {
+		return true
+	}
+	pvs := kit.ToString(pv)
+	switch at.Op {
+	case "=":
+		return pvs == at.Value
+	case "~=":
+		for _, f := range strings.Fields(pvs) {
+			if f == at.Value {
+				return true
+			}
+		}
+		return false
+	case "^=":
+		return strings.HasPrefix(pvs, at.Value)
+	case "$=":
+		return strings.HasSuffix(pvs, at.Value)
+	case "*=":
+		return strings.Contains(pvs, at.Value)
+	}
+	return false
+}
+
+// selParser is the state for parsing a selector string
+type selParser struct {
+	src []rune
+	pos int
+}
+
+func (sp *selParser) done() bool {
+	return sp.pos >= len(sp.src)
+}
+
+func (sp *selParser) peek() rune {
+	if sp.done() {
+		return 0
+	}
+	return sp.src[sp.pos]
+}
+
+// skipSpace skips over any white space, returning true if there was some
+func (sp *selParser) skipSpace() bool {
+	st := sp.pos
+	for !sp.done() && unicode.IsSpace(sp.src[sp.pos]) {
+		sp.pos++
+	}
+	return sp.pos > st
+}
+
+func isSelIdentRune(r rune) bool {
+	return r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// ident parses an identifier, returning an error if there isn't one
+func (sp *selParser) ident() (string, error) {
+	st := sp.pos
+	for !sp.done() && isSelIdentRune(sp.src[sp.pos]) {
+		sp.pos++
+	}
+	if sp.pos == st {
+		if sp.done() {
+			return "", fmt.Errorf("expected a name at end")
+		}
+		return "", fmt.Errorf("expected a name at position %v, got %q", st, sp.src[st])
+	}
+	return string(sp.src[st:sp.pos]), nil
+}
+
+// parsePart parses one compound selector
+func (sp *selParser) parsePart() (*SelectorPart, error) {
+	pt := &SelectorPart{}
+	if sp.peek() == '*' {
+		sp.pos++
+		pt.Type = "*"
+	} else if isSelIdentRune(sp.peek()) {
+		id, _ := sp.ident()
+		pt.Type = strings.ToLower(id)
+	}
+	for !sp.done() {
+		switch sp.peek() {
+		case '#':
+			sp.pos++
+			id, err := sp.ident()
+			if err != nil {
+				return nil, err
+			}
+			pt.Name = strings.ToLower(id)
+		case '.':
+			sp.pos++
+			id, err := sp.ident()
+			if err != nil {
+				return nil, err
+			}
+			pt.Classes = append(pt.Classes, strings.ToLower(id))
+		case ':':
+			sp.pos++
+			id, err := sp.ident()
+			if err != nil {
+				return nil, err
+			}
+			if err := sp.parsePseudo(pt, strings.ToLower(id)); err != nil {
+				return nil, err
+			}
+		case '[':
+			sp.pos++
+			at, err := sp.parseAttr()
+			if err != nil {
+				return nil, err
+			}
+			pt.Attrs = append(pt.Attrs, at)
+		default:
+			return pt, nil
+		}
+	}
+	return pt, nil
+}
+
+// parsePseudo parses a :pseudo-class with given name, after the name
+func (sp *selParser) parsePseudo(pt *SelectorPart, nm string) error {
+	switch nm {
+	case "first-child":
+		pt.Nths = append(pt.Nths, SelNth{B: 1})
+		return nil
+	case "last-child":
+		pt.Nths = append(pt.Nths, SelNth{B: 1, FromEnd: true})
+		return nil
+	case "only-child":
+		pt.Nths = append(pt.Nths, SelNth{B: 1}, SelNth{B: 1, FromEnd: true})
+		return nil
+	case "nth-child", "nth-last-child":
+		if sp.peek() != '(' {
+			return fmt.Errorf(":%v requires an argument", nm)
+		}
+		sp.pos++
+		st := sp.pos
+		for !sp.done() && sp.src[sp.pos] != ')' {
+			sp.pos++
+		}
+		if sp.done() {
+			return fmt.Errorf("unterminated :%v argument", nm)
+		}
+		sn, err := parseNth(string(sp.src[st:sp.pos]))
+		if err != nil {
+			return err
+		}
+		sp.pos++
+		sn.FromEnd = (nm == "nth-last-child")
+		pt.Nths = append(pt.Nths, sn)
+		return nil
+	}
+	if al, ok := SelectorStateAliases[nm]; ok {
+		nm = al
+	}
+	if _, ok := SelectorStates[nm]; !ok {
+		return fmt.Errorf("pseudo-class :%v not supported", nm)
+	}
+	pt.States = append(pt.States, nm)
+	return nil
+}
+
+// parseNth parses an :nth-child argument: odd, even, B, or An+B
+func parseNth(arg string) (SelNth, error) {
+	sn := SelNth{}
+	a := strings.ToLower(strings.Replace(arg, " ", "", -1))
+	switch a {
+	case "odd":
+		return SelNth{A: 2, B: 1}, nil
+	case "even":
+		return SelNth{A: 2, B: 0}, nil
+	}
+	ni := strings.Index(a, "n")
+	if ni < 0 {
+		b, err := strconv.Atoi(a)
+		if err != nil {
+			return sn, fmt.Errorf("invalid :nth-child argument: %v", arg)
+		}
+		sn.B = b
+		return sn, nil
+	}
+	switch as := a[:ni]; as {
+	case "", "+":
+		sn.A = 1
+	case "-":
+		sn.A = -1
+	default:
+		av, err := strconv.Atoi(as)
+		if err != nil {
+			return sn, fmt.Errorf("invalid :nth-child argument: %v", arg)
+		}
+		sn.A = av
+	}
+	if bs := a[ni+1:]; bs != "" {
+		bv, err := strconv.Atoi(bs)
+		if err != nil {
+			return sn, fmt.Errorf("invalid :nth-child argument: %v", arg)
+		}
+		sn.B = bv
+	}
+	return sn, nil
+}
+
+// parseAttr parses an attribute filter, after the opening [
+func (sp *selParser) parseAttr() (SelAttr, error) {
+	at := SelAttr{}
+	sp.skipSpace()
+	id, err := sp.ident()
+	if err != nil {
+		return at, err
+	}
+	at.Name = id
+	sp.skipSpace()
+	if sp.peek() == ']' {
+		sp.pos++
+		return at, nil
+	}
+	switch sp.peek() {
+	case '=':
+		at.Op = "="
+		sp.pos++
+	case '~', '^', '$', '*':
+		if sp.pos+1 >= len(sp.src) || sp.src[sp.pos+1] != '=' {
+			return at, fmt.Errorf("invalid attribute operator at position %v", sp.pos)
+		}
+		at.Op = string(sp.src[sp.pos : sp.pos+2])
+		sp.pos += 2
+	default:
+		return at, fmt.Errorf("invalid attribute operator at position %v", sp.pos)
+	}
+	sp.skipSpace()
+	if q := sp.peek(); q == '"' || q == '\'' {
+		sp.pos++
+		st := sp.pos
+		for !sp.done() && sp.src[sp.pos] != q {
+			sp.pos++
+		}
+		if sp.done() {
+			return at, fmt.Errorf("unterminated attribute value string")
+		}
+		at.Value = string(sp.src[st:sp.pos])
+		sp.pos++
+	} else {
+		id, err := sp.ident()
+		if err != nil {
+			return at, err
+		}
+		at.Value = id
+	}
+	sp.skipSpace()
+	if sp.peek() != ']' {
+		return at, fmt.Errorf("expected ] at position %v", sp.pos)
+	}
+	sp.pos++
+	return at, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   FindAll, FindFirst
+
+// FindAll returns all the Node2D nodes at or below root (including those in
+// widget Parts) that match the given CSS-style selector, which can be a
+// comma-separated list of selectors, in depth-first order -- see Selector
+// for the supported syntax.  Ancestor combinators can match nodes above
+// root.  Logs an error and returns nil if the selector is invalid.
+func FindAll(root ki.Ki, selector string) []Node2D {
+	sels, err := ParseSelectors(selector)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	var rval []Node2D
+	findSelectors(root, sels, func(nii Node2D) bool {
+		rval = append(rval, nii)
+		return true
+	})
+	return rval
+}
+
+// FindFirst returns the first Node2D node at or below root (in depth-first
+// order) that matches the given CSS-style selector -- see FindAll -- returns
+// nil if not found or the selector is invalid.
+func FindFirst(root ki.Ki, selector string) Node2D {
+	sels, err := ParseSelectors(selector)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	var fnd Node2D
+	findSelectors(root, sels, func(nii Node2D) bool {
+		fnd = nii
+		return false
+	})
+	return fnd
+}
+
+// findSelectors calls fun for each Node2D at or below root that matches any
+// of the selectors, stopping when fun returns false
+func findSelectors(root ki.Ki, sels []*Selector, fun func(nii Node2D) bool) {
+	if root == nil {
+		return
+	}
+	stop := false
+	root.FuncDownMeFirst(0, nil, func(k ki.Ki, level int, d interface{}) bool {
+		if stop {
+			return false
+		}
+		if k.IsDeleted() || k.IsDestroyed() {
+			return false
+		}
+		nii, _ := KiToNode2D(k)
+		if nii == nil {
+			return true
+		}
+		for _, sel := range sels {
+			if sel.Matches(k) {
+				if !fun(nii) {
+					stop = true
+					return false
+				}
+				break
+			}
+		}
+		return true
+	})
+}
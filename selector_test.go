@@ -0,0 +1,257 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+
+	"github.com/goki/ki"
+	"github.com/goki/ki/bitflag"
+)
+
+// testSelTree makes a small tree for testing selectors:
+// top (frame) > bar (layout) > ok, cancel (buttons); top > title (label)
+func testSelTree() *Frame {
+	fr := &Frame{}
+	fr.InitName(fr, "top")
+	bar := fr.AddNewChild(KiT_Layout, "bar").(*Layout)
+	ok := bar.AddNewChild(KiT_Button, "ok").(*Button)
+	ok.Class = "primary Big"
+	bar.AddNewChild(KiT_Button, "cancel")
+	lb := fr.AddNewChild(KiT_Label, "title").(*Label)
+	lb.SetProp("kind", "heading main")
+	return fr
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		sel string
+		cor string // canonical string, empty if an error is expected
+	}{
+		{"Button.Primary", "button.primary"},
+		{"frame  >  #OK", "frame > #ok"},
+		{"layout button:focus", "layout button:focus"},
+		{"*", "*"},
+		{".a.b", ".a.b"},
+		{"  #top   .big ", "#top .big"},
+		{"", ""},
+		{"button >", ""},
+		{"button..x", ""},
+		{"button:nosuch", ""},
+		{"a ~ b", ""},
+	}
+	for _, tt := range tests {
+		sel, err := ParseSelector(tt.sel)
+		if tt.cor == "" {
+			if err == nil {
+				t.Errorf("ParseSelector(%q): expected an error, got: %v\n", tt.sel, sel)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSelector(%q): %v\n", tt.sel, err)
+			continue
+		}
+		if s := sel.String(); s != tt.cor {
+			t.Errorf("ParseSelector(%q): got %q, expected %q\n", tt.sel, s, tt.cor)
+		}
+	}
+	if sels, err := ParseSelectors("button, .primary"); err != nil || len(sels) != 2 {
+		t.Errorf("ParseSelectors: got %v selectors, err: %v\n", len(sels), err)
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	fr := testSelTree()
+	tests := []struct {
+		sel string
+		cor []string
+	}{
+		{"button", []string{"ok", "cancel"}},
+		{".primary", []string{"ok"}},
+		{".big.primary", []string{"ok"}},
+		{"#top #cancel", []string{"cancel"}},
+		{"frame > layout > button", []string{"ok", "cancel"}},
+		{"frame > button", nil},
+		{"button, label", []string{"ok", "cancel", "title"}},
+		{"slider", nil},
+	}
+	for _, tt := range tests {
+		fnd := FindAll(fr, tt.sel)
+		var nms []string
+		for _, nii := range fnd {
+			nms = append(nms, nii.Name())
+		}
+		if len(nms) != len(tt.cor) {
+			t.Errorf("FindAll(%q): got %v, expected %v\n", tt.sel, nms, tt.cor)
+			continue
+		}
+		for i := range nms {
+			if nms[i] != tt.cor[i] {
+				t.Errorf("FindAll(%q): got %v, expected %v\n", tt.sel, nms, tt.cor)
+				break
+			}
+		}
+	}
+	if fst := FindFirst(fr, "button"); fst == nil || fst.Name() != "ok" {
+		t.Errorf("FindFirst(button): got %v, expected ok\n", fst)
+	}
+	if fst := FindFirst(fr, "slider"); fst != nil {
+		t.Errorf("FindFirst(slider): got %v, expected nil\n", fst.Name())
+	}
+}
+
+func TestSelectorSpecificity(t *testing.T) {
+	tests := []struct {
+		sel  string
+		spec int
+	}{
+		{"*", 0},
+		{"button", 1},
+		{"button.primary", 101},
+		{"[kind]", 100},
+		{":first-child", 100},
+		{"#ok", 10000},
+		{"frame #ok.big", 10101},
+		{"frame > layout button:focus", 103},
+		{"label[kind~=heading]:nth-child(2n+1)", 201},
+	}
+	for _, tt := range tests {
+		sel, err := ParseSelector(tt.sel)
+		if err != nil {
+			t.Errorf("ParseSelector(%q): %v\n", tt.sel, err)
+			continue
+		}
+		if spec := sel.Specificity(); spec != tt.spec {
+			t.Errorf("Specificity(%q): got %v, expected %v\n", tt.sel, spec, tt.spec)
+		}
+	}
+}
+
+func TestSelNth(t *testing.T) {
+	tests := []struct {
+		arg   string
+		nth   SelNth
+		match []int // 1-based indexes that match, out of 1..6
+	}{
+		{"odd", SelNth{A: 2, B: 1}, []int{1, 3, 5}},
+		{"even", SelNth{A: 2, B: 0}, []int{2, 4, 6}},
+		{"3", SelNth{B: 3}, []int{3}},
+		{"2n+1", SelNth{A: 2, B: 1}, []int{1, 3, 5}},
+		{"-n+3", SelNth{A: -1, B: 3}, []int{1, 2, 3}},
+		{"n", SelNth{A: 1}, []int{1, 2, 3, 4, 5, 6}},
+		{" 3n - 2 ", SelNth{A: 3, B: -2}, []int{1, 4}},
+	}
+	for _, tt := range tests {
+		sn, err := parseNth(tt.arg)
+		if err != nil {
+			t.Errorf("parseNth(%q): %v\n", tt.arg, err)
+			continue
+		}
+		if sn != tt.nth {
+			t.Errorf("parseNth(%q): got %v, expected %v\n", tt.arg, sn, tt.nth)
+		}
+		var match []int
+		for i := 1; i <= 6; i++ {
+			if sn.Matches(i) {
+				match = append(match, i)
+			}
+		}
+		if len(match) != len(tt.match) {
+			t.Errorf("%v matches: got %v, expected %v\n", tt.arg, match, tt.match)
+			continue
+		}
+		for i := range match {
+			if match[i] != tt.match[i] {
+				t.Errorf("%v matches: got %v, expected %v\n", tt.arg, match, tt.match)
+				break
+			}
+		}
+	}
+	if _, err := parseNth("x"); err == nil {
+		t.Errorf("parseNth(x): expected an error\n")
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	fr := testSelTree()
+	tests := []struct {
+		sel string
+		nm  string
+		cor bool
+	}{
+		{"label[kind]", "title", true},
+		{"label[kind~=heading]", "title", true},
+		{"[kind^=head]", "title", true},
+		{"[kind$=main]", "title", true},
+		{"[kind*='ing ma']", "title", true},
+		{"[kind=heading]", "title", false},
+		{"[size]", "title", false},
+		{"button:first-child", "ok", true},
+		{"button:first-child", "cancel", false},
+		{"button:last-child", "cancel", true},
+		{"button:nth-child(2)", "cancel", true},
+		{"label:nth-last-child(1)", "title", true},
+		{"layout:only-child", "bar", false},
+		{"frame > layout > .primary", "ok", true},
+		{"frame > .primary", "ok", false},
+		{"frame .primary", "ok", true},
+		{"button:hover", "ok", false},
+		{"button:enabled", "ok", true},
+		{"button:disabled", "ok", false},
+	}
+	for _, tt := range tests {
+		sel, err := ParseSelector(tt.sel)
+		if err != nil {
+			t.Errorf("ParseSelector(%q): %v\n", tt.sel, err)
+			continue
+		}
+		nd := FindFirst(fr, "#"+tt.nm)
+		if nd == nil {
+			t.Errorf("node %v not found\n", tt.nm)
+			continue
+		}
+		if m := sel.Matches(nd); m != tt.cor {
+			t.Errorf("%q matches %v: got %v, expected %v\n", tt.sel, tt.nm, m, tt.cor)
+		}
+	}
+
+	ok := FindFirst(fr, "#ok")
+	hov, _ := ParseSelector("button:hover")
+	if !hov.HasState() || !hov.MatchesState(ok, "hover") || hov.MatchesState(ok, "focus") {
+		t.Errorf("button:hover should match the hover state style of a button, and only that\n")
+	}
+}
+
+func TestMatchCSS(t *testing.T) {
+	fr := testSelTree()
+	ok := FindFirst(fr, "#ok")
+	css := ki.Props{
+		"#ok":            ki.Props{"color": "blue"},
+		".primary":       ki.Props{"color": "green"},
+		"button":         ki.Props{"color": "red"},
+		"layout > label": ki.Props{"color": "black"},
+		"button:hover":   ki.Props{"color": "yellow"},
+	}
+	ms := MatchCSS(ok, css, "")
+	cor := []string{"red", "green", "blue"}
+	if len(ms) != len(cor) {
+		t.Fatalf("MatchCSS: got %v, expected colors %v\n", ms, cor)
+	}
+	for i, pr := range ms {
+		if pr["color"] != cor[i] {
+			t.Errorf("MatchCSS: got %v, expected colors %v in order of specificity\n", ms, cor)
+			break
+		}
+	}
+	bitflag.Set(&ok.AsNode2D().Flag, int(MouseHasEntered))
+	if hm := MatchCSS(ok, css, ""); len(hm) != len(cor) {
+		t.Errorf("MatchCSS while hovered: got %v, expected the button:hover props only in the hover state\n", hm)
+	}
+	hs := MatchCSS(ok, css, ":hover")
+	if len(hs) != 1 || hs[0]["color"] != "yellow" {
+		t.Errorf("MatchCSS for hover state: got %v, expected only the button:hover props\n", hs)
+	}
+}
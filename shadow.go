@@ -0,0 +1,444 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+)
+
+// Box shadows: the box-shadow.* properties set the first shadow in
+// Style.BoxShadow, and the CSS shorthand box-shadow property can also set a
+// comma-separated list of shadows, e.g., "2px 2px 4px black, inset 0 0 2px
+// 1px rgba(0,0,0,0.5)", with any after the first stored in
+// Style.MoreShadows.  Each shadow is the shape of the box, offset, grown by
+// the spread, and blurred with a Gaussian whose standard deviation is half
+// the blur radius (as in CSS) -- inset shadows are drawn inside the padding
+// box, outside of the offset and shrunk box.  The first shadow is drawn on
+// top.  The blurred alpha masks are cached per size and shape, so redrawing
+// the same box is fast.
+
+// ShadowCacheMax is the maximum number of blurred shadow masks to keep in
+// the cache -- the cache is reset when it reaches this size
+var ShadowCacheMax = 256
+
+// shadowKey is the key for a cached shadow mask: everything that determines
+// its shape
+type shadowKey struct {
+	w, h   int
+	rad    SideFloats
+	blur   float32
+	spread float32
+	off    Vec2D
+	inset  bool
+}
+
+// shadowMask is a cached blurred shadow mask, with the offset of its origin
+// relative to the box position
+type shadowMask struct {
+	mask *image.Alpha
+	org  image.Point
+}
+
+var shadowCache = map[shadowKey]*shadowMask{}
+var shadowCacheMu sync.Mutex
+
+// ToDots converts the shadow units to dots
+func (s *ShadowStyle) ToDots(uc *units.Context) {
+	s.HOffset.ToDots(uc)
+	s.VOffset.ToDots(uc)
+	s.Blur.ToDots(uc)
+	s.Spread.ToDots(uc)
+}
+
+// HasShadow returns true if the shadow will render anything: it has a
+// color, and an offset (positive or negative), blur or spread
+func (s *ShadowStyle) HasShadow() bool {
+	if s.Color.IsNil() {
+		return false
+	}
+	return s.HOffset.Dots != 0 || s.VOffset.Dots != 0 || s.Blur.Dots > 0 || s.Spread.Dots != 0
+}
+
+// ParseBoxShadows parses a CSS box-shadow value: a comma-separated list of
+// shadows, each with an optional inset keyword, 2 to 4 lengths (h-offset,
+// v-offset, blur, spread) and an optional color, which defaults to the
+// Prefs shadow color -- "none" returns no shadows
+func ParseBoxShadows(str string) ([]ShadowStyle, error) {
+	str = strings.TrimSpace(str)
+	if str == "" || str == "none" {
+		return nil, nil
+	}
+	var shs []ShadowStyle
+	for _, sstr := range splitStyleList(str) {
+		var sh ShadowStyle
+		nl := 0
+		hasClr := false
+		for _, f := range splitStyleFields(sstr) {
+			lf := strings.ToLower(f)
+			switch {
+			case lf == "inset":
+				sh.Inset = true
+			case isStyleLength(lf):
+				if nl == 4 {
+					return nil, fmt.Errorf("gi.ParseBoxShadows: too many lengths in: %v", str)
+				}
+				uv := units.StringToValue(lf)
+				switch nl {
+				case 0:
+					sh.HOffset = uv
+				case 1:
+					sh.VOffset = uv
+				case 2:
+					sh.Blur = uv
+				case 3:
+					sh.Spread = uv
+				}
+				nl++
+			default:
+				if err := sh.Color.SetString(f, nil); err != nil {
+					return nil, fmt.Errorf("gi.ParseBoxShadows: invalid color %q in: %v", f, str)
+				}
+				hasClr = true
+			}
+		}
+		if nl < 2 {
+			return nil, fmt.Errorf("gi.ParseBoxShadows: need at least h-offset and v-offset in: %v", str)
+		}
+		if !hasClr {
+			sh.Color = Prefs.Colors.Shadow
+		}
+		shs = append(shs, sh)
+	}
+	return shs, nil
+}
+
+// splitStyleList splits a property value string on commas, except within
+// parentheses, e.g., rgba(0,0,0,0.5)
+func splitStyleList(str string) []string {
+	var lst []string
+	depth := 0
+	st := 0
+	for i, r := range str {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				lst = append(lst, strings.TrimSpace(str[st:i]))
+				st = i + 1
+			}
+		}
+	}
+	return append(lst, strings.TrimSpace(str[st:]))
+}
+
+// isStyleLength returns true if the (lowercase) property value field is a
+// length: a number with optional units, or a calc() expression
+func isStyleLength(f string) bool {
+	if strings.HasPrefix(f, "calc(") {
+		return true
+	}
+	c := f[0]
+	if c == '-' || c == '+' {
+		if len(f) == 1 {
+			return false
+		}
+		c = f[1]
+	}
+	return (c >= '0' && c <= '9') || c == '.'
+}
+
+// SetBoxShadows sets the shadows from the box-shadow shorthand property in
+// props, if present -- the first shadow goes in BoxShadow and any others in
+// MoreShadows -- called in SetStyleProps
+func (s *Style) SetBoxShadows(par *Style, props ki.Props) {
+	str, ok := PropStringWithVars(props, "box-shadow", s.Vars)
+	if !ok {
+		return
+	}
+	switch str {
+	case "inherit":
+		if par != nil {
+			s.BoxShadow = par.BoxShadow
+			s.MoreShadows = par.MoreShadows
+		}
+		return
+	case "initial":
+		s.BoxShadow = ShadowStyle{}
+		s.MoreShadows = nil
+		return
+	}
+	shs, err := ParseBoxShadows(str)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	s.MoreShadows = nil
+	if len(shs) == 0 {
+		s.BoxShadow = ShadowStyle{}
+		return
+	}
+	s.BoxShadow = shs[0]
+	if len(shs) > 1 {
+		s.MoreShadows = shs[1:]
+	}
+}
+
+// BoxShadows returns all of the shadows that render, from the top (first)
+// to the bottom
+func (s *Style) BoxShadows() []ShadowStyle {
+	var shs []ShadowStyle
+	if s.BoxShadow.HasShadow() {
+		shs = append(shs, s.BoxShadow)
+	}
+	for i := range s.MoreShadows {
+		if s.MoreShadows[i].HasShadow() {
+			shs = append(shs, s.MoreShadows[i])
+		}
+	}
+	return shs
+}
+
+// HasShadows returns true if any of the shadows render, and if inset is
+// true, if any of them are inset shadows, and otherwise outset shadows
+func (s *Style) HasShadows(inset bool) bool {
+	if s.BoxShadow.HasShadow() && s.BoxShadow.Inset == inset {
+		return true
+	}
+	for i := range s.MoreShadows {
+		if s.MoreShadows[i].HasShadow() && s.MoreShadows[i].Inset == inset {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderShadows renders the shadows for a box with given position and size
+// of the outer edge of its border, and corner radii -- if inset is false,
+// the outset shadows are rendered, which should be done before rendering
+// the background of the box, and otherwise the inset shadows are rendered,
+// within the border, which should be done after the background
+func (s *Style) RenderShadows(rs *RenderState, pos, sz Vec2D, rad SideFloats, inset bool) {
+	if !s.HasShadows(inset) {
+		return
+	}
+	if inset {
+		bw := s.Border.WidthDots()
+		pos = pos.Add(bw.Pos())
+		sz = sz.Sub(bw.Size())
+		rad = InnerRadii(rad, bw)
+	}
+	shs := s.BoxShadows()
+	for i := len(shs) - 1; i >= 0; i-- {
+		if shs[i].Inset == inset {
+			shs[i].Render(rs, pos, sz, rad)
+		}
+	}
+}
+
+// Render renders the shadow for a box with given position and size, and
+// corner radii -- for an inset shadow, this is the padding box, within
+// which the shadow is drawn
+func (s *ShadowStyle) Render(rs *RenderState, pos, sz Vec2D, rad SideFloats) {
+	if rs.Image == nil || sz.X <= 0 || sz.Y <= 0 {
+		return
+	}
+	off := Vec2D{s.HOffset.Dots, s.VOffset.Dots}
+	ipos := pos.ToPointFloor()
+	isz := sz.ToPointRound()
+	key := shadowKey{w: isz.X, h: isz.Y, rad: rad, blur: Max32(s.Blur.Dots, 0),
+		spread: s.Spread.Dots, inset: s.Inset}
+	if s.Inset {
+		key.off = off
+	} else {
+		ipos = pos.Add(off).ToPointFloor()
+	}
+	sm := shadowMaskForKey(key)
+	if sm == nil {
+		return
+	}
+	org := ipos.Add(sm.org)
+	dr := sm.mask.Rect.Add(org).Intersect(rs.Bounds)
+	if dr.Empty() {
+		return
+	}
+	draw.DrawMask(rs.Image, dr, image.NewUniform(&s.Color), image.ZP, sm.mask, dr.Min.Sub(org), draw.Over)
+	if rs.Rec != nil {
+		rs.Rec.Add(DisplayOp{Op: DisplayMask, Image: sm.mask, Color: s.Color, XForm: Translate2D(float32(org.X), float32(org.Y))})
+	}
+}
+
+// shadowMaskForKey returns the blurred shadow mask for given key, from the
+// cache if available
+func shadowMaskForKey(key shadowKey) *shadowMask {
+	shadowCacheMu.Lock()
+	defer shadowCacheMu.Unlock()
+	if sm, ok := shadowCache[key]; ok {
+		return sm
+	}
+	sm := newShadowMask(key)
+	if len(shadowCache) >= ShadowCacheMax {
+		shadowCache = map[shadowKey]*shadowMask{}
+	}
+	shadowCache[key] = sm
+	return sm
+}
+
+// newShadowMask renders the blurred shadow mask for given key
+func newShadowMask(key shadowKey) *shadowMask {
+	sigma := 0.5 * key.blur
+	pad := int(math.Ceil(float64(3 * sigma)))
+	w, h := float32(key.w), float32(key.h)
+	sp := key.spread
+	// shadow box, relative to the box position
+	bx, by, bw, bh := -sp, -sp, w+2*sp, h+2*sp
+	if key.inset {
+		bx, by, bw, bh = key.off.X+sp, key.off.Y+sp, w-2*sp, h-2*sp
+	}
+	srad := key.rad
+	for i := range srad {
+		if srad[i] > 0 {
+			srad[i] = Max32(srad[i]+sp*signOf(!key.inset), 0)
+		}
+	}
+	var org image.Point
+	var mw, mh int
+	if key.inset {
+		org = image.Point{-pad, -pad}
+		mw, mh = key.w+2*pad, key.h+2*pad
+	} else {
+		if bw <= 0 || bh <= 0 {
+			return nil
+		}
+		org = image.Point{int(math.Floor(float64(bx))) - pad, int(math.Floor(float64(by))) - pad}
+		mw = int(math.Ceil(float64(bx+bw))) + pad - org.X
+		mh = int(math.Ceil(float64(by+bh))) + pad - org.Y
+	}
+	buf := make([]float32, mw*mh)
+	for y := 0; y < mh; y++ {
+		py := float32(y+org.Y) + 0.5 - by
+		for x := 0; x < mw; x++ {
+			px := float32(x+org.X) + 0.5 - bx
+			cv := roundRectCoverage(px, py, bw, bh, srad)
+			if key.inset {
+				cv = 1 - cv
+			}
+			buf[y*mw+x] = cv
+		}
+	}
+	edge := float32(0)
+	if key.inset {
+		edge = 1
+	}
+	gaussianBlur(buf, mw, mh, sigma, edge)
+	if !key.inset {
+		mask := image.NewAlpha(image.Rect(0, 0, mw, mh))
+		for i, a := range buf {
+			mask.Pix[i] = uint8(Min32(Max32(a, 0), 1)*255 + 0.5)
+		}
+		return &shadowMask{mask: mask, org: org}
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, key.w, key.h)) // clip to the padding box
+	for y := 0; y < key.h; y++ {
+		for x := 0; x < key.w; x++ {
+			a := buf[(y+pad)*mw+x+pad] * roundRectCoverage(float32(x)+0.5, float32(y)+0.5, w, h, key.rad)
+			mask.Pix[y*mask.Stride+x] = uint8(Min32(Max32(a, 0), 1)*255 + 0.5)
+		}
+	}
+	return &shadowMask{mask: mask}
+}
+
+// signOf returns 1 if pos is true and -1 otherwise
+func signOf(pos bool) float32 {
+	if pos {
+		return 1
+	}
+	return -1
+}
+
+// roundRectCoverage returns the anti-aliased coverage (0-1) of the point by
+// a rectangle at 0,0 with given size and corner radii, using the signed
+// distance to its edge
+func roundRectCoverage(px, py, w, h float32, rad SideFloats) float32 {
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	cx, cy := 0.5*w, 0.5*h
+	ci := 0 // corner: top-left, top-right, bottom-right, bottom-left
+	switch {
+	case px >= cx && py < cy:
+		ci = 1
+	case px >= cx && py >= cy:
+		ci = 2
+	case px < cx && py >= cy:
+		ci = 3
+	}
+	r := Min32(rad[ci], Min32(cx, cy))
+	qx := float32(math.Abs(float64(px-cx))) - (cx - r)
+	qy := float32(math.Abs(float64(py-cy))) - (cy - r)
+	ox, oy := Max32(qx, 0), Max32(qy, 0)
+	d := float32(math.Sqrt(float64(ox*ox+oy*oy))) + Min32(Max32(qx, qy), 0) - r
+	return Min32(Max32(0.5-d, 0), 1)
+}
+
+// gaussianBlur blurs the w x h buffer in place with a separable Gaussian
+// with given standard deviation -- values beyond the edges are taken to be
+// edge
+func gaussianBlur(buf []float32, w, h int, sigma, edge float32) {
+	if sigma < 0.1 {
+		return
+	}
+	kr := int(math.Ceil(float64(3 * sigma)))
+	kern := make([]float32, 2*kr+1)
+	var sum float32
+	for i := range kern {
+		d := float64(i - kr)
+		kern[i] = float32(math.Exp(-d * d / (2 * float64(sigma*sigma))))
+		sum += kern[i]
+	}
+	for i := range kern {
+		kern[i] /= sum
+	}
+	mx := w
+	if h > mx {
+		mx = h
+	}
+	line := make([]float32, mx)
+	blurLine := func(get func(i int) float32, set func(i int, v float32), n int) {
+		for i := 0; i < n; i++ {
+			line[i] = get(i)
+		}
+		for i := 0; i < n; i++ {
+			var v float32
+			for k, kv := range kern {
+				j := i + k - kr
+				if j < 0 || j >= n {
+					v += kv * edge
+				} else {
+					v += kv * line[j]
+				}
+			}
+			set(i, v)
+		}
+	}
+	for y := 0; y < h; y++ {
+		row := buf[y*w : (y+1)*w]
+		blurLine(func(i int) float32 { return row[i] }, func(i int, v float32) { row[i] = v }, w)
+	}
+	for x := 0; x < w; x++ {
+		blurLine(func(i int) float32 { return buf[i*w+x] }, func(i int, v float32) { buf[i*w+x] = v }, h)
+	}
+}
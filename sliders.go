@@ -193,8 +193,8 @@ func (sb *SliderBase) SizeFromAlloc() {
 	if sb.Min == 0 && sb.Max == 0 { // uninit
 		sb.Defaults()
 	}
-	spc := sb.Sty.BoxSpace()
-	sb.Size = sb.LayData.AllocSize.Dim(sb.Dim) - 2.0*spc
+	spc := sb.Sty.BoxSpaceSides().Size()
+	sb.Size = sb.LayData.AllocSize.Dim(sb.Dim) - spc.Dim(sb.Dim)
 	if sb.Size <= 0 {
 		return
 	}
@@ -369,7 +369,7 @@ func (sb *SliderBase) MouseEvent() {
 				if me.Action == mouse.Press {
 					ed := sbb.PointToRelPos(me.Where)
 					st := &sbb.Sty
-					spc := st.Layout.Margin.Dots + 0.5*sbb.ThSize
+					spc := st.Layout.MarginDots().Pos().Dim(sbb.Dim) + 0.5*sbb.ThSize
 					if sbb.Dim == X {
 						sbb.SliderPressed(float32(ed.X) - spc)
 					} else {
@@ -457,12 +457,12 @@ func (sb *SliderBase) ConfigPartsIfNeeded(render bool) {
 		ick, ok := sb.Parts.Children().ElemByType(KiT_Icon, true, 0)
 		if ok {
 			ic := ick.(*Icon)
-			mrg := sb.Sty.Layout.Margin.Dots
-			pad := sb.Sty.Layout.Padding.Dots
-			spc := mrg + pad
+			mrg := sb.Sty.Layout.MarginDots().Pos()
+			pad := sb.Sty.Layout.PaddingDots().Pos()
+			spc := mrg.Add(pad)
 			odim := OtherDim(sb.Dim)
-			ic.LayData.AllocPosRel.SetDim(sb.Dim, sb.Pos+spc-0.5*sb.ThSize)
-			ic.LayData.AllocPosRel.SetDim(odim, -pad)
+			ic.LayData.AllocPosRel.SetDim(sb.Dim, sb.Pos+spc.Dim(sb.Dim)-0.5*sb.ThSize)
+			ic.LayData.AllocPosRel.SetDim(odim, -pad.Dim(odim))
 			ic.LayData.AllocSize.X = sb.ThSize
 			ic.LayData.AllocSize.Y = sb.ThSize
 			if render {
@@ -561,6 +561,7 @@ func (sr *Slider) StyleSlider() {
 	for i := 0; i < int(SliderStatesN); i++ {
 		sr.StateStyles[i].CopyFrom(&sr.Sty)
 		sr.StateStyles[i].SetStyleProps(pst, sr.StyleProps(SliderSelectors[i]))
+		sr.StateStyles[i].StyleCSS(sr.This.(Node2D), sr.CSSAgg, SliderSelectors[i])
 		sr.StateStyles[i].CopyUnitContext(&sr.Sty.UnContext)
 	}
 	SliderFields.Style(sr, nil, sr.Props)
@@ -582,8 +583,9 @@ func (sr *Slider) Size2D(iter int) {
 	}
 	st := &sr.Sty
 	// get at least thumbsize + margin + border.size
-	sz := sr.ThSize + 2.0*(st.Layout.Margin.Dots+st.Border.Width.Dots)
-	sr.LayData.AllocSize.SetDim(OtherDim(sr.Dim), sz)
+	odim := OtherDim(sr.Dim)
+	sz := sr.ThSize + st.Layout.MarginDots().Add(st.Border.WidthDots()).Size().Dim(odim)
+	sr.LayData.AllocSize.SetDim(odim, sz)
 }
 
 func (sr *Slider) Layout2D(parBBox image.Rectangle, iter int) bool {
@@ -628,8 +630,8 @@ func (sr *Slider) Render2DDefaultStyle() {
 	// overall fill box
 	sr.RenderStdBox(&sr.StateStyles[SliderBox])
 
-	pc.StrokeStyle.SetColor(&st.Border.Color)
-	pc.StrokeStyle.Width = st.Border.Width
+	rad := st.Border.RadiusDots()
+	pc.StrokeStyle.SetColor(nil)
 	pc.FillStyle.SetColorSpec(&st.Font.BgColor)
 
 	// layout is as follows, for width dimension
@@ -640,7 +642,7 @@ func (sr *Slider) Render2DDefaultStyle() {
 	//
 	// for length: | spc | ht | <-start of slider
 
-	spc := st.BoxSpace()
+	spc := st.BoxSpaceSides()
 	pos := sr.LayData.AllocPos
 	sz := sr.LayData.AllocSize
 	bpos := pos // box pos
@@ -650,18 +652,23 @@ func (sr *Slider) Render2DDefaultStyle() {
 	ht := 0.5 * sr.ThSize
 
 	odim := OtherDim(sr.Dim)
-	bpos.SetAddDim(odim, spc)
-	bsz.SetSubDim(odim, 2.0*spc)
-	bpos.SetAddDim(sr.Dim, spc+ht)
-	bsz.SetSubDim(sr.Dim, 2.0*(spc+ht))
-	sr.RenderBoxImpl(bpos, bsz, st.Border.Radius.Dots)
+	bpos.SetAddDim(odim, spc.Pos().Dim(odim))
+	bsz.SetSubDim(odim, spc.Size().Dim(odim))
+	bpos.SetAddDim(sr.Dim, spc.Pos().Dim(sr.Dim)+ht)
+	bsz.SetSubDim(sr.Dim, spc.Size().Dim(sr.Dim)+2.0*ht)
+	sr.RenderBoxRadiiImpl(bpos, bsz, rad)
+	sr.RenderBorder(st, bpos, bsz, rad)
 
 	bsz.SetDim(sr.Dim, sr.Pos)
+	pc.StrokeStyle.SetColor(nil)
 	pc.FillStyle.SetColorSpec(&sr.StateStyles[SliderValue].Font.BgColor)
-	sr.RenderBoxImpl(bpos, bsz, st.Border.Radius.Dots)
+	sr.RenderBoxRadiiImpl(bpos, bsz, rad)
+	sr.RenderBorder(st, bpos, bsz, rad)
 
 	tpos.SetDim(sr.Dim, bpos.Dim(sr.Dim)+sr.Pos)
 	tpos.SetAddDim(odim, 0.5*sz.Dim(odim)) // ctr
+	pc.StrokeStyle.SetColor(&st.Border.Color)
+	pc.StrokeStyle.Width = st.Border.TopWidth // the thumb has no sides
 	pc.FillStyle.SetColorSpec(&st.Font.BgColor)
 
 	if sr.Icon.IsValid() && sr.Parts.HasChildren() {
@@ -756,6 +763,7 @@ func (sb *ScrollBar) StyleScrollBar() {
 	for i := 0; i < int(SliderStatesN); i++ {
 		sb.StateStyles[i].CopyFrom(&sb.Sty)
 		sb.StateStyles[i].SetStyleProps(pst, sb.StyleProps(SliderSelectors[i]))
+		sb.StateStyles[i].StyleCSS(sb.This.(Node2D), sb.CSSAgg, SliderSelectors[i])
 		sb.StateStyles[i].CopyUnitContext(&sb.Sty.UnContext)
 	}
 	SliderFields.Style(sb, nil, sb.Props)
@@ -811,20 +819,23 @@ func (sb *ScrollBar) Render2DDefaultStyle() {
 	// overall fill box
 	sb.RenderStdBox(&sb.StateStyles[SliderBox])
 
-	pc.StrokeStyle.SetColor(&st.Border.Color)
-	pc.StrokeStyle.Width = st.Border.Width
+	rad := st.Border.RadiusDots()
+	pc.StrokeStyle.SetColor(nil)
 	pc.FillStyle.SetColorSpec(&st.Font.BgColor)
 
 	// scrollbar is basic box in content size
-	spc := st.BoxSpace()
-	pos := sb.LayData.AllocPos.AddVal(spc)
-	sz := sb.LayData.AllocSize.SubVal(2.0 * spc)
+	spc := st.BoxSpaceSides()
+	pos := sb.LayData.AllocPos.Add(spc.Pos())
+	sz := sb.LayData.AllocSize.Sub(spc.Size())
 
-	sb.RenderBoxImpl(pos, sz, st.Border.Radius.Dots) // surround box
-	pos.SetAddDim(sb.Dim, sb.Pos)                    // start of thumb
+	sb.RenderBoxRadiiImpl(pos, sz, rad) // surround box
+	sb.RenderBorder(st, pos, sz, rad)
+	pos.SetAddDim(sb.Dim, sb.Pos) // start of thumb
 	sz.SetDim(sb.Dim, sb.ThSize)
+	pc.StrokeStyle.SetColor(nil)
 	pc.FillStyle.SetColorSpec(&sb.StateStyles[SliderValue].Font.BgColor)
-	sb.RenderBoxImpl(pos, sz, st.Border.Radius.Dots)
+	sb.RenderBoxRadiiImpl(pos, sz, rad)
+	sb.RenderBorder(st, pos, sz, rad)
 }
 
 func (sb *ScrollBar) ConnectEvents2D() {
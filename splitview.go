@@ -210,10 +210,10 @@ func (sv *SplitView) ConfigSplitters() {
 	sz := len(sv.Kids)
 	mods, updt := sv.Parts.SetNChildren(sz-1, KiT_Splitter, "Splitter")
 	odim := OtherDim(sv.Dim)
-	spc := sv.Sty.BoxSpace()
-	size := sv.LayData.AllocSize.Dim(sv.Dim) - 2*spc
+	spc := sv.Sty.BoxSpaceSides().Size()
+	size := sv.LayData.AllocSize.Dim(sv.Dim) - spc.Dim(sv.Dim)
 	handsz := sv.HandleSize.Dots
-	mid := 0.5 * (sv.LayData.AllocSize.Dim(odim) - 2*spc)
+	mid := 0.5 * (sv.LayData.AllocSize.Dim(odim) - spc.Dim(odim))
 	spicon := IconName("")
 	if sv.Dim == X {
 		spicon = IconName("widget-handle-circles-vert")
@@ -320,11 +320,11 @@ func (sv *SplitView) Layout2D(parBBox image.Rectangle, iter int) bool {
 	// fmt.Printf("handsz: %v\n", handsz)
 	sz := len(sv.Kids)
 	odim := OtherDim(sv.Dim)
-	spc := sv.Sty.BoxSpace()
-	size := sv.LayData.AllocSize.Dim(sv.Dim) - 2*spc
+	spc := sv.Sty.BoxSpaceSides()
+	size := sv.LayData.AllocSize.Dim(sv.Dim) - spc.Size().Dim(sv.Dim)
 	avail := size - handsz*float32(sz-1)
 	// fmt.Printf("avail: %v\n", avail)
-	osz := sv.LayData.AllocSize.Dim(odim) - 2*spc
+	osz := sv.LayData.AllocSize.Dim(odim) - spc.Size().Dim(odim)
 	pos := float32(0.0)
 
 	spsum := float32(0)
@@ -341,7 +341,7 @@ func (sv *SplitView) Layout2D(parBBox image.Rectangle, iter int) bool {
 		gis.LayData.AllocSize.SetDim(odim, osz)
 		gis.LayData.AllocSizeOrig = gis.LayData.AllocSize
 		gis.LayData.AllocPosRel.SetDim(sv.Dim, pos)
-		gis.LayData.AllocPosRel.SetDim(odim, spc)
+		gis.LayData.AllocPosRel.SetDim(odim, spc.Pos().Dim(odim))
 		// fmt.Printf("spl: %v sp: %v size: %v alloc: %v  pos: %v\n", i, sp, isz, gis.LayData.AllocSizeOrig, gis.LayData.AllocPosRel)
 
 		pos += isz + handsz
@@ -470,14 +470,14 @@ func (sr *Splitter) ConfigPartsIfNeeded(render bool) {
 	}
 	ic := ick.(*Icon)
 	handsz := sr.ThumbSize.Dots
-	spc := sr.Sty.BoxSpace()
+	spc := sr.Sty.BoxSpaceSides().Size()
 	odim := OtherDim(sr.Dim)
-	sr.LayData.AllocSize.SetDim(odim, 2*(handsz+2*spc))
+	sr.LayData.AllocSize.SetDim(odim, 2*(handsz+spc.Dim(odim)))
 	sr.LayData.AllocSizeOrig = sr.LayData.AllocSize
 
 	ic.LayData.AllocSize.SetDim(odim, 2*handsz)
 	ic.LayData.AllocSize.SetDim(sr.Dim, handsz)
-	ic.LayData.AllocPosRel.SetDim(sr.Dim, sr.Pos-(0.5*(handsz+spc)))
+	ic.LayData.AllocPosRel.SetDim(sr.Dim, sr.Pos-(0.5*(handsz+0.5*spc.Dim(sr.Dim))))
 	ic.LayData.AllocPosRel.SetDim(odim, 0)
 	if render {
 		ic.Layout2DTree()
@@ -492,6 +492,7 @@ func (sr *Splitter) Style2D() {
 	for i := 0; i < int(SliderStatesN); i++ {
 		sr.StateStyles[i].CopyFrom(&sr.Sty)
 		sr.StateStyles[i].SetStyleProps(pst, sr.StyleProps(SliderSelectors[i]))
+		sr.StateStyles[i].StyleCSS(sr.This.(Node2D), sr.CSSAgg, SliderSelectors[i])
 		sr.StateStyles[i].CopyUnitContext(&sr.Sty.UnContext)
 	}
 	SliderFields.Style(sr, nil, sr.Props)
@@ -520,8 +521,9 @@ func (sr *Splitter) Layout2D(parBBox image.Rectangle, iter int) bool {
 }
 
 func (sr *Splitter) UpdateSplitterPos() {
-	spc := sr.Sty.BoxSpace()
-	ispc := int(spc)
+	spc := sr.Sty.BoxSpaceSides()
+	odim := OtherDim(sr.Dim)
+	ispc := int(spc.Pos().Dim(odim))
 	handsz := sr.ThumbSize.Dots
 	off := 0
 	if sr.Dim == X {
@@ -531,7 +533,7 @@ func (sr *Splitter) UpdateSplitterPos() {
 	}
 	sz := handsz
 	if !sr.IsDragging() {
-		sz += 2 * spc
+		sz += spc.Size().Dim(sr.Dim)
 	}
 	pos := off + int(sr.Pos-0.5*sz)
 	mxpos := off + int(sr.Pos+0.5*sz)
@@ -549,7 +551,7 @@ func (sr *Splitter) Render2D() {
 	win := vp.Win
 	sr.This.(Node2D).ConnectEvents2D()
 	if sr.IsDragging() {
-		// spc := sr.Sty.BoxSpace()
+		// spc := sr.Sty.BoxSpaceSides().Pos()
 		// odim := OtherDim(sr.Dim)
 		ick, ok := sr.Parts.Children().ElemByType(KiT_Icon, true, 0)
 		if !ok {
@@ -7,8 +7,10 @@ package gi
 import (
 	"image/color"
 	"log"
+	"math"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/goki/gi/units"
 	"github.com/goki/ki"
@@ -68,6 +70,8 @@ type StrokeStyle struct {
 	Cap        LineCap     `xml:"stroke-linecap" desc:"how to draw the end cap of lines"`
 	Join       LineJoin    `xml:"stroke-linejoin" desc:"how to join line segments"`
 	MiterLimit float32     `xml:"stroke-miterlimit" min:"1" desc:"limit of how far to miter -- must be 1 or larger"`
+
+	DashOffset float64 `xml:"stroke-dashoffset" desc:"distance into the dash pattern at which to start each subpath -- may be negative"`
 }
 
 // Defaults initializes default values for paint stroke
@@ -112,15 +116,70 @@ func (ps *StrokeStyle) SetColorSpec(cl *ColorSpec) {
 	}
 }
 
-// ParseDashesString gets a dash slice from given string
+// DashPattern returns the dash pattern and offset to use for stroking, with
+// the lengths scaled by given factor (from the transform) -- the pattern is
+// nil for a solid line, including if any value is negative or all are zero,
+// per SVG -- the offset is wrapped into the length of the pattern (twice the
+// sum of the values if there is an odd number of them) so it is never negative
+func (ps *StrokeStyle) DashPattern(sc float64) ([]float64, float64) {
+	if len(ps.Dashes) == 0 {
+		return nil, 0
+	}
+	sum := 0.0
+	for _, d := range ps.Dashes {
+		if d < 0 {
+			return nil, 0
+		}
+		sum += d
+	}
+	sum *= sc
+	if sum <= 0 {
+		return nil, 0
+	}
+	dash := make([]float64, len(ps.Dashes))
+	for i, d := range ps.Dashes {
+		dash[i] = d * sc
+	}
+	if len(dash)%2 == 1 {
+		sum *= 2
+	}
+	off := math.Mod(ps.DashOffset*sc, sum)
+	if off < 0 {
+		off += sum
+	}
+	return dash, off
+}
+
+// SetBorderDashes sets the dash pattern and end caps for stroking a border
+// or outline drawn in given style with the current Width: dotted is round
+// dots two widths apart, dashed is dashes and gaps of three widths, and all
+// other styles are solid -- call with BorderSolid to reset after drawing
+func (ps *StrokeStyle) SetBorderDashes(bs BorderDrawStyle) {
+	w := float64(ps.Width.Dots)
+	ps.DashOffset = 0
+	switch bs {
+	case BorderDotted:
+		ps.Dashes = []float64{0, 2 * w}
+		ps.Cap = LineCapRound
+	case BorderDashed:
+		ps.Dashes = []float64{3 * w, 3 * w}
+		ps.Cap = LineCapButt
+	default:
+		ps.Dashes = nil
+		ps.Cap = LineCapButt
+	}
+}
+
+// ParseDashesString gets a dash slice from given string, with the values
+// separated by commas and / or spaces
 func ParseDashesString(str string) []float64 {
 	if len(str) == 0 || str == "none" {
 		return nil
 	}
-	ds := strings.Split(str, ",")
+	ds := strings.FieldsFunc(str, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
 	dl := make([]float64, len(ds))
 	for i, dstr := range ds {
-		d, err := strconv.ParseFloat(strings.TrimSpace(dstr), 64)
+		d, err := strconv.ParseFloat(dstr, 64)
 		if err != nil {
 			log.Printf("gi.ParseDashesString parsing error: %v\n", err)
 			return nil
@@ -34,20 +34,24 @@ type Style struct {
 	Visible       bool          `xml:"visible" desc:"todo big enum of how to display item -- controls layout etc"`
 	Inactive      bool          `xml:"inactive" desc:"make a control inactive so it does not respond to input"`
 	Layout        LayoutStyle   `desc:"layout styles -- do not prefix with any xml"`
-	Border        BorderStyle   `xml:"border" desc:"border around the box element -- todo: can have separate ones for different sides"`
-	BoxShadow     ShadowStyle   `xml:"box-shadow" desc:"type of shadow to render around box"`
+	Border        BorderStyle   `xml:"border" desc:"border around the box element -- width and radius can be specified per side"`
+	BoxShadow     ShadowStyle   `xml:"box-shadow" desc:"type of shadow to render around box -- the first one if multiple are set using the box-shadow property"`
 	Font          FontStyle     `desc:"font parameters -- no xml prefix -- also has color, background-color"`
 	Text          TextStyle     `desc:"text parameters -- no xml prefix"`
 	Outline       BorderStyle   `xml:"outline" desc:"draw an outline around an element -- mostly same styles as border -- default to none"`
 	PointerEvents bool          `xml:"pointer-events" desc:"does this element respond to pointer events -- default is true"`
 	UnContext     units.Context `xml:"-" desc:"units context -- parameters necessary for anchoring relative units"`
+	Vars          ki.Props      `xml:"-" desc:"CSS custom properties (variables, e.g., --gap) in effect for this element: inherited from the parent, and declared on the element itself -- used to resolve var() references -- see SetStyleVars"`
+	DeclVars      ki.Props      `xml:"-" desc:"CSS custom properties declared on this element itself, which override inherited ones in Vars"`
+	MoreShadows   []ShadowStyle `xml:"-" desc:"any additional shadows after BoxShadow, from a comma-separated list in the box-shadow property -- rendered below it"`
 	IsSet         bool          `desc:"has this style been set from object values yet?"`
 	PropsNil      bool          `desc:"set to true if parent node has no props -- allows optimization of styling"`
 	dotsSet       bool
 	lastUnCtxt    units.Context
-}
 
-// todo: Animation
+	Transitions []StyleTransition `xml:"-" desc:"transitions of properties when changing between state styles, from the transition property -- see anim.go"`
+	Animation   StyleAnimation    `xml:"-" desc:"keyframe animation, from the animation property -- see anim.go"`
+}
 
 // Clear -- no floating elements
 
@@ -65,8 +69,6 @@ type Style struct {
 
 // visibility -- support more than just hidden  inherit:"true"
 
-// transition -- animation of hover, etc
-
 // RebuildDefaultStyles is a global state var used by Prefs to trigger rebuild
 // of all the default styles, which are otherwise compiled and not updated
 var RebuildDefaultStyles bool
@@ -177,12 +179,21 @@ func (ev *BorderDrawStyle) UnmarshalJSON(b []byte) error { return kit.EnumUnmars
 // BorderStyle contains style parameters for borders
 type BorderStyle struct {
 	Style  BorderDrawStyle `xml:"style" desc:"how to draw the border"`
-	Width  units.Value     `xml:"width" desc:"width of the border"`
-	Radius units.Value     `xml:"radius" desc:"rounding of the corners"`
+	Width  units.Value     `xml:"width" desc:"width of the border -- first value if set per side -- see TopWidth etc for the width used on each side"`
+	Radius units.Value     `xml:"radius" desc:"rounding of the corners -- first value if set per corner -- see TopLeftRadius etc for the radius used on each corner"`
 	Color  Color           `xml:"color" desc:"color of the border"`
+
+	TopWidth          units.Value `xml:"top-width" desc:"width of the border on the top side"`
+	RightWidth        units.Value `xml:"right-width" desc:"width of the border on the right side"`
+	BottomWidth       units.Value `xml:"bottom-width" desc:"width of the border on the bottom side"`
+	LeftWidth         units.Value `xml:"left-width" desc:"width of the border on the left side"`
+	TopLeftRadius     units.Value `xml:"top-left-radius" desc:"rounding of the top-left corner"`
+	TopRightRadius    units.Value `xml:"top-right-radius" desc:"rounding of the top-right corner"`
+	BottomRightRadius units.Value `xml:"bottom-right-radius" desc:"rounding of the bottom-right corner"`
+	BottomLeftRadius  units.Value `xml:"bottom-left-radius" desc:"rounding of the bottom-left corner"`
 }
 
-// style parameters for shadows
+// style parameters for shadows -- see shadow.go for rendering
 type ShadowStyle struct {
 	HOffset units.Value `xml:".h-offset" desc:"horizontal offset of shadow -- positive = right side, negative = left side"`
 	VOffset units.Value `xml:".v-offset" desc:"vertical offset of shadow -- positive = below, negative = above"`
@@ -192,10 +203,6 @@ type ShadowStyle struct {
 	Inset   bool        `xml:".inset" desc:"shadow is inset within box instead of outset outside of box"`
 }
 
-func (s *ShadowStyle) HasShadow() bool {
-	return (s.HOffset.Dots > 0 || s.VOffset.Dots > 0)
-}
-
 // CurrentColor is automatically updated from the Color setting of a Style and
 // accessible as a color name in any other style as currentColor
 var CurrentColor Color
@@ -236,6 +243,7 @@ func (s *Style) CopyFrom(cp *Style) {
 func (s *Style) InheritFields(par *Style) {
 	s.Font.InheritFields(&par.Font)
 	s.Text.InheritFields(&par.Text)
+	s.Vars = InheritStyleVars(par.Vars, s.DeclVars)
 }
 
 // SetStyleProps sets style values based on given property map (name: value pairs),
@@ -245,7 +253,14 @@ func (s *Style) SetStyleProps(par *Style, props ki.Props) {
 		// StyleFields.Inherit(s, par) // very slow for some mysterious reason
 		s.InheritFields(par)
 	}
-	StyleFields.Style(s, par, props)
+	if HasStyleVars(props) {
+		SetStyleVars(&s.DeclVars, props)
+		SetStyleVars(&s.Vars, props)
+	}
+	StyleFields.StyleWithVars(s, par, props, s.Vars)
+	s.SetBoxSides(par, props)
+	s.SetBoxShadows(par, props)
+	s.SetAnimProps(par, props)
 	s.Text.AlignV = s.Layout.AlignV
 	if s.Layout.Margin.Val > 0 && s.Text.ParaSpacing.Val == 0 {
 		s.Text.ParaSpacing = s.Layout.Margin
@@ -316,13 +331,14 @@ func (s *Style) CopyUnitContext(ctxt *units.Context) {
 // need to have set the UnContext first
 func (s *Style) ToDots() {
 	StyleFields.ToDots(s, &s.UnContext)
-}
-
-// BoxSpace returns extra space around the central content in the box model,
-// in dots -- todo: must complicate this if we want different spacing on
-// different sides box outside-in: margin | border | padding | content
-func (s *Style) BoxSpace() float32 {
-	return s.Layout.Margin.Dots + s.Border.Width.Dots + s.Layout.Padding.Dots
+	if len(s.MoreShadows) > 0 { // may be shared with a copied style
+		shs := make([]ShadowStyle, len(s.MoreShadows))
+		copy(shs, s.MoreShadows)
+		for i := range shs {
+			shs[i].ToDots(&s.UnContext)
+		}
+		s.MoreShadows = shs
+	}
 }
 
 // ApplyCSS applies css styles for given node, using key to select sub-props
@@ -348,16 +364,20 @@ func (s *Style) ApplyCSS(node Node2D, css ki.Props, key, selector string) bool {
 	return true
 }
 
-// StyleCSS applies css style properties to given Widget node, parsing out
-// type, .class, and #name selectors, along with optional sub-selector
-// (:hover, :active etc)
+// StyleCSS applies css style properties to given Widget node, using full
+// CSS selector matching (type, .class, #name, [attr], combinators and
+// pseudo-classes -- see Selector), in order of selector specificity, along
+// with optional state selector (:hover, :active etc) for computing the
+// StateStyles of widgets -- see MatchCSS
 func (s *Style) StyleCSS(node Node2D, css ki.Props, selector string) {
-	tyn := strings.ToLower(node.Type().Name()) // type is most general, first
-	s.ApplyCSS(node, css, tyn, selector)
-	cln := "." + strings.ToLower(node.AsNode2D().Class) // then class
-	s.ApplyCSS(node, css, cln, selector)
-	idnm := "#" + strings.ToLower(node.Name()) // then name
-	s.ApplyCSS(node, css, idnm, selector)
+	pmaps := MatchCSS(node, css, selector)
+	if len(pmaps) == 0 {
+		return
+	}
+	parSty := node.AsNode2D().ParentStyle()
+	for _, pmap := range pmaps {
+		s.SetStyleProps(parSty, pmap)
+	}
 }
 
 // SubProps returns a sub-property map from given prop map for a given styling
@@ -524,7 +544,7 @@ func (sf *StyledFields) Inherit(obj, par interface{}) {
 	}
 	for _, fld := range sf.Inherits {
 		pfi := fld.FieldIface(parptr)
-		fld.FromProps(sf.Fields, objptr, parptr, pfi, hasPar)
+		fld.FromProps(sf.Fields, objptr, parptr, pfi, hasPar, nil)
 		// fmt.Printf("inh: %v\n", fld.Field.Name)
 	}
 	// pr.End()
@@ -532,6 +552,13 @@ func (sf *StyledFields) Inherit(obj, par interface{}) {
 
 // Style applies styles to the fields from given properties for given object
 func (sf *StyledFields) Style(obj, par interface{}, props ki.Props) {
+	sf.StyleWithVars(obj, par, props, nil)
+}
+
+// StyleWithVars applies styles to the fields from given properties for given
+// object, using given CSS custom properties (variables) to resolve var()
+// references in the property values
+func (sf *StyledFields) StyleWithVars(obj, par interface{}, props, vars ki.Props) {
 	if props == nil {
 		return
 	}
@@ -547,7 +574,7 @@ func (sf *StyledFields) Style(obj, par interface{}, props ki.Props) {
 		if len(key) == 0 {
 			continue
 		}
-		if key[0] == '#' || key[0] == '.' || key[0] == ':' || key[0] == '_' {
+		if key[0] == '#' || key[0] == '.' || key[0] == ':' || key[0] == '_' || IsStyleVar(key) {
 			continue
 		}
 		if vstr, ok := val.(string); ok {
@@ -556,7 +583,7 @@ func (sf *StyledFields) Style(obj, par interface{}, props ki.Props) {
 				if vfld, nok := sf.Fields[nkey]; nok {
 					nval := vfld.FieldIface(objptr)
 					if fld, fok := sf.Fields[key]; fok {
-						fld.FromProps(sf.Fields, objptr, parptr, nval, hasPar)
+						fld.FromProps(sf.Fields, objptr, parptr, nval, hasPar, vars)
 						continue
 					}
 				}
@@ -570,7 +597,7 @@ func (sf *StyledFields) Style(obj, par interface{}, props ki.Props) {
 			// log.Printf("SetStyleFields: Property key: %v not among xml or alt field tags for styled obj: %T\n", key, obj)
 			continue
 		}
-		fld.FromProps(sf.Fields, objptr, parptr, val, hasPar)
+		fld.FromProps(sf.Fields, objptr, parptr, val, hasPar, vars)
 	}
 	pr.End()
 }
@@ -642,14 +669,32 @@ func (sf *StyledField) UnitsValue(objptr uintptr) *units.Value {
 	return uv
 }
 
-// FromProps styles given field from property value val, with optional parent object obj
-func (fld *StyledField) FromProps(fields map[string]*StyledField, objptr, parptr uintptr, val interface{}, hasPar bool) {
+// hyphenEnumProps are the flexbox alignment properties, whose css values
+// such as flex-start and space-between map to the lower-case Align names
+// without the -
+var hyphenEnumProps = map[string]bool{
+	"justify-content": true,
+	"align-items":     true,
+	"align-content":   true,
+}
+
+// FromProps styles given field from property value val, with optional parent
+// object obj -- var() references in val are resolved using the custom
+// properties in vars, and the field is not set if they can't be resolved
+func (fld *StyledField) FromProps(fields map[string]*StyledField, objptr, parptr uintptr, val interface{}, hasPar bool, vars ki.Props) {
 	errstr := "gi.StyledField FromProps: Field:"
 	fi := fld.FieldIface(objptr)
 	if kit.IfaceIsNil(fi) {
 		fmt.Printf("%v %v of type %v has nil value\n", errstr, fld.Field.Name, fld.Field.Type.String())
 		return
 	}
+	if vstr, ok := val.(string); ok && HasVarRef(vstr) {
+		rval, ok := ResolveVars(vstr, vars)
+		if !ok {
+			return
+		}
+		val = rval
+	}
 	switch valv := val.(type) {
 	case string:
 		if valv == "inherit" {
@@ -733,6 +778,9 @@ func (fld *StyledField) FromProps(fields map[string]*StyledField, objptr, parptr
 			case string:
 				tn := kit.FullTypeName(fld.Field.Type)
 				if kit.Enums.Enum(tn) != nil {
+					if hyphenEnumProps[fld.Field.Tag.Get("xml")] {
+						valv = strings.Replace(valv, "-", "", -1)
+					}
 					kit.Enums.SetAnyEnumIfaceFromString(fi, valv)
 				} else if tn == "..int" {
 					kit.SetRobust(fi, val)
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	// "reflect"
 	"testing"
+	"time"
 
 	"github.com/goki/gi/units"
 	"github.com/goki/ki"
@@ -38,3 +39,140 @@ func TestStyle(t *testing.T) {
 	fmt.Printf("style box-shaodw.v-offset: %v\n", s.BoxShadow.VOffset)
 	fmt.Printf("style border-style: %v\n", s.Border.Style)
 }
+
+func TestStyleVars(t *testing.T) {
+	pprops := ki.Props{
+		"--gap":   "2em",
+		"--brand": "#08F",
+		"--pad":   units.NewValue(3, units.Px),
+	}
+	props := ki.Props{
+		"--gap":         "var(--pad)", // overrides parent's
+		"margin":        "var(--gap)",
+		"padding":       "var(--pad)",
+		"color":         "var(--brand)",
+		"width":         "calc(100% - var(--gap, 1em))",
+		"height":        "var(--missing, 5px)",
+		"border-radius": "var(--missing)",
+	}
+	var s, p Style
+	s.Defaults()
+	p.Defaults()
+	p.SetStyleProps(nil, pprops)
+	s.SetStyleProps(&p, props)
+
+	if s.Layout.Margin != units.NewValue(3, units.Px) {
+		t.Errorf("margin not set from overridden var: %v\n", s.Layout.Margin)
+	}
+	if s.Layout.Padding != units.NewValue(3, units.Px) {
+		t.Errorf("padding not set from inherited var: %v\n", s.Layout.Padding)
+	}
+	var clr Color
+	clr.SetString("#08F", nil)
+	if s.Font.Color != clr {
+		t.Errorf("color not set from inherited var: %v\n", s.Font.Color)
+	}
+	if s.Layout.Width.Calc == nil || s.Layout.Width.Calc.Expr != "calc(100% - 3.000000px)" {
+		t.Errorf("width calc not set from var: %v\n", s.Layout.Width.String())
+	}
+	if s.Layout.Height != units.NewValue(5, units.Px) {
+		t.Errorf("height not set from fallback: %v\n", s.Layout.Height)
+	}
+	if s.Border.Radius.Val != 0 {
+		t.Errorf("border-radius should not be set from missing var: %v\n", s.Border.Radius)
+	}
+	if _, has := p.Vars["--pad"]; !has || p.Vars["--gap"] != "2em" {
+		t.Errorf("parent vars modified: %v\n", p.Vars)
+	}
+}
+
+func TestBoxSides(t *testing.T) {
+	props := ki.Props{
+		"margin":                  "1px 2px 3px",
+		"padding":                 units.NewValue(4, units.Px),
+		"border-width":            "0 0 2px",
+		"border-radius":           "6px 6px 0 0",
+		"border-top-right-radius": "0",
+	}
+	var s Style
+	s.Defaults()
+	s.SetStyleProps(nil, props)
+
+	mrg := [BoxN]units.Value{s.Layout.MarginTop, s.Layout.MarginRight, s.Layout.MarginBottom, s.Layout.MarginLeft}
+	if mrg != [BoxN]units.Value{units.NewValue(1, units.Px), units.NewValue(2, units.Px), units.NewValue(3, units.Px), units.NewValue(2, units.Px)} {
+		t.Errorf("margin sides not set from 3 values: %v\n", mrg)
+	}
+	if s.Layout.PaddingLeft != units.NewValue(4, units.Px) {
+		t.Errorf("padding sides not set from single value: %v\n", s.Layout.PaddingLeft)
+	}
+	if s.Border.BottomWidth.Val != 2 || s.Border.TopWidth.Val != 0 || s.Border.LeftWidth.Val != 0 {
+		t.Errorf("border width sides not set: %v %v %v\n", s.Border.TopWidth, s.Border.BottomWidth, s.Border.LeftWidth)
+	}
+	if s.Border.TopLeftRadius.Val != 6 || s.Border.TopRightRadius.Val != 0 || s.Border.BottomLeftRadius.Val != 0 {
+		t.Errorf("border radius corners not set, or longhand not taking precedence: %v %v %v\n", s.Border.TopLeftRadius, s.Border.TopRightRadius, s.Border.BottomLeftRadius)
+	}
+}
+
+func TestBoxShadows(t *testing.T) {
+	props := ki.Props{
+		"box-shadow": "2px -3px 4px rgba(0,0,0,0.5), inset 0 0 2px 1px red",
+	}
+	var s Style
+	s.Defaults()
+	s.SetStyleProps(nil, props)
+
+	if s.BoxShadow.HOffset.Val != 2 || s.BoxShadow.VOffset.Val != -3 || s.BoxShadow.Blur.Val != 4 || s.BoxShadow.Inset {
+		t.Errorf("first box shadow not set: %v\n", s.BoxShadow)
+	}
+	if len(s.MoreShadows) != 1 || !s.MoreShadows[0].Inset || s.MoreShadows[0].Spread.Val != 1 || s.MoreShadows[0].Color.R != 255 {
+		t.Errorf("second box shadow not set: %v\n", s.MoreShadows)
+	}
+	if _, err := ParseBoxShadows("2px red"); err == nil {
+		t.Errorf("box shadow with only one length should be an error\n")
+	}
+}
+
+func TestStyleAnimProps(t *testing.T) {
+	props := ki.Props{
+		"transition": "background-color 0.2s ease-out, color 100ms 50ms",
+		"animation":  "pulse 1s ease-in-out infinite alternate",
+	}
+	var s Style
+	s.Defaults()
+	s.SetStyleProps(nil, props)
+
+	if len(s.Transitions) != 2 || s.Transitions[0].Prop != "background-color" || s.Transitions[0].Duration != 200*time.Millisecond || s.Transitions[0].Easing != EaseOut {
+		t.Errorf("transitions not set: %v\n", s.Transitions)
+	}
+	if s.Transitions[1].Delay != 50*time.Millisecond || s.Transitions[1].Easing != Ease {
+		t.Errorf("transition delay or default easing not set: %v\n", s.Transitions[1])
+	}
+	an := s.Animation
+	if an.Name != "pulse" || an.Duration != time.Second || an.Iters != -1 || !an.Alternate || an.Easing != EaseInOut {
+		t.Errorf("animation not set: %v\n", an)
+	}
+	if e := EaseInOut.Eval(0.5); e < 0.49 || e > 0.51 {
+		t.Errorf("ease-in-out at 0.5 should be 0.5: %v\n", e)
+	}
+}
+
+func TestFlexProps(t *testing.T) {
+	props := ki.Props{
+		"flex-direction":  "column",
+		"flex-wrap":       "wrap",
+		"justify-content": "space-between",
+		"align-items":     "flex-start",
+		"align-content":   "space-evenly",
+	}
+	var s Style
+	s.Defaults()
+	s.SetStyleProps(nil, props)
+
+	ls := &s.Layout
+	if ls.FlexDirection != FlexColumn || ls.FlexWrap != FlexWrap {
+		t.Errorf("flex direction or wrap not set: %v %v\n", ls.FlexDirection, ls.FlexWrap)
+	}
+	if ls.JustifyContent != AlignSpaceBetween || ls.AlignItems != AlignFlexStart || ls.AlignContent != AlignSpaceEvenly {
+		t.Errorf("hyphenated flex alignments not set: %v %v %v\n", ls.JustifyContent, ls.AlignItems, ls.AlignContent)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/goki/ki"
+)
+
+// StyleFilesPollMSec is the interval in msec at which the Preferences
+// StyleFiles are checked for changes on disk
+var StyleFilesPollMSec = 500
+
+// prefsCSS is the compiled custom css from Preferences CustomStyles and
+// StyleFiles -- rebuilt by ApplyStyleFiles, and never modified after that
+var prefsCSS ki.Props
+
+// styleFilesList is a copy of the StyleFiles as of the last ApplyStyleFiles,
+// for use by the watcher outside of the window event loops
+var styleFilesList []FileName
+
+// styleFilesMods records the modification times of the StyleFiles when last
+// parsed
+var styleFilesMods map[FileName]time.Time
+
+// styleFilesWatching is true when the watcher goroutine is running
+var styleFilesWatching bool
+
+// styleFilesMu protects the above style files state
+var styleFilesMu sync.Mutex
+
+// CustomCSS returns the compiled custom css style sheet, which has the
+// CustomStyles merged with the styles from all the StyleFiles (which
+// override CustomStyles with the same selectors) -- this is applied to all
+// widgets, as defaults for their own styles, or overriding them if
+// PrefsOverride is set.
+func (pf *Preferences) CustomCSS() ki.Props {
+	styleFilesMu.Lock()
+	defer styleFilesMu.Unlock()
+	return prefsCSS
+}
+
+// OpenStyleFile parses the given .css file into a StyleSheet and returns
+// its properties
+func OpenStyleFile(filename FileName) (ki.Props, error) {
+	b, err := ioutil.ReadFile(string(filename))
+	if err != nil {
+		return nil, err
+	}
+	ss := &StyleSheet{}
+	if err := ss.ParseString(string(b)); err != nil {
+		return nil, fmt.Errorf("gi.OpenStyleFile: error parsing %v: %v", filename, err)
+	}
+	return ss.CSSProps(), nil
+}
+
+// ApplyStyleFiles (re)builds the CustomCSS from CustomStyles and the
+// StyleFiles, and starts monitoring the StyleFiles for changes if not
+// already doing so -- files that can't be read or parsed are skipped, and
+// the first such error is returned.  Called in Apply.
+func (pf *Preferences) ApplyStyleFiles() error {
+	var css ki.Props
+	AggCSS(&css, pf.CustomStyles)
+	mods := make(map[FileName]time.Time, len(pf.StyleFiles))
+	var rerr error
+	for _, fn := range pf.StyleFiles {
+		if st, err := os.Stat(string(fn)); err == nil {
+			mods[fn] = st.ModTime()
+		}
+		fcss, err := OpenStyleFile(fn)
+		if err != nil {
+			log.Println(err)
+			if rerr == nil {
+				rerr = err
+			}
+			continue
+		}
+		for sel, sp := range fcss { // merge selectors across files
+			if fsp, ok := sp.(ki.Props); ok {
+				if csp, has := css[sel]; has {
+					if cspm, ok := csp.(ki.Props); ok {
+						mp := make(ki.Props, len(cspm)+len(fsp))
+						AggCSS(&mp, cspm)
+						AggCSS(&mp, fsp)
+						css[sel] = mp
+						continue
+					}
+				}
+			}
+			css[sel] = sp
+		}
+	}
+	styleFilesMu.Lock()
+	prefsCSS = css
+	styleFilesList = append([]FileName(nil), pf.StyleFiles...)
+	styleFilesMods = mods
+	startWatch := len(styleFilesList) > 0 && !styleFilesWatching
+	if startWatch {
+		styleFilesWatching = true
+	}
+	styleFilesMu.Unlock()
+	if startWatch {
+		go pf.watchStyleFiles()
+	}
+	return rerr
+}
+
+// styleFilesChanged returns true if any of the StyleFiles has been modified
+// since it was last parsed
+func (pf *Preferences) styleFilesChanged() bool {
+	styleFilesMu.Lock()
+	defer styleFilesMu.Unlock()
+	if len(styleFilesList) != len(styleFilesMods) {
+		for _, fn := range styleFilesList {
+			if _, err := os.Stat(string(fn)); err == nil {
+				if _, has := styleFilesMods[fn]; !has {
+					return true // newly available
+				}
+			}
+		}
+	}
+	for fn, mt := range styleFilesMods {
+		st, err := os.Stat(string(fn))
+		if err != nil || !st.ModTime().Equal(mt) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchStyleFiles polls the StyleFiles for changes, every StyleFilesPollMSec,
+// and re-applies them to all windows when they change, within the window
+// event loops -- runs until there are no more StyleFiles
+func (pf *Preferences) watchStyleFiles() {
+	for {
+		time.Sleep(time.Duration(StyleFilesPollMSec) * time.Millisecond)
+		styleFilesMu.Lock()
+		if len(styleFilesList) == 0 {
+			styleFilesWatching = false
+			styleFilesMu.Unlock()
+			return
+		}
+		styleFilesMu.Unlock()
+		if !pf.styleFilesChanged() {
+			continue
+		}
+		err := RunInAllEventLoops(func() {
+			pf.ApplyStyleFiles()
+		})
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if err := RestyleAllWindows(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// RestyleAllWindows rebuilds the default styles and fully re-renders all
+// open windows, under RunInAllEventLoops so that no other window is
+// rendering while the global RebuildDefaultStyles is set -- use this to
+// apply style changes from outside of any window event loop.  Returns an
+// error if the windows did not respond.
+func RestyleAllWindows() error {
+	for pass := 0; pass < 2; pass++ { // needs another pass through to get it right, as in Update
+		err := RunInAllEventLoops(func() {
+			RebuildDefaultStyles = (pass == 0)
+			for _, w := range AllWindows {
+				if !w.IsClosed() {
+					w.FullReRender()
+				}
+			}
+			RebuildDefaultStyles = false
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
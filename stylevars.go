@@ -0,0 +1,215 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// CSS custom properties (variables): any property whose name starts with --,
+// e.g., "--gap": "4px" or "--brand": "#08F", in ki.Props or style sheets, is
+// not a style itself, but defines a value that is inherited by the element
+// and all of its children, and can be referenced in any other property value
+// as var(--gap), or var(--gap, 2px) with a fallback value used if --gap is
+// not defined.  Values in ki.Props can also be non-string values, e.g.,
+// units.Value or Color, which are used directly when the property value is
+// just the var() reference.
+
+// StyleVarsMaxDepth is the maximum depth of var() references within the
+// values of other custom properties -- protects against circular references
+var StyleVarsMaxDepth = 10
+
+// IsStyleVar returns true if the property key is a custom property name,
+// starting with --
+func IsStyleVar(key string) bool {
+	return strings.HasPrefix(key, "--")
+}
+
+// HasStyleVars returns true if the props have any custom property
+// declarations
+func HasStyleVars(props ki.Props) bool {
+	for key := range props {
+		if IsStyleVar(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStyleVars adds the custom property declarations in props to the vars
+// -- the vars map may be shared with other styles (e.g., inherited from the
+// parent), so it is copied before adding new values
+func SetStyleVars(vars *ki.Props, props ki.Props) {
+	if !HasStyleVars(props) {
+		return
+	}
+	nv := make(ki.Props, len(*vars)+len(props))
+	for key, val := range *vars {
+		nv[key] = val
+	}
+	for key, val := range props {
+		if IsStyleVar(key) {
+			nv[key] = val
+		}
+	}
+	*vars = nv
+}
+
+// InheritStyleVars returns the custom properties inherited from the parent
+// vars, with those declared on the element itself overriding them -- avoids
+// making a new map if either is empty
+func InheritStyleVars(par, decl ki.Props) ki.Props {
+	if len(decl) == 0 {
+		return par
+	}
+	if len(par) == 0 {
+		return decl
+	}
+	nv := make(ki.Props, len(par)+len(decl))
+	for key, val := range par {
+		nv[key] = val
+	}
+	for key, val := range decl {
+		nv[key] = val
+	}
+	return nv
+}
+
+// HasVarRef returns true if the string has a var() reference in it
+func HasVarRef(str string) bool {
+	return strings.Contains(str, "var(")
+}
+
+// ResolveVars returns the value of given property value string with all of
+// its var() references replaced by the values of the custom properties in
+// vars, or their fallbacks -- if the string is just a var() reference, the
+// custom property value is returned as-is (i.e., it can be a non-string
+// value), and otherwise values are substituted as strings.  Returns false if
+// a reference could not be resolved, in which case the property should not
+// be set.
+func ResolveVars(str string, vars ki.Props) (interface{}, bool) {
+	return resolveVars(str, vars, 0)
+}
+
+func resolveVars(str string, vars ki.Props, depth int) (interface{}, bool) {
+	if depth > StyleVarsMaxDepth {
+		return nil, false
+	}
+	var sb strings.Builder
+	rest := str
+	for {
+		st := strings.Index(rest, "var(")
+		if st < 0 {
+			break
+		}
+		ed := matchParen(rest, st+3)
+		if ed < 0 {
+			return nil, false
+		}
+		val, ok := resolveVarRef(rest[st+4:ed], vars, depth)
+		if !ok {
+			return nil, false
+		}
+		if st == 0 && ed == len(rest)-1 && sb.Len() == 0 { // just the ref
+			return val, true
+		}
+		sb.WriteString(rest[:st])
+		sb.WriteString(styleVarString(val))
+		rest = rest[ed+1:]
+	}
+	sb.WriteString(rest)
+	return sb.String(), true
+}
+
+// resolveVarRef returns the value for the contents of a var() reference:
+// --name optionally followed by a comma and fallback value
+func resolveVarRef(ref string, vars ki.Props, depth int) (interface{}, bool) {
+	name := ref
+	fallback := ""
+	hasFb := false
+	if ci := strings.Index(ref, ","); ci >= 0 {
+		name = ref[:ci]
+		fallback = strings.TrimSpace(ref[ci+1:])
+		hasFb = true
+	}
+	name = strings.TrimSpace(name)
+	if val, has := vars[name]; has {
+		if vs, ok := val.(string); ok {
+			vs = strings.TrimSpace(vs)
+			if HasVarRef(vs) {
+				return resolveVars(vs, vars, depth+1)
+			}
+			return vs, true
+		}
+		return val, true
+	}
+	if !hasFb {
+		return nil, false
+	}
+	if HasVarRef(fallback) {
+		return resolveVars(fallback, vars, depth+1)
+	}
+	return fallback, true
+}
+
+// PropStringWithVars returns the string value of given property in props,
+// with any var() references resolved using vars -- returns false if the
+// property is not set, is not a string, or could not be resolved
+func PropStringWithVars(props ki.Props, key string, vars ki.Props) (string, bool) {
+	val, has := props[key]
+	if !has {
+		return "", false
+	}
+	if vstr, ok := val.(string); ok && HasVarRef(vstr) {
+		rval, ok := ResolveVars(vstr, vars)
+		if !ok {
+			return "", false
+		}
+		val = rval
+	}
+	str, ok := val.(string)
+	return str, ok
+}
+
+// styleVarString returns the string representation of a custom property
+// value, for substituting into another value string
+func styleVarString(val interface{}) string {
+	switch vv := val.(type) {
+	case string:
+		return vv
+	case units.Value:
+		return vv.String()
+	case *units.Value:
+		return vv.String()
+	case Color:
+		return fmt.Sprintf("rgba(%d,%d,%d,%d)", vv.R, vv.G, vv.B, vv.A)
+	case *Color:
+		return styleVarString(*vv)
+	}
+	return kit.ToString(val)
+}
+
+// matchParen returns the index of the parenthesis matching the open one at
+// given index, or -1 if not found
+func matchParen(str string, open int) int {
+	depth := 0
+	for i := open; i < len(str); i++ {
+		switch str[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
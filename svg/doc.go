@@ -16,6 +16,10 @@ will ultimately be expanded to support more advanced editing.  Also in that
 directory are a number of test files that stress different aspects of
 rendering.
 
+SVG files are read with OpenXML / ReadXML, and the (possibly edited) drawing
+can be written back out with SaveXML / WriteXML.  To export the rendering of
+any widget tree (including an SVG) as SVG markup, see gi.Viewport2D.SaveSVG.
+
 svg.NodeBase is the base type for all SVG elements -- unlike Widget nodes, SVG
 nodes do not use layout logic, and just draw directly into a parent SVG
 viewport, with cumulative transforms determining drawing position, etc.  The
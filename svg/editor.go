@@ -19,9 +19,10 @@ import (
 // Editor supports editing of SVG elements
 type Editor struct {
 	SVG
-	Trans         gi.Vec2D `desc:"view translation offset (from dragging)"`
-	Scale         float32  `desc:"view scaling (from zooming)"`
-	SetDragCursor bool     `desc:"has dragging cursor been set yet?"`
+	Trans         gi.Vec2D  `desc:"view translation offset (from dragging)"`
+	Scale         float32   `desc:"view scaling (from zooming)"`
+	SetDragCursor bool      `desc:"has dragging cursor been set yet?"`
+	EditSig       ki.Signal `json:"-" xml:"-" view:"-" desc:"signal for when an element has been edited in its element view -- data is the element -- zooming and panning are not edits"`
 }
 
 var KiT_Editor = kit.Types.AddType(&Editor{}, nil)
@@ -79,7 +80,15 @@ func (svg *Editor) EditorEvents() {
 		if me.Action == mouse.Release && me.Button == mouse.Right {
 			me.SetProcessed()
 			if obj != nil {
-				giv.StructViewDialog(ssvg.Viewport, obj, giv.DlgOpts{Title: "SVG Element View"}, nil, nil)
+				dlg := giv.StructViewDialog(ssvg.Viewport, obj, giv.DlgOpts{Title: "SVG Element View"}, nil, nil)
+				if svk, ok := dlg.Frame().ChildByName("struct-view", 0); ok {
+					svk.(*giv.StructView).ViewSig.Connect(ssvg.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+						esvg := recv.Embed(KiT_Editor).(*Editor)
+						esvg.SetFullReRender()
+						esvg.UpdateSig()
+						esvg.EditSig.Emit(esvg.This, 0, obj)
+					})
+				}
 			}
 		}
 	})
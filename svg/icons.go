@@ -94,6 +94,7 @@ func (ic *Icon) Render2D() {
 	}
 	if ic.PushBounds() {
 		if ic.NeedsReRender() {
+			ic.RecordForParent()
 			rs := &ic.Render
 			if ic.Fill {
 				ic.FillViewport()
@@ -11,16 +11,21 @@
 package svg
 
 import (
+	"bufio"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/goki/gi"
 	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
 	"golang.org/x/net/html/charset"
 )
 
@@ -411,7 +416,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 						}
 					case "textLength":
 						tl, err := gi.ParseFloat32(attr.Value)
-						if err != nil {
+						if err == nil {
 							txt.TextLength = tl
 						}
 					case "lengthAdjust":
@@ -518,7 +523,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 						szx, err = gi.ParseFloat32(attr.Value)
 					case "markerHeight":
 						szy, err = gi.ParseFloat32(attr.Value)
-					case "matrixUnits":
+					case "markerUnits", "matrixUnits":
 						if attr.Value == "strokeWidth" {
 							mrk.Units = StrokeWidth
 						} else {
@@ -680,6 +685,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 			switch {
 			// case :
 			// 	md.MetaData = string(se)
+			case trspc == "": // whitespace between elements, e.g., after a tspan
 			case inTitle:
 				curSvg.Title += trspc
 			case inDesc:
@@ -703,3 +709,332 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 	}
 	return nil
 }
+
+// SaveXML saves the svg to given file as XML-formatted SVG, which can be
+// opened again with OpenXML -- e.g., after editing a file opened with OpenXML
+func (svg *SVG) SaveXML(filename string) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer fp.Close()
+	bw := bufio.NewWriter(fp)
+	err = svg.WriteXML(bw, true)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+// WriteXML writes XML-formatted SVG output of the svg scenegraph to
+// io.Writer, with nested elements indented if indent is true
+func (svg *SVG) WriteXML(w io.Writer, indent bool) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if indent {
+		enc.Indent("", "  ")
+	}
+	if err := svg.MarshalXML(enc, xml.StartElement{}); err != nil {
+		return err
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// MarshalXML marshals the svg using xml.Encoder, as the complement of
+// UnmarshalXML: elements are written with their geometry and properties
+// (including those set from style attributes), and an id for nodes that do
+// not have the default name given by UnmarshalXML.  Elements that are
+// expanded when parsed (use, and gradient href) are written in expanded
+// form, and namespace declarations other than the standard svg and xlink
+// ones are not written, nor are the viewing properties of the top-level svg
+// (see SVGViewProps).  The given start element is not used.
+func (svg *SVG) MarshalXML(enc *xml.Encoder, se xml.StartElement) error {
+	return marshalNodeXML(enc, svg, true)
+}
+
+// SVGViewProps are the properties of a top-level svg that are set by the
+// widget viewing it (e.g., the Editor zoom and pan transform), and not
+// written by MarshalXML -- property values that are not strings are also
+// not written for the top-level svg
+var SVGViewProps = map[string]bool{
+	"transform":        true,
+	"width":            true,
+	"height":           true,
+	"background-color": true,
+}
+
+// xmlNum formats a number for an XML attribute
+func xmlNum(v float32) string {
+	return strconv.FormatFloat(float64(v), 'f', -1, 32)
+}
+
+// xmlNums formats a list of numbers for an XML attribute
+func xmlNums(vs []float32) string {
+	strs := make([]string, len(vs))
+	for i, v := range vs {
+		strs[i] = xmlNum(v)
+	}
+	return strings.Join(strs, " ")
+}
+
+// xmlPoints formats a list of points for an XML points attribute
+func xmlPoints(pts []gi.Vec2D) string {
+	strs := make([]string, len(pts))
+	for i, p := range pts {
+		strs[i] = xmlNum(p.X) + "," + xmlNum(p.Y)
+	}
+	return strings.Join(strs, " ")
+}
+
+// xmlViewBox formats a viewbox for an XML viewBox attribute
+func xmlViewBox(vb *ViewBox) string {
+	return xmlNums([]float32{vb.Min.X, vb.Min.Y, vb.Size.X, vb.Size.Y})
+}
+
+// marshalNodeXML writes the given node and its children as SVG elements,
+// for the top-level svg if top -- nodes that are not SVG elements are skipped
+func marshalNodeXML(enc *xml.Encoder, nd gi.Node2D, top bool) error {
+	nb := nd.AsNode2D()
+	se := xml.StartElement{}
+	attr := func(nm, val string) {
+		se.Attr = append(se.Attr, xml.Attr{Name: xml.Name{Local: nm}, Value: val})
+	}
+	defnm := "" // default name given by UnmarshalXML
+	text := ""
+	var svg *SVG
+	switch g := nd.(type) {
+	case *SVG:
+		svg = g
+		se.Name.Local, defnm = "svg", "svg"
+		if top {
+			attr("xmlns", "http://www.w3.org/2000/svg")
+			attr("xmlns:xlink", "http://www.w3.org/1999/xlink")
+		}
+		if g.ViewBox.Size != gi.Vec2DZero {
+			attr("width", xmlNum(g.ViewBox.Size.X))
+			attr("height", xmlNum(g.ViewBox.Size.Y))
+			attr("viewBox", xmlViewBox(&g.ViewBox))
+		}
+	case *Group:
+		se.Name.Local, defnm = "g", "g"
+	case *Rect:
+		se.Name.Local, defnm = "rect", "rect"
+		attr("x", xmlNum(g.Pos.X))
+		attr("y", xmlNum(g.Pos.Y))
+		attr("width", xmlNum(g.Size.X))
+		attr("height", xmlNum(g.Size.Y))
+		if g.Radius.X != 0 || g.Radius.Y != 0 {
+			attr("rx", xmlNum(g.Radius.X))
+			attr("ry", xmlNum(g.Radius.Y))
+		}
+	case *Circle:
+		se.Name.Local, defnm = "circle", "circle"
+		attr("cx", xmlNum(g.Pos.X))
+		attr("cy", xmlNum(g.Pos.Y))
+		attr("r", xmlNum(g.Radius))
+	case *Ellipse:
+		se.Name.Local, defnm = "ellipse", "ellipse"
+		attr("cx", xmlNum(g.Pos.X))
+		attr("cy", xmlNum(g.Pos.Y))
+		attr("rx", xmlNum(g.Radii.X))
+		attr("ry", xmlNum(g.Radii.Y))
+	case *Line:
+		se.Name.Local, defnm = "line", "line"
+		attr("x1", xmlNum(g.Start.X))
+		attr("y1", xmlNum(g.Start.Y))
+		attr("x2", xmlNum(g.End.X))
+		attr("y2", xmlNum(g.End.Y))
+	case *Polygon:
+		se.Name.Local, defnm = "polygon", "polygon"
+		attr("points", xmlPoints(g.Points))
+	case *Polyline:
+		se.Name.Local, defnm = "polyline", "polyline"
+		attr("points", xmlPoints(g.Points))
+	case *Path:
+		se.Name.Local, defnm = "path", "path"
+		if len(g.Data) > 0 {
+			attr("d", PathDataString(g.Data))
+		} else {
+			attr("d", g.DataStr)
+		}
+	case *Text:
+		se.Name.Local, defnm = "text", "txt"
+		par, isSpan := g.Parent().(*Text)
+		if isSpan {
+			se.Name.Local, defnm = "tspan", "tspan"
+		}
+		if len(g.CharPosX) > 0 {
+			attr("x", xmlNums(g.CharPosX))
+		} else if !isSpan || g.Pos.X != par.Pos.X {
+			attr("x", xmlNum(g.Pos.X))
+		}
+		if len(g.CharPosY) > 0 {
+			attr("y", xmlNums(g.CharPosY))
+		} else if !isSpan || g.Pos.Y != par.Pos.Y {
+			attr("y", xmlNum(g.Pos.Y))
+		}
+		if len(g.CharPosDX) > 0 {
+			attr("dx", xmlNums(g.CharPosDX))
+		}
+		if len(g.CharPosDY) > 0 {
+			attr("dy", xmlNums(g.CharPosDY))
+		}
+		if len(g.CharRots) > 0 {
+			attr("rotate", xmlNums(g.CharRots))
+		}
+		if g.TextLength > 0 {
+			attr("textLength", xmlNum(g.TextLength))
+			if g.AdjustGlyphs {
+				attr("lengthAdjust", "spacingAndGlyphs")
+			}
+		}
+		text = g.Text
+	case *ClipPath:
+		se.Name.Local, defnm = "clipPath", "clip-path"
+	case *Marker:
+		se.Name.Local, defnm = "marker", "marker"
+		if g.RefPos != gi.Vec2DZero {
+			attr("refX", xmlNum(g.RefPos.X))
+			attr("refY", xmlNum(g.RefPos.Y))
+		}
+		attr("markerWidth", xmlNum(g.Size.X))
+		attr("markerHeight", xmlNum(g.Size.Y))
+		if g.Units == UserSpaceOnUse {
+			attr("markerUnits", "userSpaceOnUse")
+		}
+		if g.ViewBox.Size != gi.Vec2DZero {
+			attr("viewBox", xmlViewBox(&g.ViewBox))
+		}
+		if g.Orient != "" {
+			attr("orient", g.Orient)
+		}
+	case *Flow:
+		se.Name.Local, defnm = g.FlowType, g.FlowType
+	case *Filter:
+		se.Name.Local, defnm = g.FilterType, g.FilterType
+	case *gi.MetaData2D:
+		se.Name.Local, defnm = g.Class, g.Class
+	case *gi.Gradient:
+		defnm = "lin-grad"
+		if g.Grad.Source == gi.RadialGradient {
+			defnm = "rad-grad"
+		}
+		if g.Nm != defnm {
+			attr("id", g.Nm)
+		}
+		return g.Grad.MarshalXML(enc, se)
+	case *gi.StyleSheet:
+		se.Name.Local, defnm = "style", "style"
+		if g.Sheet != nil {
+			text = g.Sheet.String()
+		}
+	}
+	if se.Name.Local == "" {
+		return nil
+	}
+	var ids []xml.Attr // id and class go first
+	if nb.Nm != defnm {
+		ids = append(ids, xml.Attr{Name: xml.Name{Local: "id"}, Value: nb.Nm})
+	}
+	if nb.Class != "" && nb.Class != se.Name.Local {
+		ids = append(ids, xml.Attr{Name: xml.Name{Local: "class"}, Value: nb.Class})
+	}
+	se.Attr = append(ids, se.Attr...)
+	keys := make([]string, 0, len(nb.Props))
+	for key := range nb.Props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var val string
+		switch pv := nb.Props[key].(type) {
+		case ki.Props:
+			continue
+		case string:
+			val = pv
+		default:
+			if top {
+				continue
+			}
+			val = kit.ToString(pv)
+		}
+		if top && SVGViewProps[key] {
+			continue
+		}
+		if svg != nil && (key == "xmlns" || strings.HasPrefix(val, "http://")) {
+			continue // namespace declarations
+		}
+		attr(key, val)
+	}
+
+	if err := enc.EncodeToken(se); err != nil {
+		return err
+	}
+	if svg != nil {
+		if svg.Title != "" {
+			if err := marshalTextXML(enc, "title", svg.Title); err != nil {
+				return err
+			}
+		}
+		if svg.Desc != "" {
+			if err := marshalTextXML(enc, "desc", svg.Desc); err != nil {
+				return err
+			}
+		}
+		if svg.Defs.HasChildren() {
+			ds := xml.StartElement{Name: xml.Name{Local: "defs"}}
+			if err := enc.EncodeToken(ds); err != nil {
+				return err
+			}
+			if err := marshalKidsXML(enc, &svg.Defs.Node2DBase); err != nil {
+				return err
+			}
+			if err := enc.EncodeToken(ds.End()); err != nil {
+				return err
+			}
+		}
+	}
+	if text != "" {
+		if err := enc.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+	if err := marshalKidsXML(enc, nb); err != nil {
+		return err
+	}
+	return enc.EncodeToken(se.End())
+}
+
+// marshalKidsXML writes the children of given node as SVG elements
+func marshalKidsXML(enc *xml.Encoder, nb *gi.Node2DBase) error {
+	for _, kid := range nb.Kids {
+		if knd, ok := kid.(gi.Node2D); ok {
+			if err := marshalNodeXML(enc, knd, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// marshalTextXML writes an element with just the given text
+func marshalTextXML(enc *xml.Encoder, nm, text string) error {
+	se := xml.StartElement{Name: xml.Name{Local: nm}}
+	if err := enc.EncodeToken(se); err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(xml.CharData(text)); err != nil {
+		return err
+	}
+	return enc.EncodeToken(se.End())
+}
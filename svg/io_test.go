@@ -0,0 +1,115 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testSVGSrc = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 50">
+  <title>Shapes</title>
+  <g id="layer1" fill="red">
+    <rect x="10" y="5" width="30" height="20" rx="2" ry="3" style="stroke:blue"/>
+    <circle cx="50" cy="25" r="10"/>
+    <ellipse cx="70" cy="25" rx="8" ry="4"/>
+    <line x1="0" y1="0" x2="100" y2="50"/>
+    <polygon points="1,2 3,4 5,6"/>
+    <text x="5" y="45" textLength="40">Hello</text>
+  </g>
+</svg>
+`
+
+// testWriteXML reads the svg source into a new SVG and returns it written
+// back out with WriteXML
+func testWriteXML(t *testing.T, src string) string {
+	sv := &SVG{}
+	sv.InitName(sv, "svg")
+	if err := sv.ReadXML(strings.NewReader(src)); err != nil {
+		t.Fatalf("ReadXML: %v\n", err)
+	}
+	var b bytes.Buffer
+	if err := sv.WriteXML(&b, true); err != nil {
+		t.Fatalf("WriteXML: %v\n", err)
+	}
+	return b.String()
+}
+
+func TestWriteXML(t *testing.T) {
+	out := testWriteXML(t, testSVGSrc)
+	tests := []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="100" height="50" viewBox="0 0 100 50">`,
+		`<title>Shapes</title>`,
+		`<g id="layer1" fill="red">`,
+		`<rect x="10" y="5" width="30" height="20" rx="2" ry="3" stroke="blue"></rect>`,
+		`<circle cx="50" cy="25" r="10"></circle>`,
+		`<ellipse cx="70" cy="25" rx="8" ry="4"></ellipse>`,
+		`<line x1="0" y1="0" x2="100" y2="50"></line>`,
+		`<polygon points="1,2 3,4 5,6"></polygon>`,
+		`<text x="5" y="45" textLength="40">Hello</text>`,
+	}
+	for _, cor := range tests {
+		if !strings.Contains(out, cor) {
+			t.Errorf("WriteXML: expected output to contain:\n%v\ngot:\n%v\n", cor, out)
+		}
+	}
+	if again := testWriteXML(t, out); again != out {
+		t.Errorf("WriteXML: output changed when read and written again:\n%v\nvs.\n%v\n", out, again)
+	}
+}
+
+func TestSaveXML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "svgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "shapes.svg")
+
+	sv := &SVG{}
+	sv.InitName(sv, "svg")
+	if err := sv.ReadXML(strings.NewReader(testSVGSrc)); err != nil {
+		t.Fatalf("ReadXML: %v\n", err)
+	}
+	if err := sv.SaveXML(fn); err != nil {
+		t.Fatalf("SaveXML: %v\n", err)
+	}
+	sv2 := &SVG{}
+	sv2.InitName(sv2, "svg")
+	if err := sv2.OpenXML(fn); err != nil {
+		t.Fatalf("OpenXML: %v\n", err)
+	}
+	if sv2.Title != "Shapes" || sv2.ViewBox.Size.X != 100 || sv2.ViewBox.Size.Y != 50 {
+		t.Errorf("OpenXML of saved file: got title %q viewbox %v, expected Shapes, 100 x 50\n", sv2.Title, sv2.ViewBox.Size)
+	}
+	if len(sv2.Kids) != 1 {
+		t.Fatalf("OpenXML of saved file: got %v top-level elements, expected 1 group\n", len(sv2.Kids))
+	}
+	g, ok := sv2.Kids[0].(*Group)
+	if !ok || len(g.Kids) != 6 {
+		t.Fatalf("OpenXML of saved file: expected a group with 6 shapes, got: %v\n", sv2.Kids[0])
+	}
+	if fill, _ := g.Prop("fill"); g.Nm != "layer1" || fill != "red" {
+		t.Errorf("OpenXML of saved file: got group %v fill %v, expected layer1, red\n", g.Nm, fill)
+	}
+	if rect, ok := g.Kids[0].(*Rect); !ok {
+		t.Errorf("OpenXML of saved file: expected a rect, got: %v\n", g.Kids[0])
+	} else if stroke, _ := rect.Prop("stroke"); rect.Pos.X != 10 || rect.Size.Y != 20 || rect.Radius.Y != 3 || stroke != "blue" {
+		t.Errorf("OpenXML of saved file: rect not restored: pos %v size %v radius %v stroke %v\n", rect.Pos, rect.Size, rect.Radius, stroke)
+	}
+	txt, ok := g.Kids[5].(*Text)
+	if !ok || txt.Text != "Hello" || txt.TextLength != 40 {
+		t.Errorf("OpenXML of saved file: text not restored: %v\n", g.Kids[5])
+	}
+
+	if err := sv.SaveXML(filepath.Join(dir, "nosuch", "shapes.svg")); err == nil {
+		t.Errorf("SaveXML to a missing directory: expected an error\n")
+	}
+}
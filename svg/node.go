@@ -97,10 +97,6 @@ func StyleSVG(gii gi.Node2D) {
 // ApplyCSSSVG applies css styles to given node, using key to select sub-props
 // from overall properties list
 func ApplyCSSSVG(node gi.Node2D, key string, css ki.Props) bool {
-	pntr, ok := node.(gi.Painter)
-	if !ok {
-		return false
-	}
 	pp, got := css[key]
 	if !got {
 		return false
@@ -109,7 +105,15 @@ func ApplyCSSSVG(node gi.Node2D, key string, css ki.Props) bool {
 	if !ok {
 		return false
 	}
+	return applyPropsSVG(node, pmap)
+}
 
+// applyPropsSVG applies given css style properties to the Paint of the node
+func applyPropsSVG(node gi.Node2D, pmap ki.Props) bool {
+	pntr, ok := node.(gi.Painter)
+	if !ok {
+		return false
+	}
 	pc := pntr.Paint()
 
 	if pgi, _ := gi.KiToNode2D(node.Parent()); pgi != nil {
@@ -124,15 +128,12 @@ func ApplyCSSSVG(node gi.Node2D, key string, css ki.Props) bool {
 	return true
 }
 
-// StyleCSS applies css style properties to given SVG node, parsing
-// out type, .class, and #name selectors
+// StyleCSS applies css style properties to given SVG node, using full CSS
+// selector matching in order of specificity -- see gi.MatchCSS
 func StyleCSS(node gi.Node2D, css ki.Props) {
-	tyn := strings.ToLower(node.Type().Name()) // type is most general, first
-	ApplyCSSSVG(node, tyn, css)
-	cln := "." + strings.ToLower(node.AsNode2D().Class) // then class
-	ApplyCSSSVG(node, cln, css)
-	idnm := "#" + strings.ToLower(node.Name()) // then name
-	ApplyCSSSVG(node, idnm, css)
+	for _, pmap := range gi.MatchCSS(node, css, "") {
+		applyPropsSVG(node, pmap)
+	}
 }
 
 func (g *NodeBase) Style2D() {
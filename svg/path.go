@@ -9,6 +9,7 @@ import (
 	"log"
 	"math"
 	"strconv"
+	"strings"
 	"unicode"
 
 	"github.com/chewxy/math32"
@@ -778,3 +779,29 @@ func PathDataParse(d string) ([]PathData, error) {
 	return pd, nil
 	// todo: add some error checking..
 }
+
+// pathCmdRunes are the runes for the path commands, in PathCmds order
+const pathCmdRunes = "MmLlHhVvCcSsQqTtAaZz"
+
+// PathDataString returns the string representation of the path data, in the
+// standard SVG path syntax parsed by PathDataParse -- e.g., for saving
+// paths that have been edited
+func PathDataString(data []PathData) string {
+	var sb strings.Builder
+	sz := len(data)
+	for i := 0; i < sz; {
+		cmd, n := PathDataNextCmd(data, &i)
+		if cmd >= PcErr {
+			break
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteByte(pathCmdRunes[cmd])
+		for np := 0; np < n && i < sz; np++ {
+			sb.WriteByte(' ')
+			sb.WriteString(strconv.FormatFloat(float64(PathDataNext(data, &i)), 'f', -1, 32))
+		}
+	}
+	return sb.String()
+}
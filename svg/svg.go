@@ -104,6 +104,7 @@ func (svg *SVG) Layout2D(parBBox image.Rectangle, iter int) bool {
 
 func (svg *SVG) Render2D() {
 	if svg.PushBounds() {
+		svg.RecordForParent()
 		rs := &svg.Render
 		if svg.Fill {
 			svg.FillViewport()
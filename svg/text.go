@@ -62,7 +62,7 @@ func (g *Text) Render2D() {
 		} else if gi.IsAlignEnd(pc.TextStyle.Align) || pc.TextStyle.Anchor == gi.AnchorEnd {
 			pos.X -= g.Render.Size.X
 		}
-		pc.FontStyle.Size = units.Value{orgsz.Val * scy, orgsz.Un, orgsz.Dots * scy} // rescale by y
+		pc.FontStyle.Size = units.Value{Val: orgsz.Val * scy, Un: orgsz.Un, Dots: orgsz.Dots * scy} // rescale by y
 		pc.FontStyle.OpenFont(&pc.UnContext)
 		sr := &(g.Render.Spans[0])
 		sr.Render[0].Face = pc.FontStyle.Face // upscale
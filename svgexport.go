@@ -0,0 +1,493 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/gi/units"
+	"golang.org/x/image/font"
+)
+
+// SVG export: as for PDF export (see pdf.go), the rendering of a viewport or
+// widget subtree is recorded in a DisplayList, which is then written as an
+// SVG document with paths for all fills, strokes and clipping, linear and
+// radial gradients, text elements in the family, weight and style of the
+// fonts used, and images and masks (e.g., box shadows) embedded as PNG
+// data.  The document has the size of the rendered region at the logical DPI
+// of the viewport.  Limitations: fonts are referenced by name and not
+// embedded, and horizontally scaled glyphs are exported unscaled.  To save
+// the elements of an svg.SVG drawing itself, for editing, use its SaveXML
+// method instead.
+
+// SaveSVG renders the viewport and saves it as an SVG file at given path --
+// see also SavePDF and SavePNG
+func (vp *Viewport2D) SaveSVG(path string) error {
+	if vp.Pixels == nil {
+		return fmt.Errorf("gi.SaveSVG: viewport %v has not been rendered", vp.PathUnique())
+	}
+	dl := vp.RecordRender2DTree()
+	return dl.SaveSVG(path, vp.Pixels.Bounds(), vp.ExportDPI())
+}
+
+// SaveSVG renders this node and its children and saves them as an SVG file
+// at given path, with the size of the node's region in its viewport
+func (nb *Node2DBase) SaveSVG(path string) error {
+	if nb.Viewport == nil || nb.VpBBox.Empty() {
+		return fmt.Errorf("gi.SaveSVG: node %v has not been rendered", nb.PathUnique())
+	}
+	dl := nb.RecordRender2DTree()
+	return dl.SaveSVG(path, nb.VpBBox, nb.Viewport.ExportDPI())
+}
+
+// SaveSVG saves the given region of the list (in device coordinates) as an
+// SVG file at given path, with the physical size set from the dpi (dots per
+// inch) of the device coordinates
+func (dl *DisplayList) SaveSVG(path string, bounds image.Rectangle, dpi float32) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	err = dl.WriteSVG(bw, bounds, dpi)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// WriteSVG writes the given region of the list (in device coordinates) as an
+// SVG document to w, with the physical size set from the dpi (dots per inch)
+// of the device coordinates, which are used as the user coordinates
+func (dl *DisplayList) WriteSVG(w io.Writer, bounds image.Rectangle, dpi float32) error {
+	if bounds.Empty() {
+		return fmt.Errorf("gi.WriteSVG: empty bounds")
+	}
+	if dpi <= 0 {
+		dpi = units.PxPerInch
+	}
+	sc := 72 / dpi
+	sw := &svgWriter{imgIDs: make(map[image.Image]string), fonts: make(map[font.Face]string)}
+	vw := &vectorWalker{ex: sw}
+	vw.list(dl, Identity2D())
+	vw.popClips(0)
+
+	var out bytes.Buffer
+	out.WriteString(xml.Header)
+	fmt.Fprintf(&out, "<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" version=\"1.1\" width=\"%spt\" height=\"%spt\" viewBox=\"%d %d %d %d\">\n",
+		vecNum(sc*float32(bounds.Dx())), vecNum(sc*float32(bounds.Dy())), bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy())
+	if sw.defs.Len() > 0 {
+		out.WriteString("<defs>\n")
+		out.Write(sw.defs.Bytes())
+		out.WriteString("</defs>\n")
+	}
+	out.Write(sw.body.Bytes())
+	out.WriteString("</svg>\n")
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// svgWriter writes a DisplayList as an SVG document
+type svgWriter struct {
+	defs    bytes.Buffer           // definitions of gradients, clips and images
+	body    bytes.Buffer           // drawing elements
+	path    bytes.Buffer           // data of the current path
+	pathBox vectorBBox             // bounding box of the current path
+	nids    int                    // number of ids generated
+	imgIDs  map[image.Image]string // ids of images in the defs by source image
+	fonts   map[font.Face]string   // font attributes of faces
+}
+
+// newID returns a new unique id for an element in the defs
+func (sw *svgWriter) newID(prefix string) string {
+	sw.nids++
+	return fmt.Sprintf("%s%d", prefix, sw.nids)
+}
+
+// pathCmd adds a command with given points to the current path
+func (sw *svgWriter) pathCmd(cmd byte, pts ...Vec2D) {
+	sw.pathBox.add(pts...)
+	if sw.path.Len() > 0 {
+		sw.path.WriteByte(' ')
+	}
+	sw.path.WriteByte(cmd)
+	for _, p := range pts {
+		fmt.Fprintf(&sw.path, " %s %s", vecNum(p.X), vecNum(p.Y))
+	}
+}
+
+func (sw *svgWriter) moveTo(p Vec2D) {
+	sw.pathCmd('M', p)
+}
+
+func (sw *svgWriter) lineTo(p Vec2D) {
+	sw.pathCmd('L', p)
+}
+
+func (sw *svgWriter) quadTo(q, p Vec2D) {
+	sw.pathCmd('Q', q, p)
+}
+
+func (sw *svgWriter) cubicTo(c1, c2, p Vec2D) {
+	sw.pathCmd('C', c1, c2, p)
+}
+
+func (sw *svgWriter) closePath() {
+	if sw.path.Len() > 0 {
+		sw.pathCmd('Z')
+	}
+}
+
+func (sw *svgWriter) clearPath() {
+	sw.path.Reset()
+	sw.pathBox.reset()
+}
+
+func (sw *svgWriter) pathData() string {
+	return sw.path.String()
+}
+
+// paint returns the attributes that set the fill (or stroke) to given color
+// spec, with given opacity, for the current path under given transform --
+// returns false if there is nothing to paint
+func (sw *svgWriter) paint(cs *ColorSpec, opacity float32, xf Matrix2D, attr string) (string, bool) {
+	if opacity <= 0 {
+		return "", false
+	}
+	var pnt string
+	if cs.Source == SolidColor || cs.Gradient == nil || len(cs.Gradient.Stops) < 2 {
+		clr := color.Color(cs.Color)
+		if cs.Source != SolidColor && cs.Gradient != nil && len(cs.Gradient.Stops) == 1 {
+			clr = cs.Gradient.Stops[0].StopColor
+		}
+		hex, a := svgColor(clr)
+		opacity *= a
+		if opacity <= 0 {
+			return "", false
+		}
+		pnt = fmt.Sprintf(" %s=\"%s\"", attr, hex)
+	} else {
+		pnt = fmt.Sprintf(" %s=\"url(#%s)\"", attr, sw.gradient(cs, xf))
+	}
+	if opacity < 1 {
+		pnt += fmt.Sprintf(" %s-opacity=\"%s\"", attr, vecNum(opacity))
+	}
+	return pnt, true
+}
+
+// gradient adds a gradient in given color spec to the defs, for the current
+// path under given transform, returning its id
+func (sw *svgWriter) gradient(cs *ColorSpec, xf Matrix2D) string {
+	g := cs.Gradient
+	id := sw.newID("grad")
+	p := g.Points
+	elnm := "linearGradient"
+	if cs.Source == RadialGradient {
+		elnm = "radialGradient"
+		fmt.Fprintf(&sw.defs, "<%s id=\"%s\" cx=\"%s\" cy=\"%s\" fx=\"%s\" fy=\"%s\" r=\"%s\"", elnm, id, vecNum(float32(p[0])), vecNum(float32(p[1])),
+			vecNum(float32(p[2])), vecNum(float32(p[3])), vecNum(float32(p[4])))
+	} else {
+		fmt.Fprintf(&sw.defs, "<%s id=\"%s\" x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\"", elnm, id, vecNum(float32(p[0])), vecNum(float32(p[1])),
+			vecNum(float32(p[2])), vecNum(float32(p[3])))
+	}
+	fmt.Fprintf(&sw.defs, " gradientUnits=\"userSpaceOnUse\" gradientTransform=\"%s\"", svgMatrix(vectorGradXForm(g, xf, &sw.pathBox)))
+	if sp := svgSpreads[g.Spread]; sp != "" {
+		fmt.Fprintf(&sw.defs, " spreadMethod=\"%s\"", sp)
+	}
+	sw.defs.WriteString(">\n")
+	for _, s := range g.Stops {
+		hex, a := svgColor(s.StopColor)
+		fmt.Fprintf(&sw.defs, "<stop offset=\"%s\" stop-color=\"%s\"", vecNum(float32(s.Offset)), hex)
+		if op := a * float32(s.Opacity); op < 1 {
+			fmt.Fprintf(&sw.defs, " stop-opacity=\"%s\"", vecNum(op))
+		}
+		sw.defs.WriteString("/>\n")
+	}
+	fmt.Fprintf(&sw.defs, "</%s>\n", elnm)
+	return id
+}
+
+// fill fills the current path with given paint, under given transform
+func (sw *svgWriter) fill(pc *Paint, xf Matrix2D) {
+	if sw.path.Len() == 0 {
+		return
+	}
+	pnt, ok := sw.paint(&pc.FillStyle.Color, pc.FontStyle.Opacity*pc.FillStyle.Opacity, xf, "fill")
+	if !ok {
+		return
+	}
+	if pc.FillStyle.Rule == FillRuleEvenOdd {
+		pnt += " fill-rule=\"evenodd\""
+	}
+	fmt.Fprintf(&sw.body, "<path d=\"%s\"%s/>\n", sw.path.String(), pnt)
+}
+
+// stroke strokes the current path with given paint, under given transform
+func (sw *svgWriter) stroke(pc *Paint, xf Matrix2D) {
+	if sw.path.Len() == 0 {
+		return
+	}
+	lw := pc.StrokeWidth(&RenderState{XForm: xf})
+	if lw <= 0 {
+		return
+	}
+	pnt, ok := sw.paint(&pc.StrokeStyle.Color, pc.FontStyle.Opacity*pc.StrokeStyle.Opacity, xf, "stroke")
+	if !ok {
+		return
+	}
+	pnt += fmt.Sprintf(" stroke-width=\"%s\"", vecNum(lw))
+	switch pc.StrokeStyle.Cap {
+	case LineCapRound, LineCapCubic, LineCapQuadratic:
+		pnt += " stroke-linecap=\"round\""
+	case LineCapSquare:
+		pnt += " stroke-linecap=\"square\""
+	}
+	switch pc.StrokeStyle.Join {
+	case LineJoinRound, LineJoinArcs, LineJoinArcsClip:
+		pnt += " stroke-linejoin=\"round\""
+	case LineJoinBevel:
+		pnt += " stroke-linejoin=\"bevel\""
+	default:
+		pnt += fmt.Sprintf(" stroke-miterlimit=\"%s\"", vecNum(Max32(pc.StrokeStyle.MiterLimit, 1)))
+	}
+	if dash, doff := pc.StrokeDashes(&RenderState{XForm: xf}); dash != nil {
+		pnt += fmt.Sprintf(" stroke-dasharray=\"%s\"", vecDashes(dash))
+		if doff != 0 {
+			pnt += fmt.Sprintf(" stroke-dashoffset=\"%s\"", vecNum(float32(doff)))
+		}
+	}
+	fmt.Fprintf(&sw.body, "<path d=\"%s\" fill=\"none\"%s/>\n", sw.path.String(), pnt)
+}
+
+// fillBox fills the rectangle with given color, under given transform
+func (sw *svgWriter) fillBox(r image.Rectangle, clr color.Color, xf Matrix2D) {
+	hex, a := svgColor(clr)
+	if a == 0 {
+		return
+	}
+	pts := [4]Vec2D{{float32(r.Min.X), float32(r.Min.Y)}, {float32(r.Max.X), float32(r.Min.Y)},
+		{float32(r.Max.X), float32(r.Max.Y)}, {float32(r.Min.X), float32(r.Max.Y)}}
+	sw.body.WriteString("<path d=\"")
+	for i, p := range pts {
+		tp := xf.TransformPointVec2D(p)
+		cmd := "L"
+		if i == 0 {
+			cmd = "M"
+		}
+		fmt.Fprintf(&sw.body, "%s %s %s ", cmd, vecNum(tp.X), vecNum(tp.Y))
+	}
+	fmt.Fprintf(&sw.body, "Z\" fill=\"%s\"", hex)
+	if a < 1 {
+		fmt.Fprintf(&sw.body, " fill-opacity=\"%s\"", vecNum(a))
+	}
+	sw.body.WriteString("/>\n")
+}
+
+// image draws the src image, or src through the alpha mask if non-nil, with
+// given transform from source to user coordinates
+func (sw *svgWriter) image(src image.Image, mask image.Image, xf Matrix2D) {
+	sr := src.Bounds()
+	if mask != nil {
+		sr = mask.Bounds()
+	}
+	if sr.Empty() {
+		return
+	}
+	id := ""
+	if mask == nil {
+		id = sw.imgIDs[src]
+	}
+	if id == "" {
+		id = sw.addImage(src, mask, sr)
+		if mask == nil {
+			sw.imgIDs[src] = id
+		}
+	}
+	im := Translate2D(float32(sr.Min.X), float32(sr.Min.Y))
+	fmt.Fprintf(&sw.body, "<use xlink:href=\"#%s\" transform=\"%s\"/>\n", id, svgMatrix(im.Multiply(xf)))
+}
+
+// addImage adds an image to the defs with the PNG data for region sr of src,
+// with the alpha from the mask if non-nil, returning its id
+func (sw *svgWriter) addImage(src image.Image, mask image.Image, sr image.Rectangle) string {
+	img := image.NewRGBA(image.Rect(0, 0, sr.Dx(), sr.Dy()))
+	if mask != nil {
+		draw.DrawMask(img, img.Bounds(), src, sr.Min, mask, sr.Min, draw.Src)
+	} else {
+		draw.Draw(img, img.Bounds(), src, sr.Min, draw.Src)
+	}
+	var pb bytes.Buffer
+	png.Encode(&pb, img)
+	id := sw.newID("img")
+	fmt.Fprintf(&sw.defs, "<image id=\"%s\" width=\"%d\" height=\"%d\" preserveAspectRatio=\"none\" xlink:href=\"data:image/png;base64,%s\"/>\n",
+		id, sr.Dx(), sr.Dy(), base64.StdEncoding.EncodeToString(pb.Bytes()))
+	return id
+}
+
+// text draws the glyphs of the span at given position, under given
+// transform, as a text element for each run of glyphs with the same face and
+// color, with the position (and rotation) of each glyph
+func (sw *svgWriter) text(sr *SpanRender, tpos Vec2D, xf Matrix2D) {
+	curFace := sr.Render[0].Face
+	curColor := sr.Render[0].Color
+	var runFace font.Face
+	var runColor color.Color
+	var txt bytes.Buffer
+	var xs, ys, rots []string
+	rotated := false
+	flush := func() {
+		if len(xs) == 0 {
+			return
+		}
+		hex, a := svgColor(runColor)
+		if a > 0 {
+			fmt.Fprintf(&sw.body, "<text xml:space=\"preserve\"%s fill=\"%s\"", sw.fontAttrs(runFace), hex)
+			if a < 1 {
+				fmt.Fprintf(&sw.body, " fill-opacity=\"%s\"", vecNum(a))
+			}
+			if xf != Identity2D() {
+				fmt.Fprintf(&sw.body, " transform=\"%s\"", svgMatrix(xf))
+			}
+			fmt.Fprintf(&sw.body, " x=\"%s\" y=\"%s\"", strings.Join(xs, " "), strings.Join(ys, " "))
+			if rotated {
+				fmt.Fprintf(&sw.body, " rotate=\"%s\"", strings.Join(rots, " "))
+			}
+			fmt.Fprintf(&sw.body, ">%s</text>\n", txt.String())
+		}
+		txt.Reset()
+		xs, ys, rots = xs[:0], ys[:0], rots[:0]
+		rotated = false
+	}
+	for i, r := range sr.Text {
+		rr := &sr.Render[i]
+		if rr.Color != nil {
+			curColor = rr.Color
+		}
+		curFace = rr.CurFace(curFace)
+		if !unicode.IsPrint(r) {
+			continue
+		}
+		if curFace != runFace || curColor != runColor {
+			flush()
+			runFace, runColor = curFace, curColor
+		}
+		rp := tpos.Add(rr.RelPos)
+		xs = append(xs, vecNum(rp.X))
+		ys = append(ys, vecNum(rp.Y))
+		rots = append(rots, vecNum(rr.RotRad*180/math32.Pi))
+		if rr.RotRad != 0 {
+			rotated = true
+		}
+		xml.EscapeText(&txt, []byte(string(r)))
+	}
+	flush()
+}
+
+// fontAttrs returns the font attributes for given face
+func (sw *svgWriter) fontAttrs(face font.Face) string {
+	if fa, ok := sw.fonts[face]; ok {
+		return fa
+	}
+	fontnm, size := vectorFaceName(face)
+	fam := "sans-serif"
+	str, wt, sty := FontStrNormal, WeightNormal, FontNormal
+	for _, fi := range FontLibrary.FontInfo {
+		if strings.ToLower(fi.Name) == fontnm {
+			fam, str, wt, sty = FontNameToMods(fi.Name)
+			break
+		}
+	}
+	fa := fmt.Sprintf(" font-family=\"%s\" font-size=\"%s\"", svgEscape(fam), vecNum(size))
+	if wn := svgFontWeights[FontWeightToNameMap[wt]]; wn != "" {
+		fa += fmt.Sprintf(" font-weight=\"%s\"", wn)
+	}
+	if sty != FontNormal {
+		fa += fmt.Sprintf(" font-style=\"%s\"", strings.ToLower(FontStyleNames[sty]))
+	}
+	if str != FontStrNormal && int(str) < len(svgFontStretches) {
+		fa += fmt.Sprintf(" font-stretch=\"%s\"", svgFontStretches[str])
+	}
+	sw.fonts[face] = fa
+	return fa
+}
+
+func (sw *svgWriter) beginClip(c *vectorClip) {
+	if c.id == "" {
+		c.id = sw.newID("clip")
+		if c.bounds {
+			r := c.rect
+			fmt.Fprintf(&sw.defs, "<clipPath id=\"%s\"><rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\"/></clipPath>\n", c.id, r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+		} else {
+			rule := ""
+			if c.evenOdd {
+				rule = " clip-rule=\"evenodd\""
+			}
+			fmt.Fprintf(&sw.defs, "<clipPath id=\"%s\"><path d=\"%s\"%s/></clipPath>\n", c.id, c.path, rule)
+		}
+	}
+	fmt.Fprintf(&sw.body, "<g clip-path=\"url(#%s)\">\n", c.id)
+}
+
+func (sw *svgWriter) endClip(c *vectorClip) {
+	sw.body.WriteString("</g>\n")
+}
+
+// svgSpreads are the SVG spreadMethod values for rasterx spreads, with the
+// default pad omitted
+var svgSpreads = []string{"", "reflect", "repeat"}
+
+// svgFontWeights are the numerical CSS font weights for the names of
+// non-normal weights in regularized font names
+var svgFontWeights = map[string]string{
+	"Thin":       "100",
+	"ExtraLight": "200",
+	"Light":      "300",
+	"Medium":     "500",
+	"SemiBold":   "600",
+	"Bold":       "700",
+	"ExtraBold":  "800",
+	"Black":      "900",
+}
+
+// svgFontStretches are the CSS font-stretch values for the FontStretch values
+var svgFontStretches = []string{"normal", "ultra-condensed", "extra-condensed", "semi-condensed", "semi-expanded", "extra-expanded", "ultra-expanded", "condensed", "expanded", "narrower", "wider"}
+
+// svgColor returns the SVG hex string for the RGB components of a color, and
+// its alpha as an opacity
+func svgColor(clr color.Color) (string, float32) {
+	if clr == nil {
+		return "#000000", 1
+	}
+	nc := color.NRGBAModel.Convert(clr).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", nc.R, nc.G, nc.B), float32(nc.A) / 255
+}
+
+// svgMatrix formats a transform as an SVG matrix transform
+func svgMatrix(m Matrix2D) string {
+	return fmt.Sprintf("matrix(%s %s %s %s %s %s)", vecNum(m.XX), vecNum(m.YX), vecNum(m.XY), vecNum(m.YY), vecNum(m.X0), vecNum(m.Y0))
+}
+
+// svgEscape escapes a string for use in an attribute value
+func svgEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
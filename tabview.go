@@ -377,9 +377,10 @@ func (tv *TabView) RenderTabSeps() {
 	rs := &tv.Viewport.Render
 	pc := &rs.Paint
 	st := &tv.Sty
-	pc.StrokeStyle.Width = st.Border.Width
+	pc.StrokeStyle.Width = st.Border.LeftWidth // separators are at the left of each tab
 	pc.StrokeStyle.SetColor(&st.Border.Color)
-	bw := st.Border.Width.Dots
+	bw := st.Border.LeftWidth.Dots
+	msz := st.Layout.MarginDots().Size()
 
 	tbs := tv.Tabs()
 	sz := len(tbs.Kids)
@@ -388,7 +389,7 @@ func (tv *TabView) RenderTabSeps() {
 		ni := tb.AsWidget()
 
 		pos := ni.LayData.AllocPos
-		sz := ni.LayData.AllocSize.AddVal(-2.0 * st.Layout.Margin.Dots)
+		sz := ni.LayData.AllocSize.Sub(msz)
 		pc.DrawLine(rs, pos.X-bw, pos.Y, pos.X-bw, pos.Y+sz.Y)
 	}
 	pc.FillStrokeClear(rs)
@@ -470,8 +471,8 @@ var TabButtonProps = ki.Props{
 		"background-color": "linear-gradient(lighter-0, highlight-10)",
 	},
 	ButtonSelectors[ButtonInactive]: ki.Props{
-		"border-color": "lighter-50",
-		"color":        "lighter-50",
+		"border-color": "highlight-50",
+		"color":        "highlight-50",
 	},
 	ButtonSelectors[ButtonHover]: ki.Props{
 		"background-color": "linear-gradient(highlight-10, highlight-10)",
@@ -481,7 +482,7 @@ var TabButtonProps = ki.Props{
 		"background-color": "linear-gradient(samelight-50, highlight-10)",
 	},
 	ButtonSelectors[ButtonDown]: ki.Props{
-		"color":            "lighter-90",
+		"color":            "highlight-90",
 		"background-color": "linear-gradient(highlight-30, highlight-10)",
 	},
 	ButtonSelectors[ButtonSelected]: ki.Props{
@@ -533,8 +534,8 @@ func (tb *TabButton) ConfigParts() {
 
 func (tb *TabButton) Size2D(iter int) {
 	ppref := tb.Parts.LayData.Size.Pref // get from parts
-	spc := tb.Sty.BoxSpace()
-	tb.SetProp("width", units.NewValue(ppref.X+2*spc, units.Dot))
-	tb.SetProp("height", units.NewValue(ppref.Y+2*spc, units.Dot))
+	spc := tb.Sty.BoxSpaceSides().Size()
+	tb.SetProp("width", units.NewValue(ppref.X+spc.X, units.Dot))
+	tb.SetProp("height", units.NewValue(ppref.Y+spc.Y, units.Dot))
 	tb.InitLayout2D() // sets from props
 }
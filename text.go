@@ -714,16 +714,8 @@ func (tr *TextRender) Render(rs *RenderState, pos Vec2D) {
 		if sr.IsValid() != nil {
 			continue
 		}
-		curFace := sr.Render[0].Face
-		curColor := sr.Render[0].Color
 		tpos := pos.Add(sr.RelPos)
 
-		d := &font.Drawer{
-			Dst:  rs.Image,
-			Src:  image.NewUniform(curColor),
-			Face: curFace,
-		}
-
 		// todo: cache flags if these are actually needed
 		if bitflag.Has32(int32(sr.HasDeco), int(DecoBgColor)) {
 			sr.RenderBg(rs, tpos)
@@ -734,57 +726,88 @@ func (tr *TextRender) Render(rs *RenderState, pos Vec2D) {
 		if bitflag.Has32(int32(sr.HasDeco), int(DecoOverline)) {
 			sr.RenderLine(rs, tpos, DecoOverline, 1.1)
 		}
+		if rs.Rec != nil {
+			rsr := sr // spans are updated in place, so record a copy
+			rsr.Text = append([]rune(nil), sr.Text...)
+			rsr.Render = append([]RuneRender(nil), sr.Render...)
+			rs.Rec.Add(DisplayOp{Op: DisplayText, Span: &rsr, Pos: tpos})
+		}
+		sr.RenderGlyphs(rs, tpos, Identity2D())
+		if bitflag.Has32(int32(sr.HasDeco), int(DecoLineThrough)) {
+			sr.RenderLine(rs, tpos, DecoLineThrough, 0.25)
+		}
+	}
+}
 
-		for i, r := range sr.Text {
-			rr := &(sr.Render[i])
-			if rr.Color != nil {
-				curColor = rr.Color
-				d.Src = image.NewUniform(curColor)
-			}
-			curFace = rr.CurFace(curFace)
-			if !unicode.IsPrint(r) {
-				continue
-			}
-			dsc32 := FixedToFloat32(curFace.Metrics().Descent)
-			rp := tpos.Add(rr.RelPos)
-			scx := float32(1)
-			if rr.ScaleX != 0 {
-				scx = rr.ScaleX
-			}
-			tx := Scale2D(scx, 1).Rotate(rr.RotRad)
-			ll := rp.Add(tx.TransformVectorVec2D(Vec2D{0, dsc32}))
-			ur := ll.Add(tx.TransformVectorVec2D(Vec2D{rr.Size.X, -rr.Size.Y}))
-			if int(math32.Floor(ll.X)) > rs.Bounds.Max.X || int(math32.Floor(ur.Y)) > rs.Bounds.Max.Y ||
-				int(math32.Ceil(ur.X)) < rs.Bounds.Min.X || int(math32.Ceil(ll.Y)) < rs.Bounds.Min.Y {
-				continue
-			}
-			d.Face = curFace
-			d.Dot = rp.Fixed()
-			dr, mask, maskp, _, ok := d.Face.Glyph(d.Dot, r)
-			if !ok {
-				// fmt.Printf("not ok rendering rune: %v\n", string(r))
-				continue
-			}
+// RenderGlyphs renders the glyphs of the span (without any decorations) at
+// given position, further transformed by xf, which is Identity2D except when
+// replaying a DisplayList -- glyphs are drawn from their masks at the font
+// size, with BiLinear interpolation for anything other than a translation
+func (sr *SpanRender) RenderGlyphs(rs *RenderState, tpos Vec2D, xf Matrix2D) {
+	curFace := sr.Render[0].Face
+	curColor := sr.Render[0].Color
+	ident := xf == Identity2D()
+
+	d := &font.Drawer{
+		Dst:  rs.Image,
+		Src:  image.NewUniform(curColor),
+		Face: curFace,
+	}
+
+	for i, r := range sr.Text {
+		rr := &(sr.Render[i])
+		if rr.Color != nil {
+			curColor = rr.Color
+			d.Src = image.NewUniform(curColor)
+		}
+		curFace = rr.CurFace(curFace)
+		if !unicode.IsPrint(r) {
+			continue
+		}
+		dsc32 := FixedToFloat32(curFace.Metrics().Descent)
+		rp := tpos.Add(rr.RelPos)
+		scx := float32(1)
+		if rr.ScaleX != 0 {
+			scx = rr.ScaleX
+		}
+		tx := Scale2D(scx, 1).Rotate(rr.RotRad)
+		ll := rp.Add(tx.TransformVectorVec2D(Vec2D{0, dsc32}))
+		ur := ll.Add(tx.TransformVectorVec2D(Vec2D{rr.Size.X, -rr.Size.Y}))
+		if !ident {
+			tll, tur := xf.TransformPointVec2D(ll), xf.TransformPointVec2D(ur)
+			ll = Vec2D{Min32(tll.X, tur.X), Max32(tll.Y, tur.Y)}
+			ur = Vec2D{Max32(tll.X, tur.X), Min32(tll.Y, tur.Y)}
+		}
+		if int(math32.Floor(ll.X)) > rs.Bounds.Max.X || int(math32.Floor(ur.Y)) > rs.Bounds.Max.Y ||
+			int(math32.Ceil(ur.X)) < rs.Bounds.Min.X || int(math32.Ceil(ll.Y)) < rs.Bounds.Min.Y {
+			continue
+		}
+		d.Face = curFace
+		d.Dot = rp.Fixed()
+		dr, mask, maskp, _, ok := d.Face.Glyph(d.Dot, r)
+		if !ok {
+			// fmt.Printf("not ok rendering rune: %v\n", string(r))
+			continue
+		}
+		if ident && rr.RotRad == 0 && (rr.ScaleX == 0 || rr.ScaleX == 1) {
 			idr := dr.Intersect(rs.Bounds)
 			soff := idr.Min.Sub(dr.Min)
-			if rr.RotRad == 0 && (rr.ScaleX == 0 || rr.ScaleX == 1) {
-				draw.DrawMask(d.Dst, idr, d.Src, soff, mask, maskp, draw.Over)
-			} else {
-				srect := dr.Sub(dr.Min)
-				dbase := Vec2D{rp.X - float32(dr.Min.X), rp.Y - float32(dr.Min.Y)}
-
-				transformer := draw.BiLinear
-				fx, fy := float32(dr.Min.X), float32(dr.Min.Y)
-				m := Translate2D(fx+dbase.X, fy+dbase.Y).Scale(scx, 1).Rotate(rr.RotRad).Translate(-dbase.X, -dbase.Y)
-				s2d := f64.Aff3{float64(m.XX), float64(m.XY), float64(m.X0), float64(m.YX), float64(m.YY), float64(m.Y0)}
-				transformer.Transform(d.Dst, s2d, d.Src, srect, draw.Over, &draw.Options{
-					SrcMask:  mask,
-					SrcMaskP: maskp,
-				})
+			draw.DrawMask(d.Dst, idr, d.Src, soff, mask, maskp, draw.Over)
+		} else {
+			srect := dr.Sub(dr.Min)
+			dbase := Vec2D{rp.X - float32(dr.Min.X), rp.Y - float32(dr.Min.Y)}
+
+			transformer := draw.BiLinear
+			fx, fy := float32(dr.Min.X), float32(dr.Min.Y)
+			m := Translate2D(fx+dbase.X, fy+dbase.Y).Scale(scx, 1).Rotate(rr.RotRad).Translate(-dbase.X, -dbase.Y)
+			if !ident {
+				m = m.Multiply(xf)
 			}
-		}
-		if bitflag.Has32(int32(sr.HasDeco), int(DecoLineThrough)) {
-			sr.RenderLine(rs, tpos, DecoLineThrough, 0.25)
+			s2d := f64.Aff3{float64(m.XX), float64(m.XY), float64(m.X0), float64(m.YX), float64(m.YY), float64(m.Y0)}
+			transformer.Transform(d.Dst, s2d, d.Src, srect, draw.Over, &draw.Options{
+				SrcMask:  mask,
+				SrcMaskP: maskp,
+			})
 		}
 	}
 }
@@ -650,8 +650,7 @@ func (tf *TextField) StartCharPos(idx int) float32 {
 // not in visible range, position will be out of range too)
 func (tf *TextField) CharStartPos(charidx int) Vec2D {
 	st := &tf.Sty
-	spc := st.BoxSpace()
-	pos := tf.LayData.AllocPos.AddVal(spc)
+	pos := tf.LayData.AllocPos.Add(st.BoxSpaceSides().Pos())
 	cpos := tf.TextWidth(tf.StartPos, charidx)
 	return Vec2D{pos.X + cpos, pos.Y}
 }
@@ -810,8 +809,7 @@ func (tf *TextField) AutoScroll() {
 		tf.StartPos = 0
 		return
 	}
-	spc := st.BoxSpace()
-	maxw := tf.LayData.AllocSize.X - 2.0*spc
+	maxw := tf.LayData.AllocSize.X - st.BoxSpaceSides().Size().X
 	tf.CharWidth = int(maxw / st.UnContext.ToDotsFactor(units.Ch)) // rough guess in chars
 
 	// first rationalize all the values
@@ -889,8 +887,7 @@ func (tf *TextField) AutoScroll() {
 func (tf *TextField) PixelToCursor(pixOff float32) int {
 	st := &tf.Sty
 
-	spc := st.BoxSpace()
-	px := pixOff - spc
+	px := pixOff - st.BoxSpaceSides().Pos().X
 
 	if px <= 0 {
 		return tf.StartPos
@@ -1242,7 +1239,7 @@ func (tf *TextField) Render2D() {
 		tf.RenderStdBox(st)
 		cur := tf.EditTxt[tf.StartPos:tf.EndPos]
 		tf.RenderSelect()
-		pos := tf.LayData.AllocPos.AddVal(st.BoxSpace())
+		pos := tf.LayData.AllocPos.Add(st.BoxSpaceSides().Pos())
 		if len(tf.EditTxt) == 0 && len(tf.Placeholder) > 0 {
 			st.Font.Color = st.Font.Color.Highlight(50)
 			tf.RenderVis.SetString(tf.Placeholder, &st.Font, &st.UnContext, &st.Text, true, 0, 0)
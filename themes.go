@@ -0,0 +1,207 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/goki/gi/oswin"
+)
+
+// ColorThemes are named sets of ColorPrefs -- see StdColorThemes and
+// Preferences.ColorThemes
+type ColorThemes map[string]*ColorPrefs
+
+// ThemeAuto is the ActiveTheme name that follows the dark mode setting of
+// the OS, using ThemeDark when it is set, and ThemeLight otherwise -- the
+// setting is checked when the theme is applied, and then every
+// DarkModePollMSec (unless that is 0)
+const ThemeAuto = "auto"
+
+// standard theme names
+const (
+	ThemeLight        = "light"
+	ThemeDark         = "dark"
+	ThemeHighContrast = "high-contrast"
+)
+
+// DarkModePollMSec is the interval in msec at which the OS dark mode setting
+// is checked for changes, when the ActiveTheme is ThemeAuto -- this is much
+// longer than StyleFilesPollMSec as checking it can be costly (e.g., running
+// a command) -- set it to 0 before applying the Preferences to only check it
+// when the theme is applied
+var DarkModePollMSec = 5000
+
+// StdColorThemes are the standard color themes that are always available
+var StdColorThemes = ColorThemes{}
+
+func init() {
+	lt := &ColorPrefs{}
+	lt.Defaults()
+	StdColorThemes[ThemeLight] = lt
+
+	dk := &ColorPrefs{}
+	dk.Font.SetString("#E0E0E0", nil)
+	dk.Background.SetString("#202020", nil)
+	dk.Shadow.SetString("darker-30", &dk.Background)
+	dk.Border.SetString("#888", nil)
+	dk.Control.SetString("#3C3C4C", nil)
+	dk.Icon.SetString("highlight-30", dk.Control)
+	dk.Select.SetString("#254A25", nil)
+	dk.Highlight.SetString("#5C5C20", nil)
+	dk.Link.SetString("#8AB4F8", nil)
+	dk.HiStyle = "monokai"
+	StdColorThemes[ThemeDark] = dk
+
+	hc := &ColorPrefs{}
+	hc.Font.SetString("#FFF", nil)
+	hc.Background.SetString("#000", nil)
+	hc.Shadow.SetString("#444", nil)
+	hc.Border.SetString("#FFF", nil)
+	hc.Control.SetString("#000", nil)
+	hc.Icon.SetString("#FFF", nil)
+	hc.Select.SetString("#00A", nil)
+	hc.Highlight.SetString("#660", nil)
+	hc.Link.SetString("#FF0", nil)
+	hc.HiStyle = "fruity"
+	StdColorThemes[ThemeHighContrast] = hc
+}
+
+// darkModeWatching is true when the dark mode watcher goroutine is running
+var darkModeWatching bool
+
+// darkModeMu protects darkModeWatching
+var darkModeMu sync.Mutex
+
+// Theme returns the color theme of given name, looking first in the user's
+// ColorThemes and then in the StdColorThemes -- ThemeAuto returns ThemeDark
+// or ThemeLight according to the current OS dark mode setting.  Returns nil
+// if not found.
+func (pf *Preferences) Theme(name string) *ColorPrefs {
+	if name == ThemeAuto {
+		name = pf.AutoThemeName()
+	}
+	if ct, ok := pf.ColorThemes[name]; ok && ct != nil {
+		return ct
+	}
+	return StdColorThemes[name]
+}
+
+// AutoThemeName returns the name of the theme used for ThemeAuto: ThemeDark
+// if the OS is in dark mode, and ThemeLight otherwise
+func (pf *Preferences) AutoThemeName() string {
+	if oswin.TheApp != nil && oswin.TheApp.IsDark() {
+		return ThemeDark
+	}
+	return ThemeLight
+}
+
+// ThemeNames returns the sorted names of all the available color themes,
+// including ThemeAuto
+func (pf *Preferences) ThemeNames() []string {
+	nms := []string{ThemeAuto}
+	for nm := range StdColorThemes {
+		if _, has := pf.ColorThemes[nm]; !has {
+			nms = append(nms, nm)
+		}
+	}
+	for nm := range pf.ColorThemes {
+		nms = append(nms, nm)
+	}
+	sort.Strings(nms[1:])
+	return nms
+}
+
+// ApplyTheme sets the Colors from the ActiveTheme, if set, and starts
+// monitoring the OS dark mode setting if it is ThemeAuto.  Called in Apply.
+func (pf *Preferences) ApplyTheme() {
+	if pf.ActiveTheme == "" {
+		return
+	}
+	ct := pf.Theme(pf.ActiveTheme)
+	if ct == nil {
+		log.Printf("gi.Preferences ApplyTheme: color theme %v not found\n", pf.ActiveTheme)
+		return
+	}
+	pf.Colors = *ct
+	if pf.ActiveTheme != ThemeAuto || DarkModePollMSec <= 0 {
+		return
+	}
+	darkModeMu.Lock()
+	startWatch := !darkModeWatching
+	darkModeWatching = true
+	darkModeMu.Unlock()
+	if startWatch {
+		go pf.watchDarkMode()
+	}
+}
+
+// watchDarkMode polls the OS dark mode setting every DarkModePollMSec, and
+// switches the Colors and restyles all windows when it changes, within the
+// window event loops -- runs as long as the ActiveTheme is ThemeAuto and
+// DarkModePollMSec > 0
+func (pf *Preferences) watchDarkMode() {
+	cur := pf.AutoThemeName()
+	for {
+		time.Sleep(time.Duration(DarkModePollMSec) * time.Millisecond)
+		if pf.ActiveTheme != ThemeAuto || DarkModePollMSec <= 0 {
+			darkModeMu.Lock()
+			darkModeWatching = false
+			darkModeMu.Unlock()
+			return
+		}
+		nw := pf.AutoThemeName()
+		if nw == cur {
+			continue
+		}
+		err := RunInAllEventLoops(func() {
+			pf.ApplyTheme()
+		})
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		cur = nw
+		if err := RestyleAllWindows(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// SetTheme sets the ActiveTheme to given theme name, applies it to the
+// Colors, and updates all open windows to use it.
+func (pf *Preferences) SetTheme(name string) error {
+	if pf.Theme(name) == nil {
+		err := fmt.Errorf("gi.Preferences SetTheme: color theme %v not found", name)
+		log.Println(err)
+		return err
+	}
+	pf.ActiveTheme = name
+	pf.Changed = true
+	pf.Update()
+	return nil
+}
+
+// SaveColorsAsTheme saves the current Colors as one of your ColorThemes, with
+// given name, and makes it the ActiveTheme.
+func (pf *Preferences) SaveColorsAsTheme(name string) error {
+	if name == "" || name == ThemeAuto {
+		err := fmt.Errorf("gi.Preferences SaveColorsAsTheme: invalid theme name: %q", name)
+		log.Println(err)
+		return err
+	}
+	if pf.ColorThemes == nil {
+		pf.ColorThemes = make(ColorThemes)
+	}
+	ct := pf.Colors
+	pf.ColorThemes[name] = &ct
+	pf.ActiveTheme = name
+	pf.Changed = true
+	return nil
+}
@@ -0,0 +1,279 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package units
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Calc is a CSS calc() expression, e.g., calc(100% - 2em) -- all of the
+// supported operations (+, -, and * or / by plain numbers) are linear, so the
+// expression is reduced when parsed to a sum of values in different units,
+// which are each converted to dots and added up in ToDots.
+type Calc struct {
+	Expr  string  `desc:"the original expression, e.g., calc(100% - 2em)"`
+	Terms []Value `desc:"the reduced terms of the expression, one per unit, which are added together"`
+}
+
+// IsCalc returns true if the string is a calc() expression
+func IsCalc(str string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(str)), "calc(")
+}
+
+// ParseCalc parses a calc() expression -- nested parentheses and calc()'s
+// are supported, along with + and - between any values, and * and / where
+// one side (the divisor for /) is a plain number
+func ParseCalc(str string) (*Calc, error) {
+	cp := &calcParser{src: strings.TrimSpace(str)}
+	if err := cp.tokenize(); err != nil {
+		return nil, err
+	}
+	lc, err := cp.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if cp.pos < len(cp.toks) {
+		return nil, fmt.Errorf("units.ParseCalc: unexpected %q in: %v", cp.toks[cp.pos].str, str)
+	}
+	c := &Calc{Expr: cp.src}
+	for un := Unit(0); un < UnitN; un++ {
+		if lc.coef[un] != 0 {
+			c.Terms = append(c.Terms, NewValue(lc.coef[un], un))
+		}
+	}
+	if lc.num != 0 { // unitless numbers default to pixels, as in SetString
+		c.Terms = append(c.Terms, NewValue(lc.num, Px))
+	}
+	return c, nil
+}
+
+// ToDots returns the value of the expression in raw display pixels (dots
+// in DPI), setting the Dots of each term
+func (c *Calc) ToDots(ctxt *Context) float32 {
+	dots := float32(0)
+	for i := range c.Terms {
+		dots += c.Terms[i].ToDots(ctxt)
+	}
+	return dots
+}
+
+// String implements the fmt.Stringer interface
+func (c *Calc) String() string {
+	return c.Expr
+}
+
+// calcLinear is a linear combination of values in each of the units, plus a
+// unitless number
+type calcLinear struct {
+	coef [UnitN]float32
+	num  float32
+}
+
+// hasUnits returns true if any of the unit coefficients are non-zero
+func (lc *calcLinear) hasUnits() bool {
+	for _, c := range lc.coef {
+		if c != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (lc *calcLinear) add(o *calcLinear, sign float32) {
+	for i := range lc.coef {
+		lc.coef[i] += sign * o.coef[i]
+	}
+	lc.num += sign * o.num
+}
+
+func (lc *calcLinear) scale(f float32) {
+	for i := range lc.coef {
+		lc.coef[i] *= f
+	}
+	lc.num *= f
+}
+
+// calcTok is a calc() expression token: a number (with unit), an operator, or
+// a parenthesis
+type calcTok struct {
+	str  string
+	isOp bool
+	val  float32
+	un   Unit
+	unit bool
+}
+
+type calcParser struct {
+	src  string
+	toks []calcTok
+	pos  int
+}
+
+// tokenize splits the source into tokens -- the calc function itself is
+// treated as an open parenthesis
+func (cp *calcParser) tokenize() error {
+	s := cp.src
+	for i := 0; i < len(s); {
+		ch := s[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			i++
+		case strings.HasPrefix(strings.ToLower(s[i:]), "calc("):
+			cp.toks = append(cp.toks, calcTok{str: "(", isOp: true})
+			i += 5
+		case ch == '(' || ch == ')' || ch == '*' || ch == '/' || ch == '+':
+			cp.toks = append(cp.toks, calcTok{str: string(ch), isOp: true})
+			i++
+		case ch == '-' && cp.prevIsOperand():
+			cp.toks = append(cp.toks, calcTok{str: "-", isOp: true})
+			i++
+		default:
+			st := i
+			if ch == '-' {
+				i++
+			}
+			for i < len(s) && (s[i] == '.' || (s[i] >= '0' && s[i] <= '9')) {
+				i++
+			}
+			nst := i
+			for i < len(s) && (s[i] == '%' || (s[i] >= 'a' && s[i] <= 'z') || (s[i] >= 'A' && s[i] <= 'Z')) {
+				i++
+			}
+			if nst == st || (nst == st+1 && ch == '-') {
+				return fmt.Errorf("units.ParseCalc: invalid value at: %v in: %v", s[st:], s)
+			}
+			val, err := strconv.ParseFloat(s[st:nst], 32)
+			if err != nil {
+				return fmt.Errorf("units.ParseCalc: invalid number: %v in: %v", s[st:nst], s)
+			}
+			tk := calcTok{str: s[st:i], val: float32(val)}
+			if unstr := strings.ToLower(s[nst:i]); unstr != "" {
+				if unstr == "%" {
+					unstr = "pct"
+				}
+				found := false
+				for un, nm := range UnitNames {
+					if nm == unstr {
+						tk.un = Unit(un)
+						found = true
+						break
+					}
+				}
+				if !found {
+					return fmt.Errorf("units.ParseCalc: unit not recognized: %v in: %v", unstr, s)
+				}
+				tk.unit = true
+			}
+			cp.toks = append(cp.toks, tk)
+		}
+	}
+	return nil
+}
+
+// prevIsOperand returns true if the last token is a number or a closing
+// parenthesis, so that a following - is a binary operator
+func (cp *calcParser) prevIsOperand() bool {
+	if len(cp.toks) == 0 {
+		return false
+	}
+	lt := cp.toks[len(cp.toks)-1]
+	return !lt.isOp || lt.str == ")"
+}
+
+func (cp *calcParser) peek() string {
+	if cp.pos >= len(cp.toks) || !cp.toks[cp.pos].isOp {
+		return ""
+	}
+	return cp.toks[cp.pos].str
+}
+
+// parseExpr parses: term (('+'|'-') term)*
+func (cp *calcParser) parseExpr() (*calcLinear, error) {
+	lc, err := cp.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := cp.peek()
+		if op != "+" && op != "-" {
+			return lc, nil
+		}
+		cp.pos++
+		rc, err := cp.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			lc.add(rc, 1)
+		} else {
+			lc.add(rc, -1)
+		}
+	}
+}
+
+// parseTerm parses: factor (('*'|'/') factor)*
+func (cp *calcParser) parseTerm() (*calcLinear, error) {
+	lc, err := cp.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := cp.peek()
+		if op != "*" && op != "/" {
+			return lc, nil
+		}
+		cp.pos++
+		rc, err := cp.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case op == "/":
+			if rc.hasUnits() || rc.num == 0 {
+				return nil, fmt.Errorf("units.ParseCalc: can only divide by a non-zero number in: %v", cp.src)
+			}
+			lc.scale(1 / rc.num)
+		case !rc.hasUnits():
+			lc.scale(rc.num)
+		case !lc.hasUnits():
+			rc.scale(lc.num)
+			lc = rc
+		default:
+			return nil, fmt.Errorf("units.ParseCalc: can only multiply by a number in: %v", cp.src)
+		}
+	}
+}
+
+// parseFactor parses a number or a parenthesized expression
+func (cp *calcParser) parseFactor() (*calcLinear, error) {
+	if cp.pos >= len(cp.toks) {
+		return nil, fmt.Errorf("units.ParseCalc: unexpected end of expression: %v", cp.src)
+	}
+	tk := cp.toks[cp.pos]
+	cp.pos++
+	if !tk.isOp {
+		lc := &calcLinear{}
+		if tk.unit {
+			lc.coef[tk.un] = tk.val
+		} else {
+			lc.num = tk.val
+		}
+		return lc, nil
+	}
+	if tk.str != "(" {
+		return nil, fmt.Errorf("units.ParseCalc: unexpected %q in: %v", tk.str, cp.src)
+	}
+	lc, err := cp.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if cp.peek() != ")" {
+		return nil, fmt.Errorf("units.ParseCalc: missing ) in: %v", cp.src)
+	}
+	cp.pos++
+	return lc, nil
+}
@@ -20,6 +20,7 @@ package units
 
 import (
 	"fmt"
+	"log"
 	"strings"
 
 	"github.com/goki/ki"
@@ -269,11 +270,13 @@ func (uc *Context) DotsToPx(val float32) float32 {
 ////////////////////////////////////////////////////////////////////////
 //   Value
 
-// Value and units, and converted value into raw pixels (dots in DPI)
+// Value and units, and converted value into raw pixels (dots in DPI) -- can
+// also hold a calc() expression, which is converted into Dot units by ToDots
 type Value struct {
 	Val  float32
 	Un   Unit
 	Dots float32
+	Calc *Calc
 }
 
 var KiT_Value = kit.Types.AddType(&Value{}, ValueProps)
@@ -284,18 +287,26 @@ var ValueProps = ki.Props{
 
 // NewValue creates a new value with given units
 func NewValue(val float32, un Unit) Value {
-	return Value{val, un, 0.0}
+	return Value{Val: val, Un: un}
 }
 
 // Set sets value and units of an existing value
 func (v *Value) Set(val float32, un Unit) {
 	v.Val = val
 	v.Un = un
+	v.Calc = nil
 }
 
 // ToDots converts value to raw display pixels (dots as in DPI), setting also
-// the Dots field
+// the Dots field -- a calc() expression is evaluated in the given context, and
+// the result is also set as the Val in Dot units
 func (v *Value) ToDots(ctxt *Context) float32 {
+	if v.Calc != nil {
+		v.Dots = v.Calc.ToDots(ctxt)
+		v.Val = v.Dots
+		v.Un = Dot
+		return v.Dots
+	}
 	v.Dots = ctxt.ToDots(v.Val, v.Un)
 	return v.Dots
 }
@@ -309,16 +320,30 @@ func (v *Value) ToDotsFixed(ctxt *Context) fixed.Int26_6 {
 // Convert converts value to the given units, given unit context
 func (v *Value) Convert(to Unit, ctxt *Context) Value {
 	dots := v.ToDots(ctxt)
-	return Value{dots / ctxt.ToDotsFactor(to), to, dots}
+	return Value{Val: dots / ctxt.ToDotsFactor(to), Un: to, Dots: dots}
 }
 
 // String implements the fmt.Stringer interface.
 func (v *Value) String() string {
+	if v.Calc != nil {
+		return v.Calc.String()
+	}
 	return fmt.Sprintf("%f%s", v.Val, UnitNames[v.Un])
 }
 
-// SetString sets value from a string
+// SetString sets value from a string -- can be a calc() expression
 func (v *Value) SetString(str string) {
+	if IsCalc(str) {
+		c, err := ParseCalc(str)
+		if err != nil {
+			log.Println(err)
+			v.Set(0, Px)
+			return
+		}
+		v.Set(0, Dot)
+		v.Calc = c
+		return
+	}
 	trstr := strings.TrimSpace(strings.Replace(str, "%", "pct", -1))
 	sz := len(trstr)
 	if sz < 2 {
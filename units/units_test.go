@@ -31,3 +31,37 @@ func TestValCvt(t *testing.T) {
 		t.Errorf("strings don't match: %v != %v\n", s1, s2)
 	}
 }
+
+func TestCalc(t *testing.T) {
+	var ctxt Context
+	ctxt.Defaults()
+	ctxt.SetSizes(0, 0, 400, 300)
+	tests := []struct {
+		expr string
+		dots float32
+	}{
+		{"calc(100% - 2em)", 400 - 24},
+		{"calc(10px + 2 * (3em - 1em))", 10 + 48},
+		{"calc(50% / 2 + -4px)", 100 - 4},
+		{"calc(2 * calc(1in - 16px))", 2 * 80},
+		{"calc(1em*3)", 36},
+	}
+	for _, tst := range tests {
+		v := StringToValue(tst.expr)
+		if v.Calc == nil {
+			t.Errorf("%v: not parsed as calc\n", tst.expr)
+			continue
+		}
+		if d := v.ToDots(&ctxt); d != tst.dots {
+			t.Errorf("%v: got %v dots, expected: %v\n", tst.expr, d, tst.dots)
+		}
+		if v.String() != tst.expr {
+			t.Errorf("%v: String gave: %v\n", tst.expr, v.String())
+		}
+	}
+	for _, bad := range []string{"calc(2em * 3em)", "calc(1em / 0)", "calc(1em + )", "calc(1zz)", "calc((1em)"} {
+		if _, err := ParseCalc(bad); err == nil {
+			t.Errorf("%v: expected parse error\n", bad)
+		}
+	}
+}
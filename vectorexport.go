@@ -0,0 +1,272 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/goki/gi/units"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/font"
+)
+
+// ExportDPI returns the dots-per-inch used for the pages of vector files (PDF,
+// SVG) saved from this viewport: the logical DPI used in its styling
+func (vp *Viewport2D) ExportDPI() float32 {
+	if dpi := vp.Sty.UnContext.DPI; dpi > 0 {
+		return dpi
+	}
+	return units.PxPerInch
+}
+
+// vectorExporter is implemented by the writers of a DisplayList in vector
+// formats (PDF, SVG), which are driven by a vectorWalker -- all points and
+// transforms are in the user coordinates of the output
+type vectorExporter interface {
+	// moveTo, lineTo, quadTo, cubicTo and closePath add to the current path
+	moveTo(p Vec2D)
+	lineTo(p Vec2D)
+	quadTo(q, p Vec2D)
+	cubicTo(c1, c2, p Vec2D)
+	closePath()
+
+	// clearPath starts a new current path
+	clearPath()
+
+	// pathData returns the current path in the format of the exporter, or ""
+	// if it is empty
+	pathData() string
+
+	// fill and stroke paint the current path
+	fill(pc *Paint, xf Matrix2D)
+	stroke(pc *Paint, xf Matrix2D)
+
+	// fillBox fills a rectangle with a color
+	fillBox(r image.Rectangle, clr color.Color, xf Matrix2D)
+
+	// image draws the src image, or src through the alpha mask if non-nil,
+	// with given transform from source to user coordinates
+	image(src, mask image.Image, xf Matrix2D)
+
+	// text draws the glyphs of the span at given position
+	text(sr *SpanRender, pos Vec2D, xf Matrix2D)
+
+	// beginClip starts drawing within given clip, until the matching endClip
+	beginClip(c *vectorClip)
+	endClip(c *vectorClip)
+}
+
+// vectorClip is an entry on the stack of clips of a vectorWalker: either the
+// bounds of a PushBounds or sub-list, or a Clip path
+type vectorClip struct {
+	bounds  bool            // bounds pushed with PushBounds, vs. a Clip path
+	rect    image.Rectangle // rectangle of the bounds
+	path    string          // clipping path, from pathData
+	evenOdd bool            // clipping path uses the even-odd rule
+	id      string          // id of the clip in the output, if needed by the exporter
+}
+
+// vectorWalker walks the operations of a DisplayList, sending them to an
+// exporter, and maintains the stack of clips: bounds end with the list that
+// pushed them, while clipping paths persist until a ResetClip
+type vectorWalker struct {
+	ex    vectorExporter
+	clips []*vectorClip
+}
+
+// list sends the operations of the list to the exporter, with the recorded
+// device coordinates further transformed by xf
+func (vw *vectorWalker) list(dl *DisplayList, xf Matrix2D) {
+	ex := vw.ex
+	depth := len(vw.clips)
+	for i := range dl.Ops {
+		op := &dl.Ops[i]
+		switch op.Op {
+		case DisplayMoveTo:
+			ex.moveTo(xf.TransformPointVec2D(op.Pts[0]))
+		case DisplayLineTo:
+			ex.lineTo(xf.TransformPointVec2D(op.Pts[0]))
+		case DisplayQuadTo:
+			ex.quadTo(xf.TransformPointVec2D(op.Pts[0]), xf.TransformPointVec2D(op.Pts[1]))
+		case DisplayCubicTo:
+			ex.cubicTo(xf.TransformPointVec2D(op.Pts[0]), xf.TransformPointVec2D(op.Pts[1]), xf.TransformPointVec2D(op.Pts[2]))
+		case DisplayClosePath:
+			ex.closePath()
+		case DisplayClearPath:
+			ex.clearPath()
+		case DisplayFill:
+			ex.fill(op.Paint, op.XForm.Multiply(xf))
+		case DisplayStroke:
+			ex.stroke(op.Paint, op.XForm.Multiply(xf))
+		case DisplayClip:
+			d := ex.pathData()
+			if d == "" {
+				continue
+			}
+			vw.pushClip(&vectorClip{path: d, evenOdd: op.Paint.FillStyle.Rule == FillRuleEvenOdd})
+		case DisplayResetClip:
+			for ci, c := range vw.clips {
+				if !c.bounds {
+					for _, pc := range vw.popClips(ci) {
+						if pc.bounds {
+							vw.pushClip(pc)
+						}
+					}
+					break
+				}
+			}
+		case DisplayFillBox:
+			if !op.Rect.Empty() {
+				ex.fillBox(op.Rect, op.Color, xf)
+			}
+		case DisplayImage:
+			ex.image(op.Image, nil, op.XForm.Multiply(xf))
+		case DisplayMask:
+			ex.image(image.NewUniform(op.Color), op.Image, op.XForm.Multiply(xf))
+		case DisplayText:
+			if op.Span != nil && op.Span.IsValid() == nil {
+				ex.text(op.Span, op.Pos, xf)
+			}
+		case DisplayPushBounds:
+			vw.pushBounds(XFormRect(xf, op.Rect))
+		case DisplayPopBounds:
+			if len(vw.clips) > depth {
+				vw.popBounds(depth)
+			}
+		case DisplaySubList:
+			if op.List == nil {
+				continue
+			}
+			if op.Rect.Empty() {
+				vw.list(op.List, op.XForm.Multiply(xf))
+				continue
+			}
+			sd := len(vw.clips)
+			vw.pushBounds(XFormRect(xf, op.Rect))
+			vw.list(op.List, op.XForm.Multiply(xf))
+			vw.popBounds(sd)
+		}
+	}
+	for _, pc := range vw.popClips(depth) { // bounds end with the list, clips remain
+		if !pc.bounds {
+			vw.pushClip(pc)
+		}
+	}
+}
+
+// pushClip pushes a clip onto the stack
+func (vw *vectorWalker) pushClip(c *vectorClip) {
+	vw.clips = append(vw.clips, c)
+	vw.ex.beginClip(c)
+}
+
+// popClips pops the clips above given depth on the stack, returning them
+func (vw *vectorWalker) popClips(depth int) []*vectorClip {
+	if depth >= len(vw.clips) {
+		return nil
+	}
+	popped := append([]*vectorClip(nil), vw.clips[depth:]...)
+	for i := len(popped) - 1; i >= 0; i-- {
+		vw.ex.endClip(popped[i])
+	}
+	vw.clips = vw.clips[:depth]
+	return popped
+}
+
+// pushBounds restricts drawing to given rectangle, in user coordinates
+func (vw *vectorWalker) pushBounds(r image.Rectangle) {
+	if r.Dx() < 0 || r.Dy() < 0 {
+		r.Max = r.Min
+	}
+	vw.pushClip(&vectorClip{bounds: true, rect: r})
+}
+
+// popBounds pops the last bounds pushed above given depth on the stack,
+// along with any clipping paths pushed after it, which are then restored
+func (vw *vectorWalker) popBounds(depth int) {
+	for ci := len(vw.clips) - 1; ci >= depth; ci-- {
+		if vw.clips[ci].bounds {
+			popped := vw.popClips(ci)
+			for _, pc := range popped[1:] {
+				vw.pushClip(pc)
+			}
+			return
+		}
+	}
+}
+
+// vectorBBox tracks the bounding box of the points of the current path
+type vectorBBox struct {
+	min, max Vec2D
+	n        int
+}
+
+// add extends the box by given points
+func (bb *vectorBBox) add(pts ...Vec2D) {
+	for _, p := range pts {
+		if bb.n == 0 {
+			bb.min, bb.max = p, p
+		} else {
+			bb.min.SetMin(p)
+			bb.max.SetMax(p)
+		}
+		bb.n++
+	}
+}
+
+// reset empties the box
+func (bb *vectorBBox) reset() {
+	bb.n = 0
+}
+
+// vectorGradXForm returns the transform from the coordinates of the points
+// of given gradient to user coordinates, for a path with given bounding box
+// (for ObjectBoundingBox units), drawn under given transform
+func vectorGradXForm(g *rasterx.Gradient, xf Matrix2D, bb *vectorBBox) Matrix2D {
+	gm := Matrix2D{float32(g.Matrix.A), float32(g.Matrix.B), float32(g.Matrix.C), float32(g.Matrix.D), float32(g.Matrix.E), float32(g.Matrix.F)}
+	if g.Units == rasterx.ObjectBoundingBox {
+		mn, mx := bb.min.ToPointFloor(), bb.max.ToPointCeil()
+		return gm.Multiply(Scale2D(float32(mx.X-mn.X), float32(mx.Y-mn.Y))).Multiply(Translate2D(float32(mn.X), float32(mn.Y)))
+	}
+	return gm.Multiply(xf)
+}
+
+// vectorFaceName returns the regularized name of the font of given face, and
+// its size in dots, which are looked up in the FontLibrary cache of faces --
+// the name is "" if the face is not from the library, with the size then
+// approximated from its metrics
+func vectorFaceName(face font.Face) (string, float32) {
+	for nm, fm := range FontLibrary.Faces {
+		for sz, fc := range fm {
+			if fc == face {
+				return nm, float32(sz)
+			}
+		}
+	}
+	m := face.Metrics()
+	return "", FixedToFloat32(m.Ascent + m.Descent)
+}
+
+// vecNum formats a number for vector output, with up to 3 decimals
+func vecNum(v float32) string {
+	s := strconv.FormatFloat(float64(v), 'f', 3, 32)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// vecDashes formats a dash pattern for vector output, separated by spaces
+func vecDashes(dash []float64) string {
+	ds := make([]string, len(dash))
+	for i, d := range dash {
+		ds[i] = vecNum(float32(d))
+	}
+	return strings.Join(ds, " ")
+}
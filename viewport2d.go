@@ -200,18 +200,33 @@ func (vp *Viewport2D) DrawIntoParent(parVp *Viewport2D) {
 		sp = nr.Min.Sub(r.Min)
 		r = nr
 	}
+	if db := parVp.Render.DamageBounds; !db.Empty() { // parent is only re-rendering damage
+		nr := r.Intersect(db)
+		sp = sp.Add(nr.Min.Sub(r.Min))
+		r = nr
+	}
 	if Render2DTrace {
 		fmt.Printf("Render: vp DrawIntoParent: %v parVp: %v rect: %v sp: %v\n", vp.PathUnique(), parVp.PathUnique(), r, sp)
 	}
+	vp.recordIntoParent(parVp, r, sp)
 	draw.Draw(parVp.Pixels, r, vp.Pixels, sp, draw.Over)
 }
 
-// ReRender2DNode re-renders a specific node
+// ReRender2DNode re-renders a specific node -- if damage rendering is
+// available (see DamageRender2D), the region of the node is marked as damaged
+// and re-rendered, along with all other damage, at the end of the window
+// update
 func (vp *Viewport2D) ReRender2DNode(gni Node2D) {
 	gn := gni.AsNode2D()
 	if Render2DTrace {
 		fmt.Printf("Render: vp re-render: %v node: %v\n", vp.PathUnique(), gn.PathUnique())
 	}
+	if vp.Win != nil && vp.Win.DamageViewport(vp) != nil {
+		updt := vp.Win.UpdateStart()
+		gn.AddDamage()
+		vp.Win.UpdateEnd(updt) // renders and uploads the damage
+		return
+	}
 	pr := prof.Start("vp.ReRender2DNode")
 	gn.Render2DTree()
 	pr.End()
@@ -224,6 +239,8 @@ func (vp *Viewport2D) ReRender2DNode(gni Node2D) {
 
 // ReRender2DAnchor re-renders an anchor node -- the KEY diff from
 // ReRender2DNode is that it calls ReRender2DTree and not just Render2DTree!
+// With damage rendering, the anchor is re-laid-out and its old and new
+// regions are marked as damaged
 func (vp *Viewport2D) ReRender2DAnchor(gni Node2D) {
 	pw := gni.AsWidget()
 	if pw == nil {
@@ -232,6 +249,16 @@ func (vp *Viewport2D) ReRender2DAnchor(gni Node2D) {
 	if Render2DTrace {
 		fmt.Printf("Render: vp anchor re-render: %v node: %v\n", vp.PathUnique(), pw.PathUnique())
 	}
+	if vp.Win != nil && vp.Win.DamageViewport(vp) != nil {
+		updt := vp.Win.UpdateStart()
+		obb := pw.WinBBox
+		pr := prof.Start("vp.ReRender2DNode")
+		pw.ReLayout2DTree()
+		pr.End()
+		vp.Win.AddDamage(vp, obb.Union(pw.WinBBox))
+		vp.Win.UpdateEnd(updt) // renders and uploads the damage
+		return
+	}
 	pr := prof.Start("vp.ReRender2DNode")
 	pw.ReRender2DTree()
 	pr.End()
@@ -340,6 +367,9 @@ func (vp *Viewport2D) RenderViewport2D() {
 	if vp.IsOverlay() {
 		return
 	}
+	if !vp.Render.DamageBounds.Empty() && (vp.IsPopup() || vp.Viewport == nil) {
+		return // window uploads the damage, see Window.RenderDamage
+	}
 	if vp.IsPopup() { // popup has a parent that is the window
 		vp.SetCurWin()
 		if Render2DTrace {
@@ -393,6 +423,9 @@ func (vp *Viewport2D) PushBounds() bool {
 			// fmt.Printf("not rendering vp %v bc empty winbox -- ours: %v par: %v\n", vp.Nm, vp.WinBBox, vp.Viewport.WinBBox)
 			return false
 		}
+		if db := vp.Viewport.Render.DamageBounds; !vp.IsPopup() && !db.Empty() && !vp.Geom.Bounds().Overlaps(db) {
+			return false
+		}
 	}
 	rs := &vp.Render
 	rs.PushBounds(vp.VpBBox)
@@ -431,6 +464,7 @@ func (vp *Viewport2D) Render2D() {
 		return
 	}
 	if vp.PushBounds() {
+		vp.RecordForParent()
 		if vp.Fill {
 			vp.FillViewport()
 		}
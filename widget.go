@@ -33,6 +33,10 @@ type WidgetBase struct {
 	LayData      LayoutData   `json:"-" xml:"-" desc:"all the layout information for this item"`
 	WidgetSig    ki.Signal    `json:"-" xml:"-" view:"-" desc:"general widget signals supported by all widgets, including select, focus, and context menu (right mouse button) events, which can be used by views and other compound widgets"`
 	CtxtMenuFunc CtxtMenuFunc `view:"-" json:"-" xml:"-" desc:"optional context menu function called by MakeContextMenu AFTER any native items are added -- this function can decide where to insert new elements -- typically add a separator to disambiguate"`
+
+	styleTo       *Style
+	styleAnim     *StyleAnim
+	styleAnimName string
 }
 
 var KiT_WidgetBase = kit.Types.AddType(&WidgetBase{}, WidgetBaseProps)
@@ -150,13 +154,19 @@ func (wb *WidgetBase) Style2DWidget() {
 		wb.Sty.SetStyleProps(parSty, sp)
 	}
 
+	wb.CSSAgg = nil // restart
+	pcss := Prefs.CustomCSS()
+	if !Prefs.PrefsOverride {
+		AggCSS(&wb.CSSAgg, pcss) // prefs provide defaults
+	}
 	pagg := wb.ParentCSSAgg()
 	if pagg != nil {
 		AggCSS(&wb.CSSAgg, *pagg)
-	} else {
-		wb.CSSAgg = nil // restart
 	}
 	AggCSS(&wb.CSSAgg, wb.CSS)
+	if Prefs.PrefsOverride {
+		AggCSS(&wb.CSSAgg, pcss)
+	}
 	wb.Sty.StyleCSS(gii, wb.CSSAgg, "")
 
 	wb.Sty.SetUnitContext(wb.Viewport, Vec2DZero) // todo: test for use of el-relative
@@ -164,6 +174,7 @@ func (wb *WidgetBase) Style2DWidget() {
 		wb.SetInactive()
 	}
 	wb.Sty.Use() // activates currentColor etc
+	wb.StartStyleAnimation()
 }
 
 // StylePart sets the style properties for a child in parts (or any other
@@ -290,11 +301,11 @@ func (wb *WidgetBase) Layout2D(parBBox image.Rectangle, iter int) bool {
 // margin and padding to children -- call in ChildrenBBox2D for most widgets
 func (wb *WidgetBase) ChildrenBBox2DWidget() image.Rectangle {
 	nb := wb.VpBBox
-	spc := int(wb.Sty.BoxSpace())
-	nb.Min.X += spc
-	nb.Min.Y += spc
-	nb.Max.X -= spc
-	nb.Max.Y -= spc
+	spc := wb.Sty.BoxSpaceSides()
+	nb.Min.X += int(spc[BoxLeft])
+	nb.Min.Y += int(spc[BoxTop])
+	nb.Max.X -= int(spc[BoxRight])
+	nb.Max.Y -= int(spc[BoxBottom])
 	return nb
 }
 
@@ -306,12 +317,15 @@ func (wb *WidgetBase) ChildrenBBox2D() image.Rectangle {
 // so, calls ReRender2DTree and returns true -- call this at start of each
 // Render2D
 func (wb *WidgetBase) FullReRenderIfNeeded() bool {
-	if wb.InBounds() && wb.NeedsFullReRender() {
+	if wb.InBounds() && wb.NeedsFullReRender() && !wb.OutsideDamage() {
 		if Render2DTrace {
 			fmt.Printf("Render: NeedsFullReRender for %v at %v\n", wb.PathUnique(), wb.VpBBox)
 		}
 		wb.ClearFullReRender()
 		wb.ReRender2DTree()
+		if !wb.Viewport.Render.DamageBounds.Empty() {
+			wb.AddDamage() // only rendered within the damage so far -- get the rest
+		}
 		return true
 	}
 	return false
@@ -351,6 +365,9 @@ func (wb *WidgetBase) PushBounds() bool {
 		wb.ClearFullReRender()
 		return false
 	}
+	if wb.OutsideDamage() {
+		return false
+	}
 	rs := &wb.Viewport.Render
 	rs.PushBounds(wb.VpBBox)
 	wb.ConnectToViewport()
@@ -387,6 +404,16 @@ func (wb *WidgetBase) Render2D() {
 // ReRender2DTree does a re-render of the tree -- after it has already been
 // initialized and styled -- redoes the full stack
 func (wb *WidgetBase) ReRender2DTree() {
+	updt := wb.UpdateStart()
+	wb.ReLayout2DTree()
+	wb.Render2DTree()
+	wb.UpdateEndNoSig(updt)
+}
+
+// ReLayout2DTree does the init, style, size and layout steps of
+// ReRender2DTree, without rendering -- e.g., for an anchor whose region is
+// then re-rendered as damage, see Window.AddDamage
+func (wb *WidgetBase) ReLayout2DTree() {
 	parBBox := image.ZR
 	pni, _ := KiToNode2D(wb.Par)
 	if pni != nil {
@@ -404,7 +431,6 @@ func (wb *WidgetBase) ReRender2DTree() {
 	if !delta.IsZero() {
 		wb.Move2D(delta.ToPointFloor(), parBBox)
 	}
-	wb.Render2DTree()
 	wb.UpdateEndNoSig(updt)
 }
 
@@ -625,44 +651,131 @@ func (wb *WidgetBase) RenderBoxImpl(pos Vec2D, sz Vec2D, rad float32) {
 	pc.FillStrokeClear(rs)
 }
 
+// RenderBoxRadiiImpl implements the standard box model rendering with a
+// separate radius for each corner (top-left, top-right, bottom-right,
+// bottom-left) -- assumes all paint params have already been set
+func (wb *WidgetBase) RenderBoxRadiiImpl(pos Vec2D, sz Vec2D, rad SideFloats) {
+	rs := &wb.Viewport.Render
+	pc := &rs.Paint
+	pc.DrawRoundedRectangleRadii(rs, pos.X, pos.Y, sz.X, sz.Y, rad)
+	pc.FillStrokeClear(rs)
+}
+
+// RenderBorder draws the border of a box with given position and size of
+// the outer edge of the border, and corner radii -- a border with the same
+// width on all sides is stroked along its center, and otherwise the region
+// between the outer edge and the inner (padding) edge is filled, so that
+// each side can have a different width, including none -- dotted and dashed
+// borders with different widths are instead stroked one side at a time,
+// without rounded corners
+func (wb *WidgetBase) RenderBorder(st *Style, pos, sz Vec2D, rad SideFloats) {
+	rs := &wb.Viewport.Render
+	pc := &rs.Paint
+	bs := st.Border.Style
+	if bs == BorderNone || bs == BorderHidden {
+		return
+	}
+	bw := st.Border.WidthDots()
+	if bw.IsUniform() {
+		pc.StrokeStyle.SetColor(&st.Border.Color)
+		pc.StrokeStyle.Width = st.Border.TopWidth
+		pc.StrokeStyle.SetBorderDashes(bs)
+		pc.FillStyle.SetColor(nil)
+		wb.RenderBoxRadiiImpl(pos.AddVal(0.5*bw[0]), sz.SubVal(bw[0]), rad)
+		pc.StrokeStyle.SetBorderDashes(BorderSolid)
+		return
+	}
+	if bs == BorderDotted || bs == BorderDashed {
+		pc.StrokeStyle.SetColor(&st.Border.Color)
+		pc.FillStyle.SetColor(nil)
+		mx := pos.Add(sz)
+		for side, w := range bw {
+			if w <= 0 {
+				continue
+			}
+			pc.StrokeStyle.Width.Dots = w
+			pc.StrokeStyle.SetBorderDashes(bs)
+			switch BoxSides(side) {
+			case BoxTop:
+				pc.DrawLine(rs, pos.X, pos.Y+0.5*w, mx.X, pos.Y+0.5*w)
+			case BoxRight:
+				pc.DrawLine(rs, mx.X-0.5*w, pos.Y, mx.X-0.5*w, mx.Y)
+			case BoxBottom:
+				pc.DrawLine(rs, mx.X, mx.Y-0.5*w, pos.X, mx.Y-0.5*w)
+			case BoxLeft:
+				pc.DrawLine(rs, pos.X+0.5*w, mx.Y, pos.X+0.5*w, pos.Y)
+			}
+			pc.Stroke(rs)
+		}
+		pc.StrokeStyle.SetBorderDashes(BorderSolid)
+		return
+	}
+	pc.StrokeStyle.SetColor(nil)
+	pc.FillStyle.SetColor(&st.Border.Color)
+	rule := pc.FillStyle.Rule
+	pc.FillStyle.Rule = FillRuleEvenOdd
+	pc.DrawRoundedRectangleRadii(rs, pos.X, pos.Y, sz.X, sz.Y, rad)
+	ipos := pos.Add(bw.Pos())
+	isz := sz.Sub(bw.Size())
+	irad := InnerRadii(rad, bw)
+	pc.DrawRoundedRectangleRadii(rs, ipos.X, ipos.Y, isz.X, isz.Y, irad)
+	pc.FillStrokeClear(rs)
+	pc.FillStyle.Rule = rule
+}
+
 // RenderStdBox draws standard box using given style
 func (wb *WidgetBase) RenderStdBox(st *Style) {
 	rs := &wb.Viewport.Render
 	pc := &rs.Paint
 
-	pos := wb.LayData.AllocPos.AddVal(st.Layout.Margin.Dots)
-	sz := wb.LayData.AllocSize.AddVal(-2.0 * st.Layout.Margin.Dots)
-	rad := st.Border.Radius.Dots
+	mrg := st.Layout.MarginDots()
+	pos := wb.LayData.AllocPos.Add(mrg.Pos())
+	sz := wb.LayData.AllocSize.Sub(mrg.Size())
+	rad := st.Border.RadiusDots()
 
-	// first do any shadow
-	if st.BoxShadow.HasShadow() {
-		spos := pos.Add(Vec2D{st.BoxShadow.HOffset.Dots, st.BoxShadow.VOffset.Dots})
-		pc.StrokeStyle.SetColor(nil)
-		pc.FillStyle.Color.SetShadowGradient(st.BoxShadow.Color, "")
-		// todo: this is not rendering a transparent gradient
-		// pc.FillStyle.Opacity = .5
-		wb.RenderBoxImpl(spos, sz, rad)
-		// pc.FillStyle.Opacity = 1.0
-	}
+	// first do any outset shadows
+	st.RenderShadows(rs, pos, sz, rad, false)
 	// then draw the box over top of that -- note: won't work well for
 	// transparent! need to set clipping to box first..
 	if !st.Font.BgColor.IsNil() {
-		if rad == 0 {
+		if rad.IsZero() {
 			pc.FillBox(rs, pos, sz, &st.Font.BgColor)
 		} else {
 			pc.FillStyle.SetColorSpec(&st.Font.BgColor)
-			pc.DrawRoundedRectangle(rs, pos.X, pos.Y, sz.X, sz.Y, rad)
+			pc.DrawRoundedRectangleRadii(rs, pos.X, pos.Y, sz.X, sz.Y, rad)
 			pc.Fill(rs)
 		}
 	}
 
-	pc.StrokeStyle.SetColor(&st.Border.Color)
-	pc.StrokeStyle.Width = st.Border.Width
-	// pc.FillStyle.SetColor(&st.Font.BgColor)
-	pos = pos.AddVal(0.5 * st.Border.Width.Dots)
-	sz = sz.SubVal(st.Border.Width.Dots)
+	st.RenderShadows(rs, pos, sz, rad, true)
+	wb.RenderBorder(st, pos, sz, rad)
+	wb.RenderOutline(st, pos, sz, rad)
+}
+
+// RenderOutline draws the outline of a box with given position and size of
+// the outer edge of its border, and corner radii -- the outline is stroked
+// just outside the border, does not take up any space in the layout, and is
+// only drawn if its style is not none or hidden (the default is none)
+func (wb *WidgetBase) RenderOutline(st *Style, pos, sz Vec2D, rad SideFloats) {
+	ol := &st.Outline
+	ow := ol.Width.Dots
+	if ol.Style == BorderNone || ol.Style == BorderHidden || ow <= 0 {
+		return
+	}
+	rs := &wb.Viewport.Render
+	pc := &rs.Paint
+	pc.StrokeStyle.SetColor(&ol.Color)
+	pc.StrokeStyle.Width = ol.Width
+	pc.StrokeStyle.SetBorderDashes(ol.Style)
 	pc.FillStyle.SetColor(nil)
-	wb.RenderBoxImpl(pos, sz, st.Border.Radius.Dots)
+	orad := rad
+	for i := range orad {
+		if orad[i] > 0 {
+			orad[i] += 0.5 * ow
+		}
+	}
+	wb.RenderBoxRadiiImpl(pos.SubVal(0.5*ow), sz.AddVal(ow), orad)
+	pc.StrokeStyle.SetBorderDashes(BorderSolid)
 }
 
 // set our LayData.AllocSize from constraints
@@ -674,22 +787,22 @@ func (wb *WidgetBase) Size2DFromWH(w, h float32) {
 	if st.Layout.Height.Dots > 0 {
 		h = Max32(st.Layout.Height.Dots, h)
 	}
-	spc := st.BoxSpace()
-	w += 2.0 * spc
-	h += 2.0 * spc
+	spc := st.BoxSpaceSides().Size()
+	w += spc.X
+	h += spc.Y
 	wb.LayData.AllocSize = Vec2D{w, h}
 }
 
 // Size2DAddSpace adds space to existing AllocSize
 func (wb *WidgetBase) Size2DAddSpace() {
-	spc := wb.Sty.BoxSpace()
-	wb.LayData.AllocSize.SetAddVal(2 * spc)
+	spc := wb.Sty.BoxSpaceSides()
+	wb.LayData.AllocSize.SetAdd(spc.Size())
 }
 
-// Size2DSubSpace returns AllocSize minus 2 * BoxSpace -- the amount avail to the internal elements
+// Size2DSubSpace returns AllocSize minus the BoxSpaceSides on both sides -- the amount avail to the internal elements
 func (wb *WidgetBase) Size2DSubSpace() Vec2D {
-	spc := wb.Sty.BoxSpace()
-	return wb.LayData.AllocSize.SubVal(2 * spc)
+	spc := wb.Sty.BoxSpaceSides()
+	return wb.LayData.AllocSize.Sub(spc.Size())
 }
 
 // SetMinPrefWidth sets minimum and preferred width -- will get at least this
@@ -779,9 +892,9 @@ func (wb *PartsWidgetBase) ComputeBBox2D(parBBox image.Rectangle, delta image.Po
 }
 
 func (wb *PartsWidgetBase) Layout2DParts(parBBox image.Rectangle, iter int) {
-	spc := wb.Sty.BoxSpace()
-	wb.Parts.LayData.AllocPos = wb.LayData.AllocPos.AddVal(spc)
-	wb.Parts.LayData.AllocSize = wb.LayData.AllocSize.AddVal(-2.0 * spc)
+	spc := wb.Sty.BoxSpaceSides()
+	wb.Parts.LayData.AllocPos = wb.LayData.AllocPos.Add(spc.Pos())
+	wb.Parts.LayData.AllocSize = wb.LayData.AllocSize.Sub(spc.Size())
 	wb.Parts.Layout2D(parBBox, iter)
 }
 
@@ -139,8 +139,18 @@ type Window struct {
 	GotPaint         bool                                    `json:"-" xml:"-" desc:"have we received our first paint event yet?  ignore other window events before this point"`
 	EventSigs        [oswin.EventTypeN][EventPrisN]ki.Signal `json:"-" xml:"-" view:"-" desc:"signals for communicating each type of event, organized by priority"`
 	GoLoop           bool                                    `json:"-" xml:"-" desc:"true if we are running from GoStartEventLoop -- requires a WinWait.Done at end"`
+	EventRec         *EventRecording                         `json:"-" xml:"-" view:"-" desc:"if non-nil, all events entering the event loop are recorded here -- see StartEventRecording"`
+	EventRecMu       sync.Mutex                              `json:"-" xml:"-" view:"-" desc:"mutex that protects EventRec"`
+	Anims            []Animator                              `json:"-" xml:"-" view:"-" desc:"currently running animations, driven by AnimTicker -- see AddAnim"`
+	AnimTicker       *time.Ticker                            `json:"-" xml:"-" view:"-" desc:"frame ticker for animations -- only running while there are Anims"`
+	AnimMu           sync.Mutex                              `json:"-" xml:"-" view:"-" desc:"mutex that protects Anims, AnimTicker, and the animation state of widgets"`
+	Damage           []WinDamage                             `json:"-" xml:"-" view:"-" desc:"damaged regions to be re-rendered and uploaded when the current window update ends -- see AddDamage"`
+	DamageMu         sync.Mutex                              `json:"-" xml:"-" view:"-" desc:"mutex that protects Damage"`
 	stopEventLoop    bool
-	updating         int32 // atomic flag around global updating -- routines can check IsUpdating and bail
+	replayNow        time.Time   // virtual event loop clock during fast ReplayEvents
+	simLastPos       image.Point // last mouse position sent by synthetic input methods
+	animPending      bool        // a frame of the Anims has been posted to the event loop and not yet run
+	updating         int32       // atomic flag around global updating -- routines can check IsUpdating and bail
 }
 
 var KiT_Window = kit.Types.AddType(&Window{}, nil)
@@ -758,12 +768,14 @@ func (w *Window) Publish() {
 }
 
 // SignalWindowPublish is the signal receiver function that publishes the
-// window updates when the window update signal (UpdateEnd) occurs
+// window updates when the window update signal (UpdateEnd) occurs -- any
+// damage is rendered and uploaded first
 func SignalWindowPublish(winki, node ki.Ki, sig int64, data interface{}) {
 	win := winki.Embed(KiT_Window).(*Window)
 	if Render2DTrace {
 		fmt.Printf("Window: %v publishing image due to signal: %v from node: %v\n", win.PathUnique(), ki.NodeSignals(sig), node.PathUnique())
 	}
+	win.RenderDamage()
 	win.Publish()
 }
 
@@ -989,6 +1001,11 @@ mainloop:
 			fmt.Println("stop event loop")
 			break
 		}
+		if fe, ok := evi.(*winFuncEvent); ok {
+			fe.run()
+			continue
+		}
+		w.RecordEvent(evi)
 		et := evi.Type()
 		if lastWinMenuUpdate != WinNewCloseTime {
 			if et != oswin.WindowEvent && et != oswin.WindowResizeEvent &&
@@ -1011,6 +1028,9 @@ mainloop:
 		// Filter repeated laggy events -- key for responsive resize, scroll, etc
 
 		now := time.Now()
+		if !w.replayNow.IsZero() {
+			now = w.replayNow
+		}
 		lag := now.Sub(evi.Time())
 		lagMs := int(lag / time.Millisecond)
 		if et != oswin.MouseMoveEvent {
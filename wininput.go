@@ -0,0 +1,482 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/ki"
+)
+
+// Synthetic input: these methods generate the same oswin events that the
+// OS driver would, and push them onto the window's event queue, so they go
+// through exactly the same EventLoop / SendEventSignal processing as real
+// input.  This allows scripted UI tests (e.g., using the offscreen driver)
+// to exercise focus, popups, drag-n-drop etc.  All of them wait until the
+// events have been fully processed (including any re-rendering that
+// happens during that processing) before returning, via SimWait.  The
+// event loop must be running in another goroutine (see GoStartEventLoop).
+
+// SimWaitTimeout is the maximum amount of time that SimWait waits for the
+// window event loop to process pending events.
+var SimWaitTimeout = 10 * time.Second
+
+// winFuncEvent is an event that runs a function within the window event
+// loop goroutine -- used for synchronizing with the event loop and for
+// doing things that must be done there (e.g., setting the focus).
+type winFuncEvent struct {
+	oswin.EventBase
+	Fun  func()
+	Done chan struct{}
+}
+
+// Type is EventTypeN, which is never dispatched to any receiver
+func (ev winFuncEvent) Type() oswin.EventType {
+	return oswin.EventTypeN
+}
+
+func (ev winFuncEvent) HasPos() bool {
+	return false
+}
+
+func (ev winFuncEvent) Pos() image.Point {
+	return image.ZP
+}
+
+func (ev winFuncEvent) OnFocus() bool {
+	return false
+}
+
+// run is called by the EventLoop when this event arrives
+func (ev *winFuncEvent) run() {
+	if ev.Fun != nil {
+		ev.Fun()
+	}
+	close(ev.Done)
+}
+
+// RunInEventLoop runs the given function within the window's event loop
+// goroutine, after all currently pending events have been processed, and
+// waits for it to complete -- returns an error if it did not complete
+// within SimWaitTimeout.
+func (w *Window) RunInEventLoop(fun func()) error {
+	if w.IsClosed() {
+		return fmt.Errorf("gi.Window RunInEventLoop: window %v is closed", w.Nm)
+	}
+	ev := &winFuncEvent{Fun: fun, Done: make(chan struct{})}
+	ev.Init()
+	w.OSWin.Send(ev)
+	select {
+	case <-ev.Done:
+		return nil
+	case <-time.After(SimWaitTimeout):
+		return fmt.Errorf("gi.Window RunInEventLoop: window %v event loop did not respond within %v", w.Nm, SimWaitTimeout)
+	}
+}
+
+// PostInEventLoop runs the given function within the window's event loop
+// goroutine, after all currently pending events have been processed,
+// without waiting for it -- it does nothing if the window is closed
+func (w *Window) PostInEventLoop(fun func()) {
+	if w.IsClosed() {
+		return
+	}
+	ev := &winFuncEvent{Fun: fun, Done: make(chan struct{})}
+	ev.Init()
+	w.OSWin.Send(ev)
+}
+
+// RunInAllEventLoops runs the given function within the event loop of the
+// first open window, while the event loops of all the other open windows
+// are paused between events, and waits for it to complete -- use this to
+// change global state that is used in rendering, such as the Preferences,
+// from another goroutine.  It must not be called from within an event loop.
+// Returns an error if the windows did not respond within SimWaitTimeout.
+func RunInAllEventLoops(fun func()) error {
+	var wins WindowList
+	for _, w := range AllWindows {
+		if !w.IsClosed() {
+			wins = append(wins, w)
+		}
+	}
+	if len(wins) == 0 {
+		fun()
+		return nil
+	}
+	var paused sync.WaitGroup
+	paused.Add(len(wins) - 1)
+	resume := make(chan struct{})
+	defer close(resume)
+	for _, w := range wins[1:] {
+		w.PostInEventLoop(func() {
+			paused.Done()
+			<-resume
+		})
+	}
+	allPaused := make(chan struct{})
+	go func() {
+		paused.Wait()
+		close(allPaused)
+	}()
+	var err error
+	rerr := wins[0].RunInEventLoop(func() {
+		select {
+		case <-allPaused:
+		case <-time.After(SimWaitTimeout):
+			err = fmt.Errorf("gi.RunInAllEventLoops: window event loops did not pause within %v", SimWaitTimeout)
+			return
+		}
+		fun()
+	})
+	if rerr != nil {
+		return rerr
+	}
+	return err
+}
+
+// SimWait waits until all events sent to the window so far have been
+// processed, including any resulting re-rendering.
+func (w *Window) SimWait() error {
+	return w.RunInEventLoop(nil)
+}
+
+// SimFindNode finds the node for the given target, which is either a path
+// (containing a /, as returned by PathUnique) or the unique name of a node
+// anywhere in the window (the first one found, depth-first) -- the current
+// popup, if any, is searched first.
+func (w *Window) SimFindNode(target string) (Node2D, error) {
+	var roots []ki.Ki
+	if w.Popup != nil {
+		roots = append(roots, w.Popup)
+	}
+	roots = append(roots, w.This)
+	for _, root := range roots {
+		var fk ki.Ki
+		if strings.Contains(target, "/") {
+			if k, ok := root.FindPathUnique(target); ok {
+				fk = k
+			}
+		} else {
+			root.FuncDownMeFirst(0, nil, func(k ki.Ki, level int, d interface{}) bool {
+				if fk != nil {
+					return false
+				}
+				if k.Name() == target {
+					fk = k
+					return false
+				}
+				return true
+			})
+		}
+		if fk == nil {
+			continue
+		}
+		nii, _ := KiToNode2D(fk)
+		if nii == nil {
+			return nil, fmt.Errorf("gi.Window SimFindNode: target %v is not a 2D node", target)
+		}
+		return nii, nil
+	}
+	return nil, fmt.Errorf("gi.Window SimFindNode: target %v not found in window %v", target, w.Nm)
+}
+
+// SimNodePos returns the position of the center of the given target node,
+// in window coordinates -- see SimFindNode for target.
+func (w *Window) SimNodePos(target string) (image.Point, error) {
+	nii, err := w.SimFindNode(target)
+	if err != nil {
+		return image.ZP, err
+	}
+	ni := nii.AsNode2D()
+	if ni.WinBBox.Empty() {
+		return image.ZP, fmt.Errorf("gi.Window SimNodePos: target %v is not visible", target)
+	}
+	pos := image.Point{(ni.WinBBox.Min.X + ni.WinBBox.Max.X) / 2, (ni.WinBBox.Min.Y + ni.WinBBox.Max.Y) / 2}
+	return pos, nil
+}
+
+// simSendMove sends a mouse.MoveEvent to given position, from the last
+// position sent
+func (w *Window) simSendMove(pos image.Point, mods int32) {
+	ev := &mouse.MoveEvent{
+		Event: mouse.Event{
+			Where:     pos,
+			Button:    mouse.NoButton,
+			Action:    mouse.Move,
+			Modifiers: mods,
+		},
+		From: w.simLastPos,
+	}
+	ev.Init()
+	w.simLastPos = pos
+	w.OSWin.Send(ev)
+}
+
+func (w *Window) simSendButton(pos image.Point, but mouse.Buttons, act mouse.Actions, mods int32) {
+	ev := &mouse.Event{
+		Where:     pos,
+		Button:    but,
+		Action:    act,
+		Modifiers: mods,
+	}
+	ev.Init()
+	w.simLastPos = pos
+	w.OSWin.Send(ev)
+}
+
+// simModBits returns the modifier bits for given modifiers
+func simModBits(mods []key.Modifiers) int32 {
+	var bits int32
+	key.SetModifierBits(&bits, mods...)
+	return bits
+}
+
+// SimMouseMovePos moves the mouse to given window position, generating
+// mouse focus (enter / exit) events as the window does for real moves.
+func (w *Window) SimMouseMovePos(pos image.Point, mods ...key.Modifiers) error {
+	w.simSendMove(pos, simModBits(mods))
+	return w.SimWait()
+}
+
+// SimMouseMove moves the mouse to the center of the given target node.
+func (w *Window) SimMouseMove(target string, mods ...key.Modifiers) error {
+	pos, err := w.SimNodePos(target)
+	if err != nil {
+		return err
+	}
+	return w.SimMouseMovePos(pos, mods...)
+}
+
+// SimClickPos moves the mouse to given window position, and presses and
+// releases given button there.
+func (w *Window) SimClickPos(pos image.Point, but mouse.Buttons, mods ...key.Modifiers) error {
+	mb := simModBits(mods)
+	w.simSendMove(pos, mb)
+	w.simSendButton(pos, but, mouse.Press, mb)
+	w.simSendButton(pos, but, mouse.Release, mb)
+	return w.SimWait()
+}
+
+// SimClick clicks the given button at the center of the given target node.
+func (w *Window) SimClick(target string, but mouse.Buttons, mods ...key.Modifiers) error {
+	pos, err := w.SimNodePos(target)
+	if err != nil {
+		return err
+	}
+	return w.SimClickPos(pos, but, mods...)
+}
+
+// SimDoubleClick double-clicks the given button at the center of the given
+// target node -- as with the OS drivers, the second press is sent as a
+// DoubleClick action.
+func (w *Window) SimDoubleClick(target string, but mouse.Buttons, mods ...key.Modifiers) error {
+	pos, err := w.SimNodePos(target)
+	if err != nil {
+		return err
+	}
+	mb := simModBits(mods)
+	w.simSendMove(pos, mb)
+	w.simSendButton(pos, but, mouse.Press, mb)
+	w.simSendButton(pos, but, mouse.Release, mb)
+	w.simSendButton(pos, but, mouse.DoubleClick, mb)
+	w.simSendButton(pos, but, mouse.Release, mb)
+	return w.SimWait()
+}
+
+// SimDragPos presses given button at from, drags in nsteps equal steps to
+// to, and releases there.  The start of the drag is time-stamped in the
+// past, so that the window's drag and drag-n-drop start delays
+// (DragStartMSec, DNDStartMSec) are satisfied -- thus a drag that moves
+// further than DNDStartPix starts a drag-n-drop on nodes that support it.
+func (w *Window) SimDragPos(from, to image.Point, nsteps int, but mouse.Buttons, mods ...key.Modifiers) error {
+	if nsteps < 1 {
+		nsteps = 1
+	}
+	mb := simModBits(mods)
+	w.simSendMove(from, mb)
+	w.simSendButton(from, but, mouse.Press, mb)
+	last := from
+	for i := 0; i <= nsteps; i++ {
+		pos := from
+		if i > 0 {
+			pos.X += (to.X - from.X) * i / nsteps
+			pos.Y += (to.Y - from.Y) * i / nsteps
+		}
+		ev := &mouse.DragEvent{
+			MoveEvent: mouse.MoveEvent{
+				Event: mouse.Event{
+					Where:     pos,
+					Button:    but,
+					Action:    mouse.Drag,
+					Modifiers: mb,
+				},
+				From: last,
+			},
+		}
+		ev.Init()
+		if i == 0 {
+			dms := DragStartMSec
+			if DNDStartMSec > dms {
+				dms = DNDStartMSec
+			}
+			t := time.Now().Add(-time.Duration(dms+1) * time.Millisecond)
+			ev.GenTimeSec = t.Unix()
+			ev.GenTimeNSec = uint32(t.Nanosecond())
+		}
+		w.OSWin.Send(ev)
+		last = pos
+	}
+	w.simLastPos = to
+	w.simSendButton(to, but, mouse.Release, mb)
+	return w.SimWait()
+}
+
+// SimDrag drags with given button from the center of the from target node
+// to the center of the to target node, in nsteps steps -- see SimDragPos.
+func (w *Window) SimDrag(from, to string, nsteps int, but mouse.Buttons, mods ...key.Modifiers) error {
+	fpos, err := w.SimNodePos(from)
+	if err != nil {
+		return err
+	}
+	tpos, err := w.SimNodePos(to)
+	if err != nil {
+		return err
+	}
+	return w.SimDragPos(fpos, tpos, nsteps, but, mods...)
+}
+
+// SimScroll moves the mouse to the center of the given target node and
+// scrolls by given delta there (in raw dots, as for a mouse.ScrollEvent).
+func (w *Window) SimScroll(target string, delta image.Point, mods ...key.Modifiers) error {
+	pos, err := w.SimNodePos(target)
+	if err != nil {
+		return err
+	}
+	mb := simModBits(mods)
+	w.simSendMove(pos, mb)
+	ev := &mouse.ScrollEvent{
+		Event: mouse.Event{
+			Where:     pos,
+			Action:    mouse.Scroll,
+			Modifiers: mb,
+		},
+		Delta: delta,
+	}
+	ev.Init()
+	w.OSWin.Send(ev)
+	return w.SimWait()
+}
+
+// SimFocus sets the keyboard focus to the given target node, within the
+// event loop.
+func (w *Window) SimFocus(target string) error {
+	nii, err := w.SimFindNode(target)
+	if err != nil {
+		return err
+	}
+	return w.RunInEventLoop(func() {
+		w.SetFocus(nii.AsNode2D().This)
+	})
+}
+
+// simCodeForName returns the key code with given name (without the Code
+// prefix), e.g., ReturnEnter, or CodeUnknown if not found.
+func simCodeForName(nm string) key.Codes {
+	for c := key.CodeUnknown; c <= key.CodeRightGUI; c++ {
+		if strings.TrimPrefix(c.String(), "Code") == nm {
+			return c
+		}
+	}
+	return key.CodeUnknown
+}
+
+// simSendChord sends the key.Event press / release pair and the
+// key.ChordEvent, as the OS drivers do, for given rune, code and modifiers
+func (w *Window) simSendChord(r rune, code key.Codes, mods int32) {
+	kev := &key.Event{
+		Rune:      r,
+		Code:      code,
+		Modifiers: mods,
+		Action:    key.Press,
+	}
+	kev.Init()
+	w.OSWin.Send(kev)
+	che := &key.ChordEvent{Event: *kev}
+	w.OSWin.Send(che)
+	rev := *kev
+	rev.Action = key.Release
+	w.OSWin.Send(&rev)
+}
+
+// SimKeyChord sends the given key chord (e.g., "Control+C", "ReturnEnter",
+// "a") to the current focus item.
+func (w *Window) SimKeyChord(chord key.Chord) error {
+	cs := string(chord)
+	var mods int32
+	for m := key.Shift; m < key.ModifiersN; m++ {
+		mstr := m.String() + "+"
+		if strings.HasPrefix(cs, mstr) {
+			key.SetModifierBits(&mods, m)
+			cs = strings.TrimPrefix(cs, mstr)
+		}
+	}
+	rs := []rune(cs)
+	switch {
+	case len(rs) == 1:
+		r := rs[0]
+		if mods != 0 { // modded chords are uppercase, actual key is not
+			r = []rune(strings.ToLower(cs))[0]
+		}
+		w.simSendChord(r, key.CodeUnknown, mods)
+	case cs == "Spacebar":
+		w.simSendChord(' ', key.CodeSpacebar, mods)
+	default:
+		code := simCodeForName(cs)
+		if code == key.CodeUnknown {
+			return fmt.Errorf("gi.Window SimKeyChord: key chord %v not recognized", chord)
+		}
+		w.simSendChord(-1, code, mods)
+	}
+	return w.SimWait()
+}
+
+// SimKeyFun sends the key chord for given KeyFun in the ActiveKeyMap to the
+// current focus item.
+func (w *Window) SimKeyFun(kf KeyFuns) error {
+	chord := ActiveKeyMap.ChordForFun(kf)
+	if chord == "" {
+		return fmt.Errorf("gi.Window SimKeyFun: no key chord for key function %v in active keymap", kf)
+	}
+	return w.SimKeyChord(chord)
+}
+
+// SimTypeText focuses the given target node (if target is non-empty) and
+// types the given text into it, one key chord per rune -- newlines are sent
+// as ReturnEnter and tabs as Tab.
+func (w *Window) SimTypeText(target string, text string) error {
+	if target != "" {
+		if err := w.SimFocus(target); err != nil {
+			return err
+		}
+	}
+	for _, r := range text {
+		switch r {
+		case '\n':
+			w.simSendChord(-1, key.CodeReturnEnter, 0)
+		case '\t':
+			w.simSendChord(-1, key.CodeTab, 0)
+		default:
+			w.simSendChord(r, key.CodeUnknown, 0)
+		}
+	}
+	return w.SimWait()
+}
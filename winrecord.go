@@ -0,0 +1,281 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/dnd"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/gi/oswin/touch"
+	"github.com/goki/gi/oswin/window"
+)
+
+// Event recording and replay: when a Window has an active EventRec, every
+// oswin event that enters its EventLoop is recorded, along with the time it
+// arrived, how long it had been waiting (its lag, which determines whether
+// it is skipped), and the window geometry at that point.  The recording can
+// be saved to a JSON file, and replayed into a window later with ReplayEvents,
+// which reproduces the same sequence of event processing -- e.g., to turn a
+// bug report about odd focus, hover, or popup behavior into a replayable trace.
+
+// EventRecording is a recording of the oswin events processed by a Window
+// EventLoop -- see Window.StartEventRecording and Window.ReplayEvents.
+type EventRecording struct {
+	Window     string           `desc:"name of the window that was recorded"`
+	Start      time.Time        `desc:"time when the recording started"`
+	Geom       image.Rectangle  `desc:"window geometry (position and size, in raw dots) when the recording started"`
+	LogicalDPI float32          `desc:"logical DPI of the window when the recording started"`
+	Events     []*RecordedEvent `desc:"the recorded events, in the order they entered the event loop"`
+}
+
+// RecordedEvent is one event in an EventRecording
+type RecordedEvent struct {
+	T     time.Duration   `desc:"time since the start of the recording at which the event entered the event loop"`
+	Lag   time.Duration   `desc:"time between the generation of the event and its entering the event loop -- determines if it is skipped as a laggy event"`
+	Geom  image.Rectangle `desc:"window geometry (position and size, in raw dots) when the event entered the event loop"`
+	Kind  string          `desc:"concrete type of the event, as package.Type, e.g., mouse.DragEvent"`
+	Event json.RawMessage `desc:"JSON encoding of the event itself"`
+}
+
+// recEventKinds has the event types that can be recorded, with functions
+// returning a new event of that kind and its EventBase (for setting the time)
+var recEventKinds = map[string]func() (oswin.Event, *oswin.EventBase){
+	"mouse.Event":       func() (oswin.Event, *oswin.EventBase) { ev := &mouse.Event{}; return ev, &ev.EventBase },
+	"mouse.MoveEvent":   func() (oswin.Event, *oswin.EventBase) { ev := &mouse.MoveEvent{}; return ev, &ev.EventBase },
+	"mouse.DragEvent":   func() (oswin.Event, *oswin.EventBase) { ev := &mouse.DragEvent{}; return ev, &ev.EventBase },
+	"mouse.ScrollEvent": func() (oswin.Event, *oswin.EventBase) { ev := &mouse.ScrollEvent{}; return ev, &ev.EventBase },
+	"mouse.FocusEvent":  func() (oswin.Event, *oswin.EventBase) { ev := &mouse.FocusEvent{}; return ev, &ev.EventBase },
+	"mouse.HoverEvent":  func() (oswin.Event, *oswin.EventBase) { ev := &mouse.HoverEvent{}; return ev, &ev.EventBase },
+	"key.Event":         func() (oswin.Event, *oswin.EventBase) { ev := &key.Event{}; return ev, &ev.EventBase },
+	"key.ChordEvent":    func() (oswin.Event, *oswin.EventBase) { ev := &key.ChordEvent{}; return ev, &ev.EventBase },
+	"dnd.Event":         func() (oswin.Event, *oswin.EventBase) { ev := &dnd.Event{}; return ev, &ev.EventBase },
+	"dnd.MoveEvent":     func() (oswin.Event, *oswin.EventBase) { ev := &dnd.MoveEvent{}; return ev, &ev.EventBase },
+	"dnd.FocusEvent":    func() (oswin.Event, *oswin.EventBase) { ev := &dnd.FocusEvent{}; return ev, &ev.EventBase },
+	"touch.Event":       func() (oswin.Event, *oswin.EventBase) { ev := &touch.Event{}; return ev, &ev.EventBase },
+	"window.Event":      func() (oswin.Event, *oswin.EventBase) { ev := &window.Event{}; return ev, &ev.EventBase },
+}
+
+// StartEventRecording starts recording all events entering the window's
+// EventLoop, replacing any existing recording.
+func (w *Window) StartEventRecording() {
+	rec := &EventRecording{
+		Window: w.Nm,
+		Start:  time.Now(),
+		Geom:   w.winGeom(),
+		Events: make([]*RecordedEvent, 0, 1000),
+	}
+	if w.OSWin != nil {
+		rec.LogicalDPI = w.OSWin.LogicalDPI()
+	}
+	w.EventRecMu.Lock()
+	w.EventRec = rec
+	w.EventRecMu.Unlock()
+}
+
+// StopEventRecording stops recording events, and returns the recording, or
+// nil if not recording.
+func (w *Window) StopEventRecording() *EventRecording {
+	w.EventRecMu.Lock()
+	rec := w.EventRec
+	w.EventRec = nil
+	w.EventRecMu.Unlock()
+	return rec
+}
+
+// IsRecordingEvents returns true if events are currently being recorded
+func (w *Window) IsRecordingEvents() bool {
+	w.EventRecMu.Lock()
+	defer w.EventRecMu.Unlock()
+	return w.EventRec != nil
+}
+
+// winGeom returns the current window geometry, as a rectangle with the
+// position of the window as Min and the size as its size
+func (w *Window) winGeom() image.Rectangle {
+	if w.OSWin == nil {
+		return image.ZR
+	}
+	pos := w.OSWin.Position()
+	return image.Rectangle{Min: pos, Max: pos.Add(w.OSWin.Size())}
+}
+
+// RecordEvent records given event if currently recording -- called by
+// EventLoop for every event as it enters the loop.
+func (w *Window) RecordEvent(evi oswin.Event) {
+	w.EventRecMu.Lock()
+	defer w.EventRecMu.Unlock()
+	if w.EventRec == nil {
+		return
+	}
+	kind := strings.TrimPrefix(fmt.Sprintf("%T", evi), "*")
+	if _, ok := recEventKinds[kind]; !ok {
+		return
+	}
+	var b []byte
+	var err error
+	switch e := evi.(type) { // Source and Target nodes can't be recorded
+	case *dnd.Event:
+		de := *e
+		de.Source, de.Target = nil, nil
+		b, err = json.Marshal(&de)
+	case *dnd.MoveEvent:
+		de := *e
+		de.Source, de.Target = nil, nil
+		b, err = json.Marshal(&de)
+	case *dnd.FocusEvent:
+		de := *e
+		de.Source, de.Target = nil, nil
+		b, err = json.Marshal(&de)
+	default:
+		b, err = json.Marshal(evi)
+	}
+	if err != nil {
+		log.Printf("gi.Window RecordEvent: could not encode %v event: %v\n", kind, err)
+		return
+	}
+	now := time.Now()
+	re := &RecordedEvent{
+		T:     now.Sub(w.EventRec.Start),
+		Lag:   now.Sub(evi.Time()),
+		Geom:  w.winGeom(),
+		Kind:  kind,
+		Event: b,
+	}
+	w.EventRec.Events = append(w.EventRec.Events, re)
+}
+
+// OpenJSON opens an event recording from a JSON-formatted file.
+func (er *EventRecording) OpenJSON(filename FileName) error {
+	b, err := ioutil.ReadFile(string(filename))
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	*er = EventRecording{} // reset
+	return json.Unmarshal(b, er)
+}
+
+// SaveJSON saves the event recording to a JSON-formatted file.
+func (er *EventRecording) SaveJSON(filename FileName) error {
+	b, err := json.MarshalIndent(er, "", "  ")
+	if err != nil {
+		log.Println(err) // unlikely
+		return err
+	}
+	err = ioutil.WriteFile(string(filename), b, 0644)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+// Duration returns the total duration of the recording, up to the last event
+func (er *EventRecording) Duration() time.Duration {
+	if len(er.Events) == 0 {
+		return 0
+	}
+	return er.Events[len(er.Events)-1].T
+}
+
+// NewEvent returns a new event decoded from the recorded event.
+func (re *RecordedEvent) NewEvent() (oswin.Event, *oswin.EventBase, error) {
+	nf, ok := recEventKinds[re.Kind]
+	if !ok {
+		return nil, nil, fmt.Errorf("gi.RecordedEvent: event kind %v not recognized", re.Kind)
+	}
+	ev, eb := nf()
+	if err := json.Unmarshal(re.Event, ev); err != nil {
+		return nil, nil, fmt.Errorf("gi.RecordedEvent: could not decode %v event: %v", re.Kind, err)
+	}
+	return ev, eb, nil
+}
+
+// ReplayEvents replays the given recording into this window, whose event
+// loop must be running in another goroutine (see GoStartEventLoop) -- this
+// method blocks until all events have been processed.  The window is first
+// set to the recorded geometry, and window resize and move events are
+// replayed by setting the window geometry, so that the window itself
+// generates the corresponding events.  If realTime is true, events are sent
+// at the same pace as they were recorded -- otherwise they are sent as fast
+// as possible, using a virtual clock for the event loop so that all time-based
+// processing (skipping of laggy events, drag and drag-n-drop start delays)
+// happens exactly as recorded.  Hover events depend on timers that run in
+// real time, so they are only reproduced with realTime pacing.  Each event is
+// fully processed before the next one is sent.
+func (w *Window) ReplayEvents(rec *EventRecording, realTime bool) error {
+	if w.IsClosed() {
+		return fmt.Errorf("gi.Window ReplayEvents: window %v is closed", w.Nm)
+	}
+	if rec.Geom.Size() != (image.Point{}) && rec.Geom != w.winGeom() {
+		w.OSWin.SetGeom(rec.Geom.Min, rec.Geom.Size())
+	}
+	if err := w.SimWait(); err != nil {
+		return err
+	}
+	start := time.Now()
+	for i, re := range rec.Events {
+		ev, eb, err := re.NewEvent()
+		if err != nil {
+			log.Printf("gi.Window ReplayEvents: skipping event %v: %v\n", i, err)
+			continue
+		}
+		var gen time.Time
+		if realTime {
+			if dt := re.T - time.Since(start); dt > 0 {
+				time.Sleep(dt)
+			}
+			gen = time.Now().Add(-re.Lag)
+		} else {
+			vnow := start.Add(re.T)
+			gen = vnow.Add(-re.Lag)
+			fe := &winFuncEvent{Fun: func() { w.replayNow = vnow }, Done: make(chan struct{})}
+			fe.Init()
+			w.OSWin.Send(fe)
+		}
+		eb.GenTimeSec = gen.Unix()
+		eb.GenTimeNSec = uint32(gen.Nanosecond())
+		eb.Processed = false
+		if we, ok := ev.(*window.Event); ok {
+			switch we.Action {
+			case window.Resize, window.Move:
+				if re.Geom != w.winGeom() {
+					w.OSWin.SetGeom(re.Geom.Min, re.Geom.Size())
+				}
+				ev = nil
+			case window.Close:
+				w.OSWin.Close()
+				return nil
+			}
+		}
+		if ev != nil {
+			w.OSWin.Send(ev)
+		}
+		if err := w.SimWait(); err != nil {
+			return err
+		}
+	}
+	return w.RunInEventLoop(func() {
+		w.replayNow = time.Time{} // back to the real clock
+	})
+}
+
+// ReplayEventsJSON opens an event recording from the given JSON file and
+// replays it into this window -- see ReplayEvents.
+func (w *Window) ReplayEventsJSON(filename FileName, realTime bool) error {
+	rec := &EventRecording{}
+	if err := rec.OpenJSON(filename); err != nil {
+		return err
+	}
+	return w.ReplayEvents(rec, realTime)
+}